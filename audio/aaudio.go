@@ -0,0 +1,360 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package audio
+
+/*
+#include <stdlib.h>
+#include <stdint.h>
+#include <dlfcn.h>
+
+// 不包含 <aaudio/AAudio.h>：minSdk 低于 26 时头文件中没有这些函数，
+// 所以在运行时从 libaaudio.so 取得，参数中的 AAudioStream* 等都用 void* 表示
+#define AAUDIO_FORMAT_PCM_I16 1
+#define AAUDIO_FORMAT_PCM_FLOAT 2
+#define AAUDIO_SHARING_MODE_EXCLUSIVE 0
+#define AAUDIO_SHARING_MODE_SHARED 1
+#define AAUDIO_PERFORMANCE_MODE_NONE 10
+#define AAUDIO_PERFORMANCE_MODE_POWER_SAVING 11
+#define AAUDIO_PERFORMANCE_MODE_LOW_LATENCY 12
+#define AAUDIO_CALLBACK_RESULT_CONTINUE 0
+#define AAUDIO_CALLBACK_RESULT_STOP 1
+
+typedef int32_t (*aaudioDataCallback)(void* stream, void* userData, void* audioData, int32_t numFrames);
+typedef void (*aaudioErrorCallback)(void* stream, void* userData, int32_t error);
+
+extern int32_t cgoAAudioData(void* stream, void* userData, void* audioData, int32_t numFrames);
+extern void cgoAAudioError(void* stream, void* userData, int32_t error);
+
+static int32_t (*aaudioCreateStreamBuilderC)(void** builder);
+static const char* (*aaudioConvertResultToTextC)(int32_t result);
+static void (*builderSetDirectionC)(void* builder, int32_t direction);
+static void (*builderSetSampleRateC)(void* builder, int32_t sampleRate);
+static void (*builderSetChannelCountC)(void* builder, int32_t channelCount);
+static void (*builderSetFormatC)(void* builder, int32_t format);
+static void (*builderSetSharingModeC)(void* builder, int32_t sharingMode);
+static void (*builderSetPerformanceModeC)(void* builder, int32_t mode);
+static void (*builderSetFramesPerDataCallbackC)(void* builder, int32_t numFrames);
+static void (*builderSetDataCallbackC)(void* builder, aaudioDataCallback callback, void* userData);
+static void (*builderSetErrorCallbackC)(void* builder, aaudioErrorCallback callback, void* userData);
+static int32_t (*builderOpenStreamC)(void* builder, void** stream);
+static int32_t (*builderDeleteC)(void* builder);
+static int32_t (*streamRequestStartC)(void* stream);
+static int32_t (*streamRequestStopC)(void* stream);
+static int32_t (*streamCloseC)(void* stream);
+static int32_t (*streamWaitForStateChangeC)(void* stream, int32_t inputState, int32_t* nextState, int64_t timeoutNanoseconds);
+static int32_t (*streamGetStateC)(void* stream);
+static int32_t (*streamGetSampleRateC)(void* stream);
+static int32_t (*streamGetChannelCountC)(void* stream);
+static int32_t (*streamGetFormatC)(void* stream);
+static int32_t (*streamGetSharingModeC)(void* stream);
+static int32_t (*streamGetPerformanceModeC)(void* stream);
+static int32_t (*streamGetFramesPerBurstC)(void* stream);
+static int32_t (*streamGetXRunCountC)(void* stream);
+
+static int initAAudio() {
+	void *handler = dlopen("libaaudio.so", RTLD_NOW);
+	if (handler == NULL) return 0;
+#define LOAD(p, name) if ((*(void**)&p = dlsym(handler, name)) == NULL) return 0
+	LOAD(aaudioCreateStreamBuilderC, "AAudio_createStreamBuilder");
+	LOAD(aaudioConvertResultToTextC, "AAudio_convertResultToText");
+	LOAD(builderSetDirectionC, "AAudioStreamBuilder_setDirection");
+	LOAD(builderSetSampleRateC, "AAudioStreamBuilder_setSampleRate");
+	LOAD(builderSetChannelCountC, "AAudioStreamBuilder_setChannelCount");
+	LOAD(builderSetFormatC, "AAudioStreamBuilder_setFormat");
+	LOAD(builderSetSharingModeC, "AAudioStreamBuilder_setSharingMode");
+	LOAD(builderSetPerformanceModeC, "AAudioStreamBuilder_setPerformanceMode");
+	LOAD(builderSetFramesPerDataCallbackC, "AAudioStreamBuilder_setFramesPerDataCallback");
+	LOAD(builderSetDataCallbackC, "AAudioStreamBuilder_setDataCallback");
+	LOAD(builderSetErrorCallbackC, "AAudioStreamBuilder_setErrorCallback");
+	LOAD(builderOpenStreamC, "AAudioStreamBuilder_openStream");
+	LOAD(builderDeleteC, "AAudioStreamBuilder_delete");
+	LOAD(streamRequestStartC, "AAudioStream_requestStart");
+	LOAD(streamRequestStopC, "AAudioStream_requestStop");
+	LOAD(streamCloseC, "AAudioStream_close");
+	LOAD(streamWaitForStateChangeC, "AAudioStream_waitForStateChange");
+	LOAD(streamGetStateC, "AAudioStream_getState");
+	LOAD(streamGetSampleRateC, "AAudioStream_getSampleRate");
+	LOAD(streamGetChannelCountC, "AAudioStream_getChannelCount");
+	LOAD(streamGetFormatC, "AAudioStream_getFormat");
+	LOAD(streamGetSharingModeC, "AAudioStream_getSharingMode");
+	LOAD(streamGetPerformanceModeC, "AAudioStream_getPerformanceMode");
+	LOAD(streamGetFramesPerBurstC, "AAudioStream_getFramesPerBurst");
+	LOAD(streamGetXRunCountC, "AAudioStream_getXRunCount");
+#undef LOAD
+	return 1;
+}
+
+static int32_t aaudioOpen(int32_t direction, int32_t sampleRate, int32_t channelCount,
+		int32_t format, int32_t sharingMode, int32_t performanceMode,
+		int32_t framesPerCallback, void* userData, void** stream) {
+	void* builder = NULL;
+	int32_t r = aaudioCreateStreamBuilderC(&builder);
+	if (r != 0) return r;
+	builderSetDirectionC(builder, direction);
+	if (sampleRate > 0) builderSetSampleRateC(builder, sampleRate);
+	if (channelCount > 0) builderSetChannelCountC(builder, channelCount);
+	builderSetFormatC(builder, format);
+	builderSetSharingModeC(builder, sharingMode);
+	builderSetPerformanceModeC(builder, performanceMode);
+	if (framesPerCallback > 0) builderSetFramesPerDataCallbackC(builder, framesPerCallback);
+	builderSetDataCallbackC(builder, cgoAAudioData, userData);
+	builderSetErrorCallbackC(builder, cgoAAudioError, userData);
+	r = builderOpenStreamC(builder, stream);
+	builderDeleteC(builder);
+	return r;
+}
+
+static const char* aaudioResultText(int32_t r) {
+	return aaudioConvertResultToTextC(r);
+}
+static int32_t aaudioRequestStart(void* stream) {
+	return streamRequestStartC(stream);
+}
+static int32_t aaudioRequestStop(void* stream) {
+	return streamRequestStopC(stream);
+}
+static int32_t aaudioClose(void* stream) {
+	return streamCloseC(stream);
+}
+static int32_t aaudioWaitForStateChange(void* stream, int32_t inputState, int32_t* nextState, int64_t timeout) {
+	return streamWaitForStateChangeC(stream, inputState, nextState, timeout);
+}
+static int32_t aaudioGetState(void* stream) {
+	return streamGetStateC(stream);
+}
+static int32_t aaudioGetSampleRate(void* stream) {
+	return streamGetSampleRateC(stream);
+}
+static int32_t aaudioGetChannelCount(void* stream) {
+	return streamGetChannelCountC(stream);
+}
+static int32_t aaudioGetFormat(void* stream) {
+	return streamGetFormatC(stream);
+}
+static int32_t aaudioGetSharingMode(void* stream) {
+	return streamGetSharingModeC(stream);
+}
+static int32_t aaudioGetPerformanceMode(void* stream) {
+	return streamGetPerformanceModeC(stream);
+}
+static int32_t aaudioGetFramesPerBurst(void* stream) {
+	return streamGetFramesPerBurstC(stream);
+}
+static int32_t aaudioGetXRunCount(void* stream) {
+	return streamGetXRunCountC(stream);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Result AAudio 的 aaudio_result_t
+type Result int32
+
+const (
+	OK                     Result = 0
+	ERROR_DISCONNECTED     Result = -899
+	ERROR_ILLEGAL_ARGUMENT Result = -898
+	ERROR_INTERNAL         Result = -896
+	ERROR_INVALID_STATE    Result = -895
+	ERROR_INVALID_HANDLE   Result = -892
+	ERROR_UNIMPLEMENTED    Result = -890
+	ERROR_UNAVAILABLE      Result = -889
+	ERROR_NO_FREE_HANDLES  Result = -888
+	ERROR_NO_MEMORY        Result = -887
+	ERROR_NULL             Result = -886
+	ERROR_TIMEOUT          Result = -885
+	ERROR_WOULD_BLOCK      Result = -884
+	ERROR_INVALID_FORMAT   Result = -883
+	ERROR_OUT_OF_RANGE     Result = -882
+	ERROR_NO_SERVICE       Result = -881
+	ERROR_INVALID_RATE     Result = -880
+)
+
+func (r Result) Error() string {
+	if aaudioAvailable() {
+		return "audio: " + C.GoString(C.aaudioResultText(C.int32_t(r)))
+	}
+	return fmt.Sprintf("audio: AAUDIO_ERROR_%d", int32(r))
+}
+
+func aaudioStatus(r C.int32_t) error {
+	if r < 0 {
+		return Result(r)
+	}
+	return nil
+}
+
+var (
+	aaudioOnce sync.Once
+	aaudioOK   bool
+)
+
+// aaudioAvailable 设备是否支持 AAudio (API 26)
+func aaudioAvailable() bool {
+	aaudioOnce.Do(func() {
+		aaudioOK = C.initAAudio() != 0
+	})
+	return aaudioOK
+}
+
+const aaudioStateTimeout = 2 * time.Second
+
+type aaudioStream struct {
+	stream unsafe.Pointer // AAudioStream*
+	key    unsafe.Pointer // 回调的 userData
+}
+
+var (
+	aaudioLock sync.RWMutex
+	aaudioMap  = map[unsafe.Pointer]*Stream{}
+)
+
+func aaudioStreamOf(userData unsafe.Pointer) *Stream {
+	aaudioLock.RLock()
+	defer aaudioLock.RUnlock()
+	return aaudioMap[userData]
+}
+
+func openAAudio(s *Stream) (backend, error) {
+	cfg := &s.cfg
+	format := C.int32_t(C.AAUDIO_FORMAT_PCM_FLOAT)
+	if cfg.Format == FORMAT_I16 {
+		format = C.AAUDIO_FORMAT_PCM_I16
+	}
+	sharing := C.int32_t(C.AAUDIO_SHARING_MODE_SHARED)
+	if cfg.SharingMode == SHARING_MODE_EXCLUSIVE {
+		sharing = C.AAUDIO_SHARING_MODE_EXCLUSIVE
+	}
+	perf := C.int32_t(C.AAUDIO_PERFORMANCE_MODE_NONE + C.int32_t(cfg.PerformanceMode))
+
+	a := &aaudioStream{key: C.malloc(1)}
+	aaudioLock.Lock()
+	aaudioMap[a.key] = s
+	aaudioLock.Unlock()
+
+	r := C.aaudioOpen(C.int32_t(cfg.Direction), C.int32_t(cfg.SampleRate), C.int32_t(cfg.ChannelCount),
+		format, sharing, perf, C.int32_t(cfg.FramesPerCallback), a.key, &a.stream)
+	if err := aaudioStatus(r); err != nil {
+		a.release()
+		return nil, err
+	}
+
+	// 实际使用的参数
+	cfg.SampleRate = int(C.aaudioGetSampleRate(a.stream))
+	cfg.ChannelCount = int(C.aaudioGetChannelCount(a.stream))
+	cfg.SharingMode = SHARING_MODE_SHARED
+	if C.aaudioGetSharingMode(a.stream) == C.AAUDIO_SHARING_MODE_EXCLUSIVE {
+		cfg.SharingMode = SHARING_MODE_EXCLUSIVE
+	}
+	cfg.PerformanceMode = PerformanceMode(C.aaudioGetPerformanceMode(a.stream) - C.AAUDIO_PERFORMANCE_MODE_NONE)
+	s.native = FORMAT_FLOAT
+	if C.aaudioGetFormat(a.stream) == C.AAUDIO_FORMAT_PCM_I16 {
+		s.native = FORMAT_I16
+	}
+	return a, nil
+}
+
+func (a *aaudioStream) release() {
+	aaudioLock.Lock()
+	delete(aaudioMap, a.key)
+	aaudioLock.Unlock()
+	C.free(a.key)
+}
+
+// waitFor 等待流离开 transient 状态，返回新的状态
+func (a *aaudioStream) waitFor(transient State) (State, error) {
+	var next C.int32_t
+	r := C.aaudioWaitForStateChange(a.stream, C.int32_t(transient), &next, C.int64_t(aaudioStateTimeout))
+	return State(next), aaudioStatus(r)
+}
+
+func (a *aaudioStream) start() error {
+	if err := aaudioStatus(C.aaudioRequestStart(a.stream)); err != nil {
+		return err
+	}
+	state, err := a.waitFor(STATE_STARTING)
+	if err == nil && state != STATE_STARTED {
+		err = ERROR_INVALID_STATE
+	}
+	return err
+}
+
+func (a *aaudioStream) stop() error {
+	if err := aaudioStatus(C.aaudioRequestStop(a.stream)); err != nil {
+		return err
+	}
+	_, err := a.waitFor(STATE_STOPPING)
+	return err
+}
+
+func (a *aaudioStream) close() error {
+	err := aaudioStatus(C.aaudioClose(a.stream))
+	a.release()
+	return err
+}
+
+func (a *aaudioStream) state() State {
+	return State(C.aaudioGetState(a.stream))
+}
+
+func (a *aaudioStream) framesPerBurst() int {
+	return int(C.aaudioGetFramesPerBurst(a.stream))
+}
+
+func (a *aaudioStream) xRunCount() int {
+	n := int(C.aaudioGetXRunCount(a.stream))
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+/**
+ * Prototype for the data function that is passed to AAudioStreamBuilder_setDataCallback().
+ *
+ * For an output stream, this function should render and write from one to numFrames of data
+ * in the streams current data format to the audioData buffer.
+ *
+ * For an input stream, this function should read and process numFrames of data
+ * from the audioData buffer.
+ */
+//typedef aaudio_data_callback_result_t (*AAudioStream_dataCallback)(
+//        AAudioStream *stream,
+//        void *userData,
+//        void *audioData,
+//        int32_t numFrames);
+//export cgoAAudioData
+func cgoAAudioData(stream, userData, audioData unsafe.Pointer, numFrames C.int32_t) C.int32_t {
+	s := aaudioStreamOf(userData)
+	if s == nil || !s.process(audioData, int(numFrames)) {
+		return C.AAUDIO_CALLBACK_RESULT_STOP
+	}
+	return C.AAUDIO_CALLBACK_RESULT_CONTINUE
+}
+
+/**
+ * Prototype for the callback function that is passed to
+ * AAudioStreamBuilder_setErrorCallback().
+ *
+ * The following may NOT be called from the error callback:
+ * - AAudioStream_requestStop()
+ * - AAudioStream_close()
+ */
+//typedef void (*AAudioStream_errorCallback)(
+//        AAudioStream *stream,
+//        void *userData,
+//        aaudio_result_t error);
+//export cgoAAudioError
+func cgoAAudioError(stream, userData unsafe.Pointer, status C.int32_t) {
+	if s := aaudioStreamOf(userData); s != nil {
+		s.fail(Result(status))
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package aiff 写 AIFF 文件，浮点样本写为 AIFF-C (fl32/fl64)。
+// 与 wav.Writer 一样定期把当前大小写回文件头 (见 pcm.FlushWriter)。
+package aiff
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+
+	"github.com/gooid/gooid/audio/pcm"
+)
+
+// FORM 的大小是 32 位的
+const maxSize = 0xffffffff
+
+// AIFF-C 版本 1 的时间戳
+const aifcVersion1 = 0xa2805140
+
+// Writer 写 AIFF 文件 (大端，8 位有符号)。超过 4GB 时 Write 返回 pcm.ErrTooLarge
+type Writer struct {
+	*pcm.FlushWriter
+
+	w      io.WriteSeeker
+	format pcm.Format
+
+	dataOffset     int64
+	framesOffset   int64 // COMM 中的 numSampleFrames
+	ssndSizeOffset int64
+
+	buf []byte
+}
+
+// NewWriter 在 w 的开头写入文件头
+func NewWriter(w io.WriteSeeker, format pcm.Format) (*Writer, error) {
+	return newWriter(w, nil, format)
+}
+
+// Create 创建文件 path，Close 时关闭文件
+func Create(path string, format pcm.Format) (w *Writer, err error) {
+	err = pcm.CreateFile(path, func(f *os.File) error {
+		w, err = newWriter(f, f, format)
+		return err
+	})
+	return w, err
+}
+
+func newWriter(w io.WriteSeeker, f *os.File, format pcm.Format) (*Writer, error) {
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+	wr := &Writer{w: w, format: format}
+	head := wr.header()
+	if _, err := w.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(head); err != nil {
+		return nil, err
+	}
+	wr.FlushWriter = pcm.NewFlushWriter(w, pcm.FlushOptions{
+		File:    f,
+		MaxSize: maxSize + 8 - 1 - wr.dataOffset,
+		Align:   true,
+		Update:  wr.update,
+	})
+	return wr, nil
+}
+
+func (w *Writer) header() []byte {
+	f := w.format
+	be := binary.BigEndian
+	var b []byte
+	if f.Float {
+		b = append(b, "FORM\x00\x00\x00\x00AIFCFVER\x00\x00\x00\x04"...)
+		b = appendUint32(b, aifcVersion1)
+		b = append(b, "COMM"...)
+		b = appendUint32(b, 40)
+	} else {
+		b = append(b, "FORM\x00\x00\x00\x00AIFFCOMM"...)
+		b = appendUint32(b, 18)
+	}
+	b = appendUint16(b, uint16(f.Channels))
+	w.framesOffset = int64(len(b))
+	b = appendUint32(b, 0)
+	b = appendUint16(b, uint16(f.BitsPerSample))
+	b = appendExtended(b, float64(f.SampleRate))
+	if f.Float {
+		// compressionType 和 pstring 格式的名字 (含长度字节共 18 字节，已是偶数)
+		if f.BitsPerSample == 64 {
+			b = append(b, "fl64\x11IEEE 64-bit float"...)
+		} else {
+			b = append(b, "fl32\x11IEEE 32-bit float"...)
+		}
+	}
+	b = append(b, "SSND"...)
+	w.ssndSizeOffset = int64(len(b))
+	b = appendUint32(b, 8)
+	b = appendUint32(b, 0) // offset
+	b = appendUint32(b, 0) // blockSize
+	w.dataOffset = int64(len(b))
+	be.PutUint32(b[4:], uint32(len(b)-8))
+	return b
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+// appendExtended 追加 80 位 IEEE 754 扩展精度的 v (v > 0)
+func appendExtended(b []byte, v float64) []byte {
+	frac, exp := math.Frexp(v) // v = frac * 2^exp, 0.5 <= frac < 1
+	b = appendUint16(b, uint16(exp-1+16383))
+	m := uint64(math.Ldexp(frac, 64))
+	return append(b, byte(m>>56), byte(m>>48), byte(m>>40), byte(m>>32),
+		byte(m>>24), byte(m>>16), byte(m>>8), byte(m))
+}
+
+// Format 文件格式
+func (w *Writer) Format() pcm.Format {
+	return w.format
+}
+
+// Frames 已写入的帧数
+func (w *Writer) Frames() int64 {
+	return w.Size() / int64(w.format.FrameSize())
+}
+
+// WriteSamples 编码并写入交错存放的 float32 样本
+func (w *Writer) WriteSamples(samples []float32) error {
+	w.buf = pcm.Encode(w.buf[:0], samples, w.format, pcm.BigEndian)
+	_, err := w.Write(w.buf)
+	return err
+}
+
+// update 把 size 写回 FORM、COMM 和 SSND
+func (w *Writer) update(size int64) error {
+	sizes := []struct {
+		offset int64
+		v      uint32
+	}{
+		{4, uint32(w.dataOffset + size + size&1 - 8)},
+		{w.framesOffset, uint32(size / int64(w.format.FrameSize()))},
+		{w.ssndSizeOffset, uint32(8 + size)},
+	}
+	var b [4]byte
+	for _, s := range sizes {
+		if _, err := w.w.Seek(s.offset, io.SeekStart); err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint32(b[:], s.v)
+		if _, err := w.w.Write(b[:]); err != nil {
+			return err
+		}
+	}
+	_, err := w.w.Seek(w.dataOffset+size, io.SeekStart)
+	return err
+}
@@ -0,0 +1,144 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package aiff
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gooid/gooid/audio/pcm"
+)
+
+// unhex 忽略空白的十六进制串
+func unhex(s string) []byte {
+	b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestAppendExtended(t *testing.T) {
+	for _, c := range []struct {
+		rate float64
+		want string
+	}{
+		{8000, "400b fa00000000000000"},
+		{22050, "400d ac44000000000000"},
+		{44100, "400e ac44000000000000"},
+		{48000, "400e bb80000000000000"},
+		{96000, "400f bb80000000000000"},
+		{1, "3fff 8000000000000000"},
+	} {
+		if got := appendExtended(nil, c.rate); !bytes.Equal(got, unhex(c.want)) {
+			t.Errorf("%v: % x, want %s", c.rate, got, c.want)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, c := range []struct {
+		name    string
+		format  pcm.Format
+		samples []float32
+		want    string
+	}{
+		{"aiff 16", pcm.Format{SampleRate: 44100, Channels: 2, BitsPerSample: 16}, []float32{0.5, -0.5, 0, 1},
+			"464f524d 00000036 41494646" + // FORM, AIFF
+				"434f4d4d 00000012 0002 00000002 0010 400eac44000000000000" + // COMM
+				"53534e44 00000010 00000000 00000000 4000c000 00007fff"}, // SSND
+		// 8 位有符号，奇数长度的 SSND 补齐
+		{"aiff 8", pcm.Format{SampleRate: 8000, Channels: 1, BitsPerSample: 8}, []float32{0.5, -0.5, -1},
+			"464f524d 00000032 41494646" +
+				"434f4d4d 00000012 0001 00000003 0008 400bfa00000000000000" +
+				"53534e44 0000000b 00000000 00000000 40c080 00"},
+		{"aifc fl32", pcm.Format{SampleRate: 48000, Channels: 1, BitsPerSample: 32, Float: true}, []float32{0.5},
+			"464f524d 00000054 41494643" + // FORM, AIFC
+				"46564552 00000004 a2805140" + // FVER
+				"434f4d4d 00000028 0001 00000001 0020 400ebb80000000000000" +
+				"666c3332 11" + hex.EncodeToString([]byte("IEEE 32-bit float")) + // fl32
+				"53534e44 0000000c 00000000 00000000 3f000000"},
+	} {
+		path := filepath.Join(dir, "a.aiff")
+		w, err := Create(path, c.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteSamples(c.samples); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := unhex(c.want); !bytes.Equal(b, want) {
+			t.Errorf("%s:\n% x\nwant\n% x", c.name, b, want)
+		}
+	}
+}
+
+// TestWriterFlushInterval 写入时按 FlushInterval 更新 FORM、COMM 和 SSND 中的大小
+func TestWriterFlushInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "flush.aiff")
+	w, err := Create(path, pcm.Format{SampleRate: 44100, Channels: 1, BitsPerSample: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	sizes := func() string {
+		t.Helper()
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hex.EncodeToString(b[4:8]) + " " + hex.EncodeToString(b[22:26]) + " " + hex.EncodeToString(b[42:46])
+	}
+
+	w.FlushInterval = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if err := w.WriteSamples(make([]float32, 10)); err != nil {
+		t.Fatal(err)
+	}
+	// FORM 46+20、10 帧、SSND 8+20
+	if got, want := sizes(), "00000042 0000000a 0000001c"; got != want {
+		t.Errorf("after write: %s, want %s", got, want)
+	}
+
+	w.FlushInterval = time.Hour
+	if err := w.WriteSamples(make([]float32, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sizes(), "00000042 0000000a 0000001c"; got != want {
+		t.Errorf("before interval: %s, want %s", got, want)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sizes(), "0000004c 0000000f 00000026"; got != want {
+		t.Errorf("after Flush: %s, want %s", got, want)
+	}
+}
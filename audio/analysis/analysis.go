@@ -0,0 +1,79 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package analysis 实时音频分析：电平表、FFT 频谱、YIN 音高和语音检测。
+//
+// 各分析器的 Process 可以直接在录音回调中调用：不分配内存，
+// 结果用原子操作 (频谱帧用很短的锁) 发布，可以在其它 goroutine (如 UI) 中读取。
+// 同一个分析器的 Process 只能在一个 goroutine 中调用。
+//
+//	meter := analysis.NewMeter(48000, 1)
+//	pitch := analysis.NewPitch(48000, 80, 1000)
+//	cfg.Callback = func(s *audio.Stream, buf *audio.Buffer) bool {
+//		meter.Process(buf.Float32)
+//		pitch.Process(buf.Float32)
+//		return true
+//	}
+//	...
+//	db := analysis.DB(meter.RMS(0))
+//	freq, clarity := pitch.Pitch()
+package analysis
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// MinDB DB 的下限
+const MinDB = -120
+
+// DB 线性幅度转换为 dBFS，不小于 MinDB
+func DB(v float32) float32 {
+	if v <= 0 {
+		return MinDB
+	}
+	db := float32(20 * math.Log10(float64(v)))
+	if db < MinDB {
+		return MinDB
+	}
+	return db
+}
+
+// Downmix 把交错存放的 channels 声道平均为单声道，结果追加到 dst 后返回。
+// dst 容量足够时不分配内存
+func Downmix(dst, src []float32, channels int) []float32 {
+	if channels == 1 {
+		return append(dst, src...)
+	}
+	g := 1 / float32(channels)
+	for f := 0; f+channels <= len(src); f += channels {
+		var s float32
+		for _, v := range src[f : f+channels] {
+			s += v
+		}
+		dst = append(dst, s*g)
+	}
+	return dst
+}
+
+// coeff 时间常数为 seconds 的一阶平滑系数
+func coeff(seconds float64, sampleRate int) float32 {
+	if seconds <= 0 {
+		return 0
+	}
+	return float32(math.Exp(-1 / (seconds * float64(sampleRate))))
+}
+
+// atomicFloat 在 Process 和读取的 goroutine 之间传递 float32
+type atomicFloat struct {
+	bits uint32
+}
+
+func (a *atomicFloat) load() float32 {
+	return math.Float32frombits(atomic.LoadUint32(&a.bits))
+}
+
+func (a *atomicFloat) store(v float32) {
+	atomic.StoreUint32(&a.bits, math.Float32bits(v))
+}
@@ -0,0 +1,314 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// sine frames 个样本的 freq Hz 正弦，幅度 amp
+func sine(frames, rate int, freq, amp float64) []float32 {
+	s := make([]float32, frames)
+	for i := range s {
+		s[i] = float32(amp * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return s
+}
+
+// noise frames 个 [-amp, amp) 的均匀白噪声
+func noise(frames int, amp float32, seed uint32) []float32 {
+	s := make([]float32, frames)
+	for i := range s {
+		seed = seed*1664525 + 1013904223
+		s[i] = float32(int32(seed)) / (1 << 31) * amp
+	}
+	return s
+}
+
+// interleave 把各声道的样本交错存放
+func interleave(chans ...[]float32) []float32 {
+	s := make([]float32, 0, len(chans)*len(chans[0]))
+	for i := range chans[0] {
+		for _, c := range chans {
+			s = append(s, c[i])
+		}
+	}
+	return s
+}
+
+func within(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol
+}
+
+func TestMeterSine(t *testing.T) {
+	const rate = 48000
+	m := NewMeter(rate, 2)
+	// 2 秒，远长于 RMS 的积分时间
+	m.Process(interleave(sine(2*rate, rate, 1000, 0.5), sine(2*rate, rate, 250, 0.25)))
+	for c, amp := range []float64{0.5, 0.25} {
+		if rms := float64(m.RMS(c)); !within(rms, amp/math.Sqrt2, 0.01*amp) {
+			t.Errorf("channel %d: RMS %.4f, want %.4f", c, rms, amp/math.Sqrt2)
+		}
+		if peak := float64(m.Peak(c)); !within(peak, amp, 0.01*amp) {
+			t.Errorf("channel %d: Peak %.4f, want %.4f", c, peak, amp)
+		}
+		if hold := float64(m.PeakHold(c)); !within(hold, amp, 0.001) {
+			t.Errorf("channel %d: PeakHold %.4f, want %.4f", c, hold, amp)
+		}
+	}
+	if db := DB(m.RMS(0)); !within(float64(db), -9.03, 0.1) {
+		t.Errorf("RMS %.2f dBFS, want -9.03", db)
+	}
+	if n := m.Clips(0); n != 0 {
+		t.Errorf("%d clips without clipping", n)
+	}
+}
+
+// TestMeterHold 峰值立即释放，保持的峰值在 DefaultPeakHold 之后才下降
+func TestMeterHold(t *testing.T) {
+	const rate = 48000
+	m := NewMeter(rate, 1)
+	m.Process(sine(rate/10, rate, 1000, 0.3))
+	m.Process([]float32{-1, 1}) // 两个削波的样本
+
+	// 1 秒后：峰值按 DefaultPeakRelease 释放，保持的峰值不变
+	m.Process(sine(rate, rate, 1000, 0.3))
+	if peak, want := m.Peak(0), math.Exp(-1/DefaultPeakRelease.Seconds()); !within(float64(peak), want, 0.01) {
+		t.Errorf("after 1s: Peak %.3f, want %.3f", peak, want)
+	}
+	if hold := m.PeakHold(0); hold != 1 {
+		t.Errorf("after 1s: PeakHold %.3f, want 1", hold)
+	}
+	if n := m.Clips(0); n != 2 {
+		t.Errorf("Clips = %d, want 2", n)
+	}
+	if n := m.Clips(0); n != 0 {
+		t.Errorf("Clips after read = %d, want 0", n)
+	}
+
+	// 超过保持时间后跟随峰值，这时峰值已释放到正弦的幅度
+	m.Process(sine(rate+rate/10, rate, 1000, 0.3))
+	if hold := m.PeakHold(0); !within(float64(hold), 0.3, 0.01) {
+		t.Errorf("after 2.1s: PeakHold %.3f, want 0.3", hold)
+	}
+
+	// 静音后 RMS 和峰值按时间常数下降
+	m.Process(make([]float32, rate))
+	// 均方值按 DefaultRMSWindow 下降，RMS 的时间常数是它的两倍
+	if rms, want := m.RMS(0), 0.3/math.Sqrt2*math.Exp(-1/(2*DefaultRMSWindow.Seconds())); !within(float64(rms), want, want*0.05) {
+		t.Errorf("after 1s of silence: RMS %.4f, want %.4f", rms, want)
+	}
+	if peak := m.Peak(0); !within(float64(peak), 0.3*math.Exp(-1/DefaultPeakRelease.Seconds()), 0.01) {
+		t.Errorf("after 1s of silence: Peak %.4f, want %.4f", peak, 0.3*math.Exp(-1/DefaultPeakRelease.Seconds()))
+	}
+
+	m.Reset()
+	if m.RMS(0) != 0 || m.Peak(0) != 0 || m.PeakHold(0) != 0 {
+		t.Errorf("after Reset: %v %v %v", m.RMS(0), m.Peak(0), m.PeakHold(0))
+	}
+}
+
+func TestFFT(t *testing.T) {
+	const n = 64
+	f := NewFFT(n)
+	re, im := make([]float32, n), make([]float32, n)
+	for i := range re {
+		re[i] = float32(math.Cos(2 * math.Pi * 5 * float64(i) / n))
+	}
+	f.Transform(re, im)
+	for k := 0; k < n; k++ {
+		want := 0.0
+		if k == 5 || k == n-5 {
+			want = n / 2
+		}
+		if !within(float64(re[k]), want, 1e-3) || !within(float64(im[k]), 0, 1e-3) {
+			t.Errorf("bin %d = %v%+vi, want %v", k, re[k], im[k], want)
+		}
+	}
+}
+
+// TestSpectrumTone 落在频点中心的满幅正弦约为 0 dBFS，远离它的频点很低
+func TestSpectrumTone(t *testing.T) {
+	const rate, size, hop = 48000, 1024, 256
+	for _, w := range []Window{WindowHann, WindowHamming, WindowBlackman, WindowRect} {
+		for _, c := range []struct {
+			bin int
+			amp float64
+			db  float64
+		}{
+			{64, 1, 0},
+			{100, 0.5, -6.02},
+			{7, 0.1, -20},
+		} {
+			s := NewSpectrum(rate, size, hop, w)
+			freq := float64(s.BinFrequency(c.bin))
+			if want := float64(c.bin) * rate / size; freq != want {
+				t.Fatalf("BinFrequency(%d) = %v, want %v", c.bin, freq, want)
+			}
+			frames := 0
+			s.Process(sine(4*size, rate, freq, c.amp), func([]float32) { frames++ })
+			if want := (4*size-size)/hop + 1; frames != want {
+				t.Errorf("window %d: %d frames, want %d", w, frames, want)
+			}
+			db, n := s.Latest(nil)
+			if n != uint64(frames) || len(db) != s.Bins() || s.Bins() != size/2+1 {
+				t.Fatalf("Latest: %d bins, frame %d", len(db), n)
+			}
+			max := 0
+			for i := range db {
+				if db[i] > db[max] {
+					max = i
+				}
+			}
+			if max != c.bin || !within(float64(db[max]), c.db, 0.05) {
+				t.Errorf("window %d, %.0f Hz: peak %.2f dB at bin %d, want %.2f at %d", w, freq, db[max], max, c.db, c.bin)
+			}
+			// 旁瓣：Rect 窗的频点中心正弦没有泄漏，其它窗在主瓣之外衰减
+			for i := range db {
+				if d := i - c.bin; (d > 4 || d < -4) && db[i] > float32(c.db)-60 {
+					t.Errorf("window %d, %.0f Hz: bin %d at %.1f dB", w, freq, i, db[i])
+					break
+				}
+			}
+		}
+	}
+}
+
+// TestPitch 带谐波的周期信号，各种基频的误差都在 0.5% 以内
+func TestPitch(t *testing.T) {
+	const rate = 48000
+	p := NewPitch(rate, 60, 1000)
+	for _, f0 := range []float64{65.41, 110, 196, 261.63, 440, 659.26, 880} {
+		s := make([]float32, rate/2)
+		for i := range s {
+			x := 2 * math.Pi * f0 * float64(i) / rate
+			s[i] = float32(0.4*math.Sin(x) + 0.2*math.Sin(2*x+0.3) + 0.1*math.Sin(3*x+1.1))
+		}
+		p.Process(s)
+		freq, clarity := p.Pitch()
+		if !within(float64(freq), f0, f0*0.005) || clarity < 0.9 {
+			t.Errorf("%.2f Hz: %.2f Hz, clarity %.2f", f0, freq, clarity)
+		}
+	}
+
+	// 静音和噪声没有音高
+	for name, s := range map[string][]float32{
+		"silence": make([]float32, rate/2),
+		"noise":   noise(rate/2, 0.5, 7),
+	} {
+		p.Process(s)
+		if freq, clarity := p.Pitch(); freq != 0 {
+			t.Errorf("%s: %.2f Hz, clarity %.2f, want no pitch", name, freq, clarity)
+		}
+	}
+}
+
+// TestVAD 安静时不触发；正弦出现后在 Attack 帧内触发；
+// 回到安静后保持 Hangover，之后结束
+func TestVAD(t *testing.T) {
+	const rate = 16000
+	ms := func(d time.Duration) int { return int(d.Seconds() * rate) }
+	frame := ms(DefaultVADFrame)
+
+	v := NewVAD(rate)
+	if v.Process(noise(ms(time.Second), 0.001, 1)) {
+		t.Fatal("active on background noise")
+	}
+	if level, floor := v.Level(); !within(float64(floor), float64(level), 3) || floor > -60 {
+		t.Errorf("noise: level %.1f, floor %.1f dBFS", level, floor)
+	}
+
+	tone := sine(ms(500*time.Millisecond), rate, 300, 0.3)
+	if v.Process(tone[:(DefaultVADAttack-1)*frame]) {
+		t.Error("active before Attack frames")
+	}
+	if !v.Process(tone[(DefaultVADAttack-1)*frame:]) || !v.Active() {
+		t.Fatal("not active on a tone")
+	}
+	if level, floor := v.Level(); !within(float64(level), -13.5, 0.5) || floor > -60 {
+		t.Errorf("tone: level %.1f, floor %.1f dBFS; the floor should not follow speech", level, floor)
+	}
+
+	quiet := noise(ms(DefaultVADHangover+100*time.Millisecond), 0.001, 2)
+	hang := ms(DefaultVADHangover) - frame
+	if !v.Process(quiet[:hang]) {
+		t.Error("inactive within the hangover")
+	}
+	if v.Process(quiet[hang:]) || v.Active() {
+		t.Error("still active after the hangover")
+	}
+
+	// 缩短 hangover
+	v.SetHangover(100 * time.Millisecond)
+	v.Process(tone)
+	if v.Process(noise(ms(140*time.Millisecond), 0.001, 3)) {
+		t.Error("still active after a 100ms hangover")
+	}
+}
+
+const benchRate = 48000
+
+// benchBlocks 低延迟回调常见的块长：48kHz 下 4ms 和 10ms
+var benchBlocks = []int{192, 480}
+
+// benchSignal 1 秒的 220Hz 正弦加噪声，channels 声道交错存放
+func benchSignal(channels int) []float32 {
+	s := make([]float32, benchRate*channels)
+	seed := uint32(1)
+	for i := 0; i < benchRate; i++ {
+		v := 0.5 * math.Sin(2*math.Pi*220*float64(i)/benchRate)
+		for c := 0; c < channels; c++ {
+			seed = seed*1664525 + 1013904223
+			s[i*channels+c] = float32(v) + float32(int32(seed))/(1<<31)*0.05
+		}
+	}
+	return s
+}
+
+// benchProcess 每次迭代处理一块 frames 帧，循环使用 signal
+func benchProcess(b *testing.B, channels int, process func(block []float32)) {
+	signal := benchSignal(channels)
+	for _, frames := range benchBlocks {
+		b.Run(fmt.Sprint(frames), func(b *testing.B) {
+			n := frames * channels
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i, pos := 0, 0; i < b.N; i++ {
+				if pos+n > len(signal) {
+					pos = 0
+				}
+				process(signal[pos : pos+n])
+				pos += n
+			}
+		})
+	}
+}
+
+func BenchmarkMeterProcess(b *testing.B) {
+	m := NewMeter(benchRate, 2)
+	benchProcess(b, 2, m.Process)
+}
+
+func BenchmarkSpectrumProcess(b *testing.B) {
+	s := NewSpectrum(benchRate, 1024, 256, WindowHann)
+	benchProcess(b, 1, func(block []float32) {
+		s.Process(block, nil)
+	})
+}
+
+func BenchmarkPitchProcess(b *testing.B) {
+	p := NewPitch(benchRate, 60, 1000)
+	benchProcess(b, 1, p.Process)
+}
+
+func BenchmarkVADProcess(b *testing.B) {
+	v := NewVAD(benchRate)
+	benchProcess(b, 1, func(block []float32) {
+		v.Process(block)
+	})
+}
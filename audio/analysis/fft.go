@@ -0,0 +1,231 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"math"
+	"sync"
+)
+
+// FFT 长度为 2 的幂的基 2 复数 FFT，旋转因子预先计算
+type FFT struct {
+	n        int
+	cos, sin []float32
+	rev      []int
+}
+
+// NewFFT n 必须是 2 的幂
+func NewFFT(n int) *FFT {
+	if n < 2 || n&(n-1) != 0 {
+		panic("analysis: FFT size must be a power of two")
+	}
+	f := &FFT{
+		n:   n,
+		cos: make([]float32, n/2),
+		sin: make([]float32, n/2),
+		rev: make([]int, n),
+	}
+	for i := range f.cos {
+		a := -2 * math.Pi * float64(i) / float64(n)
+		f.cos[i] = float32(math.Cos(a))
+		f.sin[i] = float32(math.Sin(a))
+	}
+	bits := 0
+	for 1<<uint(bits) < n {
+		bits++
+	}
+	for i := range f.rev {
+		r := 0
+		for b := 0; b < bits; b++ {
+			r |= (i >> uint(b) & 1) << uint(bits-1-b)
+		}
+		f.rev[i] = r
+	}
+	return f
+}
+
+// Size 长度
+func (f *FFT) Size() int {
+	return f.n
+}
+
+// Transform 原地正变换，re 和 im 的长度都是 Size()
+func (f *FFT) Transform(re, im []float32) {
+	n := f.n
+	re, im = re[:n], im[:n]
+	for i, r := range f.rev {
+		if i < r {
+			re[i], re[r] = re[r], re[i]
+			im[i], im[r] = im[r], im[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		half := size / 2
+		step := n / size
+		for start := 0; start < n; start += size {
+			for k := 0; k < half; k++ {
+				wr, wi := f.cos[k*step], f.sin[k*step]
+				a, b := start+k, start+k+half
+				tr := re[b]*wr - im[b]*wi
+				ti := re[b]*wi + im[b]*wr
+				re[b], im[b] = re[a]-tr, im[a]-ti
+				re[a], im[a] = re[a]+tr, im[a]+ti
+			}
+		}
+	}
+}
+
+// Window 窗函数
+type Window int
+
+const (
+	WindowHann Window = iota
+	WindowHamming
+	WindowBlackman
+	WindowRect
+)
+
+// Coefficients 长度为 n 的周期窗
+func (w Window) Coefficients(n int) []float32 {
+	c := make([]float32, n)
+	for i := range c {
+		x := 2 * math.Pi * float64(i) / float64(n)
+		switch w {
+		case WindowHann:
+			c[i] = float32(0.5 - 0.5*math.Cos(x))
+		case WindowHamming:
+			c[i] = float32(0.54 - 0.46*math.Cos(x))
+		case WindowBlackman:
+			c[i] = float32(0.42 - 0.5*math.Cos(x) + 0.08*math.Cos(2*x))
+		default:
+			c[i] = 1
+		}
+	}
+	return c
+}
+
+// Spectrum 单声道输入的短时频谱，每 hop 个样本计算一帧加窗 FFT。
+// 帧是 Size()/2+1 个频点的幅度 (dBFS)，满幅正弦波的频点约为 0 dB
+type Spectrum struct {
+	sampleRate int
+	size, hop  int
+	fft        *FFT
+	window     []float32
+	scale      float32 // 幅度归一化：2 / Σwindow
+
+	ring   []float32 // 最近 size 个样本
+	pos    int       // ring 中下一个写入的位置
+	filled int
+	since  int // 上一帧之后的样本数
+
+	re, im []float32
+	frame  []float32
+
+	mu     sync.Mutex
+	latest []float32
+	frames uint64
+}
+
+// NewSpectrum size 为 FFT 长度 (2 的幂)，hop 为帧移
+func NewSpectrum(sampleRate, size, hop int, w Window) *Spectrum {
+	if hop <= 0 || hop > size {
+		hop = size / 2
+	}
+	s := &Spectrum{
+		sampleRate: sampleRate,
+		size:       size,
+		hop:        hop,
+		fft:        NewFFT(size),
+		window:     w.Coefficients(size),
+		ring:       make([]float32, size),
+		re:         make([]float32, size),
+		im:         make([]float32, size),
+		frame:      make([]float32, size/2+1),
+		latest:     make([]float32, size/2+1),
+	}
+	var sum float32
+	for _, v := range s.window {
+		sum += v
+	}
+	s.scale = 2 / sum
+	for i := range s.latest {
+		s.latest[i] = MinDB
+	}
+	return s
+}
+
+// Size FFT 长度
+func (s *Spectrum) Size() int {
+	return s.size
+}
+
+// Bins 每帧的频点数
+func (s *Spectrum) Bins() int {
+	return s.size/2 + 1
+}
+
+// BinFrequency 第 i 个频点的中心频率 (Hz)
+func (s *Spectrum) BinFrequency(i int) float32 {
+	return float32(i) * float32(s.sampleRate) / float32(s.size)
+}
+
+// Process 输入单声道样本，每凑满一帧调用 frame (可以为 nil)。
+// frame 的参数在返回后会被复用，需要保留时复制
+func (s *Spectrum) Process(samples []float32, frame func(db []float32)) {
+	for _, v := range samples {
+		s.ring[s.pos] = v
+		s.pos++
+		if s.pos == s.size {
+			s.pos = 0
+		}
+		if s.filled < s.size {
+			s.filled++
+		}
+		s.since++
+		if s.filled == s.size && s.since >= s.hop {
+			s.since = 0
+			s.compute()
+			if frame != nil {
+				frame(s.frame)
+			}
+		}
+	}
+}
+
+func (s *Spectrum) compute() {
+	// ring 从 pos 开始是最旧的样本
+	n := copy(s.re, s.ring[s.pos:])
+	copy(s.re[n:], s.ring[:s.pos])
+	for i, w := range s.window {
+		s.re[i] *= w
+		s.im[i] = 0
+	}
+	s.fft.Transform(s.re, s.im)
+	last := len(s.frame) - 1
+	for i := range s.frame {
+		re, im := s.re[i], s.im[i]
+		mag := float32(math.Sqrt(float64(re*re+im*im))) * s.scale
+		if i == 0 || i == last {
+			// 直流和奈奎斯特频点没有对称的负频率
+			mag /= 2
+		}
+		s.frame[i] = DB(mag)
+	}
+
+	s.mu.Lock()
+	copy(s.latest, s.frame)
+	s.frames++
+	s.mu.Unlock()
+}
+
+// Latest 把最近一帧复制到 dst 后返回，以及已计算的帧数。
+// 可以在其它 goroutine 中调用
+func (s *Spectrum) Latest(dst []float32) ([]float32, uint64) {
+	s.mu.Lock()
+	dst = append(dst[:0], s.latest...)
+	n := s.frames
+	s.mu.Unlock()
+	return dst, n
+}
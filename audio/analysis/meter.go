@@ -0,0 +1,148 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// 默认的电平表动态特性
+const (
+	DefaultRMSWindow   = 300 * time.Millisecond // 同 VU 表的积分时间
+	DefaultPeakRelease = 1500 * time.Millisecond
+	DefaultPeakHold    = 2 * time.Second
+)
+
+// ClipLevel 不小于此幅度的样本计为削波
+const ClipLevel = 0.999
+
+// Meter 每个声道的 RMS 和峰值电平表。
+// RMS 按时间常数积分；峰值立即上升，按指数释放；另有保持一段时间的峰值
+type Meter struct {
+	sampleRate int
+	channels   int
+
+	rmsCoeff     float32
+	releaseCoeff float32
+	holdSamples  int
+
+	ms       []float32 // 平滑后的均方值
+	peak     []float32
+	hold     []float32
+	holdLeft []int
+
+	rmsOut, peakOut, holdOut []atomicFloat
+	clips                    []uint32
+}
+
+// NewMeter channels 声道交错输入的电平表
+func NewMeter(sampleRate, channels int) *Meter {
+	m := &Meter{
+		sampleRate: sampleRate,
+		channels:   channels,
+		ms:         make([]float32, channels),
+		peak:       make([]float32, channels),
+		hold:       make([]float32, channels),
+		holdLeft:   make([]int, channels),
+		rmsOut:     make([]atomicFloat, channels),
+		peakOut:    make([]atomicFloat, channels),
+		holdOut:    make([]atomicFloat, channels),
+		clips:      make([]uint32, channels),
+	}
+	m.SetBallistics(DefaultRMSWindow, DefaultPeakRelease, DefaultPeakHold)
+	return m
+}
+
+// SetBallistics 设置 RMS 积分时间、峰值释放时间 (下降到 1/e) 和峰值保持时间。
+// 不能与 Process 同时调用
+func (m *Meter) SetBallistics(rmsWindow, peakRelease, peakHold time.Duration) {
+	m.rmsCoeff = coeff(rmsWindow.Seconds(), m.sampleRate)
+	m.releaseCoeff = coeff(peakRelease.Seconds(), m.sampleRate)
+	m.holdSamples = int(peakHold.Seconds() * float64(m.sampleRate))
+}
+
+// Channels 声道数
+func (m *Meter) Channels() int {
+	return m.channels
+}
+
+// Process 输入交错存放的样本
+func (m *Meter) Process(samples []float32) {
+	ch := m.channels
+	frames := len(samples) / ch
+	for c := 0; c < ch; c++ {
+		ms, peak, hold, holdLeft := m.ms[c], m.peak[c], m.hold[c], m.holdLeft[c]
+		var clips uint32
+		for i := c; i < frames*ch; i += ch {
+			x := samples[i]
+			if x < 0 {
+				x = -x
+			}
+			ms = x*x + m.rmsCoeff*(ms-x*x)
+			if x >= peak {
+				peak = x
+			} else {
+				peak *= m.releaseCoeff
+			}
+			if x >= hold {
+				hold, holdLeft = x, m.holdSamples
+			} else if holdLeft > 0 {
+				holdLeft--
+			} else {
+				hold = peak
+			}
+			if x >= ClipLevel {
+				clips++
+			}
+		}
+		// 防止非规格化数拖慢运算
+		if ms < 1e-20 {
+			ms = 0
+		}
+		if peak < 1e-10 {
+			peak = 0
+		}
+		m.ms[c], m.peak[c], m.hold[c], m.holdLeft[c] = ms, peak, hold, holdLeft
+		m.rmsOut[c].store(float32(math.Sqrt(float64(ms))))
+		m.peakOut[c].store(peak)
+		m.holdOut[c].store(hold)
+		if clips > 0 {
+			atomic.AddUint32(&m.clips[c], clips)
+		}
+	}
+}
+
+// RMS 声道 ch 的 RMS 电平，线性幅度
+func (m *Meter) RMS(ch int) float32 {
+	return m.rmsOut[ch].load()
+}
+
+// Peak 声道 ch 的峰值电平，线性幅度
+func (m *Meter) Peak(ch int) float32 {
+	return m.peakOut[ch].load()
+}
+
+// PeakHold 声道 ch 保持的峰值，线性幅度
+func (m *Meter) PeakHold(ch int) float32 {
+	return m.holdOut[ch].load()
+}
+
+// Clips 声道 ch 自上次调用以来削波的样本数，读取后清零
+func (m *Meter) Clips(ch int) int {
+	return int(atomic.SwapUint32(&m.clips[ch], 0))
+}
+
+// Reset 清除所有状态，不能与 Process 同时调用
+func (m *Meter) Reset() {
+	for c := 0; c < m.channels; c++ {
+		m.ms[c], m.peak[c], m.hold[c], m.holdLeft[c] = 0, 0, 0, 0
+		m.rmsOut[c].store(0)
+		m.peakOut[c].store(0)
+		m.holdOut[c].store(0)
+		atomic.SwapUint32(&m.clips[c], 0)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+// DefaultYINThreshold 累积均值归一化差分函数的阈值，越小越不容易误判，也越容易判为无音高
+const DefaultYINThreshold = 0.15
+
+// Pitch 单声道输入的 YIN 基频估计 (de Cheveigné & Kawahara, 2002)。
+// 保留最近 window+maxLag 个样本，每 hop 个样本估计一次
+type Pitch struct {
+	sampleRate     int
+	minLag, maxLag int
+	window, hop    int
+	threshold      float32
+
+	buf   []float32 // 最近 window+maxLag 个样本，线性存放
+	n     int
+	since int
+	diff  []float32
+
+	// 差分函数中的互相关项用 FFT 计算
+	fft      *FFT
+	are, aim []float32
+	bre, bim []float32
+
+	freq, clarity atomicFloat
+}
+
+// NewPitch 检测 minFreq 到 maxFreq (Hz) 之间的基频。
+// 分析窗长 = 最大周期，hop 为窗长的一半
+func NewPitch(sampleRate int, minFreq, maxFreq float64) *Pitch {
+	if minFreq <= 0 || maxFreq <= minFreq {
+		panic("analysis: bad pitch range")
+	}
+	p := &Pitch{
+		sampleRate: sampleRate,
+		minLag:     int(float64(sampleRate) / maxFreq),
+		maxLag:     int(float64(sampleRate)/minFreq) + 1,
+		threshold:  DefaultYINThreshold,
+	}
+	if p.minLag < 2 {
+		p.minLag = 2
+	}
+	p.window = p.maxLag
+	p.hop = p.window / 2
+	p.buf = make([]float32, p.window+p.maxLag)
+	p.diff = make([]float32, p.maxLag+1)
+	n := 2
+	for n < len(p.buf) {
+		n <<= 1
+	}
+	p.fft = NewFFT(n)
+	p.are, p.aim = make([]float32, n), make([]float32, n)
+	p.bre, p.bim = make([]float32, n), make([]float32, n)
+	return p
+}
+
+// SetThreshold 设置 YIN 阈值，不能与 Process 同时调用
+func (p *Pitch) SetThreshold(t float32) {
+	p.threshold = t
+}
+
+// Process 输入单声道样本
+func (p *Pitch) Process(samples []float32) {
+	size := len(p.buf)
+	for len(samples) > 0 {
+		// 每次最多补到下一次估计
+		k := p.hop - p.since
+		if k > len(samples) {
+			k = len(samples)
+		}
+		if p.n+k > size {
+			drop := p.n + k - size
+			p.n = copy(p.buf, p.buf[drop:p.n])
+		}
+		p.n += copy(p.buf[p.n:], samples[:k])
+		samples = samples[k:]
+		p.since += k
+		if p.since >= p.hop {
+			p.since = 0
+			if p.n == size {
+				p.estimate()
+			}
+		}
+	}
+}
+
+// Pitch 最近一次估计的基频 (Hz) 和清晰度 (0~1)，无音高时频率为 0。
+// 可以在其它 goroutine 中调用
+func (p *Pitch) Pitch() (freq, clarity float32) {
+	return p.freq.load(), p.clarity.load()
+}
+
+func (p *Pitch) estimate() {
+	x := p.buf
+	w := p.window
+	d := p.diff
+
+	// 差分函数 d(τ) = Σx[j]² + Σx[j+τ]² - 2Σx[j]x[j+τ]，
+	// 互相关用 FFT 计算，O(N log N) 而不是 O(window·maxLag)
+	for i := range p.are {
+		p.are[i], p.aim[i], p.bim[i] = 0, 0, 0
+	}
+	copy(p.are, x[:w])
+	n := copy(p.bre, x)
+	for i := n; i < len(p.bre); i++ {
+		p.bre[i] = 0
+	}
+	p.fft.Transform(p.are, p.aim)
+	p.fft.Transform(p.bre, p.bim)
+	// conj(A)·B，再取共轭做正变换得到逆变换
+	for i := range p.are {
+		ar, ai, br, bi := p.are[i], p.aim[i], p.bre[i], p.bim[i]
+		p.are[i] = ar*br + ai*bi
+		p.aim[i] = -(ar*bi - ai*br)
+	}
+	p.fft.Transform(p.are, p.aim)
+	scale := 1 / float32(p.fft.Size())
+
+	var e0 float64
+	for _, v := range x[:w] {
+		e0 += float64(v * v)
+	}
+	et := e0
+	d[0] = 0
+	for tau := 1; tau <= p.maxLag; tau++ {
+		a, b := x[tau-1], x[tau+w-1]
+		et += float64(b*b - a*a)
+		v := float32(e0+et) - 2*p.are[tau]*scale
+		if v < 0 {
+			v = 0
+		}
+		d[tau] = v
+	}
+
+	// 累积均值归一化
+	d[0] = 1
+	var sum float32
+	for tau := 1; tau <= p.maxLag; tau++ {
+		sum += d[tau]
+		if sum > 0 {
+			d[tau] *= float32(tau) / sum
+		} else {
+			d[tau] = 1
+		}
+	}
+
+	// 第一个低于阈值的谷
+	tau := -1
+	for t := p.minLag; t < p.maxLag; t++ {
+		if d[t] < p.threshold {
+			for t+1 < p.maxLag && d[t+1] < d[t] {
+				t++
+			}
+			tau = t
+			break
+		}
+	}
+	if tau < 0 {
+		p.freq.store(0)
+		p.clarity.store(0)
+		return
+	}
+
+	// 抛物线插值
+	period := float32(tau)
+	if a, b, c := d[tau-1], d[tau], d[tau+1]; a+c-2*b != 0 {
+		period += (a - c) / (2 * (a + c - 2*b))
+	}
+	clarity := 1 - d[tau]
+	if clarity < 0 {
+		clarity = 0
+	}
+	p.freq.store(float32(p.sampleRate) / period)
+	p.clarity.store(clarity)
+}
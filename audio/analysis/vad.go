@@ -0,0 +1,135 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// 默认的语音检测参数
+const (
+	DefaultVADFrame     = 20 * time.Millisecond
+	DefaultVADThreshold = 9 // 高于噪声基底的 dB
+	DefaultVADAttack    = 2 // 连续超过阈值的帧数
+	DefaultVADHangover  = 300 * time.Millisecond
+)
+
+// 噪声基底的跟踪：下降快，上升慢 (时间常数，秒)
+const (
+	noiseFall = 0.1
+	noiseRise = 5.0
+	noiseMin  = -90 // 数字静音时噪声基底的下限 (dBFS)
+)
+
+// VAD 基于能量的语音检测。
+// 按帧计算能量，噪声基底随安静的帧自适应；能量高于基底 Threshold dB
+// 连续 Attack 帧后判为语音，低于阈值 Hangover 时间后判为静音
+type VAD struct {
+	frameSize int
+	threshold float32
+	attack    int
+	hangover  int // 帧
+
+	fallCoeff, riseCoeff float32
+
+	sum      float32
+	count    int
+	noise    float32 // dBFS
+	above    int
+	below    int
+	active   bool
+	started  bool
+	activeAt uint32
+
+	level, floor atomicFloat
+}
+
+// NewVAD 单声道输入的语音检测器
+func NewVAD(sampleRate int) *VAD {
+	v := &VAD{
+		frameSize: int(DefaultVADFrame.Seconds() * float64(sampleRate)),
+		threshold: DefaultVADThreshold,
+		attack:    DefaultVADAttack,
+	}
+	frameRate := 1 / DefaultVADFrame.Seconds()
+	v.fallCoeff = float32(math.Exp(-1 / (noiseFall * frameRate)))
+	v.riseCoeff = float32(math.Exp(-1 / (noiseRise * frameRate)))
+	v.hangover = int(DefaultVADHangover / DefaultVADFrame)
+	return v
+}
+
+// SetThreshold 设置高于噪声基底多少 dB 判为语音，不能与 Process 同时调用
+func (v *VAD) SetThreshold(db float32) {
+	v.threshold = db
+}
+
+// SetHangover 设置语音结束后保持的时间，不能与 Process 同时调用
+func (v *VAD) SetHangover(d time.Duration) {
+	v.hangover = int(d / DefaultVADFrame)
+}
+
+// Process 输入单声道样本，返回处理后是否处于语音中
+func (v *VAD) Process(samples []float32) bool {
+	for _, x := range samples {
+		v.sum += x * x
+		v.count++
+		if v.count == v.frameSize {
+			v.frame(DB(float32(math.Sqrt(float64(v.sum / float32(v.count))))))
+			v.sum, v.count = 0, 0
+		}
+	}
+	return v.active
+}
+
+func (v *VAD) frame(level float32) {
+	if !v.started {
+		v.started = true
+		v.noise = level
+	}
+	if level > v.noise+v.threshold {
+		v.above++
+		v.below = 0
+		if v.above >= v.attack {
+			v.active = true
+		}
+	} else {
+		v.above = 0
+		v.below++
+		if v.below > v.hangover {
+			v.active = false
+		}
+	}
+
+	// 只用非语音帧更新噪声基底
+	switch {
+	case level < v.noise:
+		v.noise = level + v.fallCoeff*(v.noise-level)
+	case !v.active && v.above == 0:
+		v.noise = level + v.riseCoeff*(v.noise-level)
+	}
+	if v.noise < noiseMin {
+		v.noise = noiseMin
+	}
+
+	v.level.store(level)
+	v.floor.store(v.noise)
+	var a uint32
+	if v.active {
+		a = 1
+	}
+	atomic.StoreUint32(&v.activeAt, a)
+}
+
+// Active 是否处于语音中，可以在其它 goroutine 中调用
+func (v *VAD) Active() bool {
+	return atomic.LoadUint32(&v.activeAt) != 0
+}
+
+// Level 最近一帧的能量和噪声基底 (dBFS)，可以在其它 goroutine 中调用
+func (v *VAD) Level() (level, floor float32) {
+	return v.level.load(), v.floor.load()
+}
@@ -0,0 +1,371 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package audio 基于回调的音频播放和录音流。
+//
+// API 26 及以上的设备使用 AAudio (运行时 dlopen libaaudio.so)，
+// 其它设备使用 OpenSL ES 的 buffer queue。
+// 回调在音频线程中调用，不能阻塞，也不要在其中分配内存。
+// 录音需要 android.permission.RECORD_AUDIO 权限。
+//
+// Activity 暂停时运行中的流自动停止 (录音不再占用麦克风)，恢复时重新启动。
+// 需要在后台继续播放的流设置 Config.KeepRunningOnPause：
+//
+//	cfg.KeepRunningOnPause = true
+//	s, err := audio.Open(cfg)
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"unsafe"
+
+	app "github.com/gooid/gooid/internal/ndk"
+)
+
+// Direction 流的方向
+type Direction int
+
+const (
+	DIRECTION_OUTPUT Direction = iota // 播放
+	DIRECTION_INPUT                   // 录音
+)
+
+// Format 样本格式，样本总是交错存放
+type Format int
+
+const (
+	FORMAT_FLOAT Format = iota // float32，范围 [-1, 1]
+	FORMAT_I16                 // int16
+)
+
+func (f Format) String() string {
+	switch f {
+	case FORMAT_FLOAT:
+		return "FORMAT_FLOAT"
+	case FORMAT_I16:
+		return "FORMAT_I16"
+	}
+	return fmt.Sprintf("FORMAT_%d", int(f))
+}
+
+// PerformanceMode 性能模式，OpenSL ES 不支持，总是 PERFORMANCE_MODE_NONE
+type PerformanceMode int
+
+const (
+	PERFORMANCE_MODE_NONE PerformanceMode = iota
+	PERFORMANCE_MODE_POWER_SAVING
+	PERFORMANCE_MODE_LOW_LATENCY
+)
+
+// SharingMode 共享模式，EXCLUSIVE 不可用时 AAudio 会退回 SHARED
+type SharingMode int
+
+const (
+	SHARING_MODE_SHARED SharingMode = iota
+	SHARING_MODE_EXCLUSIVE
+)
+
+// State 流的状态，取值与 aaudio_stream_state_t 相同
+type State int
+
+const (
+	STATE_UNINITIALIZED State = iota
+	STATE_UNKNOWN
+	STATE_OPEN
+	STATE_STARTING
+	STATE_STARTED
+	STATE_PAUSING
+	STATE_PAUSED
+	STATE_FLUSHING
+	STATE_FLUSHED
+	STATE_STOPPING
+	STATE_STOPPED
+	STATE_CLOSING
+	STATE_CLOSED
+	STATE_DISCONNECTED
+)
+
+var stateNames = [...]string{
+	"UNINITIALIZED", "UNKNOWN", "OPEN", "STARTING", "STARTED",
+	"PAUSING", "PAUSED", "FLUSHING", "FLUSHED", "STOPPING",
+	"STOPPED", "CLOSING", "CLOSED", "DISCONNECTED",
+}
+
+func (s State) String() string {
+	if s >= 0 && int(s) < len(stateNames) {
+		return "STATE_" + stateNames[s]
+	}
+	return fmt.Sprintf("STATE_%d", int(s))
+}
+
+var (
+	ErrClosed     = errors.New("audio: stream closed")
+	ErrNoCallback = errors.New("audio: Config.Callback is nil")
+)
+
+// Buffer 回调中的一段音频，只在回调返回之前有效。
+// 按 Stream 的 Format，Float32 和 Int16 之一非空，长度为 Frames*Channels。
+// 播放时由回调填满，录音时只读
+type Buffer struct {
+	Frames   int
+	Channels int
+	Float32  []float32
+	Int16    []int16
+}
+
+// Callback 在音频线程中调用，返回 false 停止流
+type Callback func(s *Stream, buf *Buffer) bool
+
+// Config 打开流的参数，零值字段使用默认值
+type Config struct {
+	Direction       Direction
+	SampleRate      int // 0: 设备的最佳采样率 (OpenSL ES 为 48000)
+	ChannelCount    int // 0: 播放 2，录音 1
+	Format          Format
+	PerformanceMode PerformanceMode
+	SharingMode     SharingMode
+
+	// FramesPerCallback 每次回调的帧数，0 表示由系统决定
+	FramesPerCallback int
+
+	Callback Callback
+
+	// OnError 流出错时在新的 goroutine 中调用。
+	// 耳机拔出等设备变化时 err 为 ERROR_DISCONNECTED，流须关闭后重新打开
+	OnError func(s *Stream, err error)
+
+	// KeepRunningOnPause 为 true 时 Activity 暂停后流继续运行，如后台播放
+	KeepRunningOnPause bool
+}
+
+// backend AAudio 或 OpenSL ES 的流
+type backend interface {
+	start() error
+	stop() error
+	close() error
+	state() State
+	framesPerBurst() int
+	xRunCount() int
+}
+
+// Stream 音频流
+type Stream struct {
+	cfg    Config
+	native Format // 后端的样本格式，与 cfg.Format 不同时在回调中转换
+	impl   backend
+
+	mu        sync.Mutex
+	err       error
+	closed    bool
+	lifecycle *app.LifecycleListener
+	restart   bool // 暂停前在运行，恢复时重新启动
+
+	buf Buffer
+	f32 []float32
+	i16 []int16
+}
+
+// Open 打开流，cfg.Callback 不能为空。
+// 打开后 SampleRate 等返回实际使用的值
+func Open(cfg Config) (*Stream, error) {
+	if cfg.Callback == nil {
+		return nil, ErrNoCallback
+	}
+	s := &Stream{cfg: cfg}
+	var err error
+	if aaudioAvailable() {
+		s.impl, err = openAAudio(s)
+	} else {
+		s.impl, err = openSLES(s)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.KeepRunningOnPause {
+		s.lifecycle = &app.LifecycleListener{Pause: s.onPause, Resume: s.onResume}
+		app.AddLifecycleListener(s.lifecycle)
+	}
+	return s, nil
+}
+
+// Start 开始播放或录音，返回时流已经启动
+func (s *Stream) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	return s.impl.start()
+}
+
+// Stop 停止流，可以再次 Start。暂停中调用时恢复后不再自动启动
+func (s *Stream) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	s.restart = false
+	return s.impl.stop()
+}
+
+// Close 停止并释放流，不能在回调中调用
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.lifecycle != nil {
+		app.RemoveLifecycleListener(s.lifecycle)
+	}
+	return s.impl.close()
+}
+
+// State 流的当前状态
+func (s *Stream) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return STATE_CLOSED
+	}
+	return s.impl.state()
+}
+
+// Err 第一次出错的原因
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Stream) Direction() Direction             { return s.cfg.Direction }
+func (s *Stream) SampleRate() int                  { return s.cfg.SampleRate }
+func (s *Stream) ChannelCount() int                { return s.cfg.ChannelCount }
+func (s *Stream) Format() Format                   { return s.cfg.Format }
+func (s *Stream) PerformanceMode() PerformanceMode { return s.cfg.PerformanceMode }
+func (s *Stream) SharingMode() SharingMode         { return s.cfg.SharingMode }
+
+// FramesPerBurst 后端每次处理的帧数
+func (s *Stream) FramesPerBurst() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0
+	}
+	return s.impl.framesPerBurst()
+}
+
+// XRunCount underrun (播放) 或 overrun (录音) 的次数，OpenSL ES 总是 0
+func (s *Stream) XRunCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0
+	}
+	return s.impl.xRunCount()
+}
+
+// onPause 停止运行中的流，在 Callbacks.Pause 之前调用
+func (s *Stream) onPause(*app.Activity) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.impl.state() != STATE_STARTED {
+		return
+	}
+	s.restart = s.impl.stop() == nil
+}
+
+// onResume 重新启动暂停前运行的流，在 Callbacks.Resume 之后调用
+func (s *Stream) onResume(*app.Activity) {
+	s.mu.Lock()
+	restart := s.restart && !s.closed
+	s.restart = false
+	var err error
+	if restart {
+		err = s.impl.start()
+	}
+	s.mu.Unlock()
+	if err != nil {
+		s.fail(err)
+	}
+}
+
+// fail 记录错误并通知 OnError，可以在音频线程中调用
+func (s *Stream) fail(err error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+	if s.cfg.OnError != nil {
+		go s.cfg.OnError(s, err)
+	}
+}
+
+// process 由后端在音频线程中调用，data 是 frames 帧 native 格式的样本
+func (s *Stream) process(data unsafe.Pointer, frames int) bool {
+	n := frames * s.cfg.ChannelCount
+	input := s.cfg.Direction == DIRECTION_INPUT
+	b := &s.buf
+	b.Frames, b.Channels = frames, s.cfg.ChannelCount
+	b.Float32, b.Int16 = nil, nil
+
+	if s.native == FORMAT_FLOAT {
+		native := (*[1 << 28]float32)(data)[:n:n]
+		if s.cfg.Format == FORMAT_FLOAT {
+			b.Float32 = native
+			return s.cfg.Callback(s, b)
+		}
+		if cap(s.i16) < n {
+			s.i16 = make([]int16, n)
+		}
+		b.Int16 = s.i16[:n]
+		if input {
+			floatToInt16(b.Int16, native)
+			return s.cfg.Callback(s, b)
+		}
+		ok := s.cfg.Callback(s, b)
+		int16ToFloat(native, b.Int16)
+		return ok
+	}
+
+	native := (*[1 << 29]int16)(data)[:n:n]
+	if s.cfg.Format == FORMAT_I16 {
+		b.Int16 = native
+		return s.cfg.Callback(s, b)
+	}
+	if cap(s.f32) < n {
+		s.f32 = make([]float32, n)
+	}
+	b.Float32 = s.f32[:n]
+	if input {
+		int16ToFloat(b.Float32, native)
+		return s.cfg.Callback(s, b)
+	}
+	ok := s.cfg.Callback(s, b)
+	floatToInt16(native, b.Float32)
+	return ok
+}
+
+func int16ToFloat(dst []float32, src []int16) {
+	for i, v := range src {
+		dst[i] = float32(v) * (1.0 / 32768)
+	}
+}
+
+func floatToInt16(dst []int16, src []float32) {
+	for i, v := range src {
+		v *= 32768
+		if v > 32767 {
+			v = 32767
+		} else if v < -32768 {
+			v = -32768
+		}
+		dst[i] = int16(v)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package decoder 把音频文件解码为可以定位的 PCM 流。
+//
+// 格式按文件开头的魔数识别，与 image.RegisterFormat 相同。
+// WAV 和 FLAC 用纯 Go 解码；Android 上 Ogg Vorbis 和 MP3
+// 由 AMediaExtractor/AMediaCodec 解码。
+//
+//	asset := act.AssetManager().Open("music.ogg", app.ASSET_MODE_RANDOM)
+//	defer asset.Close()
+//	d, name, err := decoder.Open(asset)
+package decoder
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gooid/gooid/audio/pcm"
+)
+
+// ErrFormat 未知的格式
+var ErrFormat = errors.New("decoder: unknown format")
+
+// Format PCM 的格式。BitsPerSample 是源数据的位深，有损格式为 0
+type Format = pcm.Format
+
+// Decoder 解码后的 PCM 流
+type Decoder interface {
+	Format() Format
+
+	// Read 读取交错存放的 float32 样本，范围 [-1, 1]，
+	// 返回样本数 (总是声道数的整数倍)。结束时返回 io.EOF
+	Read(p []float32) (int, error)
+
+	// SeekFrame 定位到第 frame 帧，之后的 Read 从这一帧开始
+	SeekFrame(frame int64) error
+
+	// Position 下一次 Read 的帧位置
+	Position() int64
+
+	// Length 总帧数，未知时为 -1
+	Length() int64
+
+	// Close 释放解码器，不关闭传给 Open 的 reader
+	Close() error
+}
+
+// Duration d 的总时长，未知时为 -1
+func Duration(d Decoder) time.Duration {
+	n := d.Length()
+	if n < 0 {
+		return -1
+	}
+	return d.Format().Duration(n)
+}
+
+// SeekTime 定位到时间 t
+func SeekTime(d Decoder, t time.Duration) error {
+	return d.SeekFrame(d.Format().Frames(t))
+}
+
+type format struct {
+	name, magic string
+	open        func(io.ReadSeeker) (Decoder, error)
+}
+
+var (
+	formatsLock sync.Mutex
+	formats     []format
+)
+
+// RegisterFormat 注册解码器，magic 中的 '?' 匹配任意字节。
+// open 调用时 r 位于开头
+func RegisterFormat(name, magic string, open func(r io.ReadSeeker) (Decoder, error)) {
+	formatsLock.Lock()
+	formats = append(formats, format{name, magic, open})
+	formatsLock.Unlock()
+}
+
+func match(magic string, b []byte) bool {
+	if len(magic) > len(b) {
+		return false
+	}
+	for i, c := range []byte(magic) {
+		if c != '?' && b[i] != c {
+			return false
+		}
+	}
+	return true
+}
+
+const maxMagic = 16
+
+// Open 按魔数选择解码器，返回解码器和格式名 ("wav"、"flac"、"ogg"、"mp3" 等)。
+// 文件开头的 ID3v2 标签被跳过后再识别
+func Open(r io.ReadSeeker) (Decoder, string, error) {
+	offset, err := skipID3(r)
+	if err != nil {
+		return nil, "", err
+	}
+	head := make([]byte, maxMagic)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, "", err
+	}
+	head = head[:n]
+
+	formatsLock.Lock()
+	fs := formats
+	formatsLock.Unlock()
+	for _, f := range fs {
+		if !match(f.magic, head) {
+			continue
+		}
+		if _, err = r.Seek(0, io.SeekStart); err != nil {
+			return nil, "", err
+		}
+		d, err := f.open(r)
+		return d, f.name, err
+	}
+	// 只有 ID3 标签的 MP3
+	if offset > 0 {
+		for _, f := range fs {
+			if f.name == "mp3" {
+				if _, err = r.Seek(0, io.SeekStart); err != nil {
+					return nil, "", err
+				}
+				d, err := f.open(r)
+				return d, f.name, err
+			}
+		}
+	}
+	return nil, "", ErrFormat
+}
+
+// skipID3 跳过开头的 ID3v2 标签，返回标签之后的位置
+func skipID3(r io.ReadSeeker) (int64, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	var h [10]byte
+	n, err := io.ReadFull(r, h[:])
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return 0, err
+	}
+	if n < len(h) || !bytes.HasPrefix(h[:], []byte("ID3")) {
+		return r.Seek(0, io.SeekStart)
+	}
+	// 大小为 synchsafe 整数，不含 10 字节的头；标志位 0x10 表示有 10 字节的尾
+	size := int64(h[6]&0x7f)<<21 | int64(h[7]&0x7f)<<14 | int64(h[8]&0x7f)<<7 | int64(h[9]&0x7f)
+	size += 10
+	if h[5]&0x10 != 0 {
+		size += 10
+	}
+	return r.Seek(size, io.SeekStart)
+}
+
+// intToFloat 把 bits 位的整数样本转换为 float32，WAV 和 FLAC 共用
+func intToFloat(v int32, bits int) float32 {
+	return float32(v) / float32(int64(1)<<uint(bits-1))
+}
@@ -0,0 +1,752 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package decoder
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+func init() {
+	RegisterFormat("flac", "fLaC", openFLAC)
+}
+
+var errFLACFrame = errors.New("decoder: bad FLAC frame")
+
+type flacSeekPoint struct {
+	sample, offset int64
+}
+
+type flacDecoder struct {
+	r      io.ReadSeeker
+	br     bitReader
+	format Format
+
+	minBlock, maxBlock int
+	maxFrame           int
+	total              int64 // 总帧数，0 表示未知
+
+	firstFrame int64 // 第一个 FLAC 帧的文件位置
+	seekTable  []flacSeekPoint
+
+	// 当前解码的 FLAC 帧
+	block      [][]int32
+	blockBits  int
+	blockStart int64
+	blockLen   int
+	blockPos   int
+
+	pos int64
+}
+
+func openFLAC(r io.ReadSeeker) (Decoder, error) {
+	offset, err := skipID3(r)
+	if err != nil {
+		return nil, err
+	}
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+	if string(magic[:]) != "fLaC" {
+		return nil, ErrFormat
+	}
+	offset += 4
+
+	d := &flacDecoder{r: r}
+	hasInfo := false
+	for last := false; !last; {
+		var h [4]byte
+		if _, err := io.ReadFull(r, h[:]); err != nil {
+			return nil, err
+		}
+		last = h[0]&0x80 != 0
+		size := int64(h[1])<<16 | int64(h[2])<<8 | int64(h[3])
+		offset += 4
+		switch h[0] & 0x7f {
+		case 0: // STREAMINFO
+			if size < 34 {
+				return nil, fmt.Errorf("decoder: bad FLAC STREAMINFO size %d", size)
+			}
+			b := make([]byte, size)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return nil, err
+			}
+			d.parseStreamInfo(b)
+			hasInfo = true
+		case 3: // SEEKTABLE
+			b := make([]byte, size)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return nil, err
+			}
+			d.parseSeekTable(b)
+		default:
+			if _, err := r.Seek(size, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+		offset += size
+	}
+	if !hasInfo {
+		return nil, errors.New("decoder: FLAC without STREAMINFO")
+	}
+	if d.format.SampleRate == 0 || d.format.BitsPerSample < 4 {
+		return nil, fmt.Errorf("decoder: unsupported FLAC stream: %d Hz, %d bits", d.format.SampleRate, d.format.BitsPerSample)
+	}
+	d.firstFrame = offset
+	d.block = make([][]int32, d.format.Channels)
+	if err := d.SeekFrame(0); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *flacDecoder) parseStreamInfo(b []byte) {
+	be := binary.BigEndian
+	d.minBlock = int(be.Uint16(b[0:]))
+	d.maxBlock = int(be.Uint16(b[2:]))
+	d.maxFrame = int(b[7])<<16 | int(b[8])<<8 | int(b[9])
+	x := be.Uint64(b[10:])
+	d.format = Format{
+		SampleRate:    int(x >> 44),
+		Channels:      int(x>>41&7) + 1,
+		BitsPerSample: int(x>>36&31) + 1,
+	}
+	d.total = int64(x & (1<<36 - 1))
+}
+
+func (d *flacDecoder) parseSeekTable(b []byte) {
+	be := binary.BigEndian
+	for ; len(b) >= 18; b = b[18:] {
+		sample := be.Uint64(b)
+		if sample == ^uint64(0) { // 占位
+			continue
+		}
+		d.seekTable = append(d.seekTable, flacSeekPoint{int64(sample), int64(be.Uint64(b[8:]))})
+	}
+}
+
+func (d *flacDecoder) Format() Format  { return d.format }
+func (d *flacDecoder) Position() int64 { return d.pos }
+func (d *flacDecoder) Close() error    { return nil }
+
+func (d *flacDecoder) Length() int64 {
+	if d.total == 0 {
+		return -1
+	}
+	return d.total
+}
+
+func (d *flacDecoder) Read(p []float32) (int, error) {
+	ch := d.format.Channels
+	if len(p) < ch {
+		return 0, io.ErrShortBuffer
+	}
+	n := 0
+	for len(p)-n >= ch {
+		if d.blockPos >= d.blockLen {
+			if d.total > 0 && d.pos >= d.total {
+				break
+			}
+			if err := d.readFrame(); err != nil {
+				if err == io.EOF && n > 0 {
+					break
+				}
+				return n, err
+			}
+			continue
+		}
+		k := d.blockLen - d.blockPos
+		if m := (len(p) - n) / ch; k > m {
+			k = m
+		}
+		out := p[n:]
+		for c := 0; c < ch; c++ {
+			src := d.block[c][d.blockPos : d.blockPos+k]
+			for f, v := range src {
+				out[f*ch+c] = intToFloat(v, d.blockBits)
+			}
+		}
+		d.blockPos += k
+		d.pos += int64(k)
+		n += k * ch
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// SeekFrame 从 SEEKTABLE 或二分查找得到不晚于 frame 的 FLAC 帧，再向后解码到 frame
+func (d *flacDecoder) SeekFrame(frame int64) error {
+	if frame < 0 {
+		frame = 0
+	}
+	if d.total > 0 && frame > d.total {
+		frame = d.total
+	}
+	offset, sample := d.firstFrame, int64(0)
+	for _, p := range d.seekTable {
+		if p.sample <= frame && p.sample >= sample {
+			offset, sample = d.firstFrame+p.offset, p.sample
+		}
+	}
+	if frame-sample > int64(16*d.blockSize()) {
+		offset = d.bisect(offset, sample, frame)
+	}
+	if _, err := d.r.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	d.br.reset(d.r)
+	d.blockLen, d.blockPos = 0, 0
+	for {
+		err := d.readFrame()
+		if err == io.EOF {
+			d.pos = frame
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if d.blockStart+int64(d.blockLen) > frame {
+			d.blockPos = int(frame - d.blockStart)
+			if d.blockPos < 0 {
+				d.blockPos = 0
+			}
+			d.pos = d.blockStart + int64(d.blockPos)
+			return nil
+		}
+	}
+}
+
+func (d *flacDecoder) blockSize() int {
+	if d.maxBlock > 0 {
+		return d.maxBlock
+	}
+	return 4096
+}
+
+// bisect 在 [lo, 文件结尾) 中二分查找开始于 frame 之前的 FLAC 帧，返回其位置
+func (d *flacDecoder) bisect(lo, loSample, frame int64) int64 {
+	hi, err := d.r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return lo
+	}
+	window := 2 * d.maxFrame
+	if window < 64<<10 {
+		window = 64 << 10
+	}
+	buf := make([]byte, window)
+	for i := 0; i < 64 && hi-lo > int64(window); i++ {
+		mid := lo + (hi-lo)/2
+		offset, sample, ok := d.syncAfter(mid, buf)
+		if !ok || sample > frame || offset >= hi {
+			hi = mid
+			continue
+		}
+		if sample <= loSample {
+			break
+		}
+		lo, loSample = offset, sample
+	}
+	return lo
+}
+
+// syncAfter 找 offset 之后第一个有效的帧头
+func (d *flacDecoder) syncAfter(offset int64, buf []byte) (int64, int64, bool) {
+	if _, err := d.r.Seek(offset, io.SeekStart); err != nil {
+		return 0, 0, false
+	}
+	n, _ := io.ReadFull(d.r, buf)
+	b := buf[:n]
+	for i := 0; i+1 < len(b); i++ {
+		if b[i] != 0xff || b[i+1]&0xfe != 0xf8 {
+			continue
+		}
+		j := i
+		h, err := d.readHeader(func() (uint64, error) {
+			if j >= len(b) {
+				return 0, io.ErrUnexpectedEOF
+			}
+			j++
+			return uint64(b[j-1]), nil
+		})
+		if err == nil {
+			return offset + int64(i), h.sample, true
+		}
+	}
+	return 0, 0, false
+}
+
+type flacHeader struct {
+	blockSize int
+	chanCode  int
+	channels  int
+	bps       int
+	sample    int64
+}
+
+// readHeader 读取并校验帧头 (含 CRC-8)，next 每次返回一个字节
+func (d *flacDecoder) readHeader(next func() (uint64, error)) (h flacHeader, err error) {
+	var raw [16]byte
+	n := 0
+	nextByte := func() uint64 {
+		if err != nil {
+			return 0
+		}
+		var v uint64
+		v, err = next()
+		if n < len(raw) {
+			raw[n] = uint8(v)
+		}
+		n++
+		return v
+	}
+
+	b0, b1 := nextByte(), nextByte()
+	if err != nil {
+		return h, err
+	}
+	if b0 != 0xff || b1&0xfe != 0xf8 {
+		return h, errFLACFrame
+	}
+	variable := b1&1 != 0
+	b2, b3 := nextByte(), nextByte()
+	bsCode, srCode := int(b2>>4), int(b2&15)
+	h.chanCode, h.bps = int(b3>>4), int(b3>>1&7)
+
+	// UTF-8 编码的帧号或样本号
+	num := nextByte()
+	if l := bits.LeadingZeros8(^uint8(num)); l > 0 {
+		if l == 1 || l > 7 {
+			return h, errFLACFrame
+		}
+		num &= 0x7f >> uint(l)
+		for i := 1; i < l; i++ {
+			c := nextByte()
+			if c&0xc0 != 0x80 {
+				return h, errFLACFrame
+			}
+			num = num<<6 | c&0x3f
+		}
+	}
+
+	switch {
+	case bsCode == 1:
+		h.blockSize = 192
+	case bsCode >= 2 && bsCode <= 5:
+		h.blockSize = 576 << uint(bsCode-2)
+	case bsCode == 6:
+		h.blockSize = int(nextByte()) + 1
+	case bsCode == 7:
+		h.blockSize = int(nextByte()<<8|nextByte()) + 1
+	case bsCode >= 8:
+		h.blockSize = 256 << uint(bsCode-8)
+	default:
+		return h, errFLACFrame
+	}
+	switch srCode {
+	case 12:
+		nextByte()
+	case 13, 14:
+		nextByte()
+		nextByte()
+	case 15:
+		return h, errFLACFrame
+	}
+
+	switch {
+	case h.chanCode < 8:
+		h.channels = h.chanCode + 1
+	case h.chanCode <= 10:
+		h.channels = 2
+	default:
+		return h, errFLACFrame
+	}
+	switch h.bps {
+	case 0:
+		h.bps = d.format.BitsPerSample
+	case 1, 2:
+		h.bps = 4 + 4*h.bps
+	case 4, 5, 6:
+		h.bps = 4 * h.bps
+	case 7:
+		h.bps = 32
+	default:
+		return h, errFLACFrame
+	}
+
+	crc := nextByte()
+	if err != nil {
+		return h, err
+	}
+	if n > len(raw) || crc8(raw[:n-1]) != uint8(crc) {
+		return h, errFLACFrame
+	}
+	if h.channels != d.format.Channels || (d.maxBlock > 0 && h.blockSize > d.maxBlock) {
+		return h, errFLACFrame
+	}
+
+	h.sample = int64(num)
+	if !variable {
+		fixed := int64(h.blockSize)
+		if d.minBlock == d.maxBlock && d.minBlock > 0 {
+			fixed = int64(d.minBlock)
+		}
+		h.sample *= fixed
+	}
+	return h, nil
+}
+
+// readFrame 解码下一个 FLAC 帧到 d.block
+func (d *flacDecoder) readFrame() error {
+	br := &d.br
+	first := true
+	h, err := d.readHeader(func() (uint64, error) {
+		v, err := br.read(8)
+		if err == io.EOF && !first {
+			err = io.ErrUnexpectedEOF
+		}
+		first = false
+		return v, err
+	})
+	if err != nil {
+		if err == errFLACFrame && d.total > 0 && d.pos >= d.total {
+			// 最后一帧之后的 ID3v1 等数据
+			return io.EOF
+		}
+		return err
+	}
+
+	for c := 0; c < h.channels; c++ {
+		if cap(d.block[c]) < h.blockSize {
+			d.block[c] = make([]int32, h.blockSize)
+		}
+		d.block[c] = d.block[c][:h.blockSize]
+		bps := h.bps
+		// side 声道多一位
+		if (h.chanCode == 8 || h.chanCode == 10) && c == 1 || h.chanCode == 9 && c == 0 {
+			bps++
+		}
+		if err := d.readSubframe(d.block[c], uint(bps)); err != nil {
+			return err
+		}
+	}
+	br.align()
+	if _, err := br.read(16); err != nil { // CRC-16
+		return err
+	}
+
+	left, right := d.block[0], d.block[len(d.block)-1]
+	switch h.chanCode {
+	case 8: // left/side
+		for i := range right {
+			right[i] = left[i] - right[i]
+		}
+	case 9: // side/right
+		for i := range left {
+			left[i] += right[i]
+		}
+	case 10: // mid/side
+		for i := range left {
+			mid, side := left[i]<<1|right[i]&1, right[i]
+			left[i], right[i] = (mid+side)>>1, (mid-side)>>1
+		}
+	}
+
+	d.blockBits = h.bps
+	d.blockStart = h.sample
+	d.blockLen = h.blockSize
+	d.blockPos = 0
+	return nil
+}
+
+func (d *flacDecoder) readSubframe(out []int32, bps uint) error {
+	br := &d.br
+	v, err := br.read(8)
+	if err != nil {
+		return err
+	}
+	if v&0x80 != 0 {
+		return errFLACFrame
+	}
+	typ := int(v >> 1 & 0x3f)
+	wasted := uint(0)
+	if v&1 != 0 {
+		k, err := br.unary()
+		if err != nil {
+			return err
+		}
+		wasted = uint(k) + 1
+		if wasted >= bps {
+			return errFLACFrame
+		}
+		bps -= wasted
+	}
+
+	switch {
+	case typ == 0: // CONSTANT
+		s, err := br.signed(bps)
+		if err != nil {
+			return err
+		}
+		for i := range out {
+			out[i] = s
+		}
+	case typ == 1: // VERBATIM
+		for i := range out {
+			if out[i], err = br.signed(bps); err != nil {
+				return err
+			}
+		}
+	case typ >= 8 && typ <= 12: // FIXED
+		order := typ - 8
+		if err := d.readWarmup(out, order, bps); err != nil {
+			return err
+		}
+		if err := d.readResidual(out, order); err != nil {
+			return err
+		}
+		fixedPredict(out, order)
+	case typ >= 32: // LPC
+		order := typ - 31
+		if err := d.readWarmup(out, order, bps); err != nil {
+			return err
+		}
+		p, err := br.read(4)
+		if err != nil {
+			return err
+		}
+		if p == 15 {
+			return errFLACFrame
+		}
+		precision := uint(p) + 1
+		shift, err := br.signed(5)
+		if err != nil {
+			return err
+		}
+		if shift < 0 {
+			return errFLACFrame
+		}
+		var coefs [32]int32
+		for i := 0; i < order; i++ {
+			if coefs[i], err = br.signed(precision); err != nil {
+				return err
+			}
+		}
+		if err := d.readResidual(out, order); err != nil {
+			return err
+		}
+		lpcPredict(out, coefs[:order], uint(shift))
+	default:
+		return errFLACFrame
+	}
+
+	if wasted > 0 {
+		for i := range out {
+			out[i] <<= wasted
+		}
+	}
+	return nil
+}
+
+func (d *flacDecoder) readWarmup(out []int32, order int, bps uint) (err error) {
+	if order > len(out) {
+		return errFLACFrame
+	}
+	for i := 0; i < order; i++ {
+		if out[i], err = d.br.signed(bps); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readResidual 读取 Rice 编码的残差到 out[order:]
+func (d *flacDecoder) readResidual(out []int32, order int) error {
+	br := &d.br
+	method, err := br.read(2)
+	if err != nil {
+		return err
+	}
+	if method > 1 {
+		return errFLACFrame
+	}
+	paramBits, escape := uint(4), uint64(15)
+	if method == 1 {
+		paramBits, escape = 5, 31
+	}
+	partOrder, err := br.read(4)
+	if err != nil {
+		return err
+	}
+	parts := 1 << partOrder
+	per := len(out) >> partOrder
+	if per<<partOrder != len(out) || per < order {
+		return errFLACFrame
+	}
+	i := order
+	for p := 0; p < parts; p++ {
+		end := i + per
+		if p == 0 {
+			end -= order
+		}
+		k, err := br.read(paramBits)
+		if err != nil {
+			return err
+		}
+		if k == escape {
+			n, err := br.read(5)
+			if err != nil {
+				return err
+			}
+			for ; i < end; i++ {
+				if out[i], err = br.signed(uint(n)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for ; i < end; i++ {
+			q, err := br.unary()
+			if err != nil {
+				return err
+			}
+			low, err := br.read(uint(k))
+			if err != nil {
+				return err
+			}
+			u := uint32(q)<<k | uint32(low)
+			out[i] = int32(u>>1) ^ -int32(u&1)
+		}
+	}
+	return nil
+}
+
+func fixedPredict(out []int32, order int) {
+	for i := order; i < len(out); i++ {
+		var p int64
+		switch order {
+		case 1:
+			p = int64(out[i-1])
+		case 2:
+			p = 2*int64(out[i-1]) - int64(out[i-2])
+		case 3:
+			p = 3*int64(out[i-1]) - 3*int64(out[i-2]) + int64(out[i-3])
+		case 4:
+			p = 4*int64(out[i-1]) - 6*int64(out[i-2]) + 4*int64(out[i-3]) - int64(out[i-4])
+		}
+		out[i] += int32(p)
+	}
+}
+
+func lpcPredict(out []int32, coefs []int32, shift uint) {
+	for i := len(coefs); i < len(out); i++ {
+		var sum int64
+		for j, c := range coefs {
+			sum += int64(c) * int64(out[i-1-j])
+		}
+		out[i] += int32(sum >> shift)
+	}
+}
+
+var crc8Table = func() (t [256]uint8) {
+	for i := range t {
+		c := uint8(i)
+		for j := 0; j < 8; j++ {
+			if c&0x80 != 0 {
+				c = c<<1 ^ 0x07
+			} else {
+				c <<= 1
+			}
+		}
+		t[i] = c
+	}
+	return
+}()
+
+func crc8(b []byte) uint8 {
+	var c uint8
+	for _, v := range b {
+		c = crc8Table[c^v]
+	}
+	return c
+}
+
+// bitReader 按位读取，高位在前
+type bitReader struct {
+	r     *bufio.Reader
+	cache uint64
+	n     uint // cache 中剩余的位数
+}
+
+func (b *bitReader) reset(r io.Reader) {
+	if b.r == nil {
+		b.r = bufio.NewReaderSize(r, 32<<10)
+	} else {
+		b.r.Reset(r)
+	}
+	b.cache, b.n = 0, 0
+}
+
+// read 读取 n (<= 32) 位
+func (b *bitReader) read(n uint) (uint64, error) {
+	for b.n < n {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && b.n > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		b.cache = b.cache<<8 | uint64(c)
+		b.n += 8
+	}
+	b.n -= n
+	return b.cache >> b.n & (1<<n - 1), nil
+}
+
+func (b *bitReader) signed(n uint) (int32, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	v, err := b.read(n)
+	return int32(int64(v<<(64-n)) >> (64 - n)), err
+}
+
+// unary 读取 1 之前 0 的个数
+func (b *bitReader) unary() (uint, error) {
+	var q uint
+	for {
+		if b.n == 0 {
+			c, err := b.r.ReadByte()
+			if err != nil {
+				if err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				return 0, err
+			}
+			b.cache, b.n = uint64(c), 8
+		}
+		v := b.cache & (1<<b.n - 1)
+		if v == 0 {
+			q += b.n
+			b.n = 0
+			continue
+		}
+		l := uint(bits.Len64(v))
+		q += b.n - l
+		b.n = l - 1
+		return q, nil
+	}
+}
+
+func (b *bitReader) align() {
+	b.n -= b.n % 8
+}
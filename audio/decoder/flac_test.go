@@ -0,0 +1,313 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package decoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+// bitWriter 按位写入，高位在前
+type bitWriter struct {
+	buf   []byte
+	cache byte
+	n     uint
+}
+
+func (w *bitWriter) put(v uint64, n uint) {
+	for i := int(n) - 1; i >= 0; i-- {
+		w.cache = w.cache<<1 | byte(v>>uint(i)&1)
+		w.n++
+		if w.n == 8 {
+			w.buf = append(w.buf, w.cache)
+			w.cache, w.n = 0, 0
+		}
+	}
+}
+
+func (w *bitWriter) signed(v int64, n uint) {
+	w.put(uint64(v)&(1<<n-1), n)
+}
+
+func (w *bitWriter) align() {
+	for w.n != 0 {
+		w.put(0, 1)
+	}
+}
+
+// 子帧的编码方式
+const (
+	subframeVerbatim = iota
+	subframeFixed1
+	subframeFixed2
+	subframeLPC
+)
+
+// writeSubframe 全部相同的样本写为 CONSTANT，否则按 kind 编码
+func writeSubframe(w *bitWriter, x []int64, bps uint, kind int) {
+	constant := true
+	for _, v := range x {
+		if v != x[0] {
+			constant = false
+			break
+		}
+	}
+	res := make([]int64, len(x))
+	switch {
+	case constant:
+		w.put(0, 8)
+		w.signed(x[0], bps)
+	case kind == subframeVerbatim:
+		w.put(1<<1, 8)
+		for _, v := range x {
+			w.signed(v, bps)
+		}
+	case kind == subframeFixed1 || kind == subframeFixed2:
+		order := kind - subframeFixed1 + 1
+		w.put(uint64(8+order)<<1, 8)
+		for i := 0; i < order; i++ {
+			w.signed(x[i], bps)
+		}
+		for i := order; i < len(x); i++ {
+			if order == 1 {
+				res[i] = x[i] - x[i-1]
+			} else {
+				res[i] = x[i] - 2*x[i-1] + x[i-2]
+			}
+		}
+		writeResidual(w, res, order)
+	case kind == subframeLPC:
+		// 二阶 LPC，系数 4、-2，shift 1，即 2*x[i-1] - x[i-2]
+		const order = 2
+		w.put(uint64(32+order-1)<<1, 8)
+		for i := 0; i < order; i++ {
+			w.signed(x[i], bps)
+		}
+		w.put(4-1, 4) // 系数精度
+		w.signed(1, 5)
+		w.signed(4, 4)
+		w.signed(-2, 4)
+		for i := order; i < len(x); i++ {
+			res[i] = x[i] - (4*x[i-1]-2*x[i-2])>>1
+		}
+		writeResidual(w, res, order)
+	}
+}
+
+// writeResidual Rice 编码，块长为偶数时分为两个 partition
+func writeResidual(w *bitWriter, res []int64, order int) {
+	w.put(0, 2) // RICE
+	partitionOrder := uint(1)
+	if len(res)%2 != 0 || len(res)/2 < order {
+		partitionOrder = 0
+	}
+	w.put(uint64(partitionOrder), 4)
+	per := len(res) >> partitionOrder
+	i := order
+	for p := 0; p < 1<<partitionOrder; p++ {
+		end := (p + 1) * per
+		var sum uint64
+		for j := i; j < end; j++ {
+			sum += zigzag(res[j])
+		}
+		k := uint(0)
+		for n := uint64(end - i); n > 0 && n<<(k+1) < sum && k < 14; k++ {
+		}
+		w.put(uint64(k), 4)
+		for ; i < end; i++ {
+			z := zigzag(res[i])
+			for q := z >> k; q > 0; q-- {
+				w.put(0, 1)
+			}
+			w.put(1, 1)
+			w.put(z&(1<<k-1), k)
+		}
+	}
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1 ^ v>>63)
+}
+
+func crc16(b []byte) uint16 {
+	var crc uint16
+	for _, v := range b {
+		crc ^= uint16(v) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// flacFixture 16 位双声道的 FLAC，块长 block。
+// 各帧轮流使用独立、left/side、right/side、mid/side 声道和各种子帧，
+// seekTable 为 true 时每 20 帧一个 SEEKTABLE 的点，否则解码器用二分查找定位
+func flacFixture(l, r []int16, rate, block int, seekTable bool) []byte {
+	var out bytes.Buffer
+	out.WriteString("fLaC")
+	total := len(l)
+	streamInfo := make([]byte, 34)
+	binary.BigEndian.PutUint16(streamInfo[0:], uint16(block))
+	binary.BigEndian.PutUint16(streamInfo[2:], uint16(block))
+	binary.BigEndian.PutUint64(streamInfo[10:], uint64(rate)<<44|uint64(2-1)<<41|uint64(16-1)<<36|uint64(total))
+	last := byte(0x80)
+	if seekTable {
+		last = 0
+	}
+	out.Write([]byte{last, 0, 0, 34})
+	out.Write(streamInfo)
+
+	var frames bytes.Buffer
+	var points [][2]int64
+	for n, start := 0, 0; start < total; n, start = n+1, start+block {
+		if n%20 == 0 {
+			points = append(points, [2]int64{int64(start), int64(frames.Len())})
+		}
+		size := block
+		if start+size > total {
+			size = total - start
+		}
+		assignment := []uint64{1, 8, 9, 10}[n%4]
+		w := &bitWriter{}
+		w.put(0xfff8, 16)
+		w.put(7, 4) // 块长在头部之后，16 位
+		w.put(9, 4) // 44100 Hz
+		w.put(assignment, 4)
+		w.put(4, 3) // 16 位
+		w.put(0, 1)
+		// 帧号，UTF-8 编码
+		switch {
+		case n < 0x80:
+			w.put(uint64(n), 8)
+		case n < 0x800:
+			w.put(0xc0|uint64(n>>6), 8)
+			w.put(0x80|uint64(n&0x3f), 8)
+		default:
+			w.put(0xe0|uint64(n>>12), 8)
+			w.put(0x80|uint64(n>>6&0x3f), 8)
+			w.put(0x80|uint64(n&0x3f), 8)
+		}
+		w.put(uint64(size-1), 16)
+		w.put(uint64(crc8(w.buf)), 8)
+
+		a, b := make([]int64, size), make([]int64, size)
+		for i := range a {
+			L, R := int64(l[start+i]), int64(r[start+i])
+			switch assignment {
+			case 1:
+				a[i], b[i] = L, R
+			case 8:
+				a[i], b[i] = L, L-R
+			case 9:
+				a[i], b[i] = L-R, R
+			case 10:
+				a[i], b[i] = (L+R)>>1, L-R
+			}
+		}
+		bpsA, bpsB := uint(16), uint(16)
+		switch assignment {
+		case 8, 10:
+			bpsB = 17
+		case 9:
+			bpsA = 17
+		}
+		writeSubframe(w, a, bpsA, n%4)
+		writeSubframe(w, b, bpsB, (n+1)%4)
+		w.align()
+		w.put(uint64(crc16(w.buf)), 16)
+		frames.Write(w.buf)
+	}
+	if seekTable {
+		size := len(points) * 18
+		out.Write([]byte{0x80 | 3, byte(size >> 16), byte(size >> 8), byte(size)})
+		for _, p := range points {
+			var b [18]byte
+			binary.BigEndian.PutUint64(b[0:], uint64(p[0]))
+			binary.BigEndian.PutUint64(b[8:], uint64(p[1]))
+			binary.BigEndian.PutUint16(b[16:], uint16(block))
+			out.Write(b[:])
+		}
+	}
+	out.Write(frames.Bytes())
+	return out.Bytes()
+}
+
+// flacSignal 正弦加噪声，其中一段为常数 (CONSTANT 子帧)
+func flacSignal(n int) (l, r []int16) {
+	l, r = make([]int16, n), make([]int16, n)
+	seed := uint32(1)
+	for i := range l {
+		seed = seed*1664525 + 1013904223
+		noise := float64(int(seed>>20) - 2048)
+		l[i] = int16(12000*math.Sin(float64(i)*0.03) + noise)
+		r[i] = int16(9000*math.Sin(float64(i)*0.011) - noise)
+		if i/1000%7 == 3 {
+			l[i], r[i] = 5, 5
+		}
+	}
+	return
+}
+
+func TestFLAC(t *testing.T) {
+	const rate = 44100
+	const frames = 3*rate + 123
+	l, r := flacSignal(frames)
+	want := func(frame, ch int) float32 {
+		if ch == 0 {
+			return float32(l[frame]) / 32768
+		}
+		return float32(r[frame]) / 32768
+	}
+	for _, seekTable := range []bool{true, false} {
+		name := "bisect"
+		if seekTable {
+			name = "seektable"
+		}
+		t.Run(name, func(t *testing.T) {
+			data := flacFixture(l, r, rate, 1000, seekTable)
+			d, format, err := Open(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+			if format != "flac" {
+				t.Errorf("format name %q, want flac", format)
+			}
+			if f := d.Format(); f.SampleRate != rate || f.Channels != 2 || f.BitsPerSample != 16 || f.Float {
+				t.Errorf("Format = %+v", f)
+			}
+			if d.Length() != frames {
+				t.Errorf("Length = %d, want %d", d.Length(), frames)
+			}
+			if want := time.Duration(frames) * time.Second / rate; Duration(d) != want {
+				t.Errorf("Duration = %v, want %v", Duration(d), want)
+			}
+
+			all := readAll(t, d)
+			if len(all) != 2*frames {
+				t.Fatalf("read %d samples, want %d", len(all), 2*frames)
+			}
+			for i, v := range all {
+				if w := want(i/2, i%2); v != w {
+					t.Fatalf("sample %d = %v, want %v", i, v, w)
+				}
+			}
+
+			// FLAC 帧的边界前后、SEEKTABLE 的点之间和最后一帧
+			checkSeek(t, d, want, 0, 999, 1000, 1001, 20000, 20999, 45678, frames-1, 5, 131000)
+			if err := SeekTime(d, 2*time.Second); err != nil || d.Position() != 2*rate {
+				t.Errorf("SeekTime(2s): Position = %d, %v", d.Position(), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build android
+// +build android
+
+package decoder
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	media "github.com/gooid/gooid/media24"
+)
+
+func init() {
+	RegisterFormat("ogg", "OggS", openMedia)
+	for _, magic := range []string{"\xff\xfb", "\xff\xfa", "\xff\xf3", "\xff\xf2", "\xff\xe3", "\xff\xe2"} {
+		RegisterFormat("mp3", magic, openMedia)
+	}
+}
+
+// KEY_PCM_ENCODING 的取值，同 android.media.AudioFormat
+const (
+	pcmEncoding16Bit = 2
+	pcmEncodingFloat = 4
+)
+
+const codecTimeout = 10 * time.Millisecond
+
+var errNoAudioTrack = errors.New("decoder: no audio track")
+
+// mediaDecoder 用 AMediaExtractor 和 AMediaCodec 解码。
+// *os.File 和未压缩的 asset 直接使用 fd，其它 reader 需要 API 28 (AMediaDataSource)
+type mediaDecoder struct {
+	ex     *media.Extractor
+	codec  *media.Codec
+	format Format
+	length int64
+	float  bool
+
+	info    media.CodecBufferInfo
+	pcm     []float32
+	pending []float32
+
+	pos        int64
+	skip       int64 // Seek 之后丢弃此帧之前的输出
+	inputDone  bool
+	outputDone bool
+}
+
+func openMedia(r io.ReadSeeker) (Decoder, error) {
+	ex, err := newExtractor(r)
+	if err != nil {
+		return nil, err
+	}
+	d := &mediaDecoder{ex: ex, length: -1}
+	if err = d.init(); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// assetFd 由 *app.Asset、*storage.Asset 实现，未压缩的 asset 可以取得文件描述符
+type assetFd interface {
+	FileDescriptor() (fd int, start, length int64, err error)
+}
+
+func newExtractor(r io.ReadSeeker) (*media.Extractor, error) {
+	switch f := r.(type) {
+	case *os.File:
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return extractorFd(f, 0, fi.Size())
+	case assetFd:
+		if fd, start, length, err := f.FileDescriptor(); err == nil {
+			// extractor 复制了 fd
+			file := os.NewFile(uintptr(fd), "asset")
+			defer file.Close()
+			return extractorFd(file, start, length)
+		}
+	}
+	return media.NewReaderExtractor(r)
+}
+
+func extractorFd(f *os.File, offset, length int64) (*media.Extractor, error) {
+	ex, err := media.NewExtractor()
+	if err != nil {
+		return nil, err
+	}
+	if err = ex.SetDataSourceFd(int(f.Fd()), offset, length); err != nil {
+		ex.Delete()
+		return nil, err
+	}
+	return ex, nil
+}
+
+func (d *mediaDecoder) init() error {
+	track := -1
+	var format *media.Format
+	for i := 0; i < d.ex.GetTrackCount(); i++ {
+		f := d.ex.GetTrackFormat(i)
+		if f == nil {
+			continue
+		}
+		if mime, _ := f.GetString(media.KEY_MIME); strings.HasPrefix(mime, "audio/") {
+			track, format = i, f
+			break
+		}
+		f.Delete()
+	}
+	if track < 0 {
+		return errNoAudioTrack
+	}
+	defer format.Delete()
+
+	if err := d.ex.SelectTrack(track); err != nil {
+		return err
+	}
+	d.updateFormat(format)
+	if us, ok := format.GetInt64(media.KEY_DURATION); ok && d.format.SampleRate > 0 {
+		d.length = d.format.Frames(time.Duration(us) * time.Microsecond)
+	}
+
+	mime, _ := format.GetString(media.KEY_MIME)
+	codec, err := media.CreateDecoderByType(mime)
+	if err != nil {
+		return err
+	}
+	d.codec = codec
+	if err = codec.Configure(format, nil, 0); err != nil {
+		return err
+	}
+	return codec.Start()
+}
+
+func (d *mediaDecoder) updateFormat(f *media.Format) {
+	if v, ok := f.GetInt32(media.KEY_SAMPLE_RATE); ok {
+		d.format.SampleRate = int(v)
+	}
+	if v, ok := f.GetInt32(media.KEY_CHANNEL_COUNT); ok {
+		d.format.Channels = int(v)
+	}
+	if v, ok := f.GetInt32(media.KEY_PCM_ENCODING); ok {
+		d.float = v == pcmEncodingFloat
+	}
+}
+
+func (d *mediaDecoder) Format() Format  { return d.format }
+func (d *mediaDecoder) Position() int64 { return d.pos }
+func (d *mediaDecoder) Length() int64   { return d.length }
+
+func (d *mediaDecoder) Close() error {
+	if d.codec != nil {
+		d.codec.Stop()
+		d.codec.Delete()
+		d.codec = nil
+	}
+	if d.ex != nil {
+		d.ex.Delete()
+		d.ex = nil
+	}
+	return nil
+}
+
+func (d *mediaDecoder) Read(p []float32) (int, error) {
+	ch := d.format.Channels
+	if len(p) < ch {
+		return 0, io.ErrShortBuffer
+	}
+	for len(d.pending) == 0 {
+		if d.outputDone {
+			return 0, io.EOF
+		}
+		if err := d.step(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p[:len(p)/ch*ch], d.pending)
+	d.pending = d.pending[n:]
+	d.pos += int64(n / ch)
+	return n, nil
+}
+
+// SeekFrame 从 frame 之前的同步样本开始解码，丢弃 frame 之前的输出
+func (d *mediaDecoder) SeekFrame(frame int64) error {
+	if frame < 0 {
+		frame = 0
+	}
+	if err := d.ex.SeekTo(d.format.Duration(frame), media.SEEK_PREVIOUS_SYNC); err != nil {
+		return err
+	}
+	if err := d.codec.Flush(); err != nil {
+		return err
+	}
+	d.pending = nil
+	d.inputDone, d.outputDone = false, false
+	d.pos, d.skip = frame, frame
+	return nil
+}
+
+// step 送一个样本给解码器，并取出一个输出 buffer
+func (d *mediaDecoder) step() error {
+	codec := d.codec
+	if !d.inputDone {
+		if idx := codec.DequeueInputBuffer(codecTimeout); idx >= 0 {
+			n, err := d.ex.ReadSampleData(codec.GetInputBuffer(idx))
+			if err != nil {
+				d.inputDone = true
+				return codec.QueueInputBuffer(idx, 0, 0, 0, media.BUFFER_FLAG_END_OF_STREAM)
+			}
+			if err = codec.QueueInputBuffer(idx, 0, n, d.ex.GetSampleTime(), 0); err != nil {
+				return err
+			}
+			d.ex.Advance()
+		}
+	}
+
+	idx := codec.DequeueOutputBuffer(&d.info, codecTimeout)
+	switch {
+	case idx == media.INFO_OUTPUT_FORMAT_CHANGED:
+		if f := codec.GetOutputFormat(); f != nil {
+			d.updateFormat(f)
+			f.Delete()
+		}
+	case idx >= 0:
+		buf := codec.GetOutputBuffer(idx)
+		if d.info.Size > 0 && d.info.Offset+d.info.Size <= len(buf) {
+			d.appendPCM(buf[d.info.Offset:d.info.Offset+d.info.Size], d.info.PresentationTime)
+		}
+		if d.info.Flags&media.BUFFER_FLAG_END_OF_STREAM != 0 {
+			d.outputDone = true
+		}
+		return codec.ReleaseOutputBuffer(idx, false)
+	}
+	return nil
+}
+
+func (d *mediaDecoder) appendPCM(data []byte, pts time.Duration) {
+	ch := d.format.Channels
+	size := 2
+	if d.float {
+		size = 4
+	}
+	frames := len(data) / (size * ch)
+	drop := 0
+	if start := d.format.Frames(pts); start < d.skip {
+		drop = int(d.skip - start)
+		if drop > frames {
+			drop = frames
+		}
+	}
+	data = data[drop*size*ch : frames*size*ch]
+	n := len(data) / size
+	if cap(d.pcm) < n {
+		d.pcm = make([]float32, n)
+	}
+	d.pcm = d.pcm[:n]
+	le := binary.LittleEndian
+	for i := range d.pcm {
+		if d.float {
+			d.pcm[i] = math.Float32frombits(le.Uint32(data[4*i:]))
+		} else {
+			d.pcm[i] = intToFloat(int32(int16(le.Uint16(data[2*i:]))), 16)
+		}
+	}
+	d.pending = d.pcm
+}
@@ -0,0 +1,36 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package decoder
+
+import (
+	"io"
+
+	"github.com/gooid/gooid/audio/pcm"
+	"github.com/gooid/gooid/audio/wav"
+)
+
+func init() {
+	RegisterFormat("wav", "RIFF????WAVE", openWAV)
+}
+
+// wavDecoder 整数 PCM (8/16/24/32 位) 和浮点 (32/64 位)，包括 WAVE_FORMAT_EXTENSIBLE。
+// 录音中或被截断的文件，数据到文件结尾
+type wavDecoder struct {
+	*pcm.FrameReader
+}
+
+func openWAV(r io.ReadSeeker) (Decoder, error) {
+	h, err := wav.ReadHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	d := &wavDecoder{pcm.NewFrameReader(r, h.Format, pcm.LittleEndian, h.DataOffset, h.BlockAlign, h.Frames())}
+	if err := d.SeekFrame(0); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *wavDecoder) Close() error { return nil }
@@ -0,0 +1,234 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package decoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xfffe
+)
+
+// testSample 第 frame 帧第 ch 声道的样本，k/128 在各种位深中都能精确表示
+func testSample(frame, ch int) float32 {
+	return float32((frame*37+ch*11)%200-100) / 128
+}
+
+// wavFixture 生成 frames 帧的 WAV，样本为 testSample。
+// 在 fmt 之前加一个奇数长度的 LIST 块，检查块的对齐
+func wavFixture(tag, bits, channels, rate, frames int, extensible bool) []byte {
+	le := binary.LittleEndian
+	var data bytes.Buffer
+	for f := 0; f < frames; f++ {
+		for c := 0; c < channels; c++ {
+			v := testSample(f, c)
+			switch {
+			case tag == wavFormatFloat && bits == 32:
+				binary.Write(&data, le, v)
+			case tag == wavFormatFloat:
+				binary.Write(&data, le, float64(v))
+			case bits == 8:
+				data.WriteByte(byte(int(v*128) + 128))
+			default:
+				x := int64(float64(v) * float64(int64(1)<<uint(bits-1)))
+				for i := 0; i < bits/8; i++ {
+					data.WriteByte(byte(x >> uint(8*i)))
+				}
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, le, uint32(0)) // 由下面补上
+	b.WriteString("WAVE")
+	b.WriteString("LIST")
+	binary.Write(&b, le, uint32(3))
+	b.Write([]byte{'a', 'b', 'c', 0})
+	b.WriteString("fmt ")
+	if extensible {
+		binary.Write(&b, le, uint32(40))
+		binary.Write(&b, le, uint16(wavFormatExtensible))
+	} else {
+		binary.Write(&b, le, uint32(16))
+		binary.Write(&b, le, uint16(tag))
+	}
+	binary.Write(&b, le, uint16(channels))
+	binary.Write(&b, le, uint32(rate))
+	binary.Write(&b, le, uint32(rate*channels*bits/8))
+	binary.Write(&b, le, uint16(channels*bits/8))
+	binary.Write(&b, le, uint16(bits))
+	if extensible {
+		binary.Write(&b, le, uint16(22))
+		binary.Write(&b, le, uint16(bits)) // wValidBitsPerSample
+		binary.Write(&b, le, uint32(3))    // dwChannelMask
+		// SubFormat GUID 的前两个字节为格式，其余为 KSDATAFORMAT_SUBTYPE 的固定部分
+		binary.Write(&b, le, uint16(tag))
+		b.Write([]byte{0, 0, 0, 0, 0x10, 0, 0x80, 0, 0, 0xaa, 0, 0x38, 0x9b, 0x71})
+	}
+	b.WriteString("data")
+	binary.Write(&b, le, uint32(data.Len()))
+	b.Write(data.Bytes())
+	out := b.Bytes()
+	le.PutUint32(out[4:], uint32(len(out)-8))
+	return out
+}
+
+func readAll(t *testing.T, d Decoder) []float32 {
+	t.Helper()
+	var all []float32
+	buf := make([]float32, 777*d.Format().Channels)
+	for {
+		n, err := d.Read(buf)
+		if n%d.Format().Channels != 0 {
+			t.Fatalf("Read returned %d samples, not whole frames", n)
+		}
+		all = append(all, buf[:n]...)
+		if err == io.EOF {
+			return all
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// checkSeek 定位到各个位置后，Position 和读到的第一帧都准确
+func checkSeek(t *testing.T, d Decoder, want func(frame, ch int) float32, frames ...int64) {
+	t.Helper()
+	ch := d.Format().Channels
+	buf := make([]float32, ch)
+	for _, frame := range frames {
+		if err := d.SeekFrame(frame); err != nil {
+			t.Fatalf("SeekFrame(%d): %v", frame, err)
+		}
+		if pos := d.Position(); pos != frame {
+			t.Fatalf("SeekFrame(%d): Position = %d", frame, pos)
+		}
+		n, err := d.Read(buf)
+		if n != ch || err != nil {
+			t.Fatalf("SeekFrame(%d): Read = %d, %v", frame, n, err)
+		}
+		for c := 0; c < ch; c++ {
+			if w := want(int(frame), c); buf[c] != w {
+				t.Fatalf("SeekFrame(%d): channel %d = %v, want %v", frame, c, buf[c], w)
+			}
+		}
+		if pos := d.Position(); pos != frame+1 {
+			t.Fatalf("SeekFrame(%d): Position after one frame = %d", frame, pos)
+		}
+	}
+}
+
+func TestWAV(t *testing.T) {
+	const rate, frames = 8000, 1001
+	cases := []struct {
+		name       string
+		tag, bits  int
+		channels   int
+		extensible bool
+	}{
+		{"int8", wavFormatPCM, 8, 1, false},
+		{"int16", wavFormatPCM, 16, 2, false},
+		{"int24", wavFormatPCM, 24, 2, false},
+		{"int32", wavFormatPCM, 32, 2, false},
+		{"float32", wavFormatFloat, 32, 2, false},
+		{"float64", wavFormatFloat, 64, 1, false},
+		{"extensible int16", wavFormatPCM, 16, 2, true},
+		{"extensible int24", wavFormatPCM, 24, 3, true},
+		{"extensible float32", wavFormatFloat, 32, 2, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data := wavFixture(c.tag, c.bits, c.channels, rate, frames, c.extensible)
+			d, name, err := Open(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+			if name != "wav" {
+				t.Errorf("format name %q, want wav", name)
+			}
+			f := d.Format()
+			if f.SampleRate != rate || f.Channels != c.channels || f.BitsPerSample != c.bits || f.Float != (c.tag == wavFormatFloat) {
+				t.Errorf("Format = %+v", f)
+			}
+			if d.Length() != frames {
+				t.Errorf("Length = %d, want %d", d.Length(), frames)
+			}
+			if want := time.Duration(frames) * time.Second / rate; Duration(d) != want {
+				t.Errorf("Duration = %v, want %v", Duration(d), want)
+			}
+
+			all := readAll(t, d)
+			if len(all) != frames*c.channels {
+				t.Fatalf("read %d samples, want %d", len(all), frames*c.channels)
+			}
+			for i, v := range all {
+				if w := testSample(i/c.channels, i%c.channels); v != w {
+					t.Fatalf("sample %d = %v, want %v", i, v, w)
+				}
+			}
+			if n, err := d.Read(make([]float32, c.channels)); n != 0 || err != io.EOF {
+				t.Errorf("Read at end = %d, %v, want io.EOF", n, err)
+			}
+
+			checkSeek(t, d, testSample, 0, 1, 500, 999, 1000, 3)
+			if err := SeekTime(d, 100*time.Millisecond); err != nil || d.Position() != 800 {
+				t.Errorf("SeekTime(100ms): Position = %d, %v", d.Position(), err)
+			}
+			// 超出范围的位置被截断
+			if err := d.SeekFrame(frames + 10); err != nil || d.Position() != frames {
+				t.Errorf("SeekFrame past end: Position = %d, %v", d.Position(), err)
+			}
+			if err := d.SeekFrame(-5); err != nil || d.Position() != 0 {
+				t.Errorf("SeekFrame(-5): Position = %d, %v", d.Position(), err)
+			}
+		})
+	}
+}
+
+func TestWAVTruncated(t *testing.T) {
+	data := wavFixture(wavFormatPCM, 16, 2, 8000, 10, false)
+	// data 块的长度大于文件中的数据，例如录音时异常退出
+	data = data[:len(data)-6]
+	d, _, err := Open(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Length() != 8 {
+		t.Errorf("Length = %d, want 8", d.Length())
+	}
+	if all := readAll(t, d); len(all) != 16 {
+		t.Errorf("read %d samples, want 16", len(all))
+	}
+}
+
+func TestOpenUnknown(t *testing.T) {
+	if _, _, err := Open(bytes.NewReader([]byte("hello, world"))); err != ErrFormat {
+		t.Errorf("Open = %v, want ErrFormat", err)
+	}
+}
+
+func TestIntToFloat(t *testing.T) {
+	for _, bits := range []int{8, 16, 24, 32} {
+		max := int32(int64(1)<<uint(bits-1) - 1)
+		min := -max - 1
+		if v := intToFloat(min, bits); v != -1 {
+			t.Errorf("%d bits: min = %v, want -1", bits, v)
+		}
+		if v := intToFloat(max, bits); v > 1 || math.Abs(float64(v)-1) > 1e-2 {
+			t.Errorf("%d bits: max = %v", bits, v)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+// Dither 量化前加入三角分布 (TPDF) 的抖动噪声，幅度 ±1 LSB，
+// 把量化误差变成与信号无关的白噪声。不是并发安全的
+type Dither struct {
+	state uint32
+}
+
+// NewDither seed 为随机数种子
+func NewDither(seed uint32) *Dither {
+	if seed == 0 {
+		seed = 0x9e3779b9
+	}
+	return &Dither{state: seed}
+}
+
+// next xorshift32，返回 [0, 1) 的均匀分布
+func (d *Dither) next() float32 {
+	x := d.state
+	x ^= x << 13
+	x ^= x >> 17
+	x ^= x << 5
+	d.state = x
+	return float32(x>>8) / (1 << 24)
+}
+
+// Apply 给将要量化为 bits 位整数的 samples 加入抖动
+func (d *Dither) Apply(samples []float32, bits int) {
+	lsb := 1 / float32(int64(1)<<uint(bits-1))
+	for i := range samples {
+		samples[i] += (d.next() - d.next()) * lsb
+	}
+}
+
+// Int16ToFloat32 把 src 转换为 [-1, 1) 的 float32，追加到 dst 后返回
+func Int16ToFloat32(dst []float32, src []int16) []float32 {
+	for _, v := range src {
+		dst = append(dst, float32(v)/(1<<15))
+	}
+	return dst
+}
+
+// Float32ToInt16 把 src 量化为 int16，超出范围的截断，追加到 dst 后返回。
+// dither 不为 nil 时先加入抖动，src 不被修改
+func Float32ToInt16(dst []int16, src []float32, dither *Dither) []int16 {
+	for _, v := range src {
+		if dither != nil {
+			v += (dither.next() - dither.next()) / (1 << 15)
+		}
+		x := v*(1<<15) + 0.5
+		switch {
+		case x >= 32767:
+			dst = append(dst, 32767)
+		case x <= -32768:
+			dst = append(dst, -32768)
+		case x < 0:
+			// 向下取整
+			t := int16(x)
+			if float32(t) != x {
+				t--
+			}
+			dst = append(dst, t)
+		default:
+			dst = append(dst, int16(x))
+		}
+	}
+	return dst
+}
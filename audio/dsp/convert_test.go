@@ -0,0 +1,86 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFloat32ToInt16(t *testing.T) {
+	const lsb = 1.0 / (1 << 15)
+	for _, c := range []struct {
+		in   float32
+		want int16
+	}{
+		{0, 0},
+		{0.5, 16384},
+		{-0.5, -16384},
+		// 四舍五入，0.5 LSB 向上
+		{0.4 * lsb, 0},
+		{0.5 * lsb, 1},
+		{0.6 * lsb, 1},
+		{-0.4 * lsb, 0},
+		{-0.5 * lsb, 0},
+		{-0.6 * lsb, -1},
+		{-1.5 * lsb, -1},
+		{-2.6 * lsb, -3},
+		// 截断
+		{1, 32767},
+		{32766.6 * lsb, 32767},
+		{-1, -32768},
+		{2, 32767},
+		{-2, -32768},
+		{float32(math.Inf(1)), 32767},
+		{float32(math.Inf(-1)), -32768},
+	} {
+		got := Float32ToInt16([]int16{7}, []float32{c.in}, nil)
+		if len(got) != 2 || got[0] != 7 || got[1] != c.want {
+			t.Errorf("%v (%.2f LSB): %v, want [7 %d]", c.in, c.in/lsb, got, c.want)
+		}
+	}
+}
+
+// TestFloat32ToInt16Dither 抖动后的量化没有偏差：小于 1 LSB 的直流保留在均值中
+func TestFloat32ToInt16Dither(t *testing.T) {
+	const n = 100000
+	for _, level := range []float32{0, 0.25, -0.7} {
+		src := make([]float32, n)
+		for i := range src {
+			src[i] = level / (1 << 15)
+		}
+		out := Float32ToInt16(nil, src, NewDither(1))
+		var sum float64
+		for i, v := range out {
+			sum += float64(v)
+			if v < -2 || v > 2 {
+				t.Fatalf("level %v: sample %d = %d, dither exceeds ±1 LSB", level, i, v)
+			}
+		}
+		if mean := sum / n; math.Abs(mean-float64(level)) > 0.02 {
+			t.Errorf("level %v LSB: dithered mean %.3f", level, mean)
+		}
+		if src[0] != level/(1<<15) {
+			t.Errorf("Float32ToInt16 modified src")
+		}
+	}
+}
+
+func TestInt16ToFloat32(t *testing.T) {
+	got := Int16ToFloat32(nil, []int16{-32768, -16384, 0, 1, 32767})
+	want := []float32{-1, -0.5, 0, 1.0 / (1 << 15), 32767.0 / (1 << 15)}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%v, want %v", got, want)
+			break
+		}
+	}
+	// 再量化得到原来的值
+	for i, v := range Float32ToInt16(nil, got, nil) {
+		if w := []int16{-32768, -16384, 0, 1, 32767}[i]; v != w {
+			t.Errorf("round trip %d: %d, want %d", i, v, w)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	"io"
+
+	"github.com/gooid/gooid/audio/pcm"
+)
+
+// 每次从源读取的帧数
+const readFrames = 1024
+
+// Reader 把源中的 PCM (小端) 转换为另一种采样率、声道数和样本格式。
+// 输出 16 位及以下的整数、且输入或重采样、混合后的精度高于输出时加入抖动
+type Reader struct {
+	r        io.Reader
+	in, out  pcm.Format
+	matrix   Matrix
+	rs       *Resampler
+	dither   *Dither
+	raw      []byte
+	rawLen   int
+	samples  []float32
+	mixed    []float32
+	resample []float32
+	pending  []byte
+	err      error
+}
+
+// NewReader 从 r 读取 in 格式的数据，输出 out 格式，使用 QualityHigh 重采样
+func NewReader(r io.Reader, in, out pcm.Format) (*Reader, error) {
+	return NewReaderQuality(r, in, out, QualityHigh)
+}
+
+// NewReaderQuality 同 NewReader，指定重采样质量
+func NewReaderQuality(r io.Reader, in, out pcm.Format, q Quality) (*Reader, error) {
+	if err := in.Validate(); err != nil {
+		return nil, err
+	}
+	if err := out.Validate(); err != nil {
+		return nil, err
+	}
+	d := &Reader{
+		r:   r,
+		in:  in,
+		out: out,
+		raw: make([]byte, readFrames*in.FrameSize()),
+	}
+	if in.Channels != out.Channels {
+		d.matrix = DefaultMatrix(in.Channels, out.Channels)
+	}
+	if in.SampleRate != out.SampleRate {
+		d.rs = NewResampler(out.Channels, in.SampleRate, out.SampleRate, q)
+	}
+	// 精度不降低时 (如 16 位到 16 位的直接转换) 抖动只会增加噪声
+	finer := in.Float || in.BitsPerSample > out.BitsPerSample || d.matrix != nil || d.rs != nil
+	if !out.Float && out.BitsPerSample <= 16 && finer {
+		d.dither = NewDither(0)
+	}
+	return d, nil
+}
+
+// Format 输出的格式
+func (d *Reader) Format() pcm.Format {
+	return d.out
+}
+
+// SetMatrix 替换默认的声道混合矩阵，m 必须是 in 到 out 的声道数
+func (d *Reader) SetMatrix(m Matrix) {
+	if m.InChannels() != d.in.Channels || m.OutChannels() != d.out.Channels {
+		panic("dsp: matrix does not match channel counts")
+	}
+	d.matrix = m
+}
+
+// Read 读取转换后的数据，总是完整的帧，p 中不足一帧的部分不使用。
+// len(p) 小于一帧时例外，返回一帧的一部分
+func (d *Reader) Read(p []byte) (int, error) {
+	if fs := d.out.FrameSize(); len(p) >= fs {
+		p = p[:len(p)/fs*fs]
+	}
+	for len(d.pending) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.fill()
+	}
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// fill 从源读取一块并转换，结果放在 pending
+func (d *Reader) fill() {
+	n, err := d.r.Read(d.raw[d.rawLen:])
+	d.rawLen += n
+	whole := d.rawLen / d.in.FrameSize() * d.in.FrameSize()
+
+	if cap(d.samples) < whole/d.in.BytesPerSample() {
+		d.samples = make([]float32, whole/d.in.BytesPerSample())
+	}
+	d.samples = d.samples[:pcm.Decode(d.samples[:cap(d.samples)], d.raw[:whole], d.in, pcm.LittleEndian)]
+	d.rawLen = copy(d.raw, d.raw[whole:d.rawLen])
+
+	buf := d.samples
+	if d.matrix != nil {
+		d.mixed = d.matrix.Apply(d.mixed[:0], buf)
+		buf = d.mixed
+	}
+	if d.rs != nil {
+		d.resample = d.rs.Process(d.resample[:0], buf)
+		if err != nil {
+			d.resample = d.rs.Flush(d.resample)
+		}
+		buf = d.resample
+	}
+	if d.dither != nil {
+		d.dither.Apply(buf, d.out.BitsPerSample)
+	}
+	d.pending = pcm.Encode(d.pending[:0], buf, d.out, pcm.LittleEndian)
+	if err != nil {
+		d.err = err
+	}
+}
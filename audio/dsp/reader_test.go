@@ -0,0 +1,91 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/gooid/gooid/audio/pcm"
+)
+
+// readAll 每次用 size 字节的 p 读取，检查每次都是完整的帧
+func readAll(t *testing.T, r *Reader, size int) []byte {
+	t.Helper()
+	fs := r.Format().FrameSize()
+	var out []byte
+	p := make([]byte, size)
+	for {
+		n, err := r.Read(p)
+		if size >= fs && n%fs != 0 {
+			t.Fatalf("Read(%d bytes) = %d, not whole %d-byte frames", size, n, fs)
+		}
+		out = append(out, p[:n]...)
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestReaderPassthrough 格式相同时数据不变 (不加抖动)，
+// p 不是帧的整数倍时只返回完整的帧，小于一帧时拼起来也不丢数据
+func TestReaderPassthrough(t *testing.T) {
+	f := pcm.Format{SampleRate: 48000, Channels: 2, BitsPerSample: 16}
+	src := make([]byte, 4*3001)
+	for i := range src {
+		src[i] = byte(i * 7)
+	}
+	for _, size := range []int{3, 4, 7, 4097, 100000} {
+		r, err := NewReader(bytes.NewReader(src), f, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out := readAll(t, r, size); !bytes.Equal(out, src) {
+			t.Errorf("Read(%d bytes): passthrough changed the data (%d bytes, want %d)", size, len(out), len(src))
+		}
+	}
+}
+
+func TestReaderConvert(t *testing.T) {
+	in := pcm.Format{SampleRate: 48000, Channels: 2, BitsPerSample: 16}
+	src := pcm.Encode(nil, tone(48000, 2, 48000, 440, 0.5), in, pcm.LittleEndian)
+	// 末尾不完整的帧被丢弃
+	src = append(src, 1, 2)
+
+	for _, out := range []pcm.Format{
+		{SampleRate: 16000, Channels: 1, BitsPerSample: 32, Float: true},
+		{SampleRate: 44100, Channels: 2, BitsPerSample: 16},
+		{SampleRate: 48000, Channels: 1, BitsPerSample: 24},
+	} {
+		r, err := NewReader(bytes.NewReader(src), in, out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := readAll(t, r, 1001)
+		if len(b) != out.SampleRate*out.FrameSize() {
+			t.Errorf("%+v: %d bytes, want 1 second (%d)", out, len(b), out.SampleRate*out.FrameSize())
+			continue
+		}
+		samples := make([]float32, len(b)/out.BytesPerSample())
+		pcm.Decode(samples, b, out, pcm.LittleEndian)
+		// 两个声道的相位差 1 弧度，混合后幅度为 0.5*cos(0.5)
+		amp := 0.5
+		if out.Channels == 1 {
+			amp *= 0.8775825618903728
+		}
+		got, _ := measure(samples, out.Channels, 0, out.SampleRate, 440)
+		if got < amp*0.99 || got > amp*1.01 {
+			t.Errorf("%+v: 440 Hz amplitude %.4f, want %.4f", out, got, amp)
+		}
+	}
+
+	if _, err := NewReader(bytes.NewReader(src), in, pcm.Format{SampleRate: 16000, Channels: 1, BitsPerSample: 12}); err == nil {
+		t.Error("NewReader with 12-bit output: no error")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import "math"
+
+// Matrix 声道混合矩阵，Matrix[out][in] 是输入声道 in 在输出声道 out 中的增益
+type Matrix [][]float32
+
+// 声道顺序同 WAVE_FORMAT_EXTENSIBLE: FL FR FC LFE BL BR SL SR
+const (
+	chFL = iota
+	chFR
+	chFC
+	chLFE
+	chBL
+	chBR
+	chSL
+	chSR
+)
+
+// DefaultMatrix inCh 到 outCh 声道的默认混合:
+// 单声道复制到左右声道；多声道到单声道取平均；
+// 5.1/7.1 到立体声按 ITU-R BS.775 (中置和环绕 -3dB，丢弃 LFE) 并归一化；
+// 其它情况多出的输入声道按序号折叠到输出声道，缺少的输出声道为静音
+func DefaultMatrix(inCh, outCh int) Matrix {
+	m := make(Matrix, outCh)
+	for o := range m {
+		m[o] = make([]float32, inCh)
+	}
+	switch {
+	case inCh == outCh:
+		for i := range m {
+			m[i][i] = 1
+		}
+	case inCh == 1:
+		m[chFL][0] = 1
+		if outCh > 1 {
+			m[chFR][0] = 1
+		}
+	case outCh == 1:
+		for i := range m[0] {
+			m[0][i] = 1 / float32(inCh)
+		}
+	case outCh == 2 && (inCh == 6 || inCh == 8):
+		g := float32(math.Sqrt2 / 2)
+		m[0][chFL], m[1][chFR] = 1, 1
+		m[0][chFC], m[1][chFC] = g, g
+		m[0][chBL], m[1][chBR] = g, g
+		norm := 1 + 2*g
+		if inCh == 8 {
+			m[0][chSL], m[1][chSR] = g, g
+			norm += g
+		}
+		for _, row := range m {
+			for i := range row {
+				row[i] /= norm
+			}
+		}
+	case inCh < outCh:
+		for i := 0; i < inCh; i++ {
+			m[i][i] = 1
+		}
+	default:
+		count := make([]int, outCh)
+		for i := 0; i < inCh; i++ {
+			count[i%outCh]++
+		}
+		for i := 0; i < inCh; i++ {
+			m[i%outCh][i] = 1 / float32(count[i%outCh])
+		}
+	}
+	return m
+}
+
+// InChannels 输入声道数
+func (m Matrix) InChannels() int {
+	if len(m) == 0 {
+		return 0
+	}
+	return len(m[0])
+}
+
+// OutChannels 输出声道数
+func (m Matrix) OutChannels() int {
+	return len(m)
+}
+
+// Apply 混合交错存放的 src，结果追加到 dst 后返回
+func (m Matrix) Apply(dst, src []float32) []float32 {
+	inCh := m.InChannels()
+	for f := 0; f+inCh <= len(src); f += inCh {
+		in := src[f : f+inCh]
+		for _, row := range m {
+			var s float32
+			for i, g := range row {
+				s += g * in[i]
+			}
+			dst = append(dst, s)
+		}
+	}
+	return dst
+}
+
+// Remix 用 DefaultMatrix 把 inCh 声道的 src 转换为 outCh 声道，追加到 dst 后返回
+func Remix(dst, src []float32, inCh, outCh int) []float32 {
+	if inCh == outCh {
+		return append(dst, src...)
+	}
+	return DefaultMatrix(inCh, outCh).Apply(dst, src)
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-6
+}
+
+// TestDefaultMatrixSurround 5.1/7.1 到立体声：中置和环绕 -3dB，丢弃 LFE，
+// 每行之和为 1，所有声道满幅同相时不削波
+func TestDefaultMatrixSurround(t *testing.T) {
+	g := float32(math.Sqrt2 / 2)
+	for _, c := range []struct {
+		in          int
+		left, right []float32
+	}{
+		{6, []float32{1, 0, g, 0, g, 0}, []float32{0, 1, g, 0, 0, g}},
+		{8, []float32{1, 0, g, 0, g, 0, g, 0}, []float32{0, 1, g, 0, 0, g, 0, g}},
+	} {
+		m := DefaultMatrix(c.in, 2)
+		if m.InChannels() != c.in || m.OutChannels() != 2 {
+			t.Fatalf("%d->2: %dx%d matrix", c.in, m.OutChannels(), m.InChannels())
+		}
+		var norm float32
+		for _, v := range c.left {
+			norm += v
+		}
+		for o, want := range [][]float32{c.left, c.right} {
+			var sum float32
+			for i, v := range m[o] {
+				sum += v
+				if !near(v, want[i]/norm) {
+					t.Errorf("%d->2: m[%d][%d] = %v, want %v", c.in, o, i, v, want[i]/norm)
+				}
+			}
+			if !near(sum, 1) {
+				t.Errorf("%d->2: row %d sums to %v, want 1", c.in, o, sum)
+			}
+		}
+
+		full := make([]float32, c.in)
+		for i := range full {
+			full[i] = 1
+		}
+		for i, v := range m.Apply(nil, full) {
+			if v > 1+1e-6 {
+				t.Errorf("%d->2: full scale input gives %v on channel %d", c.in, v, i)
+			}
+		}
+		// 只有 LFE 时输出静音
+		lfe := make([]float32, c.in)
+		lfe[chLFE] = 1
+		for i, v := range m.Apply(nil, lfe) {
+			if v != 0 {
+				t.Errorf("%d->2: LFE leaks %v into channel %d", c.in, v, i)
+			}
+		}
+	}
+}
+
+func TestRemix(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		in, out   int
+		src, want []float32
+	}{
+		{"mono to stereo", 1, 2, []float32{0.3, -0.5}, []float32{0.3, 0.3, -0.5, -0.5}},
+		{"stereo to mono", 2, 1, []float32{0.2, 0.4, -1, 1}, []float32{0.3, 0}},
+		{"stereo to quad", 2, 4, []float32{0.2, 0.4}, []float32{0.2, 0.4, 0, 0}},
+		// 声道 0、2 折叠到左，1、3 折叠到右
+		{"quad to stereo", 4, 2, []float32{0.2, 0.4, 0.6, 0.8}, []float32{0.4, 0.6}},
+		{"3 to 2", 3, 2, []float32{0.2, 0.4, 0.6}, []float32{0.4, 0.4}},
+		{"same", 2, 2, []float32{0.2, 0.4}, []float32{0.2, 0.4}},
+		// 不完整的帧被忽略
+		{"partial frame", 2, 1, []float32{0.2, 0.4, 0.6}, []float32{0.3}},
+	} {
+		got := Remix([]float32{9}, c.src, c.in, c.out)
+		if len(got) != len(c.want)+1 || got[0] != 9 {
+			t.Errorf("%s: %v, want [9 %v]", c.name, got, c.want)
+			continue
+		}
+		for i, v := range got[1:] {
+			if !near(v, c.want[i]) {
+				t.Errorf("%s: %v, want %v", c.name, got[1:], c.want)
+				break
+			}
+		}
+	}
+}
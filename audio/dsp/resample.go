@@ -0,0 +1,220 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package dsp 纯 Go 的采样率转换、声道混合和样本格式转换。
+//
+// 设备只支持固有的采样率和声道数时，用 NewReader 把它们转换为处理代码需要的格式:
+//
+//	r, err := dsp.NewReader(capture,
+//		pcm.Format{SampleRate: 48000, Channels: 2, BitsPerSample: 16},
+//		pcm.Format{SampleRate: 16000, Channels: 1, BitsPerSample: 16})
+package dsp
+
+import "math"
+
+// Quality 重采样质量，越高滤波器越长
+type Quality int
+
+const (
+	QualityLow    Quality = iota // 每侧 8 个过零点
+	QualityMedium                // 每侧 16 个过零点
+	QualityHigh                  // 每侧 32 个过零点
+)
+
+// 超过此数的相位改为在相邻相位之间线性插值
+const maxPhases = 1024
+
+// Resampler 多相加窗 sinc (Kaiser 窗) 重采样，按块流式处理交错存放的样本。
+// 输出的第 0 帧与输入的第 0 帧对齐，没有延迟
+type Resampler struct {
+	channels    int
+	inRate      int
+	outRate     int
+	up, down    int // outRate/inRate = up/down，已约分
+	half        int // 每侧的抽头数
+	phases      int
+	coefs       []float32 // (phases+1) 组，每组 2*half 个
+	history     [][]float32
+	pos         int   // 下一个输出帧在 history 中的整数位置
+	frac        int   // 小数位置，单位 1/up
+	inFrames    int64 // 收到的输入帧数 (不含 Flush 补的零)
+	outFrames   int64
+	interpolate bool
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// NewResampler 把 channels 声道的 inRate 样本转换为 outRate
+func NewResampler(channels, inRate, outRate int, q Quality) *Resampler {
+	if channels <= 0 || inRate <= 0 || outRate <= 0 {
+		panic("dsp: bad resampler parameters")
+	}
+	g := gcd(inRate, outRate)
+	r := &Resampler{
+		channels: channels,
+		inRate:   inRate,
+		outRate:  outRate,
+		up:       outRate / g,
+		down:     inRate / g,
+	}
+	zeros := 8 << uint(q)
+	beta := 6.0 + 2*float64(q)
+
+	// 降采样时截止频率跟着降低，滤波器按比例加宽
+	cutoff := 0.5 * 0.95
+	scale := 1.0
+	if r.up < r.down {
+		scale = float64(r.up) / float64(r.down)
+		cutoff *= scale
+	}
+	r.half = int(math.Ceil(float64(zeros) / scale))
+	r.phases = r.up
+	if r.phases > maxPhases {
+		r.phases = maxPhases
+		r.interpolate = true
+	}
+	r.coefs = make([]float32, (r.phases+1)*2*r.half)
+	for p := 0; p <= r.phases; p++ {
+		frac := float64(p) / float64(r.phases)
+		taps := r.coefs[p*2*r.half : (p+1)*2*r.half]
+		for k := range taps {
+			t := frac + float64(r.half-1-k)
+			taps[k] = float32(2 * cutoff * sinc(2*cutoff*t) * kaiser(t/float64(r.half), beta))
+		}
+	}
+	r.Reset()
+	return r
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// kaiser Kaiser 窗，x 在 [-1, 1] 之外为 0
+func kaiser(x, beta float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	return bessel0(beta*math.Sqrt(1-x*x)) / bessel0(beta)
+}
+
+// bessel0 第一类零阶修正贝塞尔函数
+func bessel0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 50; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < sum*1e-12 {
+			break
+		}
+	}
+	return sum
+}
+
+// Reset 清除历史，开始新的流
+func (r *Resampler) Reset() {
+	r.history = make([][]float32, r.channels)
+	for c := range r.history {
+		// 前面补零，使第 0 帧输出对齐第 0 帧输入
+		r.history[c] = make([]float32, r.half-1)
+	}
+	r.pos = r.half - 1
+	r.frac = 0
+	r.inFrames, r.outFrames = 0, 0
+}
+
+// InRate 输入采样率
+func (r *Resampler) InRate() int { return r.inRate }
+
+// OutRate 输出采样率
+func (r *Resampler) OutRate() int { return r.outRate }
+
+// Process 输入交错存放的样本 src，把能够计算的输出追加到 dst 后返回
+func (r *Resampler) Process(dst, src []float32) []float32 {
+	ch := r.channels
+	frames := len(src) / ch
+	for c, h := range r.history {
+		for i := 0; i < frames; i++ {
+			h = append(h, src[i*ch+c])
+		}
+		r.history[c] = h
+	}
+	r.inFrames += int64(frames)
+	return r.run(dst, -1)
+}
+
+// Flush 输入结束，补零输出剩余的样本，之后可以开始新的流
+func (r *Resampler) Flush(dst []float32) []float32 {
+	// 总输出帧数 ceil(inFrames * up / down)
+	total := (r.inFrames*int64(r.up) + int64(r.down) - 1) / int64(r.down)
+	for c := range r.history {
+		r.history[c] = append(r.history[c], make([]float32, r.half+1)...)
+	}
+	if rem := total - r.outFrames; rem > 0 {
+		dst = r.run(dst, rem)
+	}
+	r.Reset()
+	return dst
+}
+
+// run 输出 limit 帧 (-1 表示不限)，丢弃不再需要的历史
+func (r *Resampler) run(dst []float32, limit int64) []float32 {
+	n := len(r.history[0])
+	taps := 2 * r.half
+	for limit != 0 && r.pos+r.half < n {
+		start := r.pos - r.half + 1
+		var c0, c1 []float32
+		var t float32
+		if r.interpolate {
+			x := float64(r.frac) * float64(r.phases) / float64(r.up)
+			p := int(x)
+			t = float32(x - float64(p))
+			c0 = r.coefs[p*taps : (p+1)*taps]
+			c1 = r.coefs[(p+1)*taps : (p+2)*taps]
+		} else {
+			c0 = r.coefs[r.frac*taps : (r.frac+1)*taps]
+		}
+		for _, h := range r.history {
+			in := h[start : start+taps]
+			var s float32
+			if c1 == nil {
+				for k, v := range in {
+					s += v * c0[k]
+				}
+			} else {
+				for k, v := range in {
+					s += v * (c0[k] + t*(c1[k]-c0[k]))
+				}
+			}
+			dst = append(dst, s)
+		}
+		r.outFrames++
+		if limit > 0 {
+			limit--
+		}
+		r.frac += r.down
+		r.pos += r.frac / r.up
+		r.frac %= r.up
+	}
+
+	if drop := r.pos - r.half + 1; drop > 0 {
+		if drop > n {
+			drop = n
+		}
+		for c, h := range r.history {
+			r.history[c] = h[:copy(h, h[drop:])]
+		}
+		r.pos -= drop
+	}
+	return dst
+}
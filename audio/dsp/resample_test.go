@@ -0,0 +1,161 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dsp
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// tone frames 帧 freq Hz、幅度 amp 的正弦，channels 声道交错存放，
+// 第 c 声道的相位偏移 c 弧度
+func tone(frames, channels, rate int, freq, amp float64) []float32 {
+	s := make([]float32, frames*channels)
+	for i := 0; i < frames; i++ {
+		for c := 0; c < channels; c++ {
+			s[i*channels+c] = float32(amp * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)+float64(c)))
+		}
+	}
+	return s
+}
+
+// resample 按 chunk 帧一块送入 r 并 Flush
+func resample(r *Resampler, src []float32, chunk int) []float32 {
+	var dst []float32
+	n := chunk * r.channels
+	for i := 0; i < len(src); i += n {
+		end := i + n
+		if end > len(src) {
+			end = len(src)
+		}
+		dst = r.Process(dst, src[i:end])
+	}
+	return r.Flush(dst)
+}
+
+// measure 第 c 声道在 freq 上的幅度和相位 (相对 sin)，跳过两端 10%
+func measure(s []float32, channels, c, rate int, freq float64) (amp, phase float64) {
+	frames := len(s) / channels
+	// 取整数个周期，避免泄漏
+	period := float64(rate) / freq
+	from := frames / 10
+	n := int(math.Floor(float64(frames*8/10)/period) * period)
+	var re, im float64
+	for i := from; i < from+n; i++ {
+		w := 2 * math.Pi * freq * float64(i) / float64(rate)
+		v := float64(s[i*channels+c])
+		re += v * math.Sin(w)
+		im += v * math.Cos(w)
+	}
+	return 2 * math.Hypot(re, im) / float64(n), math.Atan2(im, re)
+}
+
+var testRates = []struct {
+	in, out int
+}{
+	{44100, 48000},
+	{48000, 44100},
+	{48000, 16000},
+	{8000, 48000},
+	{16000, 16000},
+	{44100, 44101}, // 相位数超过 maxPhases，插值
+}
+
+func TestResamplerFrames(t *testing.T) {
+	for _, r := range testRates {
+		for _, frames := range []int{0, 1, 7, 999, 4410} {
+			for _, chunk := range []int{1, 100, 5000} {
+				rs := NewResampler(2, r.in, r.out, QualityLow)
+				in := tone(frames, 2, r.in, 440, 0.5)
+				want := (frames*r.out + r.in - 1) / r.in
+				if got := resample(rs, in, chunk); len(got) != 2*want {
+					t.Errorf("%d->%d, %d frames in chunks of %d: %d frames, want %d",
+						r.in, r.out, frames, chunk, len(got)/2, want)
+				}
+			}
+		}
+	}
+}
+
+// TestResamplerReuse Flush 之后开始新的流，结果与新的 Resampler 相同
+func TestResamplerReuse(t *testing.T) {
+	in := tone(3000, 1, 48000, 1000, 0.5)
+	rs := NewResampler(1, 48000, 44100, QualityMedium)
+	first := resample(rs, in, 256)
+	second := resample(rs, in, 700)
+	if len(first) != len(second) {
+		t.Fatalf("%d frames, then %d", len(first), len(second))
+	}
+	for i := range first {
+		if math.Abs(float64(first[i]-second[i])) > 1e-6 {
+			t.Fatalf("frame %d: %v, then %v", i, first[i], second[i])
+		}
+	}
+}
+
+// TestResamplerPassband 通带内的正弦幅度不变，与理想重采样的逐点误差小
+func TestResamplerPassband(t *testing.T) {
+	for _, r := range testRates {
+		nyquist := float64(r.in)
+		if r.out < r.in {
+			nyquist = float64(r.out)
+		}
+		nyquist /= 2
+		for _, q := range []Quality{QualityLow, QualityMedium, QualityHigh} {
+			for _, freq := range []float64{100, 1000, 0.4 * nyquist} {
+				t.Run(fmt.Sprintf("%d-%d/q%d/%.0fHz", r.in, r.out, q, freq), func(t *testing.T) {
+					in := tone(r.in/2, 2, r.in, freq, 0.5)
+					out := resample(NewResampler(2, r.in, r.out, q), in, 333)
+					want := tone(len(out)/2, 2, r.out, freq, 0.5)
+					for c := 0; c < 2; c++ {
+						amp, _ := measure(out, 2, c, r.out, freq)
+						if db := 20 * math.Log10(amp/0.5); math.Abs(db) > 0.1 {
+							t.Errorf("channel %d: gain %.3f dB", c, db)
+						}
+					}
+					// 两端受补零影响，只比较中间部分
+					frames := len(out) / 2
+					var maxErr float64
+					for i := frames / 10 * 2; i < frames*9/10*2; i++ {
+						maxErr = math.Max(maxErr, math.Abs(float64(out[i]-want[i])))
+					}
+					if maxErr > 0.01 {
+						t.Errorf("max error %.3g", maxErr)
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestResamplerPhase 输出的第 0 帧对齐输入的第 0 帧：正弦的相位不变
+func TestResamplerPhase(t *testing.T) {
+	for _, r := range testRates {
+		in := tone(r.in/4, 2, r.in, 500, 0.5)
+		out := resample(NewResampler(2, r.in, r.out, QualityHigh), in, 512)
+		for c := 0; c < 2; c++ {
+			_, phase := measure(out, 2, c, r.out, 500)
+			// 1e-3 弧度在 500Hz 下约 0.3µs，远小于一个样本
+			if d := math.Remainder(phase-float64(c), 2*math.Pi); math.Abs(d) > 1e-3 {
+				t.Errorf("%d->%d channel %d: phase off by %.3g rad (%.3f samples)",
+					r.in, r.out, c, d, d/(2*math.Pi*500)*float64(r.out))
+			}
+		}
+	}
+}
+
+// TestResamplerStopband 降采样时滤掉新 Nyquist 以上的频率
+func TestResamplerStopband(t *testing.T) {
+	in := tone(48000, 1, 48000, 7000, 0.5)
+	out := resample(NewResampler(1, 48000, 8000, QualityHigh), in, 1024)
+	var peak float64
+	for _, v := range out[800:7200] {
+		peak = math.Max(peak, math.Abs(float64(v)))
+	}
+	if db := 20 * math.Log10(peak/0.5); db > -60 {
+		t.Errorf("7 kHz alias at %.1f dB, want below -60 dB", db)
+	}
+}
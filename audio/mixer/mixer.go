@@ -0,0 +1,316 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package mixer 纯 Go 的软件混音器。
+//
+// 每个 Voice 播放一个 Sound，可以单独设置音量、声像、音高、循环和淡入淡出；
+// Voice 输出到 Bus，Bus 可以嵌套，最后汇总到 master，经过限幅器输出。
+// Render 按需生成样本，可以放在任何输出流的回调中:
+//
+//	m := mixer.New(s.SampleRate(), s.ChannelCount())
+//	cfg.Callback = func(s *audio.Stream, buf *audio.Buffer) bool {
+//		m.Render(buf.Float32)
+//		return true
+//	}
+package mixer
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// maxBlock Render 每次处理的最大帧数，增益在每块内线性过渡
+const maxBlock = 256
+
+// Sound 内存中的 PCM，交错存放的 float32，1 或 2 声道
+type Sound struct {
+	Data       []float32
+	Channels   int
+	SampleRate int
+}
+
+// Frames 帧数
+func (s *Sound) Frames() int {
+	return len(s.Data) / s.Channels
+}
+
+// Duration 时长
+func (s *Sound) Duration() time.Duration {
+	return time.Duration(s.Frames()) * time.Second / time.Duration(s.SampleRate)
+}
+
+// ramp 在一块内从 cur 线性过渡到 target，避免增益突变产生爆音
+type ramp struct {
+	cur, target float32
+}
+
+func (r *ramp) step(frames int) float32 {
+	return (r.target - r.cur) / float32(frames)
+}
+
+// Mixer 混音器，所有方法可以在任意 goroutine 中调用
+type Mixer struct {
+	mu         sync.Mutex
+	sampleRate int
+	channels   int
+	maxVoices  int
+	voices     []*Voice
+	buses      []*Bus // buses[0] 是 master，子 bus 总在父 bus 之后
+	limiter    limiter
+}
+
+// New channels 为 1 或 2
+func New(sampleRate, channels int) *Mixer {
+	if channels != 1 && channels != 2 {
+		panic("mixer: channels must be 1 or 2")
+	}
+	m := &Mixer{sampleRate: sampleRate, channels: channels}
+	m.buses = []*Bus{{m: m, name: "master", gain: ramp{1, 1}, volume: 1}}
+	m.SetLimiter(0.98, 100*time.Millisecond)
+	return m
+}
+
+func (m *Mixer) SampleRate() int { return m.sampleRate }
+func (m *Mixer) Channels() int   { return m.channels }
+
+// Master 主 bus
+func (m *Mixer) Master() *Bus {
+	return m.buses[0]
+}
+
+// NewBus 创建输出到 parent 的 bus，parent 为 nil 时输出到 master
+func (m *Mixer) NewBus(name string, parent *Bus) *Bus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if parent == nil {
+		parent = m.buses[0]
+	}
+	b := &Bus{m: m, name: name, parent: parent, gain: ramp{1, 1}, volume: 1}
+	m.buses = append(m.buses, b)
+	return b
+}
+
+// Bus 按名字查找 bus，没有时返回 nil
+func (m *Mixer) Bus(name string) *Bus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, b := range m.buses {
+		if b.name == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// SetMaxVoices 同时播放的 Voice 数，超过时停止最早开始的 Voice。0 表示不限制
+func (m *Mixer) SetMaxVoices(n int) {
+	m.mu.Lock()
+	m.maxVoices = n
+	m.mu.Unlock()
+}
+
+// Voices 正在播放的 Voice 数
+func (m *Mixer) Voices() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.voices)
+}
+
+// SetLimiter 设置 master 的限幅器：输出的绝对值不超过 threshold，
+// 增益在 release 内恢复。threshold 为 0 时关闭
+func (m *Mixer) SetLimiter(threshold float32, release time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.limiter.threshold = threshold
+	m.limiter.release = 1
+	if n := release.Seconds() * float64(m.sampleRate); n > 0 {
+		m.limiter.release = float32(1 - math.Exp(-1/n))
+	}
+	if m.limiter.env == 0 {
+		m.limiter.env = 1
+	}
+}
+
+// NewVoice 创建播放 snd 的 Voice，输出到 bus (nil 为 master)，调用 Play 开始播放
+func (m *Mixer) NewVoice(snd *Sound, bus *Bus) *Voice {
+	if bus == nil {
+		bus = m.buses[0]
+	}
+	return &Voice{m: m, snd: snd, bus: bus, gain: 1, pitch: 1, fade: 1}
+}
+
+// Play 立即播放 snd
+func (m *Mixer) Play(snd *Sound, bus *Bus) *Voice {
+	v := m.NewVoice(snd, bus)
+	v.Play()
+	return v
+}
+
+// StopAll 停止所有 Voice
+func (m *Mixer) StopAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, v := range m.voices {
+		v.reset()
+	}
+	m.voices = m.voices[:0]
+}
+
+// Render 混音 len(buf)/Channels 帧到 buf (交错存放)，覆盖原来的内容
+func (m *Mixer) Render(buf []float32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ch := m.channels
+	for len(buf) >= ch {
+		frames := len(buf) / ch
+		if frames > maxBlock {
+			frames = maxBlock
+		}
+		m.renderBlock(buf[:frames*ch], frames)
+		buf = buf[frames*ch:]
+	}
+	for i := range buf {
+		buf[i] = 0
+	}
+}
+
+func (m *Mixer) renderBlock(out []float32, frames int) {
+	for _, b := range m.buses {
+		if cap(b.buf) < len(out) {
+			b.buf = make([]float32, len(out))
+		}
+		b.buf = b.buf[:len(out)]
+		for i := range b.buf {
+			b.buf[i] = 0
+		}
+	}
+
+	active := m.voices[:0]
+	for _, v := range m.voices {
+		if v.render(v.bus.buf, frames) {
+			active = append(active, v)
+		} else {
+			v.reset()
+		}
+	}
+	for i := len(active); i < len(m.voices); i++ {
+		m.voices[i] = nil
+	}
+	m.voices = active
+
+	// 子 bus 在父 bus 之后创建，倒序汇总
+	for i := len(m.buses) - 1; i > 0; i-- {
+		b := m.buses[i]
+		b.mixInto(b.parent.buf, frames)
+	}
+	for i := range out {
+		out[i] = 0
+	}
+	m.buses[0].mixInto(out, frames)
+	m.limiter.process(out, m.channels)
+}
+
+// Bus 一组 Voice 或子 Bus 的汇总，有自己的音量和静音
+type Bus struct {
+	m      *Mixer
+	name   string
+	parent *Bus
+	gain   ramp
+	volume float32
+	muted  bool
+	buf    []float32
+}
+
+func (b *Bus) Name() string { return b.name }
+
+// Parent master 的 Parent 为 nil
+func (b *Bus) Parent() *Bus { return b.parent }
+
+// SetGain 设置音量，1 为原始音量
+func (b *Bus) SetGain(gain float32) {
+	b.m.mu.Lock()
+	defer b.m.mu.Unlock()
+	b.volume = gain
+	if !b.muted {
+		b.gain.target = gain
+	}
+}
+
+func (b *Bus) Gain() float32 {
+	b.m.mu.Lock()
+	defer b.m.mu.Unlock()
+	return b.volume
+}
+
+// SetMuted 静音，音量保留
+func (b *Bus) SetMuted(muted bool) {
+	b.m.mu.Lock()
+	defer b.m.mu.Unlock()
+	b.muted = muted
+	if muted {
+		b.gain.target = 0
+	} else {
+		b.gain.target = b.volume
+	}
+}
+
+func (b *Bus) Muted() bool {
+	b.m.mu.Lock()
+	defer b.m.mu.Unlock()
+	return b.muted
+}
+
+func (b *Bus) mixInto(dst []float32, frames int) {
+	ch := b.m.channels
+	g, dg := b.gain.cur, b.gain.step(frames)
+	if g == 0 && dg == 0 {
+		return
+	}
+	for i := 0; i < frames; i++ {
+		for c := 0; c < ch; c++ {
+			dst[i*ch+c] += b.buf[i*ch+c] * g
+		}
+		g += dg
+	}
+	b.gain.cur = b.gain.target
+}
+
+// limiter 峰值限幅器：立即压低超过阈值的帧，之后按 release 恢复
+type limiter struct {
+	threshold float32
+	release   float32 // 每帧恢复的比例
+	env       float32
+}
+
+func (l *limiter) process(buf []float32, ch int) {
+	if l.threshold <= 0 {
+		return
+	}
+	for i := 0; i+ch <= len(buf); i += ch {
+		var peak float32
+		for c := 0; c < ch; c++ {
+			if v := abs(buf[i+c]); v > peak {
+				peak = v
+			}
+		}
+		g := l.env
+		if peak*g > l.threshold {
+			g = l.threshold / peak
+		}
+		if g != 1 {
+			for c := 0; c < ch; c++ {
+				buf[i+c] *= g
+			}
+		}
+		l.env = g + (1-g)*l.release
+	}
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,408 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mixer
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// testRate 1 帧为 1ms，方便换算时间
+const testRate = 1000
+
+// newTestMixer 关闭限幅器，输出就是各 voice 的和
+func newTestMixer(channels int) *Mixer {
+	m := New(testRate, channels)
+	m.SetLimiter(0, 0)
+	return m
+}
+
+// constSound 单声道的直流信号
+func constSound(v float32, frames int) *Sound {
+	data := make([]float32, frames)
+	for i := range data {
+		data[i] = v
+	}
+	return &Sound{Data: data, Channels: 1, SampleRate: testRate}
+}
+
+// rampSound 第 i 帧为 i/128，整数位置的插值结果是精确的
+func rampSound(frames int) *Sound {
+	data := make([]float32, frames)
+	for i := range data {
+		data[i] = float32(i) / 128
+	}
+	return &Sound{Data: data, Channels: 1, SampleRate: testRate}
+}
+
+func render(m *Mixer, frames int) []float32 {
+	buf := make([]float32, frames*m.Channels())
+	for i := range buf {
+		buf[i] = 42 // Render 应覆盖原来的内容
+	}
+	m.Render(buf)
+	return buf
+}
+
+func near(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-5
+}
+
+func TestGainPan(t *testing.T) {
+	cases := []struct {
+		channels    int
+		gain, pan   float32
+		left, right float32
+	}{
+		{2, 1, 0, 0.5, 0.5},
+		{2, 0.8, 0.5, 0.2, 0.4},
+		{2, 0.8, -0.5, 0.4, 0.2},
+		{2, 1, 1, 0, 0.5},
+		{2, 1, -3, 0.5, 0}, // 超出范围的 pan 被截断
+		{1, 0.8, 0.5, 0.4, 0.4},
+	}
+	for _, c := range cases {
+		m := newTestMixer(c.channels)
+		v := m.NewVoice(constSound(0.5, 1000), nil)
+		v.SetGain(c.gain)
+		v.SetPan(c.pan)
+		v.Play()
+		out := render(m, 300)
+		for i := 0; i < 300; i++ {
+			if c.channels == 1 {
+				if !near(out[i], c.left) {
+					t.Fatalf("%+v: frame %d = %v, want %v", c, i, out[i], c.left)
+				}
+				continue
+			}
+			if !near(out[2*i], c.left) || !near(out[2*i+1], c.right) {
+				t.Fatalf("%+v: frame %d = %v/%v, want %v/%v", c, i, out[2*i], out[2*i+1], c.left, c.right)
+			}
+		}
+	}
+}
+
+func TestStereoSoundPan(t *testing.T) {
+	m := newTestMixer(2)
+	snd := &Sound{Data: []float32{0.5, -0.25, 0.5, -0.25, 0.5, -0.25}, Channels: 2, SampleRate: testRate}
+	v := m.NewVoice(snd, nil)
+	v.SetPan(0.5)
+	v.Play()
+	out := render(m, 3)
+	// 立体声的 Sound 只减弱另一侧
+	for i := 0; i < 3; i++ {
+		if !near(out[2*i], 0.25) || !near(out[2*i+1], -0.25) {
+			t.Fatalf("frame %d = %v/%v, want 0.25/-0.25", i, out[2*i], out[2*i+1])
+		}
+	}
+}
+
+// TestGainRamp 播放中改变音量，在下一块内线性过渡
+func TestGainRamp(t *testing.T) {
+	m := newTestMixer(1)
+	v := m.Play(constSound(1, 2000), nil)
+	render(m, maxBlock)
+	v.SetGain(0.5)
+	out := render(m, 2*maxBlock)
+	for i := 0; i < maxBlock; i++ {
+		want := 1 - 0.5*float32(i)/maxBlock
+		if !near(out[i], want) {
+			t.Fatalf("frame %d = %v, want %v", i, out[i], want)
+		}
+	}
+	for i := maxBlock; i < 2*maxBlock; i++ {
+		if !near(out[i], 0.5) {
+			t.Fatalf("frame %d = %v, want 0.5", i, out[i])
+		}
+	}
+}
+
+func TestPitch(t *testing.T) {
+	const frames = 400
+	cases := []struct {
+		pitch float32
+		// 输出第 i 帧对应的 Sound 中的位置
+		pos func(i int) float32
+		end int // 此后输出为 0
+	}{
+		{1, func(i int) float32 { return float32(i) }, frames},
+		{2, func(i int) float32 { return float32(2 * i) }, frames / 2},
+		// 最后半帧没有下一帧可以插值
+		{0.5, func(i int) float32 { return float32(math.Min(float64(i)/2, frames-1)) }, 2 * frames},
+	}
+	for _, c := range cases {
+		m := newTestMixer(1)
+		v := m.NewVoice(rampSound(frames), nil)
+		v.SetPitch(c.pitch)
+		v.Play()
+		out := render(m, 2*frames+10)
+		for i, got := range out {
+			want := float32(0)
+			if i < c.end {
+				want = c.pos(i) / 128
+			}
+			if !near(got, want) {
+				t.Fatalf("pitch %v: frame %d = %v, want %v", c.pitch, i, got, want)
+			}
+		}
+		if v.Playing() || m.Voices() != 0 {
+			t.Errorf("pitch %v: still playing after the end", c.pitch)
+		}
+	}
+}
+
+// TestResample Sound 的采样率与输出不同时按比例播放
+func TestResample(t *testing.T) {
+	m := newTestMixer(1)
+	snd := rampSound(100)
+	snd.SampleRate = testRate / 2
+	if snd.Duration() != 200*time.Millisecond {
+		t.Errorf("Duration = %v, want 200ms", snd.Duration())
+	}
+	m.Play(snd, nil)
+	out := render(m, 200)
+	for i := 0; i < 198; i++ {
+		if want := float32(i) / 2 / 128; !near(out[i], want) {
+			t.Fatalf("frame %d = %v, want %v", i, out[i], want)
+		}
+	}
+}
+
+func TestLoopRange(t *testing.T) {
+	m := newTestMixer(1)
+	v := m.NewVoice(rampSound(100), nil)
+	v.SetLoop(true)
+	v.SetLoopRange(20*time.Millisecond, 50*time.Millisecond)
+	v.Play()
+	out := render(m, 500)
+	// 第一次从开头播放，之后在 [20, 50) 中循环
+	for i, got := range out {
+		pos := i
+		if i >= 50 {
+			pos = 20 + (i-50)%30
+		}
+		if want := float32(pos) / 128; !near(got, want) {
+			t.Fatalf("frame %d = %v, want %v", i, got, want)
+		}
+	}
+	if !v.Playing() {
+		t.Error("looping voice stopped")
+	}
+
+	// 取消循环后播放到结尾停止
+	v.SetLoop(false)
+	render(m, 100)
+	if v.Playing() {
+		t.Error("voice still playing after SetLoop(false)")
+	}
+}
+
+func TestLoopWhole(t *testing.T) {
+	m := newTestMixer(1)
+	v := m.NewVoice(rampSound(10), nil)
+	v.SetLoop(true)
+	v.SetPitch(3)
+	v.Play()
+	out := render(m, 30)
+	for i := 0; i < len(out); i++ {
+		// 最后一帧之后插值回到开头
+		pos := float32(3 * i % 10)
+		if want := pos / 128; !near(out[i], want) {
+			t.Fatalf("frame %d = %v, want %v", i, out[i], want)
+		}
+	}
+}
+
+func TestFadeIn(t *testing.T) {
+	m := newTestMixer(1)
+	v := m.NewVoice(constSound(1, 1000), nil)
+	v.FadeIn(100 * time.Millisecond)
+	v.Play()
+	out := render(m, 200)
+	for i := 0; i < 100; i++ {
+		if want := float32(i) / 100; !near(out[i], want) {
+			t.Fatalf("frame %d = %v, want %v", i, out[i], want)
+		}
+	}
+	for i := 100; i < 200; i++ {
+		if !near(out[i], 1) {
+			t.Fatalf("frame %d = %v, want 1", i, out[i])
+		}
+	}
+}
+
+func TestFadeOut(t *testing.T) {
+	m := newTestMixer(1)
+	v := m.Play(constSound(1, 10000), nil)
+	render(m, 100)
+	v.FadeOut(50 * time.Millisecond)
+	if !v.Playing() {
+		t.Fatal("voice stopped before the fade")
+	}
+	out := render(m, 100)
+	for i := 0; i < 45; i++ {
+		if want := 1 - float32(i)/50; !near(out[i], want) {
+			t.Fatalf("frame %d = %v, want %v", i, out[i], want)
+		}
+	}
+	for i := 52; i < len(out); i++ {
+		if out[i] != 0 {
+			t.Fatalf("frame %d = %v after the fade", i, out[i])
+		}
+	}
+	if v.Playing() || m.Voices() != 0 {
+		t.Error("voice still playing after FadeOut")
+	}
+	if v.Position() != 0 {
+		t.Errorf("Position = %v after FadeOut, want 0", v.Position())
+	}
+
+	// 没有在播放时立即停止
+	v = m.NewVoice(constSound(1, 100), nil)
+	v.FadeOut(time.Second)
+	if v.Playing() {
+		t.Error("FadeOut started an idle voice")
+	}
+}
+
+func TestPauseSeek(t *testing.T) {
+	m := newTestMixer(1)
+	v := m.Play(rampSound(200), nil)
+	render(m, 30)
+	v.Pause()
+	if out := render(m, 10); out[0] != 0 || m.Voices() != 0 {
+		t.Fatal("paused voice rendered")
+	}
+	if v.Position() != 30*time.Millisecond {
+		t.Errorf("Position = %v, want 30ms", v.Position())
+	}
+	v.Play()
+	if out := render(m, 1); out[0] != 30./128 {
+		t.Errorf("resumed at %v, want %v", out[0], 30./128)
+	}
+	v.Seek(100 * time.Millisecond)
+	if out := render(m, 1); out[0] != 100./128 {
+		t.Errorf("after Seek = %v, want %v", out[0], 100./128)
+	}
+	v.Stop()
+	if v.Playing() || v.Position() != 0 {
+		t.Error("Stop did not rewind")
+	}
+}
+
+func TestBus(t *testing.T) {
+	m := newTestMixer(1)
+	sfx := m.NewBus("sfx", nil)
+	ui := m.NewBus("ui", sfx)
+	if m.Bus("ui") != ui || m.Bus("master") != m.Master() || m.Bus("none") != nil {
+		t.Fatal("Bus lookup")
+	}
+	if ui.Parent() != sfx || sfx.Parent() != m.Master() || m.Master().Parent() != nil {
+		t.Fatal("Parent")
+	}
+	m.Play(constSound(0.5, 10000), ui)
+	m.Play(constSound(0.25, 10000), nil)
+
+	check := func(step string, from, to float32) {
+		t.Helper()
+		out := render(m, 2*maxBlock)
+		for i := 0; i < maxBlock; i++ {
+			if want := from + (to-from)*float32(i)/maxBlock; !near(out[i], want) {
+				t.Fatalf("%s: frame %d = %v, want %v", step, i, out[i], want)
+			}
+		}
+		for i := maxBlock; i < len(out); i++ {
+			if !near(out[i], to) {
+				t.Fatalf("%s: frame %d = %v, want %v", step, i, out[i], to)
+			}
+		}
+	}
+	check("start", 0.75, 0.75)
+	sfx.SetGain(0.5)
+	check("sfx gain", 0.75, 0.5)
+	ui.SetMuted(true)
+	check("ui muted", 0.5, 0.25)
+	if !ui.Muted() || ui.Gain() != 1 {
+		t.Errorf("Muted = %v, Gain = %v", ui.Muted(), ui.Gain())
+	}
+	// 静音时改变音量，取消静音后生效
+	ui.SetGain(2)
+	check("gain while muted", 0.25, 0.25)
+	ui.SetMuted(false)
+	check("ui unmuted", 0.25, 0.75)
+	m.Master().SetMuted(true)
+	check("master muted", 0.75, 0)
+}
+
+func TestLimiter(t *testing.T) {
+	m := New(testRate, 2)
+	m.SetLimiter(0.5, 10*time.Millisecond)
+	loud := m.Play(constSound(1, 10000), nil)
+	loud.SetGain(2)
+	out := render(m, 500)
+	for i, v := range out {
+		if abs(v) > 0.5+1e-6 {
+			t.Fatalf("sample %d = %v above the ceiling", i, v)
+		}
+	}
+	if !near(out[len(out)-1], 0.5) {
+		t.Errorf("limited output = %v, want 0.5", out[len(out)-1])
+	}
+
+	// 之后的小信号在 release 内恢复原来的音量
+	loud.Stop()
+	m.Play(constSound(0.1, 10000), nil)
+	out = render(m, 200)
+	if out[0] >= 0.1 {
+		t.Errorf("gain recovered immediately: %v", out[0])
+	}
+	if last := out[len(out)-1]; !near(last, 0.1) {
+		t.Errorf("after release = %v, want 0.1", last)
+	}
+	for i := 2; i < len(out); i += 2 {
+		if out[i] < out[i-2] {
+			t.Fatalf("gain decreased while releasing at frame %d", i/2)
+		}
+	}
+}
+
+func TestMaxVoices(t *testing.T) {
+	m := newTestMixer(1)
+	m.SetMaxVoices(2)
+	a := m.Play(constSound(0.125, 1000), nil)
+	b := m.Play(constSound(0.25, 1000), nil)
+	c := m.Play(constSound(0.5, 1000), nil)
+	if m.Voices() != 2 {
+		t.Fatalf("Voices = %d, want 2", m.Voices())
+	}
+	if a.Playing() || !b.Playing() || !c.Playing() {
+		t.Fatalf("playing = %v %v %v, want the oldest evicted", a.Playing(), b.Playing(), c.Playing())
+	}
+	if out := render(m, 1); !near(out[0], 0.75) {
+		t.Errorf("output = %v, want 0.75", out[0])
+	}
+
+	m.SetMaxVoices(0)
+	a.Play()
+	if m.Voices() != 3 {
+		t.Errorf("Voices = %d without limit, want 3", m.Voices())
+	}
+	m.StopAll()
+	if m.Voices() != 0 || a.Playing() || b.Playing() || c.Playing() {
+		t.Error("StopAll left voices playing")
+	}
+}
+
+func TestRenderPartialFrame(t *testing.T) {
+	m := newTestMixer(2)
+	m.Play(constSound(0.5, 100), nil)
+	out := render(m, 1)
+	out = append(out, 42)
+	m.Render(out)
+	if out[2] != 0 {
+		t.Errorf("trailing partial frame = %v, want 0", out[2])
+	}
+}
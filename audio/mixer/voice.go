@@ -0,0 +1,282 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mixer
+
+import (
+	"math"
+	"time"
+)
+
+// Voice 一个正在播放 (或可以播放) 的 Sound
+type Voice struct {
+	m   *Mixer
+	snd *Sound
+	bus *Bus
+
+	playing bool
+	pos     float64 // 在 snd 中的位置，单位为帧
+
+	gain  float32
+	pan   float32
+	pitch float32
+
+	loop               bool
+	loopStart, loopEnd int // 帧，loopEnd 为 0 表示到结尾
+
+	fade          float32 // 淡入淡出的包络
+	fadeStep      float32 // 每帧的变化
+	fadeEnd       float32
+	stopAfterFade bool
+
+	left, right ramp // 实际使用的左右声道增益
+}
+
+// Play 开始或继续播放
+func (v *Voice) Play() {
+	v.m.mu.Lock()
+	defer v.m.mu.Unlock()
+	if v.playing {
+		return
+	}
+	if n := v.m.maxVoices; n > 0 && len(v.m.voices) >= n {
+		// 停止最早开始的 voice
+		v.m.voices[0].reset()
+		v.m.voices = append(v.m.voices[:0], v.m.voices[1:]...)
+	}
+	v.playing = true
+	v.left.cur, v.right.cur = v.gains()
+	v.m.voices = append(v.m.voices, v)
+}
+
+// Pause 暂停，Play 从当前位置继续
+func (v *Voice) Pause() {
+	v.m.mu.Lock()
+	defer v.m.mu.Unlock()
+	v.remove()
+	v.playing = false
+}
+
+// Stop 停止并回到开头
+func (v *Voice) Stop() {
+	v.m.mu.Lock()
+	defer v.m.mu.Unlock()
+	v.remove()
+	v.reset()
+}
+
+// Playing 是否在播放，播放结束或淡出完成后为 false
+func (v *Voice) Playing() bool {
+	v.m.mu.Lock()
+	defer v.m.mu.Unlock()
+	return v.playing
+}
+
+// SetGain 设置音量，1 为原始音量
+func (v *Voice) SetGain(gain float32) {
+	v.m.mu.Lock()
+	v.gain = gain
+	v.m.mu.Unlock()
+}
+
+// SetPan 设置声像，-1 为左，0 为中间，1 为右。
+// 单声道的 Sound 按比例分到两个声道，立体声的 Sound 减弱另一侧
+func (v *Voice) SetPan(pan float32) {
+	if pan < -1 {
+		pan = -1
+	} else if pan > 1 {
+		pan = 1
+	}
+	v.m.mu.Lock()
+	v.pan = pan
+	v.m.mu.Unlock()
+}
+
+// SetPitch 设置播放速度，2 高一个八度，0.5 低一个八度
+func (v *Voice) SetPitch(pitch float32) {
+	if pitch <= 0 {
+		pitch = 1
+	}
+	v.m.mu.Lock()
+	v.pitch = pitch
+	v.m.mu.Unlock()
+}
+
+// SetLoop 循环播放整个 Sound，或 SetLoopRange 设定的区间
+func (v *Voice) SetLoop(loop bool) {
+	v.m.mu.Lock()
+	v.loop = loop
+	v.m.mu.Unlock()
+}
+
+// SetLoopRange 设置循环区间，end 为 0 表示到结尾。第一次播放仍从开头开始
+func (v *Voice) SetLoopRange(start, end time.Duration) {
+	v.m.mu.Lock()
+	defer v.m.mu.Unlock()
+	v.loopStart = v.frameAt(start)
+	v.loopEnd = v.frameAt(end)
+}
+
+// Position 当前的播放位置
+func (v *Voice) Position() time.Duration {
+	v.m.mu.Lock()
+	defer v.m.mu.Unlock()
+	return time.Duration(v.pos * float64(time.Second) / float64(v.snd.SampleRate))
+}
+
+// Seek 跳到 pos
+func (v *Voice) Seek(pos time.Duration) {
+	v.m.mu.Lock()
+	defer v.m.mu.Unlock()
+	v.pos = float64(v.frameAt(pos))
+}
+
+// FadeIn 音量在 d 内从 0 升到 1，应在 Play 之前调用
+func (v *Voice) FadeIn(d time.Duration) {
+	v.m.mu.Lock()
+	defer v.m.mu.Unlock()
+	v.fadeTo(0, 1, d)
+	v.stopAfterFade = false
+}
+
+// FadeOut 音量在 d 内降到 0，然后停止
+func (v *Voice) FadeOut(d time.Duration) {
+	v.m.mu.Lock()
+	defer v.m.mu.Unlock()
+	if !v.playing || d <= 0 {
+		v.remove()
+		v.reset()
+		return
+	}
+	v.fadeTo(v.fade, 0, d)
+	v.stopAfterFade = true
+}
+
+func (v *Voice) fadeTo(from, to float32, d time.Duration) {
+	frames := float32(d.Seconds() * float64(v.m.sampleRate))
+	if frames < 1 {
+		v.fade, v.fadeStep = to, 0
+		return
+	}
+	v.fade, v.fadeEnd = from, to
+	v.fadeStep = (to - from) / frames
+}
+
+func (v *Voice) frameAt(d time.Duration) int {
+	n := int(d.Seconds() * float64(v.snd.SampleRate))
+	if n < 0 {
+		return 0
+	}
+	if frames := v.snd.Frames(); n > frames {
+		return frames
+	}
+	return n
+}
+
+// remove 从播放列表中移除，须持有 m.mu
+func (v *Voice) remove() {
+	for i, o := range v.m.voices {
+		if o == v {
+			v.m.voices = append(v.m.voices[:i], v.m.voices[i+1:]...)
+			return
+		}
+	}
+}
+
+// reset 停止后回到开头，须持有 m.mu
+func (v *Voice) reset() {
+	v.playing = false
+	v.pos = 0
+	v.fade, v.fadeStep = 1, 0
+	v.stopAfterFade = false
+}
+
+// gains 由 gain 和 pan 得到左右声道的增益
+func (v *Voice) gains() (float32, float32) {
+	if v.m.channels == 1 {
+		// 单声道输出取左右平均，忽略 pan
+		return v.gain / 2, v.gain / 2
+	}
+	l, r := float32(1), float32(1)
+	if v.pan > 0 {
+		l = 1 - v.pan
+	} else {
+		r = 1 + v.pan
+	}
+	return v.gain * l, v.gain * r
+}
+
+// render 把 frames 帧叠加到 dst，播放结束时返回 false
+func (v *Voice) render(dst []float32, frames int) bool {
+	snd := v.snd
+	sc := snd.Channels
+	end := snd.Frames()
+	if end == 0 {
+		return false
+	}
+	start := 0
+	if v.loop {
+		if v.loopEnd > v.loopStart {
+			end = v.loopEnd
+		}
+		start = v.loopStart
+		if start >= end {
+			start = 0
+		}
+	}
+
+	v.left.target, v.right.target = v.gains()
+	l, dl := v.left.cur, v.left.step(frames)
+	r, dr := v.right.cur, v.right.step(frames)
+	rate := float64(v.pitch) * float64(snd.SampleRate) / float64(v.m.sampleRate)
+	ch := v.m.channels
+
+	for i := 0; i < frames; i++ {
+		if v.pos >= float64(end) {
+			if !v.loop {
+				return false
+			}
+			v.pos = float64(start) + math.Mod(v.pos-float64(end), float64(end-start))
+		}
+		// 线性插值
+		idx := int(v.pos)
+		next := idx + 1
+		if next >= end {
+			next = idx
+			if v.loop {
+				next = start
+			}
+		}
+		frac := float32(v.pos - float64(idx))
+		a, b := snd.Data[idx*sc:], snd.Data[next*sc:]
+		sl := a[0] + (b[0]-a[0])*frac
+		sr := sl
+		if sc > 1 {
+			sr = a[1] + (b[1]-a[1])*frac
+		}
+
+		f := v.fade
+		if ch == 1 {
+			dst[i] += (sl*l + sr*r) * f
+		} else {
+			dst[2*i] += sl * l * f
+			dst[2*i+1] += sr * r * f
+		}
+		l += dl
+		r += dr
+		v.pos += rate
+
+		if v.fadeStep != 0 {
+			v.fade += v.fadeStep
+			if (v.fadeStep > 0 && v.fade >= v.fadeEnd) || (v.fadeStep < 0 && v.fade <= v.fadeEnd) {
+				v.fade, v.fadeStep = v.fadeEnd, 0
+				if v.stopAfterFade {
+					return false
+				}
+			}
+		}
+	}
+	v.left.cur, v.right.cur = v.left.target, v.right.target
+	return true
+}
@@ -0,0 +1,349 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package audio
+
+/*
+#cgo LDFLAGS: -lOpenSLES
+
+#include <stdlib.h>
+#include <string.h>
+#include <SLES/OpenSLES.h>
+#include <SLES/OpenSLES_Android.h>
+
+typedef struct {
+	SLObjectItf obj;
+	SLPlayItf play;
+	SLRecordItf record;
+	SLAndroidSimpleBufferQueueItf queue;
+	int input;
+	int sampleRate;
+	int channels;
+	int frames;
+	int current;
+	int16_t* buffers[2];
+} slStream;
+
+extern int cgoSLProcess(slStream* s, int16_t* buf, int frames);
+
+static SLObjectItf slEngineObj;
+static SLEngineItf slEngine;
+static SLObjectItf slMixObj;
+
+static SLresult slInit() {
+	SLresult r = slCreateEngine(&slEngineObj, 0, NULL, 0, NULL, NULL);
+	if (r != SL_RESULT_SUCCESS) return r;
+	r = (*slEngineObj)->Realize(slEngineObj, SL_BOOLEAN_FALSE);
+	if (r != SL_RESULT_SUCCESS) return r;
+	r = (*slEngineObj)->GetInterface(slEngineObj, SL_IID_ENGINE, &slEngine);
+	if (r != SL_RESULT_SUCCESS) return r;
+	r = (*slEngine)->CreateOutputMix(slEngine, &slMixObj, 0, NULL, NULL);
+	if (r != SL_RESULT_SUCCESS) return r;
+	return (*slMixObj)->Realize(slMixObj, SL_BOOLEAN_FALSE);
+}
+
+// 播放时填充刚播完的 buffer，录音时处理刚录满的 buffer，然后重新入队
+static void slCallback(SLAndroidSimpleBufferQueueItf queue, void* context) {
+	slStream* s = (slStream*)context;
+	int16_t* buf = s->buffers[s->current];
+	if (cgoSLProcess(s, buf, s->frames)) {
+		(*queue)->Enqueue(queue, buf, s->frames * s->channels * sizeof(int16_t));
+		s->current ^= 1;
+	}
+}
+
+static SLresult slOpen(slStream* s) {
+	SLresult r;
+	SLDataLocator_AndroidSimpleBufferQueue locQueue = {SL_DATALOCATOR_ANDROIDSIMPLEBUFFERQUEUE, 2};
+	SLDataFormat_PCM pcm = {
+		SL_DATAFORMAT_PCM, s->channels, s->sampleRate * 1000,
+		SL_PCMSAMPLEFORMAT_FIXED_16, SL_PCMSAMPLEFORMAT_FIXED_16,
+		s->channels == 1 ? SL_SPEAKER_FRONT_CENTER : SL_SPEAKER_FRONT_LEFT | SL_SPEAKER_FRONT_RIGHT,
+		SL_BYTEORDER_LITTLEENDIAN,
+	};
+	const SLInterfaceID ids[] = {SL_IID_ANDROIDSIMPLEBUFFERQUEUE};
+	const SLboolean req[] = {SL_BOOLEAN_TRUE};
+
+	if (s->input) {
+		SLDataLocator_IODevice locDevice = {SL_DATALOCATOR_IODEVICE, SL_IODEVICE_AUDIOINPUT,
+			SL_DEFAULTDEVICEID_AUDIOINPUT, NULL};
+		SLDataSource src = {&locDevice, NULL};
+		SLDataSink sink = {&locQueue, &pcm};
+		r = (*slEngine)->CreateAudioRecorder(slEngine, &s->obj, &src, &sink, 1, ids, req);
+		if (r != SL_RESULT_SUCCESS) return r;
+		r = (*s->obj)->Realize(s->obj, SL_BOOLEAN_FALSE);
+		if (r != SL_RESULT_SUCCESS) return r;
+		r = (*s->obj)->GetInterface(s->obj, SL_IID_RECORD, &s->record);
+	} else {
+		SLDataLocator_OutputMix locMix = {SL_DATALOCATOR_OUTPUTMIX, slMixObj};
+		SLDataSource src = {&locQueue, &pcm};
+		SLDataSink sink = {&locMix, NULL};
+		r = (*slEngine)->CreateAudioPlayer(slEngine, &s->obj, &src, &sink, 1, ids, req);
+		if (r != SL_RESULT_SUCCESS) return r;
+		r = (*s->obj)->Realize(s->obj, SL_BOOLEAN_FALSE);
+		if (r != SL_RESULT_SUCCESS) return r;
+		r = (*s->obj)->GetInterface(s->obj, SL_IID_PLAY, &s->play);
+	}
+	if (r != SL_RESULT_SUCCESS) return r;
+	r = (*s->obj)->GetInterface(s->obj, SL_IID_ANDROIDSIMPLEBUFFERQUEUE, &s->queue);
+	if (r != SL_RESULT_SUCCESS) return r;
+	r = (*s->queue)->RegisterCallback(s->queue, slCallback, s);
+	if (r != SL_RESULT_SUCCESS) return r;
+
+	size_t size = s->frames * s->channels * sizeof(int16_t);
+	s->buffers[0] = calloc(1, size);
+	s->buffers[1] = calloc(1, size);
+	if (s->buffers[0] == NULL || s->buffers[1] == NULL) return SL_RESULT_MEMORY_FAILURE;
+	return SL_RESULT_SUCCESS;
+}
+
+// slStart 播放时先放入两个静音 buffer，录音时放入两个空 buffer
+static SLresult slStart(slStream* s) {
+	size_t size = s->frames * s->channels * sizeof(int16_t);
+	SLresult r = (*s->queue)->Clear(s->queue);
+	if (r != SL_RESULT_SUCCESS) return r;
+	s->current = 0;
+	for (int i = 0; i < 2; i++) {
+		memset(s->buffers[i], 0, size);
+		r = (*s->queue)->Enqueue(s->queue, s->buffers[i], size);
+		if (r != SL_RESULT_SUCCESS) return r;
+	}
+	if (s->input) {
+		return (*s->record)->SetRecordState(s->record, SL_RECORDSTATE_RECORDING);
+	}
+	return (*s->play)->SetPlayState(s->play, SL_PLAYSTATE_PLAYING);
+}
+
+static SLresult slStop(slStream* s) {
+	SLresult r;
+	if (s->input) {
+		r = (*s->record)->SetRecordState(s->record, SL_RECORDSTATE_STOPPED);
+	} else {
+		r = (*s->play)->SetPlayState(s->play, SL_PLAYSTATE_STOPPED);
+	}
+	if (r != SL_RESULT_SUCCESS) return r;
+	return (*s->queue)->Clear(s->queue);
+}
+
+static void slClose(slStream* s) {
+	if (s->obj != NULL) {
+		(*s->obj)->Destroy(s->obj);
+		s->obj = NULL;
+	}
+	free(s->buffers[0]);
+	free(s->buffers[1]);
+	s->buffers[0] = s->buffers[1] = NULL;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// SLResult OpenSL ES 的 SLresult
+type SLResult uint32
+
+const (
+	SL_RESULT_PRECONDITIONS_VIOLATED SLResult = C.SL_RESULT_PRECONDITIONS_VIOLATED
+	SL_RESULT_PARAMETER_INVALID      SLResult = C.SL_RESULT_PARAMETER_INVALID
+	SL_RESULT_MEMORY_FAILURE         SLResult = C.SL_RESULT_MEMORY_FAILURE
+	SL_RESULT_RESOURCE_ERROR         SLResult = C.SL_RESULT_RESOURCE_ERROR
+	SL_RESULT_RESOURCE_LOST          SLResult = C.SL_RESULT_RESOURCE_LOST
+	SL_RESULT_IO_ERROR               SLResult = C.SL_RESULT_IO_ERROR
+	SL_RESULT_BUFFER_INSUFFICIENT    SLResult = C.SL_RESULT_BUFFER_INSUFFICIENT
+	SL_RESULT_CONTENT_CORRUPTED      SLResult = C.SL_RESULT_CONTENT_CORRUPTED
+	SL_RESULT_CONTENT_UNSUPPORTED    SLResult = C.SL_RESULT_CONTENT_UNSUPPORTED
+	SL_RESULT_CONTENT_NOT_FOUND      SLResult = C.SL_RESULT_CONTENT_NOT_FOUND
+	SL_RESULT_PERMISSION_DENIED      SLResult = C.SL_RESULT_PERMISSION_DENIED
+	SL_RESULT_FEATURE_UNSUPPORTED    SLResult = C.SL_RESULT_FEATURE_UNSUPPORTED
+	SL_RESULT_INTERNAL_ERROR         SLResult = C.SL_RESULT_INTERNAL_ERROR
+	SL_RESULT_UNKNOWN_ERROR          SLResult = C.SL_RESULT_UNKNOWN_ERROR
+	SL_RESULT_OPERATION_ABORTED      SLResult = C.SL_RESULT_OPERATION_ABORTED
+	SL_RESULT_CONTROL_LOST           SLResult = C.SL_RESULT_CONTROL_LOST
+)
+
+func (r SLResult) Error() string {
+	switch r {
+	case SL_RESULT_PRECONDITIONS_VIOLATED:
+		return "audio: SL_RESULT_PRECONDITIONS_VIOLATED"
+	case SL_RESULT_PARAMETER_INVALID:
+		return "audio: SL_RESULT_PARAMETER_INVALID"
+	case SL_RESULT_MEMORY_FAILURE:
+		return "audio: SL_RESULT_MEMORY_FAILURE"
+	case SL_RESULT_RESOURCE_ERROR:
+		return "audio: SL_RESULT_RESOURCE_ERROR"
+	case SL_RESULT_RESOURCE_LOST:
+		return "audio: SL_RESULT_RESOURCE_LOST"
+	case SL_RESULT_IO_ERROR:
+		return "audio: SL_RESULT_IO_ERROR"
+	case SL_RESULT_BUFFER_INSUFFICIENT:
+		return "audio: SL_RESULT_BUFFER_INSUFFICIENT"
+	case SL_RESULT_CONTENT_CORRUPTED:
+		return "audio: SL_RESULT_CONTENT_CORRUPTED"
+	case SL_RESULT_CONTENT_UNSUPPORTED:
+		return "audio: SL_RESULT_CONTENT_UNSUPPORTED"
+	case SL_RESULT_CONTENT_NOT_FOUND:
+		return "audio: SL_RESULT_CONTENT_NOT_FOUND"
+	case SL_RESULT_PERMISSION_DENIED:
+		return "audio: SL_RESULT_PERMISSION_DENIED"
+	case SL_RESULT_FEATURE_UNSUPPORTED:
+		return "audio: SL_RESULT_FEATURE_UNSUPPORTED"
+	case SL_RESULT_INTERNAL_ERROR:
+		return "audio: SL_RESULT_INTERNAL_ERROR"
+	case SL_RESULT_UNKNOWN_ERROR:
+		return "audio: SL_RESULT_UNKNOWN_ERROR"
+	case SL_RESULT_OPERATION_ABORTED:
+		return "audio: SL_RESULT_OPERATION_ABORTED"
+	case SL_RESULT_CONTROL_LOST:
+		return "audio: SL_RESULT_CONTROL_LOST"
+	}
+	return fmt.Sprintf("audio: SL_RESULT_%d", uint32(r))
+}
+
+func slStatus(r C.SLresult) error {
+	if r != C.SL_RESULT_SUCCESS {
+		return SLResult(r)
+	}
+	return nil
+}
+
+const (
+	slDefaultSampleRate = 48000
+	slBufferMillis      = 10
+)
+
+var (
+	slOnce sync.Once
+	slErr  error
+)
+
+// slesStream OpenSL ES 的 buffer queue 流，只支持 int16
+type slesStream struct {
+	s *C.slStream
+
+	mu       sync.Mutex
+	curState State
+}
+
+var (
+	slLock sync.RWMutex
+	slMap  = map[unsafe.Pointer]*Stream{}
+)
+
+func openSLES(s *Stream) (backend, error) {
+	// 所有流共用一个 engine 和 output mix
+	slOnce.Do(func() {
+		slErr = slStatus(C.slInit())
+	})
+	if slErr != nil {
+		return nil, slErr
+	}
+
+	cfg := &s.cfg
+	if cfg.SampleRate <= 0 {
+		cfg.SampleRate = slDefaultSampleRate
+	}
+	if cfg.ChannelCount <= 0 {
+		cfg.ChannelCount = 2
+		if cfg.Direction == DIRECTION_INPUT {
+			cfg.ChannelCount = 1
+		}
+	}
+	if cfg.ChannelCount > 2 {
+		return nil, SL_RESULT_CONTENT_UNSUPPORTED
+	}
+	if cfg.FramesPerCallback <= 0 {
+		cfg.FramesPerCallback = cfg.SampleRate * slBufferMillis / 1000
+	}
+	cfg.PerformanceMode = PERFORMANCE_MODE_NONE
+	cfg.SharingMode = SHARING_MODE_SHARED
+	s.native = FORMAT_I16
+
+	sl := (*C.slStream)(C.calloc(1, C.size_t(unsafe.Sizeof(C.slStream{}))))
+	if cfg.Direction == DIRECTION_INPUT {
+		sl.input = 1
+	}
+	sl.sampleRate = C.int(cfg.SampleRate)
+	sl.channels = C.int(cfg.ChannelCount)
+	sl.frames = C.int(cfg.FramesPerCallback)
+
+	slLock.Lock()
+	slMap[unsafe.Pointer(sl)] = s
+	slLock.Unlock()
+
+	st := &slesStream{s: sl, curState: STATE_OPEN}
+	if err := slStatus(C.slOpen(sl)); err != nil {
+		st.close()
+		return nil, err
+	}
+	return st, nil
+}
+
+func (st *slesStream) setState(state State) {
+	st.mu.Lock()
+	st.curState = state
+	st.mu.Unlock()
+}
+
+func (st *slesStream) start() error {
+	if err := slStatus(C.slStart(st.s)); err != nil {
+		return err
+	}
+	st.setState(STATE_STARTED)
+	return nil
+}
+
+func (st *slesStream) stop() error {
+	if err := slStatus(C.slStop(st.s)); err != nil {
+		return err
+	}
+	st.setState(STATE_STOPPED)
+	return nil
+}
+
+func (st *slesStream) close() error {
+	C.slClose(st.s)
+	slLock.Lock()
+	delete(slMap, unsafe.Pointer(st.s))
+	slLock.Unlock()
+	C.free(unsafe.Pointer(st.s))
+	st.setState(STATE_CLOSED)
+	return nil
+}
+
+func (st *slesStream) state() State {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.curState
+}
+
+func (st *slesStream) framesPerBurst() int {
+	return int(st.s.frames)
+}
+
+func (st *slesStream) xRunCount() int {
+	return 0
+}
+
+//export cgoSLProcess
+func cgoSLProcess(sl *C.slStream, buf *C.int16_t, frames C.int) C.int {
+	slLock.RLock()
+	s := slMap[unsafe.Pointer(sl)]
+	slLock.RUnlock()
+	if s == nil {
+		return 0
+	}
+	if !s.process(unsafe.Pointer(buf), int(frames)) {
+		// 不能在 buffer queue 的回调中停止
+		if st, ok := s.impl.(*slesStream); ok {
+			st.setState(STATE_STOPPING)
+		}
+		go s.Stop()
+		return 0
+	}
+	return 1
+}
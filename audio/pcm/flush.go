@@ -0,0 +1,132 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pcm
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// DefaultFlushInterval 写入时更新文件头或 sidecar 的默认间隔
+const DefaultFlushInterval = time.Second
+
+var (
+	// ErrClosed 已关闭
+	ErrClosed = errors.New("pcm: writer already closed")
+
+	// ErrTooLarge 超过文件格式的大小限制 (WAV、AIFF 为 4GB)
+	ErrTooLarge = errors.New("pcm: file exceeds format size limit")
+)
+
+// FlushOptions FlushWriter 的参数
+type FlushOptions struct {
+	// File 不为 nil 时 Flush 之后 Sync，Close 时关闭
+	File *os.File
+
+	// MaxSize 数据量的上限，0 表示不限
+	MaxSize int64
+
+	// Align Close 时把奇数长度的数据补齐到偶数 (RIFF、IFF 的块)
+	Align bool
+
+	// Update 把数据量写回文件头或 sidecar，返回时写入位置应在数据末尾
+	Update func(size int64) error
+}
+
+// FlushWriter wav、aiff 和 raw PCM Writer 共用的部分：记录写入的数据量，
+// 每隔 FlushInterval 写回文件头或 sidecar，
+// 进程被杀掉时最多丢失最后一个间隔的数据
+type FlushWriter struct {
+	// FlushInterval 写入时更新的间隔，0 表示只在 Flush/Close 时更新
+	FlushInterval time.Duration
+
+	w         io.Writer
+	opt       FlushOptions
+	size      int64
+	lastFlush time.Time
+	closed    bool
+}
+
+// NewFlushWriter 把数据写到 w
+func NewFlushWriter(w io.Writer, opt FlushOptions) *FlushWriter {
+	return &FlushWriter{
+		FlushInterval: DefaultFlushInterval,
+		w:             w,
+		opt:           opt,
+		lastFlush:     time.Now(),
+	}
+}
+
+// CreateFile 创建文件 path 后调用 open，失败时关闭并删除文件
+func CreateFile(path string, open func(f *os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = open(f); err != nil {
+		f.Close()
+		os.Remove(path)
+	}
+	return err
+}
+
+// Size 已写入的数据量，不含 Close 补齐的字节
+func (w *FlushWriter) Size() int64 {
+	return w.size
+}
+
+// Write 写入已按格式编码的数据，超过 MaxSize 时返回 ErrTooLarge
+func (w *FlushWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, ErrClosed
+	}
+	if w.opt.MaxSize > 0 && w.size+int64(len(p)) > w.opt.MaxSize {
+		return 0, ErrTooLarge
+	}
+	n, err := w.w.Write(p)
+	w.size += int64(n)
+	if err == nil && w.FlushInterval > 0 && time.Since(w.lastFlush) >= w.FlushInterval {
+		err = w.Flush()
+	}
+	return n, err
+}
+
+// Flush 把当前数据量写回文件头或 sidecar，Create 打开的文件同时 Sync
+func (w *FlushWriter) Flush() error {
+	if w.closed {
+		return ErrClosed
+	}
+	w.lastFlush = time.Now()
+	if err := w.opt.Update(w.size); err != nil {
+		return err
+	}
+	if w.opt.File != nil {
+		return w.opt.File.Sync()
+	}
+	return nil
+}
+
+// Close 按需补齐数据并 Flush，Create 打开的文件被关闭
+func (w *FlushWriter) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	var err error
+	if w.opt.Align && w.size&1 != 0 {
+		_, err = w.w.Write([]byte{0})
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	w.closed = true
+	if w.opt.File != nil {
+		if cerr := w.opt.File.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pcm
+
+import "io"
+
+// FrameReader 读取 r 中从 offset 开始的 frames 帧交错存放的样本，
+// 每帧 align 字节 (可以大于 FrameSize，多出的字节被跳过)。
+// 方法与 decoder.Decoder 相同，WAV 解码器和 Reader 共用
+type FrameReader struct {
+	r      io.ReadSeeker
+	format Format
+	order  ByteOrder
+	offset int64
+	align  int
+	frames int64
+	pos    int64
+	buf    []byte
+}
+
+// NewFrameReader 假定 r 已在第 0 帧，否则先调用 SeekFrame(0)
+func NewFrameReader(r io.ReadSeeker, format Format, order ByteOrder, offset int64, align int, frames int64) *FrameReader {
+	return &FrameReader{r: r, format: format, order: order, offset: offset, align: align, frames: frames}
+}
+
+func (r *FrameReader) Format() Format  { return r.format }
+func (r *FrameReader) Position() int64 { return r.pos }
+func (r *FrameReader) Length() int64   { return r.frames }
+
+// SeekFrame 定位到第 frame 帧
+func (r *FrameReader) SeekFrame(frame int64) error {
+	if frame < 0 {
+		frame = 0
+	} else if frame > r.frames {
+		frame = r.frames
+	}
+	if _, err := r.r.Seek(r.offset+frame*int64(r.align), io.SeekStart); err != nil {
+		return err
+	}
+	r.pos = frame
+	return nil
+}
+
+// Read 读取交错存放的 float32 样本，返回样本数。结束时返回 io.EOF
+func (r *FrameReader) Read(p []float32) (int, error) {
+	ch := r.format.Channels
+	if len(p) < ch {
+		return 0, io.ErrShortBuffer
+	}
+	frames := int64(len(p) / ch)
+	if rem := r.frames - r.pos; frames > rem {
+		frames = rem
+	}
+	if frames == 0 {
+		return 0, io.EOF
+	}
+	n := int(frames) * r.align
+	if cap(r.buf) < n {
+		r.buf = make([]byte, n)
+	}
+	k, err := io.ReadFull(r.r, r.buf[:n])
+	frames = int64(k / r.align)
+	if frames == 0 {
+		if err == nil || err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return 0, err
+	}
+	size := r.format.FrameSize()
+	if r.align == size {
+		Decode(p, r.buf[:int(frames)*size], r.format, r.order)
+	} else {
+		for f := 0; f < int(frames); f++ {
+			Decode(p[f*ch:f*ch+ch], r.buf[f*r.align:f*r.align+size], r.format, r.order)
+		}
+	}
+	r.pos += frames
+	return int(frames) * ch, nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package pcm PCM 样本的格式和编码，以及不带文件头、
+// 格式记录在旁边 JSON 文件中的 raw PCM 文件。
+package pcm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Format 交错存放的 PCM 格式
+type Format struct {
+	SampleRate int
+	Channels   int
+
+	// BitsPerSample 整数为 8、16、24、32，浮点为 32、64
+	BitsPerSample int
+	Float         bool
+}
+
+// Validate 检查格式是否支持
+func (f Format) Validate() error {
+	if f.SampleRate <= 0 || f.Channels <= 0 {
+		return fmt.Errorf("pcm: bad format: %d Hz, %d channels", f.SampleRate, f.Channels)
+	}
+	switch {
+	case f.Float && (f.BitsPerSample == 32 || f.BitsPerSample == 64):
+	case !f.Float && (f.BitsPerSample == 8 || f.BitsPerSample == 16 || f.BitsPerSample == 24 || f.BitsPerSample == 32):
+	default:
+		return fmt.Errorf("pcm: unsupported sample format: %d bits, float %v", f.BitsPerSample, f.Float)
+	}
+	return nil
+}
+
+// BytesPerSample 每个样本的字节数
+func (f Format) BytesPerSample() int {
+	return f.BitsPerSample / 8
+}
+
+// FrameSize 每帧的字节数
+func (f Format) FrameSize() int {
+	return f.Channels * f.BitsPerSample / 8
+}
+
+// Duration frames 帧的时长
+func (f Format) Duration(frames int64) time.Duration {
+	return time.Duration(frames) * time.Second / time.Duration(f.SampleRate)
+}
+
+// Frames 时长 d 对应的帧数
+func (f Format) Frames(d time.Duration) int64 {
+	return int64(d) * int64(f.SampleRate) / int64(time.Second)
+}
+
+// ByteOrder 样本的字节序
+type ByteOrder int
+
+const (
+	LittleEndian ByteOrder = iota // WAV、raw PCM，8 位样本无符号
+	BigEndian                     // AIFF，8 位样本有符号
+)
+
+func (o ByteOrder) String() string {
+	if o == BigEndian {
+		return "big"
+	}
+	return "little"
+}
+
+func (o ByteOrder) binary() binary.ByteOrder {
+	if o == BigEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// quantize 把 [-1, 1] 的样本转换为 bits 位整数，超出范围的截断
+func quantize(v float32, bits int) int64 {
+	scale := float64(int64(1) << uint(bits-1))
+	x := math.Floor(float64(v)*scale + 0.5)
+	if x >= scale {
+		return int64(scale) - 1
+	}
+	if x < -scale {
+		return -int64(scale)
+	}
+	return int64(x)
+}
+
+// Encode 把 src 按 f 和 order 编码后追加到 dst
+func Encode(dst []byte, src []float32, f Format, order ByteOrder) []byte {
+	bo := order.binary()
+	var b [8]byte
+	for _, v := range src {
+		switch {
+		case f.Float && f.BitsPerSample == 64:
+			bo.PutUint64(b[:], math.Float64bits(float64(v)))
+			dst = append(dst, b[:8]...)
+		case f.Float:
+			bo.PutUint32(b[:], math.Float32bits(v))
+			dst = append(dst, b[:4]...)
+		case f.BitsPerSample == 8:
+			x := quantize(v, 8)
+			if order == LittleEndian {
+				x += 128
+			}
+			dst = append(dst, byte(x))
+		case f.BitsPerSample == 16:
+			bo.PutUint16(b[:], uint16(quantize(v, 16)))
+			dst = append(dst, b[:2]...)
+		case f.BitsPerSample == 24:
+			x := uint32(quantize(v, 24))
+			if order == BigEndian {
+				dst = append(dst, byte(x>>16), byte(x>>8), byte(x))
+			} else {
+				dst = append(dst, byte(x), byte(x>>8), byte(x>>16))
+			}
+		default:
+			bo.PutUint32(b[:], uint32(quantize(v, 32)))
+			dst = append(dst, b[:4]...)
+		}
+	}
+	return dst
+}
+
+// Decode 把 src 中完整的样本解码到 dst，返回样本数
+func Decode(dst []float32, src []byte, f Format, order ByteOrder) int {
+	bo := order.binary()
+	size := f.BytesPerSample()
+	n := len(src) / size
+	if n > len(dst) {
+		n = len(dst)
+	}
+	for i := 0; i < n; i++ {
+		s := src[i*size:]
+		switch {
+		case f.Float && size == 8:
+			dst[i] = float32(math.Float64frombits(bo.Uint64(s)))
+		case f.Float:
+			dst[i] = math.Float32frombits(bo.Uint32(s))
+		case size == 1:
+			x := int32(int8(s[0]))
+			if order == LittleEndian {
+				x = int32(s[0]) - 128
+			}
+			dst[i] = float32(x) / (1 << 7)
+		case size == 2:
+			dst[i] = float32(int16(bo.Uint16(s))) / (1 << 15)
+		case size == 3:
+			var x int32
+			if order == BigEndian {
+				x = int32(uint32(s[0])<<24|uint32(s[1])<<16|uint32(s[2])<<8) >> 8
+			} else {
+				x = int32(uint32(s[2])<<24|uint32(s[1])<<16|uint32(s[0])<<8) >> 8
+			}
+			dst[i] = float32(x) / (1 << 23)
+		default:
+			dst[i] = float32(int32(bo.Uint32(s))) / (1 << 31)
+		}
+	}
+	return n
+}
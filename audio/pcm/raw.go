@@ -0,0 +1,158 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pcm
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// SidecarPath raw 文件 path 对应的 sidecar 文件
+func SidecarPath(path string) string {
+	return path + ".json"
+}
+
+// Sidecar raw PCM 文件旁边的 JSON 描述。
+// Frames 只在 Flush 时更新，实际帧数以数据文件长度为准
+type Sidecar struct {
+	SampleRate    int       `json:"sampleRate"`
+	Channels      int       `json:"channels"`
+	BitsPerSample int       `json:"bitsPerSample"`
+	Float         bool      `json:"float"`
+	ByteOrder     string    `json:"byteOrder"`
+	Frames        int64     `json:"frames"`
+	Created       time.Time `json:"created"`
+}
+
+// Format sidecar 记录的格式
+func (s *Sidecar) Format() Format {
+	return Format{SampleRate: s.SampleRate, Channels: s.Channels, BitsPerSample: s.BitsPerSample, Float: s.Float}
+}
+
+// ReadSidecar 读取 raw 文件 path 的 sidecar
+func ReadSidecar(path string) (*Sidecar, error) {
+	b, err := ioutil.ReadFile(SidecarPath(path))
+	if err != nil {
+		return nil, err
+	}
+	s := &Sidecar{}
+	if err = json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	if s.ByteOrder != LittleEndian.String() {
+		return nil, errors.New("pcm: unsupported byte order " + s.ByteOrder)
+	}
+	if err = s.Format().Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// writeSidecar 先写临时文件再改名，被中断时不会留下半个 JSON
+func writeSidecar(path string, s *Sidecar) error {
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp := SidecarPath(path) + ".tmp"
+	if err = ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, SidecarPath(path))
+}
+
+// Writer 写 raw PCM 文件 (小端，无文件头)，格式写在 sidecar 中，
+// 每隔 FlushInterval 更新 sidecar 中的帧数并 Sync。
+// 没有 WAV 的 4GB 限制，进程被杀掉时已写入的数据都可以读出，适合长时间录音
+type Writer struct {
+	*FlushWriter
+
+	path    string
+	sidecar Sidecar
+	buf     []byte
+}
+
+// Create 创建 raw 文件 path 和它的 sidecar
+func Create(path string, format Format) (*Writer, error) {
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+	w := &Writer{
+		path: path,
+		sidecar: Sidecar{
+			SampleRate:    format.SampleRate,
+			Channels:      format.Channels,
+			BitsPerSample: format.BitsPerSample,
+			Float:         format.Float,
+			ByteOrder:     LittleEndian.String(),
+			Created:       time.Now(),
+		},
+	}
+	err := CreateFile(path, func(f *os.File) error {
+		w.FlushWriter = NewFlushWriter(f, FlushOptions{File: f, Update: w.update})
+		return writeSidecar(path, &w.sidecar)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) update(size int64) error {
+	w.sidecar.Frames = size / int64(w.Format().FrameSize())
+	return writeSidecar(w.path, &w.sidecar)
+}
+
+// Format 文件格式
+func (w *Writer) Format() Format {
+	return w.sidecar.Format()
+}
+
+// Frames 已写入的帧数
+func (w *Writer) Frames() int64 {
+	return w.Size() / int64(w.Format().FrameSize())
+}
+
+// WriteSamples 编码并写入交错存放的 float32 样本
+func (w *Writer) WriteSamples(samples []float32) error {
+	w.buf = Encode(w.buf[:0], samples, w.Format(), LittleEndian)
+	_, err := w.Write(w.buf)
+	return err
+}
+
+// Reader 读取 raw PCM 文件，方法与 decoder.Decoder 相同。
+// 帧数按数据文件长度计算，末尾不完整的帧被忽略
+type Reader struct {
+	*FrameReader
+	f *os.File
+}
+
+// Open 按 sidecar 打开 raw 文件 path
+func Open(path string) (*Reader, error) {
+	s, err := ReadSidecar(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	format := s.Format()
+	frames := fi.Size() / int64(format.FrameSize())
+	return &Reader{FrameReader: NewFrameReader(f, format, LittleEndian, 0, format.FrameSize(), frames), f: f}, nil
+}
+
+// Close 关闭文件
+func (r *Reader) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,193 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pcm
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testSignal frames 帧的正弦，channels 声道交错存放
+func testSignal(frames, channels int) []float32 {
+	s := make([]float32, frames*channels)
+	for i := range s {
+		s[i] = float32(0.8 * math.Sin(float64(i)*0.01))
+	}
+	return s
+}
+
+func TestRawRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := Format{SampleRate: 48000, Channels: 2, BitsPerSample: 24}
+	path := filepath.Join(dir, "rec.pcm")
+	w, err := Create(path, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.FlushInterval = 0
+	in := testSignal(300, 2)
+	if err := w.WriteSamples(in[:200]); err != nil {
+		t.Fatal(err)
+	}
+
+	// sidecar 在 Flush 时更新
+	s, err := ReadSidecar(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Format() != f || s.Frames != 0 || s.ByteOrder != "little" || s.Created.IsZero() {
+		t.Errorf("sidecar before Flush: %+v", s)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = ReadSidecar(path); err != nil || s.Frames != 100 {
+		t.Errorf("sidecar after Flush: %+v, %v", s, err)
+	}
+
+	if err := w.WriteSamples(in[200:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteSamples(in); err != ErrClosed {
+		t.Errorf("WriteSamples after Close = %v, want ErrClosed", err)
+	}
+	if s, err = ReadSidecar(path); err != nil || s.Frames != 300 {
+		t.Errorf("sidecar after Close: %+v, %v", s, err)
+	}
+	if _, err := os.Stat(SidecarPath(path) + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary sidecar left behind: %v", err)
+	}
+
+	// 末尾不完整的帧被忽略
+	data, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Write([]byte{1, 2, 3, 4})
+	data.Close()
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if r.Format() != f || r.Length() != 300 {
+		t.Fatalf("Open: %+v, %d frames", r.Format(), r.Length())
+	}
+	const tol = 1.0 / (1 << 23)
+	check := func(got []float32, from int) {
+		t.Helper()
+		for i, v := range got {
+			if math.Abs(float64(v-in[from+i])) > tol {
+				t.Fatalf("sample %d = %v, want %v", from+i, v, in[from+i])
+			}
+		}
+	}
+	buf := make([]float32, 2*128+1)
+	var all []float32
+	for {
+		n, err := r.Read(buf)
+		if n%2 != 0 {
+			t.Fatalf("Read returned %d samples, not whole frames", n)
+		}
+		all = append(all, buf[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(all) != len(in) {
+		t.Fatalf("read %d samples, want %d", len(all), len(in))
+	}
+	check(all, 0)
+
+	if err := r.SeekFrame(250); err != nil || r.Position() != 250 {
+		t.Fatalf("SeekFrame(250): Position %d, %v", r.Position(), err)
+	}
+	n, err := r.Read(buf)
+	if err != nil || n != 100 || r.Position() != 300 {
+		t.Fatalf("Read after seek: %d, %v, Position %d", n, err, r.Position())
+	}
+	check(buf[:n], 500)
+	if _, err := r.Read(buf); err != io.EOF {
+		t.Errorf("Read at end = %v, want io.EOF", err)
+	}
+}
+
+func TestReadSidecarErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pcm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bad.pcm")
+	if _, err := Open(path); err == nil {
+		t.Error("Open without sidecar: no error")
+	}
+	for _, s := range []string{
+		`{"sampleRate": 48000, "channels": 2, "bitsPerSample": 16, "byteOrder": "big"}`,
+		`{"sampleRate": 48000, "channels": 2, "bitsPerSample": 12, "byteOrder": "little"}`,
+		`{"sampleRate": 48000, "channels": 2`,
+	} {
+		if err := ioutil.WriteFile(SidecarPath(path), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadSidecar(path); err == nil {
+			t.Errorf("%s: no error", s)
+		}
+	}
+}
+
+func TestFlushWriter(t *testing.T) {
+	var out bytes.Buffer
+	var sizes []int64
+	w := NewFlushWriter(&out, FlushOptions{
+		MaxSize: 5,
+		Align:   true,
+		Update: func(size int64) error {
+			sizes = append(sizes, size)
+			return nil
+		},
+	})
+	w.FlushInterval = 0
+	if _, err := w.Write([]byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte{4, 5, 6}); err != ErrTooLarge {
+		t.Errorf("Write over MaxSize = %v, want ErrTooLarge", err)
+	}
+	if len(sizes) != 0 {
+		t.Errorf("updated %v with FlushInterval 0", sizes)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 补齐的字节不计入数据量
+	if !bytes.Equal(out.Bytes(), []byte{1, 2, 3, 0}) || w.Size() != 3 || len(sizes) != 1 || sizes[0] != 3 {
+		t.Errorf("after Close: % x, Size %d, updates %v", out.Bytes(), w.Size(), sizes)
+	}
+	if _, err := w.Write([]byte{1}); err != ErrClosed {
+		t.Errorf("Write after Close = %v, want ErrClosed", err)
+	}
+	if err := w.Flush(); err != ErrClosed {
+		t.Errorf("Flush after Close = %v, want ErrClosed", err)
+	}
+}
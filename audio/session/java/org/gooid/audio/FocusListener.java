@@ -0,0 +1,27 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org.gooid.audio;
+
+import android.media.AudioManager;
+
+/**
+ * 把音频焦点的变化转发给 Go (github.com/gooid/gooid/audio/session)。
+ * 需要编译进 APK (application 的 android:hasCode="true")，
+ * native 方法由 session 在运行时用 RegisterNatives 注册。
+ */
+public class FocusListener implements AudioManager.OnAudioFocusChangeListener {
+	private final long handle;
+
+	public FocusListener(long handle) {
+		this.handle = handle;
+	}
+
+	@Override
+	public void onAudioFocusChange(int focusChange) {
+		nativeFocusChange(handle, focusChange);
+	}
+
+	private static native void nativeFocusChange(long handle, int focusChange);
+}
@@ -0,0 +1,322 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package session
+
+/*
+#include <jni.h>
+#include <stdint.h>
+
+extern void cgoFocusChange(int64_t handle, int change);
+
+static void nativeFocusChange(JNIEnv* env, jclass clazz, jlong handle, jint change) {
+	cgoFocusChange((int64_t)handle, change);
+}
+
+// 每次调用都可能在不同的线程中，按需 attach，返回前 detach
+static JNIEnv* attach(JavaVM* vm, int* attached) {
+	JNIEnv* env = NULL;
+	*attached = 0;
+	jint r = (*vm)->GetEnv(vm, (void**)&env, JNI_VERSION_1_6);
+	if (r == JNI_EDETACHED) {
+		if ((*vm)->AttachCurrentThread(vm, &env, NULL) != JNI_OK) {
+			return NULL;
+		}
+		*attached = 1;
+	} else if (r != JNI_OK) {
+		return NULL;
+	}
+	if ((*env)->PushLocalFrame(env, 16) != 0) {
+		(*env)->ExceptionClear(env);
+		if (*attached) {
+			(*vm)->DetachCurrentThread(vm);
+		}
+		return NULL;
+	}
+	return env;
+}
+
+static void detach(JavaVM* vm, JNIEnv* env, int attached) {
+	(*env)->PopLocalFrame(env, NULL);
+	if (attached) {
+		(*vm)->DetachCurrentThread(vm);
+	}
+}
+
+// failed 清除异常，返回是否有异常
+static int failed(JNIEnv* env) {
+	if ((*env)->ExceptionCheck(env)) {
+		(*env)->ExceptionClear(env);
+		return 1;
+	}
+	return 0;
+}
+
+static jobject sessionAudioManager(JavaVM* vm, jobject act) {
+	int attached;
+	JNIEnv* env = attach(vm, &attached);
+	if (env == NULL) {
+		return NULL;
+	}
+	jobject am = NULL;
+	jclass cls = (*env)->GetObjectClass(env, act);
+	jmethodID m = (*env)->GetMethodID(env, cls, "getSystemService", "(Ljava/lang/String;)Ljava/lang/Object;");
+	if (!failed(env)) {
+		jobject obj = (*env)->CallObjectMethod(env, act, m, (*env)->NewStringUTF(env, "audio"));
+		if (!failed(env) && obj != NULL) {
+			am = (*env)->NewGlobalRef(env, obj);
+		}
+	}
+	detach(vm, env, attached);
+	return am;
+}
+
+// sessionNewListener 用 activity 的 ClassLoader 加载 org.gooid.audio.FocusListener，
+// 注册 native 方法后创建实例。APK 中没有这个类时返回 NULL
+static jobject sessionNewListener(JavaVM* vm, jobject act, int64_t handle) {
+	int attached;
+	JNIEnv* env = attach(vm, &attached);
+	if (env == NULL) {
+		return NULL;
+	}
+	jobject listener = NULL;
+	do {
+		jclass actCls = (*env)->GetObjectClass(env, act);
+		jmethodID getLoader = (*env)->GetMethodID(env, actCls, "getClassLoader", "()Ljava/lang/ClassLoader;");
+		if (failed(env)) {
+			break;
+		}
+		jobject loader = (*env)->CallObjectMethod(env, act, getLoader);
+		if (failed(env) || loader == NULL) {
+			break;
+		}
+		jclass loaderCls = (*env)->FindClass(env, "java/lang/ClassLoader");
+		jmethodID loadClass = (*env)->GetMethodID(env, loaderCls, "loadClass", "(Ljava/lang/String;)Ljava/lang/Class;");
+		if (failed(env)) {
+			break;
+		}
+		jclass cls = (jclass)(*env)->CallObjectMethod(env, loader, loadClass,
+			(*env)->NewStringUTF(env, "org.gooid.audio.FocusListener"));
+		if (failed(env) || cls == NULL) {
+			break;
+		}
+		JNINativeMethod methods[] = {
+			{"nativeFocusChange", "(JI)V", (void*)nativeFocusChange},
+		};
+		if ((*env)->RegisterNatives(env, cls, methods, 1) != 0) {
+			failed(env);
+			break;
+		}
+		jmethodID ctor = (*env)->GetMethodID(env, cls, "<init>", "(J)V");
+		if (failed(env)) {
+			break;
+		}
+		jobject obj = (*env)->NewObject(env, cls, ctor, (jlong)handle);
+		if (!failed(env) && obj != NULL) {
+			listener = (*env)->NewGlobalRef(env, obj);
+		}
+	} while (0);
+	detach(vm, env, attached);
+	return listener;
+}
+
+// sessionRequestFocus 返回 AUDIOFOCUS_REQUEST_*，异常时返回 -1
+static int sessionRequestFocus(JavaVM* vm, jobject am, jobject listener, int stream, int hint) {
+	int attached;
+	JNIEnv* env = attach(vm, &attached);
+	if (env == NULL) {
+		return -1;
+	}
+	int r = -1;
+	jclass cls = (*env)->GetObjectClass(env, am);
+	jmethodID m = (*env)->GetMethodID(env, cls, "requestAudioFocus",
+		"(Landroid/media/AudioManager$OnAudioFocusChangeListener;II)I");
+	if (!failed(env)) {
+		r = (*env)->CallIntMethod(env, am, m, listener, stream, hint);
+		if (failed(env)) {
+			r = -1;
+		}
+	}
+	detach(vm, env, attached);
+	return r;
+}
+
+static int sessionAbandonFocus(JavaVM* vm, jobject am, jobject listener) {
+	int attached;
+	JNIEnv* env = attach(vm, &attached);
+	if (env == NULL) {
+		return -1;
+	}
+	int r = -1;
+	jclass cls = (*env)->GetObjectClass(env, am);
+	jmethodID m = (*env)->GetMethodID(env, cls, "abandonAudioFocus",
+		"(Landroid/media/AudioManager$OnAudioFocusChangeListener;)I");
+	if (!failed(env)) {
+		r = (*env)->CallIntMethod(env, am, m, listener);
+		if (failed(env)) {
+			r = -1;
+		}
+	}
+	detach(vm, env, attached);
+	return r;
+}
+
+static int sessionGetMode(JavaVM* vm, jobject am) {
+	int attached;
+	JNIEnv* env = attach(vm, &attached);
+	if (env == NULL) {
+		return -1;
+	}
+	int r = -1;
+	jclass cls = (*env)->GetObjectClass(env, am);
+	jmethodID m = (*env)->GetMethodID(env, cls, "getMode", "()I");
+	if (!failed(env)) {
+		r = (*env)->CallIntMethod(env, am, m);
+		if (failed(env)) {
+			r = -1;
+		}
+	}
+	detach(vm, env, attached);
+	return r;
+}
+
+static int callBool(JNIEnv* env, jobject obj, jclass cls, const char* name) {
+	jmethodID m = (*env)->GetMethodID(env, cls, name, "()Z");
+	if (failed(env)) {
+		return 0;
+	}
+	jboolean r = (*env)->CallBooleanMethod(env, obj, m);
+	return !failed(env) && r;
+}
+
+// sessionOutputs 返回已连接的输出设备，第 n 位表示 AudioDeviceInfo.TYPE_* 为 n 的设备。
+// API 23 以下只能得到有线耳机和蓝牙 A2DP。异常时返回 -1
+static int64_t sessionOutputs(JavaVM* vm, jobject am, int sdk) {
+	int attached;
+	JNIEnv* env = attach(vm, &attached);
+	if (env == NULL) {
+		return -1;
+	}
+	int64_t mask = -1;
+	jclass cls = (*env)->GetObjectClass(env, am);
+	if (sdk < 23) {
+		mask = 1 << 2; // TYPE_BUILTIN_SPEAKER
+		if (callBool(env, am, cls, "isWiredHeadsetOn")) {
+			mask |= 1 << 3; // TYPE_WIRED_HEADSET
+		}
+		if (callBool(env, am, cls, "isBluetoothA2dpOn")) {
+			mask |= 1 << 8; // TYPE_BLUETOOTH_A2DP
+		}
+		detach(vm, env, attached);
+		return mask;
+	}
+	do {
+		jmethodID getDevices = (*env)->GetMethodID(env, cls, "getDevices", "(I)[Landroid/media/AudioDeviceInfo;");
+		if (failed(env)) {
+			break;
+		}
+		jobjectArray devices = (jobjectArray)(*env)->CallObjectMethod(env, am, getDevices, 2); // GET_DEVICES_OUTPUTS
+		if (failed(env) || devices == NULL) {
+			break;
+		}
+		jclass infoCls = (*env)->FindClass(env, "android/media/AudioDeviceInfo");
+		jmethodID getType = (*env)->GetMethodID(env, infoCls, "getType", "()I");
+		if (failed(env)) {
+			break;
+		}
+		mask = 0;
+		jsize n = (*env)->GetArrayLength(env, devices);
+		for (jsize i = 0; i < n; i++) {
+			jobject info = (*env)->GetObjectArrayElement(env, devices, i);
+			jint t = (*env)->CallIntMethod(env, info, getType);
+			if (!failed(env) && t >= 0 && t < 64) {
+				mask |= (int64_t)1 << t;
+			}
+			(*env)->DeleteLocalRef(env, info);
+		}
+	} while (0);
+	detach(vm, env, attached);
+	return mask;
+}
+
+static void sessionDeleteRef(JavaVM* vm, jobject obj) {
+	int attached;
+	JNIEnv* env = attach(vm, &attached);
+	if (env == NULL) {
+		return;
+	}
+	(*env)->DeleteGlobalRef(env, obj);
+	detach(vm, env, attached);
+}
+*/
+import "C"
+
+import "unsafe"
+
+// AudioManager.STREAM_MUSIC
+const streamMusic = 3
+
+// AudioManager.AUDIOFOCUS_REQUEST_GRANTED
+const requestGranted = 1
+
+// AudioManager.MODE_NORMAL，大于它的是响铃和通话
+const modeNormal = 0
+
+// jni AudioManager 的全局引用，以及可能没有的 FocusListener
+type jni struct {
+	vm       *C.JavaVM
+	am       C.jobject
+	listener C.jobject
+}
+
+func (j *jni) open(vm, act uintptr, handle int64) bool {
+	j.vm = (*C.JavaVM)(unsafe.Pointer(vm))
+	j.am = C.sessionAudioManager(j.vm, C.jobject(unsafe.Pointer(act)))
+	if j.am == nil {
+		return false
+	}
+	j.listener = C.sessionNewListener(j.vm, C.jobject(unsafe.Pointer(act)), C.int64_t(handle))
+	return true
+}
+
+func (j *jni) hasListener() bool {
+	return j.listener != nil
+}
+
+func (j *jni) requestFocus(hint Focus) int {
+	return int(C.sessionRequestFocus(j.vm, j.am, j.listener, streamMusic, C.int(hint)))
+}
+
+func (j *jni) abandonFocus() int {
+	return int(C.sessionAbandonFocus(j.vm, j.am, j.listener))
+}
+
+func (j *jni) mode() int {
+	return int(C.sessionGetMode(j.vm, j.am))
+}
+
+func (j *jni) outputs(sdk int) int64 {
+	return int64(C.sessionOutputs(j.vm, j.am, C.int(sdk)))
+}
+
+func (j *jni) close() {
+	if j.listener != nil {
+		C.sessionDeleteRef(j.vm, j.listener)
+		j.listener = nil
+	}
+	if j.am != nil {
+		C.sessionDeleteRef(j.vm, j.am)
+		j.am = nil
+	}
+}
+
+//export cgoFocusChange
+func cgoFocusChange(handle C.int64_t, change C.int) {
+	sessionLock.RLock()
+	s := sessions[int64(handle)]
+	sessionLock.RUnlock()
+	if s != nil {
+		s.focusChanged(Focus(change))
+	}
+}
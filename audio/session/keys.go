@@ -0,0 +1,73 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package session
+
+import (
+	app "github.com/gooid/gooid/internal/ndk"
+)
+
+// Keys 交给 app 处理的按键，回调为 nil 的按键仍由系统处理
+type Keys struct {
+	// Volume 音量键，delta 为 +1/-1，静音键为 0。按住时重复调用
+	Volume func(delta int)
+
+	// Media 媒体键 (KEYCODE_MEDIA_*、KEYCODE_HEADSETHOOK)，每次按下调用一次
+	Media func(keyCode int)
+}
+
+func (k *Keys) volume(code int) (int, bool) {
+	if k.Volume == nil {
+		return 0, false
+	}
+	switch code {
+	case app.KEYCODE_VOLUME_UP:
+		return 1, true
+	case app.KEYCODE_VOLUME_DOWN:
+		return -1, true
+	case app.KEYCODE_VOLUME_MUTE:
+		return 0, true
+	}
+	return 0, false
+}
+
+func (k *Keys) media(code int) bool {
+	if k.Media == nil {
+		return false
+	}
+	switch code {
+	case app.KEYCODE_MEDIA_PLAY, app.KEYCODE_MEDIA_PAUSE, app.KEYCODE_MEDIA_PLAY_PAUSE,
+		app.KEYCODE_MEDIA_STOP, app.KEYCODE_MEDIA_NEXT, app.KEYCODE_MEDIA_PREVIOUS,
+		app.KEYCODE_MEDIA_REWIND, app.KEYCODE_MEDIA_FAST_FORWARD, app.KEYCODE_HEADSETHOOK:
+		return true
+	}
+	return false
+}
+
+// RouteKeys 在 cbs.KeyFilter 中把音量键和媒体键交给 k，
+// 按下和抬起都标记为已处理，系统不再调整音量；其它按键交给原来的 KeyFilter。
+// cbs 可以是传给 Context.Run 的 Callbacks，或运行中的 &ctx.Callbacks
+func RouteKeys(cbs *app.Callbacks, k Keys) {
+	next := cbs.KeyFilter
+	cbs.KeyFilter = func(act *app.Activity, e *app.KeyEvent) bool {
+		code := e.GetKeyCode()
+		down := e.GetAction() == app.KEY_EVENT_ACTION_DOWN
+		if delta, ok := k.volume(code); ok {
+			if down {
+				k.Volume(delta)
+			}
+			return true
+		}
+		if k.media(code) {
+			if down && e.GetRepeatCount() == 0 {
+				k.Media(code)
+			}
+			return true
+		}
+		if next != nil {
+			return next(act, e)
+		}
+		return false
+	}
+}
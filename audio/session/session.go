@@ -0,0 +1,388 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package session 音频焦点、输出路由和音量/媒体键。
+//
+// 音频焦点通过 JNI 调用 AudioManager。焦点变化的通知需要 APK 中包含
+// java/org/gooid/audio/FocusListener.java (application 的 android:hasCode="true")；
+// 没有这个类时 (默认的 hasCode="false")，只能通过轮询 AudioManager.getMode()
+// 发现来电和通话，报告为 FOCUS_LOSS_TRANSIENT，结束后报告 FOCUS_GAIN。
+//
+// 输出路由 (扬声器、有线耳机、蓝牙、USB) 定期轮询，
+// 耳机拔出时路由变为 ROUTE_SPEAKER，播放器通常应该暂停。
+//
+//	s, err := session.New(act, session.Config{
+//		OnFocusChange: func(f session.Focus) { ... },
+//		OnRouteChange: func(old, new session.Route) { ... },
+//	})
+//	err = s.RequestFocus()
+package session
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	app "github.com/gooid/gooid/internal/ndk"
+)
+
+// Focus 音频焦点，同 AudioManager.AUDIOFOCUS_*
+type Focus int
+
+const (
+	FOCUS_NONE                    Focus = 0
+	FOCUS_GAIN                    Focus = 1
+	FOCUS_GAIN_TRANSIENT          Focus = 2
+	FOCUS_GAIN_TRANSIENT_MAY_DUCK Focus = 3
+	FOCUS_LOSS                    Focus = -1
+	FOCUS_LOSS_TRANSIENT          Focus = -2
+	FOCUS_LOSS_TRANSIENT_CAN_DUCK Focus = -3
+)
+
+func (f Focus) String() string {
+	switch f {
+	case FOCUS_NONE:
+		return "FOCUS_NONE"
+	case FOCUS_GAIN:
+		return "FOCUS_GAIN"
+	case FOCUS_GAIN_TRANSIENT:
+		return "FOCUS_GAIN_TRANSIENT"
+	case FOCUS_GAIN_TRANSIENT_MAY_DUCK:
+		return "FOCUS_GAIN_TRANSIENT_MAY_DUCK"
+	case FOCUS_LOSS:
+		return "FOCUS_LOSS"
+	case FOCUS_LOSS_TRANSIENT:
+		return "FOCUS_LOSS_TRANSIENT"
+	case FOCUS_LOSS_TRANSIENT_CAN_DUCK:
+		return "FOCUS_LOSS_TRANSIENT_CAN_DUCK"
+	}
+	return fmt.Sprintf("Focus(%d)", int(f))
+}
+
+// Gained 是否持有焦点
+func (f Focus) Gained() bool {
+	return f > 0
+}
+
+// Duck 是否应该降低音量继续播放
+func (f Focus) Duck() bool {
+	return f == FOCUS_LOSS_TRANSIENT_CAN_DUCK
+}
+
+// Route 媒体的输出路由
+type Route int
+
+const (
+	ROUTE_SPEAKER Route = iota
+	ROUTE_WIRED_HEADSET
+	ROUTE_BLUETOOTH
+	ROUTE_USB
+)
+
+func (r Route) String() string {
+	switch r {
+	case ROUTE_SPEAKER:
+		return "ROUTE_SPEAKER"
+	case ROUTE_WIRED_HEADSET:
+		return "ROUTE_WIRED_HEADSET"
+	case ROUTE_BLUETOOTH:
+		return "ROUTE_BLUETOOTH"
+	case ROUTE_USB:
+		return "ROUTE_USB"
+	}
+	return fmt.Sprintf("Route(%d)", int(r))
+}
+
+// AudioDeviceInfo.TYPE_*
+const (
+	deviceWiredHeadset   = 3
+	deviceWiredHeadphone = 4
+	deviceBluetoothA2DP  = 8
+	deviceUSBDevice      = 11
+	deviceUSBHeadset     = 22
+	deviceBLEHeadset     = 26
+)
+
+// routeOf 按系统选择媒体输出的顺序：蓝牙、有线耳机、USB、扬声器
+func routeOf(outputs int64) Route {
+	has := func(types ...uint) bool {
+		for _, t := range types {
+			if outputs&(1<<t) != 0 {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has(deviceBluetoothA2DP, deviceBLEHeadset):
+		return ROUTE_BLUETOOTH
+	case has(deviceWiredHeadset, deviceWiredHeadphone):
+		return ROUTE_WIRED_HEADSET
+	case has(deviceUSBHeadset, deviceUSBDevice):
+		return ROUTE_USB
+	}
+	return ROUTE_SPEAKER
+}
+
+var (
+	// ErrNoAudioManager 得不到 AudioManager
+	ErrNoAudioManager = errors.New("session: AudioManager not available")
+
+	// ErrFocusDenied 焦点请求被拒绝，如通话中
+	ErrFocusDenied = errors.New("session: audio focus request denied")
+
+	// ErrNoListener 没有 FocusListener 类，系统不接受空的监听器
+	ErrNoListener = errors.New("session: org.gooid.audio.FocusListener not in APK")
+
+	// ErrClosed 已关闭
+	ErrClosed = errors.New("session: closed")
+)
+
+// DefaultPollInterval 路由和通话状态的默认轮询间隔
+const DefaultPollInterval = time.Second
+
+// Config 会话的设置
+type Config struct {
+	// Focus 请求的焦点类型，默认 FOCUS_GAIN
+	Focus Focus
+
+	// OnFocusChange 焦点变化，在会话的 goroutine 中按顺序调用
+	OnFocusChange func(Focus)
+
+	// OnRouteChange 输出路由变化，在会话的 goroutine 中按顺序调用
+	OnRouteChange func(old, new Route)
+
+	// PollInterval 路由和通话状态的轮询间隔，默认 DefaultPollInterval
+	PollInterval time.Duration
+}
+
+// Session 一个播放器的音频会话
+type Session struct {
+	cfg    Config
+	sdk    int
+	jni    jni
+	handle int64 // 传给 FocusListener，用来找到 Session
+
+	mu        sync.Mutex
+	focus     Focus
+	requested bool
+	inCall    bool
+	route     Route
+	closed    bool
+
+	events chan func()
+	done   chan struct{}
+}
+
+var (
+	sessionLock sync.RWMutex
+	sessions    = map[int64]*Session{}
+	// sessionSeq 最后分配的 handle，只增不减。
+	// 放弃焦点失败时 AudioManager 仍持有旧的 FocusListener，handle 不能被新的 Session 重用
+	sessionSeq int64
+)
+
+// New 创建会话并开始监视路由，不请求焦点
+func New(act *app.Activity, cfg Config) (*Session, error) {
+	if cfg.Focus <= 0 {
+		cfg.Focus = FOCUS_GAIN
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	s := &Session{
+		cfg:    cfg,
+		sdk:    act.SdkVersion(),
+		events: make(chan func(), 32),
+		done:   make(chan struct{}),
+	}
+	sessionLock.Lock()
+	sessionSeq++
+	s.handle = sessionSeq
+	sessions[s.handle] = s
+	sessionLock.Unlock()
+
+	if !s.jni.open(act.JavaVM(), act.JActivity(), s.handle) {
+		s.release()
+		return nil, ErrNoAudioManager
+	}
+	s.route = routeOf(s.jni.outputs(s.sdk))
+	go s.run()
+	return s, nil
+}
+
+// HasListener APK 中是否有 FocusListener，没有时焦点变化只能通过通话状态推测
+func (s *Session) HasListener() bool {
+	return s.jni.hasListener()
+}
+
+// RequestFocus 请求 Config.Focus 类型的焦点
+func (s *Session) RequestFocus() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	var err error
+	changed := false
+	switch s.jni.requestFocus(s.cfg.Focus) {
+	case requestGranted:
+		s.requested = true
+		changed = s.setFocus(FOCUS_GAIN)
+	case -1:
+		err = ErrNoListener
+	default:
+		err = ErrFocusDenied
+	}
+	s.mu.Unlock()
+	if changed {
+		s.notifyFocus(FOCUS_GAIN)
+	}
+	return err
+}
+
+// AbandonFocus 放弃焦点
+func (s *Session) AbandonFocus() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if !s.requested {
+		return nil
+	}
+	s.requested = false
+	s.focus = FOCUS_NONE
+	if s.jni.abandonFocus() != requestGranted {
+		return ErrFocusDenied
+	}
+	return nil
+}
+
+// Focus 当前的焦点，没有请求时为 FOCUS_NONE
+func (s *Session) Focus() Focus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.focus
+}
+
+// Route 当前的输出路由
+func (s *Session) Route() Route {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.route
+}
+
+// Close 放弃焦点，停止监视
+func (s *Session) Close() error {
+	err := s.AbandonFocus()
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	s.closed = true
+	s.mu.Unlock()
+	close(s.done)
+	s.release()
+	return err
+}
+
+func (s *Session) release() {
+	sessionLock.Lock()
+	delete(sessions, s.handle)
+	sessionLock.Unlock()
+	s.jni.close()
+}
+
+// setFocus 记录焦点，返回是否改变。调用时持有 s.mu
+func (s *Session) setFocus(f Focus) bool {
+	if s.focus == f {
+		return false
+	}
+	s.focus = f
+	return true
+}
+
+// notifyFocus 交给会话的 goroutine 调用 OnFocusChange，调用时不能持有 s.mu
+func (s *Session) notifyFocus(f Focus) {
+	cb := s.cfg.OnFocusChange
+	if cb == nil {
+		return
+	}
+	select {
+	case s.events <- func() { cb(f) }:
+	case <-s.done:
+	}
+}
+
+// focusChanged 由 FocusListener 在 Java 主线程中调用
+func (s *Session) focusChanged(f Focus) {
+	if f.Gained() {
+		f = FOCUS_GAIN
+	}
+	s.mu.Lock()
+	changed := s.requested && !s.closed && s.setFocus(f)
+	s.mu.Unlock()
+	if changed {
+		s.notifyFocus(f)
+	}
+}
+
+func (s *Session) run() {
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case fn := <-s.events:
+			fn()
+		case <-ticker.C:
+			s.poll()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// poll 在会话的 goroutine 中检查路由和通话状态，直接调用回调
+func (s *Session) poll() {
+	var notify []func()
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	if outputs := s.jni.outputs(s.sdk); outputs >= 0 {
+		if r := routeOf(outputs); r != s.route {
+			old := s.route
+			s.route = r
+			if cb := s.cfg.OnRouteChange; cb != nil {
+				notify = append(notify, func() { cb(old, r) })
+			}
+		}
+	}
+
+	// 没有 FocusListener 时，用通话状态推测焦点
+	if s.requested && !s.jni.hasListener() {
+		mode := s.jni.mode()
+		f := FOCUS_NONE
+		switch {
+		case mode > modeNormal && !s.inCall:
+			s.inCall = true
+			f = FOCUS_LOSS_TRANSIENT
+		case mode == modeNormal && s.inCall:
+			s.inCall = false
+			f = FOCUS_GAIN
+		}
+		if cb := s.cfg.OnFocusChange; f != FOCUS_NONE && s.setFocus(f) && cb != nil {
+			notify = append(notify, func() { cb(f) })
+		}
+	}
+	s.mu.Unlock()
+
+	for _, fn := range notify {
+		fn()
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package wav 读写 WAV 文件头，以及录音用的 Writer。
+//
+// Writer 定期把当前大小写回 RIFF 头，进程被杀掉时文件仍然可以播放；
+// 没有来得及更新的文件可以用 Repair 按实际长度修正。
+// 超过 4GB 的长时间录音使用 pcm.Create。
+package wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gooid/gooid/audio/pcm"
+)
+
+const (
+	formatPCM        = 1
+	formatFloat      = 3
+	formatExtensible = 0xfffe
+)
+
+var (
+	// ErrFormat 不是 WAV 文件
+	ErrFormat = errors.New("wav: not a WAVE file")
+
+	errNoData = errors.New("wav: missing fmt or data chunk")
+)
+
+// Header 解析后的 WAV 头
+type Header struct {
+	pcm.Format
+
+	// BlockAlign 每帧的字节数，可能大于 Format.FrameSize()
+	BlockAlign int
+
+	// DataOffset data 块数据在文件中的位置
+	DataOffset int64
+
+	// DataSize data 块的实际大小，被中断的录音按文件长度计算
+	DataSize int64
+
+	// Truncated 头中记录的大小与文件长度不一致
+	Truncated bool
+
+	riffSize       uint32
+	dataSizeOffset int64
+	factOffset     int64 // 没有 fact 块时为 0
+}
+
+// Frames 数据的帧数
+func (h *Header) Frames() int64 {
+	return h.DataSize / int64(h.BlockAlign)
+}
+
+// ReadHeader 从文件开头解析 WAV 头，返回后 r 的位置不确定
+func ReadHeader(r io.ReadSeeker) (*Header, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	var riff [12]byte
+	if _, err = io.ReadFull(r, riff[:]); err != nil || string(riff[:4]) != "RIFF" || string(riff[8:]) != "WAVE" {
+		return nil, ErrFormat
+	}
+	h := &Header{riffSize: binary.LittleEndian.Uint32(riff[4:])}
+	offset := int64(len(riff))
+	hasFmt := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, errNoData
+		}
+		offset += 8
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		switch string(chunk[:4]) {
+		case "fmt ":
+			if size < 16 || size > 1024 {
+				return nil, fmt.Errorf("wav: bad fmt chunk size %d", size)
+			}
+			b := make([]byte, size)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return nil, err
+			}
+			if err := h.parseFmt(b); err != nil {
+				return nil, err
+			}
+			hasFmt = true
+		case "fact":
+			h.factOffset = offset
+		case "data":
+			if !hasFmt {
+				return nil, errNoData
+			}
+			h.DataOffset = offset
+			h.dataSizeOffset = offset - 4
+			// 录音中 (大小为 0 或 0xffffffff)、被截断，或者 data 是最后一个块
+			// 而文件比头中记录的长 (上次更新头之后还写了数据)
+			last := int64(h.riffSize)+8 == offset+size+size&1
+			if size == 0 || size == 0xffffffff || offset+size > end || (last && offset+size+size&1 < end) {
+				h.Truncated = size != 0 || offset < end
+				size = end - offset
+			}
+			h.DataSize = size
+			return h, nil
+		}
+		offset += size + size&1
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (h *Header) parseFmt(b []byte) error {
+	le := binary.LittleEndian
+	tag := int(le.Uint16(b[0:]))
+	channels := int(le.Uint16(b[2:]))
+	rate := int(le.Uint32(b[4:]))
+	blockAlign := int(le.Uint16(b[12:]))
+	bits := int(le.Uint16(b[14:]))
+	if tag == formatExtensible && len(b) >= 40 {
+		// SubFormat GUID 的前两个字节是格式
+		tag = int(le.Uint16(b[24:]))
+	}
+	h.Format = pcm.Format{SampleRate: rate, Channels: channels, BitsPerSample: bits, Float: tag == formatFloat}
+	if tag != formatPCM && tag != formatFloat {
+		return fmt.Errorf("wav: unsupported format %#x", tag)
+	}
+	if err := h.Format.Validate(); err != nil {
+		return err
+	}
+	h.BlockAlign = h.FrameSize()
+	if blockAlign > h.BlockAlign {
+		h.BlockAlign = blockAlign
+	}
+	return nil
+}
+
+// Repair 按文件实际长度修正被中断的录音：丢弃末尾不完整的帧，
+// 重写 RIFF、data 和 fact 的大小。完好的文件不做修改
+func Repair(f *os.File) (*Header, error) {
+	h, err := ReadHeader(f)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	h.DataSize = h.Frames() * int64(h.BlockAlign)
+	end := h.DataOffset + h.DataSize
+	if !h.Truncated && int64(h.riffSize)+8 <= fi.Size() {
+		return h, nil
+	}
+	if end+h.DataSize&1-8 > maxSize {
+		return nil, pcm.ErrTooLarge
+	}
+	if end < fi.Size() {
+		if err = f.Truncate(end); err != nil {
+			return nil, err
+		}
+	}
+	if h.DataSize&1 != 0 {
+		// RIFF 块按偶数对齐
+		if _, err = f.WriteAt([]byte{0}, end); err != nil {
+			return nil, err
+		}
+	}
+	if err = h.writeSizes(f); err != nil {
+		return nil, err
+	}
+	h.Truncated = false
+	return h, f.Sync()
+}
+
+// RepairFile 修正文件 path，见 Repair
+func RepairFile(path string) (*Header, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	h, err := Repair(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return h, err
+}
+
+// writeSizes 把 DataSize 写回 RIFF、data 和 fact，返回后 w 的位置不确定
+func (h *Header) writeSizes(w io.WriteSeeker) error {
+	h.riffSize = uint32(h.DataOffset + h.DataSize + h.DataSize&1 - 8)
+	if err := putUint32(w, 4, h.riffSize); err != nil {
+		return err
+	}
+	if err := putUint32(w, h.dataSizeOffset, uint32(h.DataSize)); err != nil {
+		return err
+	}
+	if h.factOffset > 0 {
+		return putUint32(w, h.factOffset, uint32(h.Frames()))
+	}
+	return nil
+}
+
+func putUint32(w io.WriteSeeker, offset int64, v uint32) error {
+	if _, err := w.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	_, err := w.Write(b[:])
+	return err
+}
@@ -0,0 +1,172 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package wav
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/gooid/gooid/audio/pcm"
+)
+
+// RIFF 的大小是 32 位的
+const maxSize = 0xffffffff
+
+// KSDATAFORMAT_SUBTYPE_PCM/IEEE_FLOAT 除前两个字节外的部分
+var subFormatGUID = []byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}
+
+// Writer 写 WAV 文件 (小端，8 位无符号)。
+// 写入时每隔 FlushInterval 把当前大小写回文件头，
+// 进程被杀掉时最多丢失最后一个间隔的数据 (可用 Repair 找回)。
+// 超过 4GB 时 Write 返回 pcm.ErrTooLarge
+type Writer struct {
+	*pcm.FlushWriter
+
+	w   io.WriteSeeker
+	h   Header
+	buf []byte
+}
+
+// NewWriter 在 w 的开头写入文件头。
+// 多于 2 个声道或多于 16 位的整数使用 WAVE_FORMAT_EXTENSIBLE
+func NewWriter(w io.WriteSeeker, format pcm.Format) (*Writer, error) {
+	return newWriter(w, nil, format)
+}
+
+// Create 创建文件 path，Close 时关闭文件
+func Create(path string, format pcm.Format) (w *Writer, err error) {
+	err = pcm.CreateFile(path, func(f *os.File) error {
+		w, err = newWriter(f, f, format)
+		return err
+	})
+	return w, err
+}
+
+func newWriter(w io.WriteSeeker, f *os.File, format pcm.Format) (*Writer, error) {
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+	wr := &Writer{w: w, h: Header{Format: format, BlockAlign: format.FrameSize()}}
+	head := wr.header()
+	if _, err := w.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(head); err != nil {
+		return nil, err
+	}
+	wr.FlushWriter = pcm.NewFlushWriter(w, pcm.FlushOptions{
+		File:    f,
+		MaxSize: maxSize + 8 - 1 - wr.h.DataOffset,
+		Align:   true,
+		Update:  wr.update,
+	})
+	return wr, nil
+}
+
+// update 把 size 写回文件头
+func (w *Writer) update(size int64) error {
+	w.h.DataSize = size
+	if err := w.h.writeSizes(w.w); err != nil {
+		return err
+	}
+	_, err := w.w.Seek(w.h.DataOffset+size, io.SeekStart)
+	return err
+}
+
+// header 生成文件头，并记录各个大小字段的位置
+func (w *Writer) header() []byte {
+	f := w.h.Format
+	tag := uint16(formatPCM)
+	if f.Float {
+		tag = formatFloat
+	}
+	ext := f.Channels > 2 || (!f.Float && f.BitsPerSample > 16)
+
+	b := []byte("RIFF\x00\x00\x00\x00WAVEfmt ")
+	fmtSize := 16
+	switch {
+	case ext:
+		fmtSize = 40
+	case f.Float:
+		fmtSize = 18
+	}
+	b = appendUint32(b, uint32(fmtSize))
+	if ext {
+		b = appendUint16(b, formatExtensible)
+	} else {
+		b = appendUint16(b, tag)
+	}
+	b = appendUint16(b, uint16(f.Channels))
+	b = appendUint32(b, uint32(f.SampleRate))
+	b = appendUint32(b, uint32(f.SampleRate*w.h.BlockAlign))
+	b = appendUint16(b, uint16(w.h.BlockAlign))
+	b = appendUint16(b, uint16(f.BitsPerSample))
+	switch {
+	case ext:
+		b = appendUint16(b, 22)
+		b = appendUint16(b, uint16(f.BitsPerSample))
+		b = appendUint32(b, channelMask(f.Channels))
+		b = appendUint16(b, tag)
+		b = append(b, subFormatGUID...)
+	case f.Float:
+		b = appendUint16(b, 0)
+	}
+
+	// 非 PCM 格式需要 fact 块
+	if f.Float {
+		b = append(b, "fact\x04\x00\x00\x00"...)
+		w.h.factOffset = int64(len(b))
+		b = appendUint32(b, 0)
+	}
+	b = append(b, "data"...)
+	w.h.dataSizeOffset = int64(len(b))
+	b = appendUint32(b, 0)
+	w.h.DataOffset = int64(len(b))
+	binary.LittleEndian.PutUint32(b[4:], uint32(len(b)-8))
+	return b
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v), byte(v>>8))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+// channelMask 常见声道数的扬声器位置
+func channelMask(channels int) uint32 {
+	switch channels {
+	case 1:
+		return 0x4 // FC
+	case 2:
+		return 0x3 // FL FR
+	case 4:
+		return 0x33 // FL FR BL BR
+	case 6:
+		return 0x3f // 5.1
+	case 8:
+		return 0x63f // 7.1
+	}
+	return 0
+}
+
+// Format 文件格式
+func (w *Writer) Format() pcm.Format {
+	return w.h.Format
+}
+
+// Frames 已写入的帧数
+func (w *Writer) Frames() int64 {
+	return w.Size() / int64(w.h.BlockAlign)
+}
+
+// WriteSamples 编码并写入交错存放的 float32 样本
+func (w *Writer) WriteSamples(samples []float32) error {
+	w.buf = pcm.Encode(w.buf[:0], samples, w.h.Format, pcm.LittleEndian)
+	_, err := w.Write(w.buf)
+	return err
+}
@@ -0,0 +1,252 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package wav
+
+import (
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gooid/gooid/audio/pcm"
+)
+
+// testSignal frames 帧的正弦，channels 声道交错存放
+func testSignal(frames, channels int) []float32 {
+	s := make([]float32, frames*channels)
+	for i := range s {
+		s[i] = float32(0.8 * math.Sin(float64(i)*0.01))
+	}
+	return s
+}
+
+// readFile 解析 path 的文件头并读出全部样本
+func readFile(t *testing.T, path string) (*Header, []float32) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	h, err := ReadHeader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := pcm.NewFrameReader(f, h.Format, pcm.LittleEndian, h.DataOffset, h.BlockAlign, h.Frames())
+	if err := r.SeekFrame(0); err != nil {
+		t.Fatal(err)
+	}
+	var out []float32
+	buf := make([]float32, 999*h.Channels)
+	for {
+		n, err := r.Read(buf)
+		out = append(out, buf[:n]...)
+		if err == io.EOF {
+			return h, out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, f := range []pcm.Format{
+		{SampleRate: 44100, Channels: 1, BitsPerSample: 8},
+		{SampleRate: 48000, Channels: 2, BitsPerSample: 16},
+		{SampleRate: 48000, Channels: 3, BitsPerSample: 24},
+		{SampleRate: 8000, Channels: 6, BitsPerSample: 32},
+		{SampleRate: 48000, Channels: 2, BitsPerSample: 32, Float: true},
+		{SampleRate: 48000, Channels: 1, BitsPerSample: 64, Float: true},
+	} {
+		path := filepath.Join(dir, "a.wav")
+		w, err := Create(path, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in := testSignal(1001, f.Channels)
+		if err := w.WriteSamples(in[:500*f.Channels]); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteSamples(in[500*f.Channels:]); err != nil {
+			t.Fatal(err)
+		}
+		if w.Frames() != 1001 {
+			t.Errorf("%+v: Frames = %d, want 1001", f, w.Frames())
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != pcm.ErrClosed {
+			t.Errorf("%+v: second Close = %v, want ErrClosed", f, err)
+		}
+
+		h, out := readFile(t, path)
+		if h.Format != f || h.Truncated || len(out) != len(in) {
+			t.Fatalf("%+v: read %+v, truncated %v, %d samples", f, h.Format, h.Truncated, len(out))
+		}
+		tol := 1.0 / float64(int64(1)<<uint(f.BitsPerSample-1))
+		if f.Float {
+			tol = 1e-7
+		}
+		for i := range in {
+			if math.Abs(float64(in[i]-out[i])) > tol {
+				t.Fatalf("%+v: sample %d = %v, want %v", f, i, out[i], in[i])
+			}
+		}
+		// 奇数长度的 data 块补齐到偶数
+		if fi, err := os.Stat(path); err != nil || fi.Size()&1 != 0 || int64(h.riffSize)+8 != fi.Size() {
+			t.Errorf("%+v: file size %v, RIFF size %d, %v", f, fi.Size(), h.riffSize, err)
+		}
+	}
+}
+
+// TestWriterFlushInterval 写入时按 FlushInterval 更新文件头，
+// 不 Close 也能读出最后一次更新时的大小
+func TestWriterFlushInterval(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := pcm.Format{SampleRate: 48000, Channels: 2, BitsPerSample: 16}
+	path := filepath.Join(dir, "flush.wav")
+	w, err := Create(path, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	header := func() *Header {
+		t.Helper()
+		r, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		h, err := ReadHeader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	// 每次 Write 都到期
+	w.FlushInterval = time.Nanosecond
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		if err := w.WriteSamples(testSignal(100, 2)); err != nil {
+			t.Fatal(err)
+		}
+		if h := header(); h.Truncated || h.Frames() != int64(100*(i+1)) {
+			t.Fatalf("after write %d: truncated %v, %d frames", i, h.Truncated, h.Frames())
+		}
+	}
+
+	// 间隔未到时文件头保持上次的大小，按文件长度读出全部数据
+	w.FlushInterval = time.Hour
+	if err := w.WriteSamples(testSignal(50, 2)); err != nil {
+		t.Fatal(err)
+	}
+	h := header()
+	if !h.Truncated || h.Frames() != 350 {
+		t.Errorf("stale header: truncated %v, %d frames, want true, 350", h.Truncated, h.Frames())
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if h := header(); h.Truncated || h.Frames() != 350 {
+		t.Errorf("after Flush: truncated %v, %d frames", h.Truncated, h.Frames())
+	}
+}
+
+// TestRepair 模拟写入中被杀掉：文件头停在上次 Flush，末尾有不完整的帧
+func TestRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, c := range []struct {
+		format  pcm.Format
+		partial int // 末尾多出的字节
+		size    int64
+	}{
+		// 151 帧 8 位单声道，补齐到偶数
+		{pcm.Format{SampleRate: 44100, Channels: 1, BitsPerSample: 8}, 0, 44 + 152},
+		// 151 帧 16 位双声道加 3 个字节
+		{pcm.Format{SampleRate: 48000, Channels: 2, BitsPerSample: 16}, 3, 44 + 151*4},
+		// 浮点有 fact 块
+		{pcm.Format{SampleRate: 48000, Channels: 1, BitsPerSample: 32, Float: true}, 2, 58 + 151*4},
+	} {
+		path := filepath.Join(dir, "crash.wav")
+		w, err := Create(path, c.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.FlushInterval = 0
+		if err := w.WriteSamples(testSignal(100, c.format.Channels)); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.WriteSamples(testSignal(51, c.format.Channels)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(make([]byte, c.partial)); err != nil {
+			t.Fatal(err)
+		}
+		// 不调用 Close
+
+		h, err := RepairFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Truncated || h.Frames() != 151 {
+			t.Errorf("%+v: repaired truncated %v, %d frames, want 151", c.format, h.Truncated, h.Frames())
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() != c.size {
+			t.Errorf("%+v: repaired size %d, want %d", c.format, fi.Size(), c.size)
+		}
+		h, out := readFile(t, path)
+		if h.Truncated || int64(h.riffSize)+8 != fi.Size() || len(out) != 151*c.format.Channels {
+			t.Errorf("%+v: reread truncated %v, RIFF size %d, %d samples", c.format, h.Truncated, h.riffSize, len(out))
+		}
+		if c.format.Float {
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := int(b[h.factOffset]) | int(b[h.factOffset+1])<<8; n != 151 {
+				t.Errorf("fact sample count %d, want 151", n)
+			}
+		}
+
+		// 完好的文件不做修改
+		if _, err := RepairFile(path); err != nil {
+			t.Fatal(err)
+		}
+		if fi2, _ := os.Stat(path); fi2.Size() != fi.Size() || !fi2.ModTime().Equal(fi.ModTime()) {
+			t.Errorf("%+v: Repair modified a complete file", c.format)
+		}
+		w.Close()
+	}
+}
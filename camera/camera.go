@@ -5,6 +5,8 @@
 package camera
 
 import (
+	"image"
+
 	"github.com/gooid/gooid/internal/ndk"
 )
 
@@ -47,11 +49,32 @@ const (
 	FOCUS_DISTANCE_NEAR_INDEX    = app.CAMERA_FOCUS_DISTANCE_NEAR_INDEX
 	FOCUS_DISTANCE_OPTIMAL_INDEX = app.CAMERA_FOCUS_DISTANCE_OPTIMAL_INDEX
 	FOCUS_DISTANCE_FAR_INDEX     = app.CAMERA_FOCUS_DISTANCE_FAR_INDEX
+
+	PIXEL_FORMAT_UNKNOWN     = app.CAMERA_PIXEL_FORMAT_UNKNOWN
+	PIXEL_FORMAT_RGB_565     = app.CAMERA_PIXEL_FORMAT_RGB_565
+	PIXEL_FORMAT_NV16        = app.CAMERA_PIXEL_FORMAT_NV16
+	PIXEL_FORMAT_NV21        = app.CAMERA_PIXEL_FORMAT_NV21
+	PIXEL_FORMAT_YUY2        = app.CAMERA_PIXEL_FORMAT_YUY2
+	PIXEL_FORMAT_RAW_SENSOR  = app.CAMERA_PIXEL_FORMAT_RAW_SENSOR
+	PIXEL_FORMAT_PRIVATE     = app.CAMERA_PIXEL_FORMAT_PRIVATE
+	PIXEL_FORMAT_YUV_420_888 = app.CAMERA_PIXEL_FORMAT_YUV_420_888
+	PIXEL_FORMAT_RAW_PRIVATE = app.CAMERA_PIXEL_FORMAT_RAW_PRIVATE
+	PIXEL_FORMAT_RAW10       = app.CAMERA_PIXEL_FORMAT_RAW10
+	PIXEL_FORMAT_RAW12       = app.CAMERA_PIXEL_FORMAT_RAW12
+	PIXEL_FORMAT_JPEG        = app.CAMERA_PIXEL_FORMAT_JPEG
+	PIXEL_FORMAT_YV12        = app.CAMERA_PIXEL_FORMAT_YV12
 )
 
 type Camera = app.Camera
 type CameraCallback = app.CameraCallback
+type PixelFormat = app.CameraPixelFormat
+type FpsRange = app.CameraFpsRange
 
 func Connect(cameraId int, cb CameraCallback) Camera {
 	return app.CameraConnect(cameraId, cb)
 }
+
+// BestPreviewSize 在 sizes 中选择最接近 target 的尺寸，见 Camera.BestPreviewSize
+func BestPreviewSize(sizes []image.Point, target image.Point, aspectTolerance float64) (image.Point, bool) {
+	return app.BestCameraSize(sizes, target, aspectTolerance)
+}
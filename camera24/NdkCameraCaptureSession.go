@@ -48,6 +48,7 @@ package camera
 #endif
 
 #include <stdbool.h>
+#include <stdlib.h>
 static bool bFalse() { return false; }
 #include <camera/NdkCameraDevice.h>
 #include <camera/NdkCaptureRequest.h>
@@ -77,6 +78,7 @@ extern void cgoCaptureBufferLost(
 */
 import "C"
 import (
+	"sync"
 	"time"
 	"unsafe"
 
@@ -477,7 +479,7 @@ const (
 //void ACameraCaptureSession_close(ACameraCaptureSession* session);
 func (session *CaptureSession) Close() {
 	delete(captureSessionMap, session.cptr())
-	delete(captureSessionCaptureMap, unsafe.Pointer(session.cptr()))
+	removeSessionCaptureListeners(unsafe.Pointer(session.cptr()))
 	C.ACameraCaptureSession_close(session.cptr())
 }
 
@@ -554,9 +556,13 @@ func (session *CaptureSession) Capture(cb interface{},
 	}
 
 	ccallbacks := getCaptureCallbacks(cb, unsafe.Pointer(session.cptr()))
-	ret := C.ACameraCaptureSession_capture(session.cptr(),
-		ccallbacks, numRequests, &crequests[0], &ccaptureSequenceId)
-	return int(ccaptureSequenceId), Status(ret)
+	ret := Status(C.ACameraCaptureSession_capture(session.cptr(),
+		ccallbacks, numRequests, &crequests[0], &ccaptureSequenceId))
+	if ret != nil && ccallbacks != nil {
+		// 提交失败，不会有回调
+		releaseCaptureContext(ccallbacks.context)
+	}
+	return int(ccaptureSequenceId), ret
 }
 
 /**
@@ -613,13 +619,27 @@ func (session *CaptureSession) Capture(cb interface{},
 //        int numRequests, ACaptureRequest** requests,
 //        /*optional*/int* captureSequenceId);
 func (session *CaptureSession) SetRepeatingRequest(requests []*CaptureRequest) error {
+	_, err := session.SetRepeatingRequestWithCallbacks(nil, requests)
+	return err
+}
+
+// SetRepeatingRequestWithCallbacks cb 与 Capture 相同，
+// 在 StopRepeating 或被新的重复请求替换、序列结束后不再回调
+func (session *CaptureSession) SetRepeatingRequestWithCallbacks(cb interface{},
+	requests []*CaptureRequest) (int, error) {
 	creqs := make([]*C.ACaptureRequest, len(requests))
 	for i, r := range requests {
 		creqs[i] = r.cptr()
 	}
-	return Status(C.ACameraCaptureSession_setRepeatingRequest(session.cptr(),
-		nil, C.int(len(requests)), &creqs[0], nil))
-	// (**C.ACaptureRequest)(unsafe.Pointer(
+	csequenceId := C.int(0)
+	ccallbacks := getCaptureCallbacks(cb, unsafe.Pointer(session.cptr()))
+	ret := Status(C.ACameraCaptureSession_setRepeatingRequest(session.cptr(),
+		ccallbacks, C.int(len(requests)), &creqs[0], &csequenceId))
+	if ret != nil && ccallbacks != nil {
+		// 提交失败，不会有回调
+		releaseCaptureContext(ccallbacks.context)
+	}
+	return int(csequenceId), ret
 }
 
 /**
@@ -692,7 +712,60 @@ func (session *CaptureSession) AbortCaptures() error {
 }
 
 // capture callbacks
-var captureSessionCaptureMap = map[unsafe.Pointer]interface{}{}
+// 每次 Capture/SetRepeatingRequest 使用独立的 context，其中保存不会重复的 id。
+// session 关闭时只注销 id，context 在序列结束(completed/aborted)时释放，
+// 因此关闭后迟到的回调不会读到被其它 listener 复用的地址
+type captureListener struct {
+	session unsafe.Pointer
+	o       interface{}
+	index   int64
+}
+
+var (
+	captureSessionCaptureMap  = map[uint64]*captureListener{}
+	captureSessionCaptureSeq  uint64
+	captureSessionCaptureLock sync.Mutex
+)
+
+func newCaptureContext(l *captureListener) unsafe.Pointer {
+	context := C.malloc(C.size_t(unsafe.Sizeof(C.uint64_t(0))))
+	captureSessionCaptureLock.Lock()
+	captureSessionCaptureSeq++
+	id := captureSessionCaptureSeq
+	captureSessionCaptureMap[id] = l
+	captureSessionCaptureLock.Unlock()
+	*(*C.uint64_t)(context) = C.uint64_t(id)
+	return context
+}
+
+func captureContextId(context unsafe.Pointer) uint64 {
+	return uint64(*(*C.uint64_t)(context))
+}
+
+func captureListenerOf(context unsafe.Pointer) *captureListener {
+	captureSessionCaptureLock.Lock()
+	defer captureSessionCaptureLock.Unlock()
+	return captureSessionCaptureMap[captureContextId(context)]
+}
+
+// releaseCaptureContext 注销并释放 context，之后不会再有使用它的回调
+func releaseCaptureContext(context unsafe.Pointer) {
+	captureSessionCaptureLock.Lock()
+	delete(captureSessionCaptureMap, captureContextId(context))
+	captureSessionCaptureLock.Unlock()
+	C.free(context)
+}
+
+// removeSessionCaptureListeners 只注销，context 留到序列结束的回调中释放
+func removeSessionCaptureListeners(session unsafe.Pointer) {
+	captureSessionCaptureLock.Lock()
+	defer captureSessionCaptureLock.Unlock()
+	for id, l := range captureSessionCaptureMap {
+		if l.session == session {
+			delete(captureSessionCaptureMap, id)
+		}
+	}
+}
 
 type OnCaptureStarted interface {
 	OnCaptureStarted(*CaptureSession, *CaptureRequest, time.Duration)
@@ -701,8 +774,8 @@ type OnCaptureStarted interface {
 //export cgoCaptureStarted
 func cgoCaptureStarted(context unsafe.Pointer, session *C.ACameraCaptureSession,
 	request *C.ACaptureRequest, timestamp C.int64_t) {
-	if o, ok := captureSessionCaptureMap[context]; ok && o != nil {
-		if i, ok := o.(OnCaptureStarted); ok {
+	if l := captureListenerOf(context); l != nil {
+		if i, ok := l.o.(OnCaptureStarted); ok {
 			i.OnCaptureStarted((*CaptureSession)(session),
 				(*CaptureRequest)(request), time.Nanosecond*time.Duration(timestamp))
 		}
@@ -734,11 +807,21 @@ type OnCaptureBufferLost interface {
 	OnCaptureBufferLost(*CaptureSession, *CaptureRequest, *app.Window, int64)
 }
 
+// OnCaptureResult 每帧结束(完成或失败)时以解码后的 CaptureResult 回调
+type OnCaptureResult interface {
+	OnCaptureResult(*CaptureSession, *CaptureResult)
+}
+
+// OnCaptureSequenceEnd 序列结束时回调，aborted 表示被中止
+type OnCaptureSequenceEnd interface {
+	OnCaptureSequenceEnd(session *CaptureSession, sequenceId int, aborted bool)
+}
+
 //export cgoCaptureProgressed
 func cgoCaptureProgressed(context unsafe.Pointer, session *C.ACameraCaptureSession,
 	request *C.ACaptureRequest, result *C.ACameraMetadata) {
-	if o, ok := captureSessionCaptureMap[context]; ok && o != nil {
-		if i, ok := o.(OnCaptureProgressed); ok {
+	if l := captureListenerOf(context); l != nil {
+		if i, ok := l.o.(OnCaptureProgressed); ok {
 			i.OnCaptureProgressed((*CaptureSession)(session),
 				(*CaptureRequest)(request), (*Metadata)(result))
 		}
@@ -748,93 +831,126 @@ func cgoCaptureProgressed(context unsafe.Pointer, session *C.ACameraCaptureSessi
 //export cgoCaptureCompleted
 func cgoCaptureCompleted(context unsafe.Pointer, session *C.ACameraCaptureSession,
 	request *C.ACaptureRequest, result *C.ACameraMetadata) {
-	if o, ok := captureSessionCaptureMap[context]; ok && o != nil {
-		if i, ok := o.(OnCaptureCompleted); ok {
+	if l := captureListenerOf(context); l != nil {
+		if i, ok := l.o.(OnCaptureCompleted); ok {
 			i.OnCaptureCompleted((*CaptureSession)(session),
 				(*CaptureRequest)(request), (*Metadata)(result))
 		}
+		if i, ok := l.o.(OnCaptureResult); ok {
+			r := NewCaptureResult((*Metadata)(result))
+			r.Index = l.next()
+			i.OnCaptureResult((*CaptureSession)(session), r)
+		}
 	}
 }
 
 //export cgoCaptureFailed
 func cgoCaptureFailed(context unsafe.Pointer, session *C.ACameraCaptureSession,
 	request *C.ACaptureRequest, failure *C.ACameraCaptureFailure) {
-	if o, ok := captureSessionCaptureMap[context]; ok && o != nil {
-		if i, ok := o.(OnCaptureFailed); ok {
+	if l := captureListenerOf(context); l != nil {
+		if i, ok := l.o.(OnCaptureFailed); ok {
 			i.OnCaptureFailed((*CaptureSession)(session),
 				(*CaptureRequest)(request), (*CaptureFailure)(failure))
 		}
+		if i, ok := l.o.(OnCaptureResult); ok {
+			f := (*CaptureFailure)(failure)
+			i.OnCaptureResult((*CaptureSession)(session), &CaptureResult{
+				Index: l.next(),
+				Failure: &CaptureFailureInfo{
+					FrameNumber:      f.FrameNumber(),
+					Reason:           f.Reason(),
+					SequenceId:       f.SequenceId(),
+					WasImageCaptured: f.WasImageCaptured(),
+				},
+			})
+		}
 	}
 }
 
 //export cgoCaptureSequenceCompleted
 func cgoCaptureSequenceCompleted(context unsafe.Pointer, session *C.ACameraCaptureSession,
 	sequenceId C.int, frameNumber C.int64_t) {
-	if o, ok := captureSessionCaptureMap[context]; ok && o != nil {
-		if i, ok := o.(OnCaptureSequenceCompleted); ok {
+	if l := captureListenerOf(context); l != nil {
+		if i, ok := l.o.(OnCaptureSequenceCompleted); ok {
 			i.OnCaptureSequenceCompleted((*CaptureSession)(session),
 				int(sequenceId), int64(frameNumber))
 		}
+		if i, ok := l.o.(OnCaptureSequenceEnd); ok {
+			i.OnCaptureSequenceEnd((*CaptureSession)(session), int(sequenceId), false)
+		}
 	}
+	releaseCaptureContext(context)
 }
 
 //export cgoCaptureSequenceAborted
 func cgoCaptureSequenceAborted(context unsafe.Pointer, session *C.ACameraCaptureSession,
 	sequenceId C.int) {
-	if o, ok := captureSessionCaptureMap[context]; ok && o != nil {
-		if i, ok := o.(OnCaptureSequenceAborted); ok {
+	if l := captureListenerOf(context); l != nil {
+		if i, ok := l.o.(OnCaptureSequenceAborted); ok {
 			i.OnCaptureSequenceAborted((*CaptureSession)(session), int(sequenceId))
 		}
+		if i, ok := l.o.(OnCaptureSequenceEnd); ok {
+			i.OnCaptureSequenceEnd((*CaptureSession)(session), int(sequenceId), true)
+		}
 	}
+	releaseCaptureContext(context)
 }
 
 //export cgoCaptureBufferLost
 func cgoCaptureBufferLost(context unsafe.Pointer, session *C.ACameraCaptureSession,
 	request *C.ACaptureRequest, window *C.ANativeWindow, frameNumber C.int64_t) {
-	if o, ok := captureSessionCaptureMap[context]; ok && o != nil {
-		if i, ok := o.(OnCaptureBufferLost); ok {
+	if l := captureListenerOf(context); l != nil {
+		if i, ok := l.o.(OnCaptureBufferLost); ok {
 			i.OnCaptureBufferLost((*CaptureSession)(session),
 				(*CaptureRequest)(request), (*app.Window)(window), int64(frameNumber))
 		}
 	}
 }
 
-//
-func getCaptureCallbacks(o interface{}, context unsafe.Pointer) *C.ACameraCaptureSession_captureCallbacks {
-	var captureListener C.ACameraCaptureSession_captureCallbacks
-	captureListener.context = context
+// session 关闭前，序列结束时释放 context
+func getCaptureCallbacks(o interface{}, session unsafe.Pointer) *C.ACameraCaptureSession_captureCallbacks {
+	var callbacks C.ACameraCaptureSession_captureCallbacks
 	n := 0
 	if _, ok := o.(OnCaptureStarted); ok {
 		n++
-		captureListener.onCaptureStarted = C.ACameraCaptureSession_captureCallback_start(C.cgoCaptureStarted)
+		callbacks.onCaptureStarted = C.ACameraCaptureSession_captureCallback_start(C.cgoCaptureStarted)
 	}
 	if _, ok := o.(OnCaptureProgressed); ok {
 		n++
-		captureListener.onCaptureProgressed = C.ACameraCaptureSession_captureCallback_result(C.cgoCaptureProgressed)
-	}
-	if _, ok := o.(OnCaptureCompleted); ok {
-		n++
-		captureListener.onCaptureCompleted = C.ACameraCaptureSession_captureCallback_result(C.cgoCaptureCompleted)
+		callbacks.onCaptureProgressed = C.ACameraCaptureSession_captureCallback_result(C.cgoCaptureProgressed)
 	}
-	if _, ok := o.(OnCaptureFailed); ok {
+	_, completed := o.(OnCaptureCompleted)
+	_, result := o.(OnCaptureResult)
+	if completed || result {
 		n++
-		captureListener.onCaptureFailed = C.ACameraCaptureSession_captureCallback_failed(C.cgoCaptureFailed)
+		callbacks.onCaptureCompleted = C.ACameraCaptureSession_captureCallback_result(C.cgoCaptureCompleted)
 	}
-	if _, ok := o.(OnCaptureSequenceCompleted); ok {
+	_, failed := o.(OnCaptureFailed)
+	if failed || result {
 		n++
-		captureListener.onCaptureSequenceCompleted = C.ACameraCaptureSession_captureCallback_sequenceEnd(C.cgoCaptureSequenceCompleted)
+		callbacks.onCaptureFailed = C.ACameraCaptureSession_captureCallback_failed(C.cgoCaptureFailed)
 	}
-	if _, ok := o.(OnCaptureSequenceAborted); ok {
+	if _, ok := o.(OnCaptureBufferLost); ok {
 		n++
-		captureListener.onCaptureSequenceAborted = C.ACameraCaptureSession_captureCallback_sequenceAbort(C.cgoCaptureSequenceAborted)
+		callbacks.onCaptureBufferLost = C.ACameraCaptureSession_captureCallback_bufferLost(C.cgoCaptureBufferLost)
 	}
-	if _, ok := o.(OnCaptureBufferLost); ok {
+	switch o.(type) {
+	case OnCaptureSequenceEnd, OnCaptureSequenceCompleted, OnCaptureSequenceAborted:
 		n++
-		captureListener.onCaptureBufferLost = C.ACameraCaptureSession_captureCallback_bufferLost(C.cgoCaptureBufferLost)
 	}
 	if n > 0 {
-		captureSessionCaptureMap[context] = o
-		return &captureListener
+		// 序列结束的回调总是需要，用来释放 context
+		callbacks.onCaptureSequenceCompleted = C.ACameraCaptureSession_captureCallback_sequenceEnd(C.cgoCaptureSequenceCompleted)
+		callbacks.onCaptureSequenceAborted = C.ACameraCaptureSession_captureCallback_sequenceAbort(C.cgoCaptureSequenceAborted)
+		callbacks.context = newCaptureContext(&captureListener{session: session, o: o})
+		return &callbacks
 	}
 	return nil
 }
+
+func (l *captureListener) next() int64 {
+	captureSessionCaptureLock.Lock()
+	defer captureSessionCaptureLock.Unlock()
+	l.index++
+	return l.index
+}
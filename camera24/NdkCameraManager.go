@@ -42,20 +42,38 @@ package camera
 import (
 	"log"
 	"reflect"
+	"sync"
 	"unsafe"
 )
 
 /*
 #include <stdbool.h>
+#include <stdint.h>
 #include <stdlib.h>
 #include <camera/NdkCameraManager.h>
 
 typedef const char* pcchar;
-extern void cgoCameraAvailable(void* context, const char* cameraId);
-extern void cgoCameraUnavailable(void* context, const char* cameraId);
+extern void cgoCameraAvailable(uintptr_t id, const char* cameraId);
+extern void cgoCameraUnavailable(uintptr_t id, const char* cameraId);
 extern void cgoCameraDeviceStateCallbacksOnError(void* context, ACameraDevice* device, int error);
 extern void cgoCameraDeviceStateCallbacksOnDisconnected(void* context, ACameraDevice* device);
 
+// availability 回调的 context 中保存的是注册的 id，不是地址
+static void cameraAvailable(void* context, const char* cameraId) {
+	cgoCameraAvailable((uintptr_t)context, cameraId);
+}
+
+static void cameraUnavailable(void* context, const char* cameraId) {
+	cgoCameraUnavailable((uintptr_t)context, cameraId);
+}
+
+static camera_status_t setAvailabilityCallback(ACameraManager* manager, uintptr_t id, bool add) {
+	ACameraManager_AvailabilityCallbacks cbs = {(void*)id, cameraAvailable, cameraUnavailable};
+	if (add) {
+		return ACameraManager_registerAvailabilityCallback(manager, &cbs);
+	}
+	return ACameraManager_unregisterAvailabilityCallback(manager, &cbs);
+}
 */
 import "C"
 
@@ -95,6 +113,8 @@ func ManagerCreate() *Manager {
  */
 //void ACameraManager_delete(ACameraManager* manager);
 func (manager *Manager) Delete() {
+	stopCameraTracker(manager)
+	unregisterAvailabilityCallbacks(manager)
 	C.ACameraManager_delete(manager.cptr())
 }
 
@@ -214,26 +234,79 @@ type AvailabilityCallbacks interface {
 //camera_status_t ACameraManager_registerAvailabilityCallback(
 //        ACameraManager* manager, const ACameraManager_AvailabilityCallbacks* callback);
 func (manager *Manager) RegisterAvailabilityCallback(cbs AvailabilityCallbacks) error {
-	var ccbs C.ACameraManager_AvailabilityCallbacks
-	ccbs.context = unsafe.Pointer(^reflect.ValueOf(cbs).Pointer())
-	availabilityKeepLives[ccbs.context] = cbs
-	ccbs.onCameraAvailable = C.ACameraManager_AvailabilityCallback(C.cgoCameraAvailable)
-	ccbs.onCameraUnavailable = C.ACameraManager_AvailabilityCallback(C.cgoCameraUnavailable)
-	return Status(C.ACameraManager_registerAvailabilityCallback(manager.cptr(), &ccbs))
+	_, err := manager.AddAvailabilityCallback(cbs)
+	return err
+}
+
+// AvailabilityRegistration AddAvailabilityCallback 的注册，用于注销
+type AvailabilityRegistration struct {
+	manager *Manager
+	id      uintptr
 }
 
-var availabilityKeepLives = map[unsafe.Pointer]interface{}{}
+// AddAvailabilityCallback 同 RegisterAvailabilityCallback，返回的注册用于注销，
+// cbs 可以是任意类型(包括不可比较的)，同一个 cbs 可以注册多次
+func (manager *Manager) AddAvailabilityCallback(cbs AvailabilityCallbacks) (*AvailabilityRegistration, error) {
+	availabilityLock.Lock()
+	availabilitySeq++
+	id := availabilitySeq
+	availabilityKeepLives[id] = &availabilityListener{manager: manager, cbs: cbs}
+	availabilityLock.Unlock()
+	ret := Status(C.setAvailabilityCallback(manager.cptr(), C.uintptr_t(id), true))
+	if ret != nil {
+		availabilityLock.Lock()
+		delete(availabilityKeepLives, id)
+		availabilityLock.Unlock()
+		return nil, ret
+	}
+	return &AvailabilityRegistration{manager, id}, nil
+}
+
+// Unregister 注销，重复调用或 manager 已删除时返回 STATUS_ERROR_INVALID_PARAMETER
+func (r *AvailabilityRegistration) Unregister() error {
+	if unregisterAvailabilityContexts(r.manager, func(id uintptr, _ *availabilityListener) bool {
+		return id == r.id
+	}) == 0 {
+		return STATUS_ERROR_INVALID_PARAMETER
+	}
+	return nil
+}
+
+// availabilityListener 每次注册一个。
+// context 中保存不会重复的 id，注销后已在分发的回调找不到 listener，
+// 不会调用到之后的注册
+type availabilityListener struct {
+	manager *Manager
+	cbs     AvailabilityCallbacks
+}
+
+var (
+	availabilityLock      sync.Mutex
+	availabilityKeepLives = map[uintptr]*availabilityListener{}
+	availabilitySeq       uintptr
+)
+
+func availabilityListenerOf(id C.uintptr_t) AvailabilityCallbacks {
+	availabilityLock.Lock()
+	defer availabilityLock.Unlock()
+	if l, ok := availabilityKeepLives[uintptr(id)]; ok {
+		return l.cbs
+	}
+	return nil
+}
 
 //export cgoCameraAvailable
-func cgoCameraAvailable(context unsafe.Pointer, cameraId C.pcchar) {
-	cbs := availabilityKeepLives[context].(AvailabilityCallbacks)
-	cbs.OnCameraAvailable(C.GoString(cameraId))
+func cgoCameraAvailable(id C.uintptr_t, cameraId C.pcchar) {
+	if cbs := availabilityListenerOf(id); cbs != nil {
+		cbs.OnCameraAvailable(C.GoString(cameraId))
+	}
 }
 
 //export cgoCameraUnavailable
-func cgoCameraUnavailable(context unsafe.Pointer, cameraId C.pcchar) {
-	cbs := availabilityKeepLives[context].(AvailabilityCallbacks)
-	cbs.OnCameraUnavailable(C.GoString(cameraId))
+func cgoCameraUnavailable(id C.uintptr_t, cameraId C.pcchar) {
+	if cbs := availabilityListenerOf(id); cbs != nil {
+		cbs.OnCameraUnavailable(C.GoString(cameraId))
+	}
 }
 
 /**
@@ -252,20 +325,54 @@ func cgoCameraUnavailable(context unsafe.Pointer, cameraId C.pcchar) {
  */
 //camera_status_t ACameraManager_unregisterAvailabilityCallback(
 //        ACameraManager* manager, const ACameraManager_AvailabilityCallbacks* callback);
+//
+// cbs 的类型不可比较 (如含有 func 或 slice 的结构体) 时无法找到，
+// 此时使用 AddAvailabilityCallback 返回的注册注销
 func (manager *Manager) UnregisterAvailabilityCallback(cbs AvailabilityCallbacks) error {
-	context := unsafe.Pointer(^reflect.ValueOf(cbs).Pointer())
-	if _, ok := availabilityKeepLives[context]; ok {
-		delete(availabilityKeepLives, context)
-		if len(availabilityKeepLives) == 0 {
-			var ccbs C.ACameraManager_AvailabilityCallbacks
-			ccbs.context = context
-			ccbs.onCameraAvailable = C.ACameraManager_AvailabilityCallback(C.cgoCameraAvailable)
-			ccbs.onCameraUnavailable = C.ACameraManager_AvailabilityCallback(C.cgoCameraUnavailable)
-			return Status(C.ACameraManager_unregisterAvailabilityCallback(manager.cptr(), &ccbs))
+	t := reflect.TypeOf(cbs)
+	if t == nil || !t.Comparable() {
+		return STATUS_ERROR_INVALID_PARAMETER
+	}
+	if unregisterAvailabilityContexts(manager, func(_ uintptr, l *availabilityListener) bool {
+		return reflect.TypeOf(l.cbs) == t && sameCallbacks(l.cbs, cbs)
+	}) == 0 {
+		return STATUS_ERROR_INVALID_PARAMETER
+	}
+	return nil
+}
+
+// sameCallbacks 比较两个类型相同的回调，
+// 可比较的类型中含有不可比较的值 (如 interface 字段中的 slice) 时返回 false
+func sameCallbacks(a, b AvailabilityCallbacks) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
+
+// unregisterAvailabilityCallbacks 注销 manager 上所有的回调，返回注销的个数
+func unregisterAvailabilityCallbacks(manager *Manager) int {
+	return unregisterAvailabilityContexts(manager, func(uintptr, *availabilityListener) bool { return true })
+}
+
+// unregisterAvailabilityContexts 注销 manager 上 match 返回 true 的回调，返回注销的个数
+func unregisterAvailabilityContexts(manager *Manager, match func(uintptr, *availabilityListener) bool) int {
+	availabilityLock.Lock()
+	var ids []uintptr
+	for id, l := range availabilityKeepLives {
+		if l.manager == manager && match(id, l) {
+			ids = append(ids, id)
+			delete(availabilityKeepLives, id)
 		}
-		return nil
 	}
-	return STATUS_ERROR_INVALID_PARAMETER
+	availabilityLock.Unlock()
+
+	for _, id := range ids {
+		C.setAvailabilityCallback(manager.cptr(), C.uintptr_t(id), false)
+	}
+	return len(ids)
 }
 
 /**
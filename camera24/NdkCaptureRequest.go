@@ -215,7 +215,37 @@ func (request *CaptureRequest) GetAllTags() ([]uint32, error) {
 	var numEntries C.int32_t
 	var tags *C.uint32_t
 	ret := Status(C.ACaptureRequest_getAllTags(request.cptr(), &numEntries, &tags))
-	return (*[1 << 28]uint32)(unsafe.Pointer(&tags))[:numEntries], ret
+	if ret != nil {
+		return nil, ret
+	}
+	// tags 在 setEntry 之后失效，需复制
+	return append([]uint32{}, (*[1 << 28]uint32)(unsafe.Pointer(tags))[:numEntries]...), nil
+}
+
+// Tags 同 GetAllTags，返回 MetadataTag，实现 MetadataReader
+func (request *CaptureRequest) Tags() ([]MetadataTag, error) {
+	tags, err := request.GetAllTags()
+	if err != nil {
+		return nil, err
+	}
+	ts := make([]MetadataTag, len(tags))
+	for i, t := range tags {
+		ts[i] = MetadataTag(t)
+	}
+	return ts, nil
+}
+
+// ReadEntry 读取 tag 对应的值，实现 MetadataReader
+func (request *CaptureRequest) ReadEntry(tag MetadataTag) (*MetadataValue, error) {
+	entry, err := request.GetConstEntry(tag)
+	if err != nil {
+		return nil, &MetadataError{tag, err}
+	}
+	return entry.Value(), nil
+}
+
+func (request *CaptureRequest) Accessor() Accessor {
+	return Accessor{request}
 }
 
 /**
@@ -356,6 +386,33 @@ func (request *CaptureRequest) SetEntryRational(tag MetadataTag, data []Metadata
 	return Status(C.ACaptureRequest_setEntry_rational(request.cptr(), C.uint32_t(tag), C.uint32_t(len(data)), &cdata[0]))
 }
 
+// SetEntry 按 v.Data 的类型调用 SetEntryXXX
+func (request *CaptureRequest) SetEntry(v *MetadataValue) error {
+	if v.Count() == 0 {
+		return &MetadataError{v.Tag, STATUS_ERROR_INVALID_PARAMETER}
+	}
+	switch ds := v.Data.(type) {
+	case []uint8:
+		return request.SetEntryU8(v.Tag, ds)
+	case []int32:
+		return request.SetEntryI32(v.Tag, ds)
+	case []float32:
+		return request.SetEntryF32(v.Tag, ds)
+	case []int64:
+		return request.SetEntryI64(v.Tag, ds)
+	case []float64:
+		return request.SetEntryF64(v.Tag, ds)
+	case []Rational:
+		cdata := make([]C.ACameraMetadata_rational, len(ds))
+		for i, d := range ds {
+			cdata[i].numerator = C.int32_t(d.Numerator)
+			cdata[i].denominator = C.int32_t(d.Denominator)
+		}
+		return Status(C.ACaptureRequest_setEntry_rational(request.cptr(), C.uint32_t(v.Tag), C.uint32_t(len(cdata)), &cdata[0]))
+	}
+	return &MetadataError{v.Tag, STATUS_ERROR_INVALID_PARAMETER}
+}
+
 /**
  * Free a {@link ACaptureRequest} structure.
  *
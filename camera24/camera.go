@@ -0,0 +1,465 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	app "github.com/gooid/gooid/internal/ndk"
+)
+
+// CameraState Camera 的状态
+type CameraState int
+
+const (
+	CAMERA_CLOSED       CameraState = iota
+	CAMERA_OPENED                   // 设备已打开，没有可用的 session
+	CAMERA_READY                    // session 已就绪(空闲)
+	CAMERA_ACTIVE                   // session 正在处理请求
+	CAMERA_DISCONNECTED             // 设备断开，正在重新连接
+)
+
+func (s CameraState) String() string {
+	switch s {
+	case CAMERA_CLOSED:
+		return "CLOSED"
+	case CAMERA_OPENED:
+		return "OPENED"
+	case CAMERA_READY:
+		return "READY"
+	case CAMERA_ACTIVE:
+		return "ACTIVE"
+	case CAMERA_DISCONNECTED:
+		return "DISCONNECTED"
+	}
+	return fmt.Sprintf("UNKNOW_CAMERA_STATE%d", int(s))
+}
+
+// ErrCameraClosed Camera 已关闭
+var ErrCameraClosed = errors.New("camera: closed")
+
+// Camera 管理一个相机设备及其 session 的生命周期:
+// Open 同步打开设备，CreateSession 等待 session 就绪，
+// 设备断开后自动重新打开并以相同的输出重建 session，
+// Close 按 停止重复请求、关闭 session、释放输出、关闭设备 的顺序释放资源。
+type Camera struct {
+	id      string
+	manager *Manager
+	chars   *MetadataSnapshot
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu        sync.Mutex
+	changed   chan struct{} // 状态变化时关闭并重建
+	state     CameraState
+	err       error
+	device    *Device
+	session   *CaptureSession
+	container *CaptureSessionOutputContainer
+	outputs   []*CaptureSessionOutput
+	windows   []*app.Window
+	wg        sync.WaitGroup // 重连的 goroutine，Close 等待其结束后才删除 manager
+
+	// ReconnectTimeout 断开后重新连接的最长时间，为 0 时不重连
+	ReconnectTimeout time.Duration
+	// OnReconnected 重新连接并重建 session 后调用，可在此恢复重复请求
+	OnReconnected func(c *Camera)
+}
+
+// Open 打开 id 对应的相机，设备被占用时重试，直到成功、出错或 ctx 结束
+func Open(ctx context.Context, id string) (*Camera, error) {
+	mgr := ManagerCreate()
+	if mgr == nil {
+		return nil, STATUS_ERROR_CAMERA_SERVICE
+	}
+	metadata, err := mgr.GetCameraCharacteristics(id)
+	if err != nil {
+		mgr.Delete()
+		return nil, err
+	}
+	chars, err := metadata.Snapshot()
+	metadata.Free()
+	if err != nil {
+		mgr.Delete()
+		return nil, err
+	}
+
+	c := &Camera{
+		id:               id,
+		manager:          mgr,
+		chars:            chars,
+		changed:          make(chan struct{}),
+		ReconnectTimeout: 5 * time.Second,
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	if err = c.open(ctx); err != nil {
+		c.cancel()
+		mgr.Delete()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Camera) Id() string {
+	return c.id
+}
+
+// Characteristics 相机特性，在 Open 时读取
+func (c *Camera) Characteristics() Accessor {
+	return c.chars.Accessor()
+}
+
+func (c *Camera) State() CameraState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+// Err 导致相机不可用的错误
+func (c *Camera) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Device 当前的设备，重新连接后会改变
+func (c *Camera) Device() *Device {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.device
+}
+
+// Session 当前的 session，重新连接后会改变
+func (c *Camera) Session() *CaptureSession {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.session
+}
+
+// WaitState 等待进入状态 s
+func (c *Camera) WaitState(ctx context.Context, s CameraState) error {
+	return c.wait(ctx, func(state CameraState) bool { return state == s })
+}
+
+func (c *Camera) wait(ctx context.Context, cond func(CameraState) bool) error {
+	for {
+		c.mu.Lock()
+		state, err, changed := c.state, c.err, c.changed
+		c.mu.Unlock()
+		if cond(state) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if state == CAMERA_CLOSED {
+			return ErrCameraClosed
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// setState 必须在持有 c.mu 时调用
+func (c *Camera) setState(s CameraState) {
+	if c.state == s {
+		return
+	}
+	c.state = s
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// CreateSession 以 windows 为输出创建 session 并等待就绪，已有的 session 先被关闭。
+// windows 在 session 使用期间被 Acquire，调用者可以释放自己的引用。
+func (c *Camera) CreateSession(ctx context.Context, windows ...*app.Window) error {
+	return c.createSession(ctx, windows)
+}
+
+func (c *Camera) createSession(ctx context.Context, windows []*app.Window) error {
+	c.mu.Lock()
+	if c.state == CAMERA_CLOSED {
+		c.mu.Unlock()
+		return ErrCameraClosed
+	}
+	old, outputs, container := c.detachSession()
+	c.mu.Unlock()
+	freeSession(old, outputs, container)
+
+	container, err := CaptureSessionOutputContainerCreate()
+	if err != nil {
+		return err
+	}
+	outputs = make([]*CaptureSessionOutput, 0, len(windows))
+	for _, w := range windows {
+		w.Acquire()
+		var out *CaptureSessionOutput
+		out, err = CaptureSessionOutputCreate(w)
+		if err == nil {
+			outputs = append(outputs, out)
+			err = container.Add(out)
+		} else {
+			w.Release()
+		}
+		if err != nil {
+			freeSession(nil, outputs, container)
+			releaseWindows(windows[:len(outputs)])
+			return err
+		}
+	}
+
+	// 持有锁创建，使 OnReady 等回调能看到新的 session
+	c.mu.Lock()
+	if c.state == CAMERA_CLOSED || c.device == nil {
+		c.mu.Unlock()
+		freeSession(nil, outputs, container)
+		releaseWindows(windows)
+		return ErrCameraClosed
+	}
+	session, err := c.device.CreateCaptureSession(container, (*cameraCallbacks)(c))
+	if err != nil {
+		c.mu.Unlock()
+		freeSession(nil, outputs, container)
+		releaseWindows(windows)
+		return err
+	}
+	c.session, c.outputs, c.container = session, outputs, container
+	c.windows = append([]*app.Window{}, windows...)
+	c.mu.Unlock()
+
+	err = c.wait(ctx, func(s CameraState) bool { return s == CAMERA_READY || s == CAMERA_ACTIVE })
+	if err != nil {
+		c.CloseSession()
+	}
+	return err
+}
+
+// CloseSession 停止重复请求并关闭 session，设备保持打开
+func (c *Camera) CloseSession() {
+	c.mu.Lock()
+	session, outputs, container := c.detachSession()
+	windows := c.windows
+	c.windows = nil
+	c.mu.Unlock()
+	freeSession(session, outputs, container)
+	releaseWindows(windows)
+}
+
+// detachSession 取出当前 session 相关的对象，必须在持有 c.mu 时调用
+func (c *Camera) detachSession() (*CaptureSession, []*CaptureSessionOutput, *CaptureSessionOutputContainer) {
+	session, outputs, container := c.session, c.outputs, c.container
+	c.session, c.outputs, c.container = nil, nil, nil
+	if c.device != nil && (c.state == CAMERA_READY || c.state == CAMERA_ACTIVE) {
+		c.setState(CAMERA_OPENED)
+	}
+	return session, outputs, container
+}
+
+// freeSession 不能在持有 c.mu 时调用，关闭操作会等待相机回调线程
+func freeSession(session *CaptureSession, outputs []*CaptureSessionOutput, container *CaptureSessionOutputContainer) {
+	if session != nil {
+		session.StopRepeating()
+		session.AbortCaptures()
+		session.Close()
+	}
+	for _, out := range outputs {
+		if container != nil {
+			container.Remove(out)
+		}
+		out.Free()
+	}
+	if container != nil {
+		container.Free()
+	}
+}
+
+func releaseWindows(windows []*app.Window) {
+	for _, w := range windows {
+		w.Release()
+	}
+}
+
+// Close 关闭相机，释放所有资源
+func (c *Camera) Close() error {
+	// 先停止重复请求并等待 session 空闲
+	c.mu.Lock()
+	session, active := c.session, c.state == CAMERA_ACTIVE
+	c.mu.Unlock()
+	if session != nil && session.StopRepeating() == nil && active {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		c.WaitState(ctx, CAMERA_READY)
+		cancel()
+	}
+
+	c.cancel()
+	c.mu.Lock()
+	if c.state == CAMERA_CLOSED {
+		c.mu.Unlock()
+		return nil
+	}
+	session, outputs, container := c.detachSession()
+	windows, device := c.windows, c.device
+	c.windows, c.device = nil, nil
+	c.setState(CAMERA_CLOSED)
+	c.mu.Unlock()
+
+	freeSession(session, outputs, container)
+	releaseWindows(windows)
+	var err error
+	if device != nil {
+		err = device.Close()
+	}
+	// 重连中的 OpenCamera 还在使用 manager
+	c.wg.Wait()
+	c.manager.Delete()
+	return err
+}
+
+// open 打开设备，可以重试的错误在退避后重试
+func (c *Camera) open(ctx context.Context) error {
+	delay := 50 * time.Millisecond
+	for {
+		device, err := c.manager.OpenCamera(c.id, (*cameraCallbacks)(c))
+		if err == nil {
+			c.mu.Lock()
+			if c.ctx.Err() != nil {
+				c.mu.Unlock()
+				device.Close()
+				return ErrCameraClosed
+			}
+			c.device, c.err = device, nil
+			c.setState(CAMERA_OPENED)
+			c.mu.Unlock()
+			return nil
+		}
+		switch err {
+		case STATUS_ERROR_CAMERA_IN_USE, STATUS_ERROR_MAX_CAMERA_IN_USE,
+			STATUS_ERROR_CAMERA_DISCONNECTED, STATUS_ERROR_INVALID_OPERATION:
+		default:
+			return err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.ctx.Done():
+			return ErrCameraClosed
+		}
+		if delay < time.Second {
+			delay *= 2
+		}
+	}
+}
+
+// lost 设备断开或出错，err 为 nil 时尝试重新连接
+func (c *Camera) lost(device *Device, err error) {
+	c.mu.Lock()
+	if device != c.device || c.state == CAMERA_CLOSED {
+		c.mu.Unlock()
+		return
+	}
+	if err == nil && c.ReconnectTimeout <= 0 {
+		err = STATUS_ERROR_CAMERA_DISCONNECTED
+	}
+	c.err = err
+	c.setState(CAMERA_DISCONNECTED)
+	c.wg.Add(1)
+	c.mu.Unlock()
+
+	// 回调线程中不能关闭设备
+	go func() {
+		ok := c.reconnect(device, err == nil)
+		c.wg.Done()
+		// 在 wg 之外调用，OnReconnected 中可以 Close
+		if ok && c.OnReconnected != nil {
+			c.OnReconnected(c)
+		}
+	}()
+}
+
+// reconnect 重新打开设备并重建 session，成功时返回 true
+func (c *Camera) reconnect(failed *Device, retry bool) bool {
+	c.mu.Lock()
+	session, outputs, container := c.detachSession()
+	windows := c.windows
+	c.windows, c.device = nil, nil
+	c.mu.Unlock()
+	freeSession(session, outputs, container)
+	failed.Close()
+	if !retry {
+		releaseWindows(windows)
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.ReconnectTimeout)
+	defer cancel()
+	err := c.open(ctx)
+	if err == nil && len(windows) > 0 {
+		err = c.createSession(ctx, windows)
+	}
+	releaseWindows(windows)
+	if err != nil {
+		c.mu.Lock()
+		if c.state != CAMERA_CLOSED {
+			c.err = err
+			c.setState(CAMERA_DISCONNECTED)
+		}
+		c.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+// cameraCallbacks 实现 DeviceStateCallbacks 和 CaptureSessionStateCallbacks，
+// 避免这些方法出现在 Camera 上
+type cameraCallbacks Camera
+
+func (cb *cameraCallbacks) OnDisconnected(device *Device) {
+	(*Camera)(cb).lost(device, nil)
+}
+
+func (cb *cameraCallbacks) OnError(device *Device, code int) {
+	var err error
+	switch code {
+	case ERROR_CAMERA_DISABLED:
+		err = STATUS_ERROR_CAMERA_DISABLED
+	case ERROR_CAMERA_SERVICE:
+		err = STATUS_ERROR_CAMERA_SERVICE
+	}
+	(*Camera)(cb).lost(device, err)
+}
+
+func (cb *cameraCallbacks) sessionState(session *CaptureSession, s CameraState) {
+	c := (*Camera)(cb)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if session != c.session || c.state == CAMERA_CLOSED || c.state == CAMERA_DISCONNECTED {
+		return
+	}
+	if s == CAMERA_OPENED {
+		// 被新的 session 替换或设备出错，由 NDK 关闭
+		c.session = nil
+	}
+	c.setState(s)
+}
+
+func (cb *cameraCallbacks) OnClosed(session *CaptureSession) {
+	cb.sessionState(session, CAMERA_OPENED)
+}
+
+func (cb *cameraCallbacks) OnReady(session *CaptureSession) {
+	cb.sessionState(session, CAMERA_READY)
+}
+
+func (cb *cameraCallbacks) OnActive(session *CaptureSession) {
+	cb.sessionState(session, CAMERA_ACTIVE)
+}
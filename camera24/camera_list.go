@@ -0,0 +1,227 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// CameraInfo 摄像头的基本信息及当前是否可用
+type CameraInfo struct {
+	Id            string
+	Facing        LensFacing
+	Orientation   int // SENSOR_ORIENTATION
+	HardwareLevel HardwareLevel
+	Capabilities  []Capability
+	// Available 未被其它客户端占用。注册后由 NDK 回调更新，之前为 false
+	Available bool
+}
+
+// HasCapability 是否支持 c
+func (info *CameraInfo) HasCapability(c Capability) bool {
+	for _, v := range info.Capabilities {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNoCamera 没有符合条件的摄像头
+var ErrNoCamera = errors.New("camera: no matching camera")
+
+// cameraTracker 以 AvailabilityCallbacks 维护 Manager 的摄像头列表
+type cameraTracker struct {
+	manager *Manager
+
+	mu      sync.Mutex
+	cameras map[string]*CameraInfo
+	subs    map[int]func(CameraInfo)
+	nextSub int
+}
+
+var (
+	cameraTrackerLock sync.Mutex
+	cameraTrackers    = map[*Manager]*cameraTracker{}
+)
+
+// tracker 第一次使用时读取摄像头列表并注册可用性回调
+func (manager *Manager) tracker() (*cameraTracker, error) {
+	cameraTrackerLock.Lock()
+	defer cameraTrackerLock.Unlock()
+	if t, ok := cameraTrackers[manager]; ok {
+		return t, nil
+	}
+
+	ids, err := manager.GetCameraIdList()
+	if err != nil {
+		return nil, err
+	}
+	t := &cameraTracker{
+		manager: manager,
+		cameras: map[string]*CameraInfo{},
+		subs:    map[int]func(CameraInfo){},
+	}
+	for _, id := range ids {
+		if info, err := manager.cameraInfo(id); err == nil {
+			t.cameras[id] = info
+		}
+	}
+	if err = manager.RegisterAvailabilityCallback(t); err != nil {
+		return nil, err
+	}
+	cameraTrackers[manager] = t
+	return t, nil
+}
+
+// stopCameraTracker 在 Manager.Delete 时调用，回调由 unregisterAvailabilityCallbacks 注销
+func stopCameraTracker(manager *Manager) {
+	cameraTrackerLock.Lock()
+	delete(cameraTrackers, manager)
+	cameraTrackerLock.Unlock()
+}
+
+func (manager *Manager) cameraInfo(id string) (*CameraInfo, error) {
+	metadata, err := manager.GetCameraCharacteristics(id)
+	if err != nil {
+		return nil, err
+	}
+	defer metadata.Free()
+
+	chars := metadata.Accessor()
+	info := &CameraInfo{Id: id}
+	if info.Facing, err = chars.LensFacing(); err != nil {
+		return nil, err
+	}
+	orientation, _ := chars.SensorOrientation()
+	info.Orientation = int(orientation)
+	info.HardwareLevel, _ = chars.InfoSupportedHardwareLevel()
+	info.Capabilities, _ = chars.RequestAvailableCapabilities()
+	return info, nil
+}
+
+func (t *cameraTracker) OnCameraAvailable(id string) {
+	t.update(id, true)
+}
+
+func (t *cameraTracker) OnCameraUnavailable(id string) {
+	t.update(id, false)
+}
+
+func (t *cameraTracker) update(id string, available bool) {
+	t.mu.Lock()
+	info, ok := t.cameras[id]
+	t.mu.Unlock()
+	if !ok {
+		// 新接入的外置摄像头
+		var err error
+		if info, err = t.manager.cameraInfo(id); err != nil {
+			return
+		}
+	}
+
+	t.mu.Lock()
+	if old, ok := t.cameras[id]; ok {
+		info = old
+	} else {
+		t.cameras[id] = info
+	}
+	if ok && info.Available == available {
+		t.mu.Unlock()
+		return
+	}
+	info.Available = available
+	v := *info
+	subs := make([]func(CameraInfo), 0, len(t.subs))
+	for _, fn := range t.subs {
+		subs = append(subs, fn)
+	}
+	t.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(v)
+	}
+}
+
+func (t *cameraTracker) list() []CameraInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	infos := make([]CameraInfo, 0, len(t.cameras))
+	for _, info := range t.cameras {
+		infos = append(infos, *info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Id < infos[j].Id })
+	return infos
+}
+
+// Cameras 当前的摄像头列表，按 Id 排序。
+// 第一次调用时注册可用性回调，之后列表随之更新，直到 Manager.Delete
+func (manager *Manager) Cameras() ([]CameraInfo, error) {
+	t, err := manager.tracker()
+	if err != nil {
+		return nil, err
+	}
+	return t.list(), nil
+}
+
+// Subscribe 摄像头可用性改变或有新摄像头接入时调用 fn。
+// fn 在相机回调线程中执行，返回的函数用于取消订阅
+func (manager *Manager) Subscribe(fn func(CameraInfo)) (func(), error) {
+	t, err := manager.tracker()
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	id := t.nextSub
+	t.nextSub++
+	t.subs[id] = fn
+	t.mu.Unlock()
+	return func() {
+		t.mu.Lock()
+		delete(t.subs, id)
+		t.mu.Unlock()
+	}, nil
+}
+
+// FindCamera 返回第一个满足 match 的摄像头，优先选择可用的
+func (manager *Manager) FindCamera(match func(*CameraInfo) bool) (CameraInfo, error) {
+	infos, err := manager.Cameras()
+	if err != nil {
+		return CameraInfo{}, err
+	}
+	found := -1
+	for i := range infos {
+		if !match(&infos[i]) {
+			continue
+		}
+		if infos[i].Available {
+			return infos[i], nil
+		}
+		if found < 0 {
+			found = i
+		}
+	}
+	if found < 0 {
+		return CameraInfo{}, ErrNoCamera
+	}
+	return infos[found], nil
+}
+
+// SelectCamera 按朝向选择摄像头，并要求支持 caps 中的所有能力
+func (manager *Manager) SelectCamera(facing LensFacing, caps ...Capability) (CameraInfo, error) {
+	return manager.FindCamera(func(info *CameraInfo) bool {
+		if info.Facing != facing {
+			return false
+		}
+		for _, c := range caps {
+			if !info.HasCapability(c) {
+				return false
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,217 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"context"
+	"errors"
+	"image"
+	"sync"
+	"time"
+)
+
+type AfState = MetadataControl_af_state
+type AeState = MetadataControl_ae_state
+type AwbState = MetadataControl_awb_state
+type FlashState = MetadataFlash_state
+
+// Face 检测到的人脸，坐标基于 ActiveArraySize
+type Face struct {
+	Rect  image.Rectangle
+	Score int // [1, 100]
+	// 以下仅在 STATISTICS_FACE_DETECT_MODE_FULL 时有效
+	Id                       int
+	LeftEye, RightEye, Mouth image.Point
+}
+
+// CaptureResult 解码后的拍摄结果。
+// 结果中不存在的项保持零值。
+type CaptureResult struct {
+	// Index 在所属的 Capture/SetRepeatingRequestWithCallbacks 中的序号，从 1 开始
+	Index int64
+	// FrameNumber SYNC_FRAME_NUMBER，与 CaptureFailureInfo.FrameNumber 对应
+	FrameNumber int64
+	// Failure 失败时不为 nil，此时其它项都是零值
+	Failure *CaptureFailureInfo
+
+	Timestamp     time.Duration // SENSOR_TIMESTAMP
+	ExposureTime  time.Duration
+	FrameDuration time.Duration
+	Sensitivity   int // ISO
+
+	AfMode     AfMode
+	AfState    AfState
+	AeMode     AeMode
+	AeState    AeState
+	AwbMode    AwbMode
+	AwbState   AwbState
+	FlashState FlashState
+
+	LensFocusDistance float32 // 屈光度
+	Faces             []Face
+}
+
+// CaptureFailureInfo 失败的拍摄，见 CaptureFailure
+type CaptureFailureInfo struct {
+	FrameNumber      int64
+	Reason           int // CAPTURE_FAILURE_REASON_*
+	SequenceId       int
+	WasImageCaptured bool
+}
+
+// Failed 是否失败
+func (r *CaptureResult) Failed() bool {
+	return r.Failure != nil
+}
+
+// NewCaptureResult 从结果 metadata 中解码，r 只在此调用中使用
+func NewCaptureResult(r MetadataReader) *CaptureResult {
+	a := Accessor{r}
+	res := &CaptureResult{}
+	if v, err := a.SyncFrameNumber(); err == nil {
+		res.FrameNumber = int64(v)
+	}
+	if v, err := a.SensorTimestamp(); err == nil {
+		res.Timestamp = time.Duration(v)
+	}
+	if v, err := a.SensorExposureTime(); err == nil {
+		res.ExposureTime = time.Duration(v)
+	}
+	if v, err := a.SensorFrameDuration(); err == nil {
+		res.FrameDuration = time.Duration(v)
+	}
+	if v, err := a.SensorSensitivity(); err == nil {
+		res.Sensitivity = int(v)
+	}
+	res.AfMode, _ = a.ControlAfMode()
+	res.AfState, _ = a.ControlAfState()
+	res.AeMode, _ = a.ControlAeMode()
+	res.AeState, _ = a.ControlAeState()
+	res.AwbMode, _ = a.ControlAwbMode()
+	res.AwbState, _ = a.ControlAwbState()
+	res.FlashState, _ = a.FlashState()
+	res.LensFocusDistance, _ = a.LensFocusDistance()
+	res.Faces = decodeFaces(a)
+	return res
+}
+
+func decodeFaces(a Accessor) []Face {
+	rects, err := a.StatisticsFaceRectangles()
+	if err != nil {
+		return nil
+	}
+	faces := make([]Face, len(rects)/4)
+	scores, _ := a.StatisticsFaceScores()
+	ids, _ := a.StatisticsFaceIds()
+	landmarks, _ := a.StatisticsFaceLandmarks()
+	for i := range faces {
+		f := &faces[i]
+		// 头文件注释写的是 (left, top, width, height)，实际为 (left, top, right, bottom)
+		r := rects[i*4:]
+		f.Rect = image.Rect(int(r[0]), int(r[1]), int(r[2]), int(r[3]))
+		if i < len(scores) {
+			f.Score = int(scores[i])
+		}
+		f.Id = -1
+		if i < len(ids) {
+			f.Id = int(ids[i])
+		}
+		if len(landmarks) >= (i+1)*6 {
+			l := landmarks[i*6:]
+			f.LeftEye = image.Pt(int(l[0]), int(l[1]))
+			f.RightEye = image.Pt(int(l[2]), int(l[3]))
+			f.Mouth = image.Pt(int(l[4]), int(l[5]))
+		}
+	}
+	return faces
+}
+
+// AfLocked 对焦已结束(成功或失败)
+func (r *CaptureResult) AfLocked() bool {
+	return r.AfState == CONTROL_AF_STATE_FOCUSED_LOCKED ||
+		r.AfState == CONTROL_AF_STATE_NOT_FOCUSED_LOCKED
+}
+
+// AeConverged 曝光已收敛，FLASH_REQUIRED 也视为收敛
+func (r *CaptureResult) AeConverged() bool {
+	return r.AeState == CONTROL_AE_STATE_CONVERGED ||
+		r.AeState == CONTROL_AE_STATE_FLASH_REQUIRED ||
+		r.AeState == CONTROL_AE_STATE_LOCKED
+}
+
+// ErrSequenceEnded 序列已结束，不会再有结果
+var ErrSequenceEnded = errors.New("camera: capture sequence ended")
+
+// ResultStream 把拍摄结果转为 channel，便于顺序地编写对焦、测光等逻辑。
+// 它实现了 OnCaptureResult 和 OnCaptureSequenceEnd，
+// 可以作为 Capture 或 SetRepeatingRequestWithCallbacks 的 cb。
+//
+// channel 满时丢弃最旧的结果，不会阻塞相机线程；序列结束后 channel 被关闭。
+type ResultStream struct {
+	c      chan *CaptureResult
+	mu     sync.Mutex
+	closed bool
+}
+
+func NewResultStream(size int) *ResultStream {
+	if size < 1 {
+		size = 1
+	}
+	return &ResultStream{c: make(chan *CaptureResult, size)}
+}
+
+func (s *ResultStream) Results() <-chan *CaptureResult {
+	return s.c
+}
+
+func (s *ResultStream) OnCaptureResult(_ *CaptureSession, r *CaptureResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	for {
+		select {
+		case s.c <- r:
+			return
+		default:
+		}
+		select {
+		case <-s.c:
+		default:
+		}
+	}
+}
+
+func (s *ResultStream) OnCaptureSequenceEnd(_ *CaptureSession, _ int, _ bool) {
+	s.Close()
+}
+
+// Close 关闭 channel，之后的结果被丢弃
+func (s *ResultStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.c)
+	}
+}
+
+// WaitFor 等待第一个满足 cond 的结果，失败的结果也会传给 cond
+func (s *ResultStream) WaitFor(ctx context.Context, cond func(*CaptureResult) bool) (*CaptureResult, error) {
+	for {
+		select {
+		case r, ok := <-s.c:
+			if !ok {
+				return nil, ErrSequenceEnded
+			}
+			if cond(r) {
+				return r, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
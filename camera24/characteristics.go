@@ -0,0 +1,159 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"image"
+)
+
+// 常用枚举的简短名字
+type LensFacing = MetadataLens_facing
+type AfMode = MetadataControl_af_mode
+type AeMode = MetadataEnumAcameraControlAeMode
+type AwbMode = MetadataControl_awb_mode
+type Capability = MetadataRequest_available_capabilities
+type HardwareLevel = MetadataInfo_supported_hardware_level
+
+// StreamConfig SCALER_AVAILABLE_STREAM_CONFIGURATIONS 中的一项
+type StreamConfig struct {
+	Format PixelFormat
+	Size   image.Point
+	Input  bool
+}
+
+// StreamConfigurations 每 4 项为一组: format, width, height, input
+func (a Accessor) StreamConfigurations() ([]StreamConfig, error) {
+	ds, err := a.ScalerAvailableStreamConfigurations()
+	if err != nil {
+		return nil, err
+	}
+	cs := make([]StreamConfig, 0, len(ds)/4)
+	for i := 0; i+3 < len(ds); i += 4 {
+		cs = append(cs, StreamConfig{
+			Format: PixelFormat(ds[i]),
+			Size:   image.Pt(int(ds[i+1]), int(ds[i+2])),
+			Input:  ds[i+3] == SCALER_AVAILABLE_STREAM_CONFIGURATIONS_INPUT,
+		})
+	}
+	return cs, nil
+}
+
+// AeCompensationRange 曝光补偿范围，单位为 CONTROL_AE_COMPENSATION_STEP
+func (a Accessor) AeCompensationRange() (min, max int, err error) {
+	ds, err := a.ControlAeCompensationRange()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(ds) < 2 {
+		return 0, 0, &MetadataError{CONTROL_AE_COMPENSATION_RANGE, STATUS_ERROR_METADATA_NOT_FOUND}
+	}
+	return int(ds[0]), int(ds[1]), nil
+}
+
+// AvailableAfModes 支持的自动对焦模式
+func (a Accessor) AvailableAfModes() ([]AfMode, error) {
+	ds, err := a.ControlAfAvailableModes()
+	if err != nil {
+		return nil, err
+	}
+	ms := make([]AfMode, len(ds))
+	for i, d := range ds {
+		ms[i] = AfMode(d)
+	}
+	return ms, nil
+}
+
+// AvailableAeModes 支持的自动曝光模式
+func (a Accessor) AvailableAeModes() ([]AeMode, error) {
+	ds, err := a.ControlAeAvailableModes()
+	if err != nil {
+		return nil, err
+	}
+	ms := make([]AeMode, len(ds))
+	for i, d := range ds {
+		ms[i] = AeMode(d)
+	}
+	return ms, nil
+}
+
+// AvailableAwbModes 支持的白平衡模式
+func (a Accessor) AvailableAwbModes() ([]AwbMode, error) {
+	ds, err := a.ControlAwbAvailableModes()
+	if err != nil {
+		return nil, err
+	}
+	ms := make([]AwbMode, len(ds))
+	for i, d := range ds {
+		ms[i] = AwbMode(d)
+	}
+	return ms, nil
+}
+
+// HasCapability 是否支持 REQUEST_AVAILABLE_CAPABILITIES 中的某项
+func (a Accessor) HasCapability(c Capability) bool {
+	cs, err := a.RequestAvailableCapabilities()
+	if err != nil {
+		return false
+	}
+	for _, v := range cs {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveArraySize 传感器有效像素区域，区域、裁剪等坐标都以此为基准
+func (a Accessor) ActiveArraySize() (image.Rectangle, error) {
+	return a.rect(SENSOR_INFO_ACTIVE_ARRAY_SIZE)
+}
+
+// MaxDigitalZoom 最大数码变焦倍数
+func (a Accessor) MaxDigitalZoom() (float64, error) {
+	z, err := a.ScalerAvailableMaxDigitalZoom()
+	return float64(z), err
+}
+
+// MaxRegions 可设置的测光、白平衡和对焦区域个数
+func (a Accessor) MaxRegions() (ae, awb, af int, err error) {
+	ds, err := a.ControlMaxRegions()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if len(ds) < 3 {
+		return 0, 0, 0, &MetadataError{CONTROL_MAX_REGIONS, STATUS_ERROR_METADATA_NOT_FOUND}
+	}
+	return int(ds[0]), int(ds[1]), int(ds[2]), nil
+}
+
+// rect 读取 (left, top, width, height) 形式的区域
+func (a Accessor) rect(tag MetadataTag) (image.Rectangle, error) {
+	ds, err := a.i32s(tag)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	if len(ds) < 4 {
+		return image.Rectangle{}, &MetadataError{tag, STATUS_ERROR_METADATA_NOT_FOUND}
+	}
+	return image.Rect(int(ds[0]), int(ds[1]), int(ds[0]+ds[2]), int(ds[1]+ds[3])), nil
+}
+
+// JpegOrientationFor 根据设备当前的旋转角度(顺时针，0/90/180/270)
+// 计算 JPEG_ORIENTATION，使照片以设备当前方向正向显示
+func (a Accessor) JpegOrientationFor(deviceRotation int) (int, error) {
+	sensor, err := a.SensorOrientation()
+	if err != nil {
+		return 0, err
+	}
+	facing, err := a.LensFacing()
+	if err != nil {
+		return 0, err
+	}
+	deviceRotation = (deviceRotation + 45) / 90 * 90
+	if facing == LENS_FACING_FRONT {
+		deviceRotation = -deviceRotation
+	}
+	return ((int(sensor)+deviceRotation)%360 + 360) % 360, nil
+}
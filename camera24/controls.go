@@ -0,0 +1,52 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+// 常用控制参数的类型与取值，用于 RequestBuilder。
+// FLASH_MODE_* 等未在此列出的取值可直接使用 NdkCameraMetadataTags.go 中的常量。
+type AfTrigger = MetadataEnumAcameraControlAfTrigger
+type AePrecaptureTrigger = MetadataControl_ae_precapture_trigger
+type FlashMode = MetadataFlash_mode
+type ControlMode = MetadataControl_mode
+type CaptureIntent = MetadataControl_capture_intent
+
+const (
+	AF_MODE_OFF                AfMode = CONTROL_AF_MODE_OFF
+	AF_MODE_AUTO               AfMode = CONTROL_AF_MODE_AUTO
+	AF_MODE_MACRO              AfMode = CONTROL_AF_MODE_MACRO
+	AF_MODE_CONTINUOUS_VIDEO   AfMode = CONTROL_AF_MODE_CONTINUOUS_VIDEO
+	AF_MODE_CONTINUOUS_PICTURE AfMode = CONTROL_AF_MODE_CONTINUOUS_PICTURE
+	AF_MODE_EDOF               AfMode = CONTROL_AF_MODE_EDOF
+)
+
+const (
+	AE_MODE_OFF                  AeMode = CONTROL_AE_MODE_OFF
+	AE_MODE_ON                   AeMode = CONTROL_AE_MODE_ON
+	AE_MODE_ON_AUTO_FLASH        AeMode = CONTROL_AE_MODE_ON_AUTO_FLASH
+	AE_MODE_ON_ALWAYS_FLASH      AeMode = CONTROL_AE_MODE_ON_ALWAYS_FLASH
+	AE_MODE_ON_AUTO_FLASH_REDEYE AeMode = CONTROL_AE_MODE_ON_AUTO_FLASH_REDEYE
+)
+
+const (
+	AWB_MODE_OFF              AwbMode = CONTROL_AWB_MODE_OFF
+	AWB_MODE_AUTO             AwbMode = CONTROL_AWB_MODE_AUTO
+	AWB_MODE_INCANDESCENT     AwbMode = CONTROL_AWB_MODE_INCANDESCENT
+	AWB_MODE_FLUORESCENT      AwbMode = CONTROL_AWB_MODE_FLUORESCENT
+	AWB_MODE_WARM_FLUORESCENT AwbMode = CONTROL_AWB_MODE_WARM_FLUORESCENT
+	AWB_MODE_DAYLIGHT         AwbMode = CONTROL_AWB_MODE_DAYLIGHT
+	AWB_MODE_CLOUDY_DAYLIGHT  AwbMode = CONTROL_AWB_MODE_CLOUDY_DAYLIGHT
+	AWB_MODE_TWILIGHT         AwbMode = CONTROL_AWB_MODE_TWILIGHT
+	AWB_MODE_SHADE            AwbMode = CONTROL_AWB_MODE_SHADE
+)
+
+const (
+	AF_TRIGGER_IDLE   AfTrigger = CONTROL_AF_TRIGGER_IDLE
+	AF_TRIGGER_START  AfTrigger = CONTROL_AF_TRIGGER_START
+	AF_TRIGGER_CANCEL AfTrigger = CONTROL_AF_TRIGGER_CANCEL
+
+	AE_PRECAPTURE_TRIGGER_IDLE   AePrecaptureTrigger = CONTROL_AE_PRECAPTURE_TRIGGER_IDLE
+	AE_PRECAPTURE_TRIGGER_START  AePrecaptureTrigger = CONTROL_AE_PRECAPTURE_TRIGGER_START
+	AE_PRECAPTURE_TRIGGER_CANCEL AePrecaptureTrigger = CONTROL_AE_PRECAPTURE_TRIGGER_CANCEL
+)
@@ -0,0 +1,108 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"image"
+
+	app "github.com/gooid/gooid/internal/ndk"
+)
+
+// 与 github.com/gooid/gooid/camera 中的定义相同，
+// 取值与 AIMAGE_FORMAT_* 一致
+type PixelFormat = app.CameraPixelFormat
+type FpsRange = app.CameraFpsRange
+
+const (
+	PIXEL_FORMAT_UNKNOWN     = app.CAMERA_PIXEL_FORMAT_UNKNOWN
+	PIXEL_FORMAT_RGB_565     = app.CAMERA_PIXEL_FORMAT_RGB_565
+	PIXEL_FORMAT_NV16        = app.CAMERA_PIXEL_FORMAT_NV16
+	PIXEL_FORMAT_NV21        = app.CAMERA_PIXEL_FORMAT_NV21
+	PIXEL_FORMAT_YUY2        = app.CAMERA_PIXEL_FORMAT_YUY2
+	PIXEL_FORMAT_RAW_SENSOR  = app.CAMERA_PIXEL_FORMAT_RAW_SENSOR
+	PIXEL_FORMAT_PRIVATE     = app.CAMERA_PIXEL_FORMAT_PRIVATE
+	PIXEL_FORMAT_YUV_420_888 = app.CAMERA_PIXEL_FORMAT_YUV_420_888
+	PIXEL_FORMAT_RAW_PRIVATE = app.CAMERA_PIXEL_FORMAT_RAW_PRIVATE
+	PIXEL_FORMAT_RAW10       = app.CAMERA_PIXEL_FORMAT_RAW10
+	PIXEL_FORMAT_RAW12       = app.CAMERA_PIXEL_FORMAT_RAW12
+	PIXEL_FORMAT_JPEG        = app.CAMERA_PIXEL_FORMAT_JPEG
+	PIXEL_FORMAT_YV12        = app.CAMERA_PIXEL_FORMAT_YV12
+)
+
+// OutputFormats 所有可输出的格式
+func (a Accessor) OutputFormats() ([]PixelFormat, error) {
+	cs, err := a.StreamConfigurations()
+	if err != nil {
+		return nil, err
+	}
+	var fs []PixelFormat
+	ms := map[PixelFormat]bool{}
+	for _, c := range cs {
+		if !c.Input && !ms[c.Format] {
+			ms[c.Format] = true
+			fs = append(fs, c.Format)
+		}
+	}
+	return fs, nil
+}
+
+// OutputSizes 指定格式可输出的尺寸
+func (a Accessor) OutputSizes(format PixelFormat) ([]image.Point, error) {
+	cs, err := a.StreamConfigurations()
+	if err != nil {
+		return nil, err
+	}
+	var ps []image.Point
+	ms := map[image.Point]bool{}
+	for _, c := range cs {
+		if !c.Input && c.Format == format && !ms[c.Size] {
+			ms[c.Size] = true
+			ps = append(ps, c.Size)
+		}
+	}
+	return ps, nil
+}
+
+// FpsRanges 自动曝光支持的帧率范围 (CONTROL_AE_AVAILABLE_TARGET_FPS_RANGES)
+func (a Accessor) FpsRanges() ([]FpsRange, error) {
+	ds, err := a.ControlAeAvailableTargetFpsRanges()
+	if err != nil {
+		return nil, err
+	}
+	rs := make([]FpsRange, 0, len(ds)/2)
+	for i := 0; i+1 < len(ds); i += 2 {
+		rs = append(rs, FpsRange{Min: int(ds[i]), Max: int(ds[i+1])})
+	}
+	return rs, nil
+}
+
+// BestPreviewSize 在 format 可输出的尺寸中选择最接近 target 的，
+// 规则见 github.com/gooid/gooid/camera.BestPreviewSize
+func (a Accessor) BestPreviewSize(format PixelFormat, target image.Point, aspectTolerance float64) (image.Point, error) {
+	sizes, err := a.OutputSizes(format)
+	if err != nil {
+		return image.Point{}, err
+	}
+	if p, ok := app.BestCameraSize(sizes, target, aspectTolerance); ok {
+		return p, nil
+	}
+	return image.Point{}, STATUS_ERROR_METADATA_NOT_FOUND
+}
+
+func (metadata *Metadata) OutputFormats() ([]PixelFormat, error) {
+	return metadata.Accessor().OutputFormats()
+}
+
+func (metadata *Metadata) OutputSizes(format PixelFormat) ([]image.Point, error) {
+	return metadata.Accessor().OutputSizes(format)
+}
+
+func (metadata *Metadata) FpsRanges() ([]FpsRange, error) {
+	return metadata.Accessor().FpsRanges()
+}
+
+func (metadata *Metadata) BestPreviewSize(format PixelFormat, target image.Point, aspectTolerance float64) (image.Point, error) {
+	return metadata.Accessor().BestPreviewSize(format, target, aspectTolerance)
+}
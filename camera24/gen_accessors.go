@@ -0,0 +1,211 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build ignore
+// +build ignore
+
+// 根据 NdkCameraMetadataTags.go 生成 metadata_accessors.go:
+//
+//	go run gen_accessors.go
+//
+// 每个 tag 生成一个 Accessor 方法，tag 注释中的类型决定返回类型；
+// 每个枚举类型生成 String 方法；所有 tag 列在 metadataTags 中。
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	reEnumComment = regexp.MustCompile(`^// (ACAMERA_[A-Z0-9_]+)$`)
+	reEnumType    = regexp.MustCompile(`^type (Metadata\w+) int$`)
+	reEnumConst   = regexp.MustCompile(`^\t([A-Z0-9_]+)\s*= C\.ACAMERA_[A-Z0-9_]+`)
+	reTag         = regexp.MustCompile(`^\t([A-Z0-9_]+) MetadataTag = C\.ACAMERA_[A-Z0-9_]+ // (\w+)(\[[^\]]*\])?( \(acamera_metadata_enum_android_(\w+)_t\))?`)
+)
+
+type enum struct {
+	typ    string
+	consts []string
+}
+
+type tag struct {
+	name  string
+	base  string
+	shape string
+	enum  *enum
+}
+
+// byte, int32 ... 对应的 Go 类型和 Accessor 中的读取方法
+var baseTypes = map[string][3]string{
+	"byte":     {"uint8", "u8", "u8s"},
+	"int32":    {"int32", "i32", "i32s"},
+	"float":    {"float32", "f32", "f32s"},
+	"int64":    {"int64", "i64", "i64s"},
+	"double":   {"float64", "f64", "f64s"},
+	"rational": {"Rational", "rational", "rationals"},
+}
+
+func main() {
+	src, err := os.Open("NdkCameraMetadataTags.go")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer src.Close()
+
+	enums := map[string]*enum{}
+	var enumList []*enum
+	var tags []tag
+
+	var comment string
+	var cur *enum
+	scanner := bufio.NewScanner(src)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := reEnumComment.FindStringSubmatch(line); m != nil {
+			comment = m[1]
+			continue
+		}
+		if m := reEnumType.FindStringSubmatch(line); m != nil && comment != "" {
+			cur = &enum{typ: m[1]}
+			enums[comment] = cur
+			enumList = append(enumList, cur)
+			comment = ""
+			continue
+		}
+		if cur != nil {
+			if line == ")" {
+				cur = nil
+			} else if m := reEnumConst.FindStringSubmatch(line); m != nil {
+				cur.consts = append(cur.consts, m[1])
+			}
+			continue
+		}
+		if m := reTag.FindStringSubmatch(line); m != nil {
+			t := tag{name: m[1], base: m[2], shape: m[3]}
+			if m[5] != "" {
+				t.enum = enums["ACAMERA_"+strings.ToUpper(m[5])]
+			}
+			tags = append(tags, t)
+		}
+		comment = ""
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	// 枚举定义在 tag 之后，重新关联
+	for i := range tags {
+		if tags[i].enum == nil {
+			tags[i].enum = enums["ACAMERA_"+tags[i].name]
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "// Code generated by gen_accessors.go from NdkCameraMetadataTags.go; DO NOT EDIT.")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, "package camera")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, `import "fmt"`)
+
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, "// metadataTags 所有已知的 tag")
+	fmt.Fprintln(&buf, "var metadataTags = []MetadataTag{")
+	for _, t := range tags {
+		fmt.Fprintf(&buf, "\t%s,\n", t.name)
+	}
+	fmt.Fprintln(&buf, "}")
+
+	for _, t := range tags {
+		writeAccessor(&buf, t)
+	}
+	for _, e := range enumList {
+		writeString(&buf, e)
+	}
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("metadata_accessors.go", out, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func camelCase(s string) string {
+	var b strings.Builder
+	for _, p := range strings.Split(s, "_") {
+		if p == "" {
+			continue
+		}
+		b.WriteString(p[:1])
+		b.WriteString(strings.ToLower(p[1:]))
+	}
+	return b.String()
+}
+
+func writeAccessor(w *bytes.Buffer, t tag) {
+	bt, ok := baseTypes[t.base]
+	if !ok {
+		log.Fatalf("%s: unknown type %s", t.name, t.base)
+	}
+	method := camelCase(t.name)
+	fmt.Fprintf(w, "\n// %s %s (%s%s)\n", method, t.name, t.base, t.shape)
+
+	switch {
+	case t.shape == "" && t.enum != nil:
+		fmt.Fprintf(w, "func (a Accessor) %s() (%s, error) {\n", method, t.enum.typ)
+		fmt.Fprintf(w, "\tv, err := a.%s(%s)\n", bt[1], t.name)
+		fmt.Fprintf(w, "\treturn %s(v), err\n}\n", t.enum.typ)
+
+	case t.shape == "":
+		fmt.Fprintf(w, "func (a Accessor) %s() (%s, error) {\n", method, bt[0])
+		fmt.Fprintf(w, "\treturn a.%s(%s)\n}\n", bt[1], t.name)
+
+	case t.enum != nil && !strings.Contains(t.shape, "*"):
+		fmt.Fprintf(w, "func (a Accessor) %s() ([]%s, error) {\n", method, t.enum.typ)
+		fmt.Fprintf(w, "\tvs, err := a.%s(%s)\n", bt[2], t.name)
+		fmt.Fprintf(w, "\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+		fmt.Fprintf(w, "\tes := make([]%s, len(vs))\n", t.enum.typ)
+		fmt.Fprintf(w, "\tfor i, v := range vs {\n\t\tes[i] = %s(v)\n\t}\n", t.enum.typ)
+		fmt.Fprintf(w, "\treturn es, nil\n}\n")
+
+	default:
+		fmt.Fprintf(w, "func (a Accessor) %s() ([]%s, error) {\n", method, bt[0])
+		fmt.Fprintf(w, "\treturn a.%s(%s)\n}\n", bt[2], t.name)
+	}
+}
+
+func writeString(w *bytes.Buffer, e *enum) {
+	prefix := commonPrefix(e.consts)
+	fmt.Fprintf(w, "\nfunc (e %s) String() string {\n\tswitch e {\n", e.typ)
+	for _, c := range e.consts {
+		fmt.Fprintf(w, "\tcase %s:\n\t\treturn %q\n", c, c)
+	}
+	fmt.Fprintf(w, "\tdefault:\n\t\treturn fmt.Sprintf(\"UNKNOW_%s%%d\", int(e))\n\t}\n}\n", prefix)
+}
+
+// commonPrefix 常量名的公共前缀，截止到最后一个 '_'
+func commonPrefix(names []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	p := names[0]
+	for _, n := range names[1:] {
+		for !strings.HasPrefix(n, p) {
+			p = p[:len(p)-1]
+		}
+	}
+	if i := strings.LastIndex(p, "_"); i >= 0 {
+		return p[:i+1]
+	}
+	return ""
+}
@@ -0,0 +1,1830 @@
+// Code generated by gen_accessors.go from NdkCameraMetadataTags.go; DO NOT EDIT.
+
+package camera
+
+import "fmt"
+
+// metadataTags 所有已知的 tag
+var metadataTags = []MetadataTag{
+	COLOR_CORRECTION_MODE,
+	COLOR_CORRECTION_TRANSFORM,
+	COLOR_CORRECTION_GAINS,
+	COLOR_CORRECTION_ABERRATION_MODE,
+	COLOR_CORRECTION_AVAILABLE_ABERRATION_MODES,
+	CONTROL_AE_ANTIBANDING_MODE,
+	CONTROL_AE_EXPOSURE_COMPENSATION,
+	CONTROL_AE_LOCK,
+	CONTROL_AE_MODE,
+	CONTROL_AE_REGIONS,
+	CONTROL_AE_TARGET_FPS_RANGE,
+	CONTROL_AE_PRECAPTURE_TRIGGER,
+	CONTROL_AF_MODE,
+	CONTROL_AF_REGIONS,
+	CONTROL_AF_TRIGGER,
+	CONTROL_AWB_LOCK,
+	CONTROL_AWB_MODE,
+	CONTROL_AWB_REGIONS,
+	CONTROL_CAPTURE_INTENT,
+	CONTROL_EFFECT_MODE,
+	CONTROL_MODE,
+	CONTROL_SCENE_MODE,
+	CONTROL_VIDEO_STABILIZATION_MODE,
+	CONTROL_AE_AVAILABLE_ANTIBANDING_MODES,
+	CONTROL_AE_AVAILABLE_MODES,
+	CONTROL_AE_AVAILABLE_TARGET_FPS_RANGES,
+	CONTROL_AE_COMPENSATION_RANGE,
+	CONTROL_AE_COMPENSATION_STEP,
+	CONTROL_AF_AVAILABLE_MODES,
+	CONTROL_AVAILABLE_EFFECTS,
+	CONTROL_AVAILABLE_SCENE_MODES,
+	CONTROL_AVAILABLE_VIDEO_STABILIZATION_MODES,
+	CONTROL_AWB_AVAILABLE_MODES,
+	CONTROL_MAX_REGIONS,
+	CONTROL_AE_STATE,
+	CONTROL_AF_STATE,
+	CONTROL_AWB_STATE,
+	CONTROL_AE_LOCK_AVAILABLE,
+	CONTROL_AWB_LOCK_AVAILABLE,
+	CONTROL_AVAILABLE_MODES,
+	CONTROL_POST_RAW_SENSITIVITY_BOOST_RANGE,
+	CONTROL_POST_RAW_SENSITIVITY_BOOST,
+	CONTROL_ENABLE_ZSL,
+	EDGE_MODE,
+	EDGE_AVAILABLE_EDGE_MODES,
+	FLASH_MODE,
+	FLASH_STATE,
+	FLASH_INFO_AVAILABLE,
+	HOT_PIXEL_MODE,
+	HOT_PIXEL_AVAILABLE_HOT_PIXEL_MODES,
+	JPEG_GPS_COORDINATES,
+	JPEG_GPS_PROCESSING_METHOD,
+	JPEG_GPS_TIMESTAMP,
+	JPEG_ORIENTATION,
+	JPEG_QUALITY,
+	JPEG_THUMBNAIL_QUALITY,
+	JPEG_THUMBNAIL_SIZE,
+	JPEG_AVAILABLE_THUMBNAIL_SIZES,
+	LENS_APERTURE,
+	LENS_FILTER_DENSITY,
+	LENS_FOCAL_LENGTH,
+	LENS_FOCUS_DISTANCE,
+	LENS_OPTICAL_STABILIZATION_MODE,
+	LENS_FACING,
+	LENS_POSE_ROTATION,
+	LENS_POSE_TRANSLATION,
+	LENS_FOCUS_RANGE,
+	LENS_STATE,
+	LENS_INTRINSIC_CALIBRATION,
+	LENS_RADIAL_DISTORTION,
+	LENS_INFO_AVAILABLE_APERTURES,
+	LENS_INFO_AVAILABLE_FILTER_DENSITIES,
+	LENS_INFO_AVAILABLE_FOCAL_LENGTHS,
+	LENS_INFO_AVAILABLE_OPTICAL_STABILIZATION,
+	LENS_INFO_HYPERFOCAL_DISTANCE,
+	LENS_INFO_MINIMUM_FOCUS_DISTANCE,
+	LENS_INFO_SHADING_MAP_SIZE,
+	LENS_INFO_FOCUS_DISTANCE_CALIBRATION,
+	NOISE_REDUCTION_MODE,
+	NOISE_REDUCTION_AVAILABLE_NOISE_REDUCTION_MODES,
+	REQUEST_MAX_NUM_OUTPUT_STREAMS,
+	REQUEST_PIPELINE_DEPTH,
+	REQUEST_PIPELINE_MAX_DEPTH,
+	REQUEST_PARTIAL_RESULT_COUNT,
+	REQUEST_AVAILABLE_CAPABILITIES,
+	REQUEST_AVAILABLE_REQUEST_KEYS,
+	REQUEST_AVAILABLE_RESULT_KEYS,
+	REQUEST_AVAILABLE_CHARACTERISTICS_KEYS,
+	SCALER_CROP_REGION,
+	SCALER_AVAILABLE_MAX_DIGITAL_ZOOM,
+	SCALER_AVAILABLE_STREAM_CONFIGURATIONS,
+	SCALER_AVAILABLE_MIN_FRAME_DURATIONS,
+	SCALER_AVAILABLE_STALL_DURATIONS,
+	SCALER_CROPPING_TYPE,
+	SENSOR_EXPOSURE_TIME,
+	SENSOR_FRAME_DURATION,
+	SENSOR_SENSITIVITY,
+	SENSOR_REFERENCE_ILLUMINANT1,
+	SENSOR_REFERENCE_ILLUMINANT2,
+	SENSOR_CALIBRATION_TRANSFORM1,
+	SENSOR_CALIBRATION_TRANSFORM2,
+	SENSOR_COLOR_TRANSFORM1,
+	SENSOR_COLOR_TRANSFORM2,
+	SENSOR_FORWARD_MATRIX1,
+	SENSOR_FORWARD_MATRIX2,
+	SENSOR_BLACK_LEVEL_PATTERN,
+	SENSOR_MAX_ANALOG_SENSITIVITY,
+	SENSOR_ORIENTATION,
+	SENSOR_TIMESTAMP,
+	SENSOR_NEUTRAL_COLOR_POINT,
+	SENSOR_NOISE_PROFILE,
+	SENSOR_GREEN_SPLIT,
+	SENSOR_TEST_PATTERN_DATA,
+	SENSOR_TEST_PATTERN_MODE,
+	SENSOR_AVAILABLE_TEST_PATTERN_MODES,
+	SENSOR_ROLLING_SHUTTER_SKEW,
+	SENSOR_OPTICAL_BLACK_REGIONS,
+	SENSOR_DYNAMIC_BLACK_LEVEL,
+	SENSOR_DYNAMIC_WHITE_LEVEL,
+	SENSOR_INFO_ACTIVE_ARRAY_SIZE,
+	SENSOR_INFO_SENSITIVITY_RANGE,
+	SENSOR_INFO_COLOR_FILTER_ARRANGEMENT,
+	SENSOR_INFO_EXPOSURE_TIME_RANGE,
+	SENSOR_INFO_MAX_FRAME_DURATION,
+	SENSOR_INFO_PHYSICAL_SIZE,
+	SENSOR_INFO_PIXEL_ARRAY_SIZE,
+	SENSOR_INFO_WHITE_LEVEL,
+	SENSOR_INFO_TIMESTAMP_SOURCE,
+	SENSOR_INFO_LENS_SHADING_APPLIED,
+	SENSOR_INFO_PRE_CORRECTION_ACTIVE_ARRAY_SIZE,
+	SHADING_MODE,
+	SHADING_AVAILABLE_MODES,
+	STATISTICS_FACE_DETECT_MODE,
+	STATISTICS_HOT_PIXEL_MAP_MODE,
+	STATISTICS_FACE_IDS,
+	STATISTICS_FACE_LANDMARKS,
+	STATISTICS_FACE_RECTANGLES,
+	STATISTICS_FACE_SCORES,
+	STATISTICS_LENS_SHADING_MAP,
+	STATISTICS_SCENE_FLICKER,
+	STATISTICS_HOT_PIXEL_MAP,
+	STATISTICS_LENS_SHADING_MAP_MODE,
+	STATISTICS_INFO_AVAILABLE_FACE_DETECT_MODES,
+	STATISTICS_INFO_MAX_FACE_COUNT,
+	STATISTICS_INFO_AVAILABLE_HOT_PIXEL_MAP_MODES,
+	STATISTICS_INFO_AVAILABLE_LENS_SHADING_MAP_MODES,
+	TONEMAP_CURVE_BLUE,
+	TONEMAP_CURVE_GREEN,
+	TONEMAP_CURVE_RED,
+	TONEMAP_MODE,
+	TONEMAP_MAX_CURVE_POINTS,
+	TONEMAP_AVAILABLE_TONE_MAP_MODES,
+	TONEMAP_GAMMA,
+	TONEMAP_PRESET_CURVE,
+	INFO_SUPPORTED_HARDWARE_LEVEL,
+	BLACK_LEVEL_LOCK,
+	SYNC_FRAME_NUMBER,
+	SYNC_MAX_LATENCY,
+	DEPTH_AVAILABLE_DEPTH_STREAM_CONFIGURATIONS,
+	DEPTH_AVAILABLE_DEPTH_MIN_FRAME_DURATIONS,
+	DEPTH_AVAILABLE_DEPTH_STALL_DURATIONS,
+	DEPTH_DEPTH_IS_EXCLUSIVE,
+}
+
+// ColorCorrectionMode COLOR_CORRECTION_MODE (byte)
+func (a Accessor) ColorCorrectionMode() (MetadataColor_correction_mode, error) {
+	v, err := a.u8(COLOR_CORRECTION_MODE)
+	return MetadataColor_correction_mode(v), err
+}
+
+// ColorCorrectionTransform COLOR_CORRECTION_TRANSFORM (rational[3*3])
+func (a Accessor) ColorCorrectionTransform() ([]Rational, error) {
+	return a.rationals(COLOR_CORRECTION_TRANSFORM)
+}
+
+// ColorCorrectionGains COLOR_CORRECTION_GAINS (float[4])
+func (a Accessor) ColorCorrectionGains() ([]float32, error) {
+	return a.f32s(COLOR_CORRECTION_GAINS)
+}
+
+// ColorCorrectionAberrationMode COLOR_CORRECTION_ABERRATION_MODE (byte)
+func (a Accessor) ColorCorrectionAberrationMode() (MetadataColor_correction_aberration_mode, error) {
+	v, err := a.u8(COLOR_CORRECTION_ABERRATION_MODE)
+	return MetadataColor_correction_aberration_mode(v), err
+}
+
+// ColorCorrectionAvailableAberrationModes COLOR_CORRECTION_AVAILABLE_ABERRATION_MODES (byte[n])
+func (a Accessor) ColorCorrectionAvailableAberrationModes() ([]uint8, error) {
+	return a.u8s(COLOR_CORRECTION_AVAILABLE_ABERRATION_MODES)
+}
+
+// ControlAeAntibandingMode CONTROL_AE_ANTIBANDING_MODE (byte)
+func (a Accessor) ControlAeAntibandingMode() (MetadataControl_ae_antibanding_mode, error) {
+	v, err := a.u8(CONTROL_AE_ANTIBANDING_MODE)
+	return MetadataControl_ae_antibanding_mode(v), err
+}
+
+// ControlAeExposureCompensation CONTROL_AE_EXPOSURE_COMPENSATION (int32)
+func (a Accessor) ControlAeExposureCompensation() (int32, error) {
+	return a.i32(CONTROL_AE_EXPOSURE_COMPENSATION)
+}
+
+// ControlAeLock CONTROL_AE_LOCK (byte)
+func (a Accessor) ControlAeLock() (MetadataControl_ae_lock, error) {
+	v, err := a.u8(CONTROL_AE_LOCK)
+	return MetadataControl_ae_lock(v), err
+}
+
+// ControlAeMode CONTROL_AE_MODE (byte)
+func (a Accessor) ControlAeMode() (MetadataEnumAcameraControlAeMode, error) {
+	v, err := a.u8(CONTROL_AE_MODE)
+	return MetadataEnumAcameraControlAeMode(v), err
+}
+
+// ControlAeRegions CONTROL_AE_REGIONS (int32[5*area_count])
+func (a Accessor) ControlAeRegions() ([]int32, error) {
+	return a.i32s(CONTROL_AE_REGIONS)
+}
+
+// ControlAeTargetFpsRange CONTROL_AE_TARGET_FPS_RANGE (int32[2])
+func (a Accessor) ControlAeTargetFpsRange() ([]int32, error) {
+	return a.i32s(CONTROL_AE_TARGET_FPS_RANGE)
+}
+
+// ControlAePrecaptureTrigger CONTROL_AE_PRECAPTURE_TRIGGER (byte)
+func (a Accessor) ControlAePrecaptureTrigger() (MetadataControl_ae_precapture_trigger, error) {
+	v, err := a.u8(CONTROL_AE_PRECAPTURE_TRIGGER)
+	return MetadataControl_ae_precapture_trigger(v), err
+}
+
+// ControlAfMode CONTROL_AF_MODE (byte)
+func (a Accessor) ControlAfMode() (MetadataControl_af_mode, error) {
+	v, err := a.u8(CONTROL_AF_MODE)
+	return MetadataControl_af_mode(v), err
+}
+
+// ControlAfRegions CONTROL_AF_REGIONS (int32[5*area_count])
+func (a Accessor) ControlAfRegions() ([]int32, error) {
+	return a.i32s(CONTROL_AF_REGIONS)
+}
+
+// ControlAfTrigger CONTROL_AF_TRIGGER (byte)
+func (a Accessor) ControlAfTrigger() (MetadataEnumAcameraControlAfTrigger, error) {
+	v, err := a.u8(CONTROL_AF_TRIGGER)
+	return MetadataEnumAcameraControlAfTrigger(v), err
+}
+
+// ControlAwbLock CONTROL_AWB_LOCK (byte)
+func (a Accessor) ControlAwbLock() (MetadataControl_awb_lock, error) {
+	v, err := a.u8(CONTROL_AWB_LOCK)
+	return MetadataControl_awb_lock(v), err
+}
+
+// ControlAwbMode CONTROL_AWB_MODE (byte)
+func (a Accessor) ControlAwbMode() (MetadataControl_awb_mode, error) {
+	v, err := a.u8(CONTROL_AWB_MODE)
+	return MetadataControl_awb_mode(v), err
+}
+
+// ControlAwbRegions CONTROL_AWB_REGIONS (int32[5*area_count])
+func (a Accessor) ControlAwbRegions() ([]int32, error) {
+	return a.i32s(CONTROL_AWB_REGIONS)
+}
+
+// ControlCaptureIntent CONTROL_CAPTURE_INTENT (byte)
+func (a Accessor) ControlCaptureIntent() (MetadataControl_capture_intent, error) {
+	v, err := a.u8(CONTROL_CAPTURE_INTENT)
+	return MetadataControl_capture_intent(v), err
+}
+
+// ControlEffectMode CONTROL_EFFECT_MODE (byte)
+func (a Accessor) ControlEffectMode() (MetadataControl_effect_mode, error) {
+	v, err := a.u8(CONTROL_EFFECT_MODE)
+	return MetadataControl_effect_mode(v), err
+}
+
+// ControlMode CONTROL_MODE (byte)
+func (a Accessor) ControlMode() (MetadataControl_mode, error) {
+	v, err := a.u8(CONTROL_MODE)
+	return MetadataControl_mode(v), err
+}
+
+// ControlSceneMode CONTROL_SCENE_MODE (byte)
+func (a Accessor) ControlSceneMode() (MetadataControl_scene_mode, error) {
+	v, err := a.u8(CONTROL_SCENE_MODE)
+	return MetadataControl_scene_mode(v), err
+}
+
+// ControlVideoStabilizationMode CONTROL_VIDEO_STABILIZATION_MODE (byte)
+func (a Accessor) ControlVideoStabilizationMode() (MetadataControl_video_stabilization_mode, error) {
+	v, err := a.u8(CONTROL_VIDEO_STABILIZATION_MODE)
+	return MetadataControl_video_stabilization_mode(v), err
+}
+
+// ControlAeAvailableAntibandingModes CONTROL_AE_AVAILABLE_ANTIBANDING_MODES (byte[n])
+func (a Accessor) ControlAeAvailableAntibandingModes() ([]uint8, error) {
+	return a.u8s(CONTROL_AE_AVAILABLE_ANTIBANDING_MODES)
+}
+
+// ControlAeAvailableModes CONTROL_AE_AVAILABLE_MODES (byte[n])
+func (a Accessor) ControlAeAvailableModes() ([]uint8, error) {
+	return a.u8s(CONTROL_AE_AVAILABLE_MODES)
+}
+
+// ControlAeAvailableTargetFpsRanges CONTROL_AE_AVAILABLE_TARGET_FPS_RANGES (int32[2*n])
+func (a Accessor) ControlAeAvailableTargetFpsRanges() ([]int32, error) {
+	return a.i32s(CONTROL_AE_AVAILABLE_TARGET_FPS_RANGES)
+}
+
+// ControlAeCompensationRange CONTROL_AE_COMPENSATION_RANGE (int32[2])
+func (a Accessor) ControlAeCompensationRange() ([]int32, error) {
+	return a.i32s(CONTROL_AE_COMPENSATION_RANGE)
+}
+
+// ControlAeCompensationStep CONTROL_AE_COMPENSATION_STEP (rational)
+func (a Accessor) ControlAeCompensationStep() (Rational, error) {
+	return a.rational(CONTROL_AE_COMPENSATION_STEP)
+}
+
+// ControlAfAvailableModes CONTROL_AF_AVAILABLE_MODES (byte[n])
+func (a Accessor) ControlAfAvailableModes() ([]uint8, error) {
+	return a.u8s(CONTROL_AF_AVAILABLE_MODES)
+}
+
+// ControlAvailableEffects CONTROL_AVAILABLE_EFFECTS (byte[n])
+func (a Accessor) ControlAvailableEffects() ([]uint8, error) {
+	return a.u8s(CONTROL_AVAILABLE_EFFECTS)
+}
+
+// ControlAvailableSceneModes CONTROL_AVAILABLE_SCENE_MODES (byte[n])
+func (a Accessor) ControlAvailableSceneModes() ([]uint8, error) {
+	return a.u8s(CONTROL_AVAILABLE_SCENE_MODES)
+}
+
+// ControlAvailableVideoStabilizationModes CONTROL_AVAILABLE_VIDEO_STABILIZATION_MODES (byte[n])
+func (a Accessor) ControlAvailableVideoStabilizationModes() ([]uint8, error) {
+	return a.u8s(CONTROL_AVAILABLE_VIDEO_STABILIZATION_MODES)
+}
+
+// ControlAwbAvailableModes CONTROL_AWB_AVAILABLE_MODES (byte[n])
+func (a Accessor) ControlAwbAvailableModes() ([]uint8, error) {
+	return a.u8s(CONTROL_AWB_AVAILABLE_MODES)
+}
+
+// ControlMaxRegions CONTROL_MAX_REGIONS (int32[3])
+func (a Accessor) ControlMaxRegions() ([]int32, error) {
+	return a.i32s(CONTROL_MAX_REGIONS)
+}
+
+// ControlAeState CONTROL_AE_STATE (byte)
+func (a Accessor) ControlAeState() (MetadataControl_ae_state, error) {
+	v, err := a.u8(CONTROL_AE_STATE)
+	return MetadataControl_ae_state(v), err
+}
+
+// ControlAfState CONTROL_AF_STATE (byte)
+func (a Accessor) ControlAfState() (MetadataControl_af_state, error) {
+	v, err := a.u8(CONTROL_AF_STATE)
+	return MetadataControl_af_state(v), err
+}
+
+// ControlAwbState CONTROL_AWB_STATE (byte)
+func (a Accessor) ControlAwbState() (MetadataControl_awb_state, error) {
+	v, err := a.u8(CONTROL_AWB_STATE)
+	return MetadataControl_awb_state(v), err
+}
+
+// ControlAeLockAvailable CONTROL_AE_LOCK_AVAILABLE (byte)
+func (a Accessor) ControlAeLockAvailable() (MetadataControl_ae_lock_available, error) {
+	v, err := a.u8(CONTROL_AE_LOCK_AVAILABLE)
+	return MetadataControl_ae_lock_available(v), err
+}
+
+// ControlAwbLockAvailable CONTROL_AWB_LOCK_AVAILABLE (byte)
+func (a Accessor) ControlAwbLockAvailable() (MetadataControl_awb_lock_available, error) {
+	v, err := a.u8(CONTROL_AWB_LOCK_AVAILABLE)
+	return MetadataControl_awb_lock_available(v), err
+}
+
+// ControlAvailableModes CONTROL_AVAILABLE_MODES (byte[n])
+func (a Accessor) ControlAvailableModes() ([]uint8, error) {
+	return a.u8s(CONTROL_AVAILABLE_MODES)
+}
+
+// ControlPostRawSensitivityBoostRange CONTROL_POST_RAW_SENSITIVITY_BOOST_RANGE (int32[2])
+func (a Accessor) ControlPostRawSensitivityBoostRange() ([]int32, error) {
+	return a.i32s(CONTROL_POST_RAW_SENSITIVITY_BOOST_RANGE)
+}
+
+// ControlPostRawSensitivityBoost CONTROL_POST_RAW_SENSITIVITY_BOOST (int32)
+func (a Accessor) ControlPostRawSensitivityBoost() (int32, error) {
+	return a.i32(CONTROL_POST_RAW_SENSITIVITY_BOOST)
+}
+
+// ControlEnableZsl CONTROL_ENABLE_ZSL (byte)
+func (a Accessor) ControlEnableZsl() (MetadataControl_enable_zsl, error) {
+	v, err := a.u8(CONTROL_ENABLE_ZSL)
+	return MetadataControl_enable_zsl(v), err
+}
+
+// EdgeMode EDGE_MODE (byte)
+func (a Accessor) EdgeMode() (MetadataEdge_mode, error) {
+	v, err := a.u8(EDGE_MODE)
+	return MetadataEdge_mode(v), err
+}
+
+// EdgeAvailableEdgeModes EDGE_AVAILABLE_EDGE_MODES (byte[n])
+func (a Accessor) EdgeAvailableEdgeModes() ([]uint8, error) {
+	return a.u8s(EDGE_AVAILABLE_EDGE_MODES)
+}
+
+// FlashMode FLASH_MODE (byte)
+func (a Accessor) FlashMode() (MetadataFlash_mode, error) {
+	v, err := a.u8(FLASH_MODE)
+	return MetadataFlash_mode(v), err
+}
+
+// FlashState FLASH_STATE (byte)
+func (a Accessor) FlashState() (MetadataFlash_state, error) {
+	v, err := a.u8(FLASH_STATE)
+	return MetadataFlash_state(v), err
+}
+
+// FlashInfoAvailable FLASH_INFO_AVAILABLE (byte)
+func (a Accessor) FlashInfoAvailable() (MetadataFlash_info_available, error) {
+	v, err := a.u8(FLASH_INFO_AVAILABLE)
+	return MetadataFlash_info_available(v), err
+}
+
+// HotPixelMode HOT_PIXEL_MODE (byte)
+func (a Accessor) HotPixelMode() (MetadataHot_pixel_mode, error) {
+	v, err := a.u8(HOT_PIXEL_MODE)
+	return MetadataHot_pixel_mode(v), err
+}
+
+// HotPixelAvailableHotPixelModes HOT_PIXEL_AVAILABLE_HOT_PIXEL_MODES (byte[n])
+func (a Accessor) HotPixelAvailableHotPixelModes() ([]uint8, error) {
+	return a.u8s(HOT_PIXEL_AVAILABLE_HOT_PIXEL_MODES)
+}
+
+// JpegGpsCoordinates JPEG_GPS_COORDINATES (double[3])
+func (a Accessor) JpegGpsCoordinates() ([]float64, error) {
+	return a.f64s(JPEG_GPS_COORDINATES)
+}
+
+// JpegGpsProcessingMethod JPEG_GPS_PROCESSING_METHOD (byte)
+func (a Accessor) JpegGpsProcessingMethod() (uint8, error) {
+	return a.u8(JPEG_GPS_PROCESSING_METHOD)
+}
+
+// JpegGpsTimestamp JPEG_GPS_TIMESTAMP (int64)
+func (a Accessor) JpegGpsTimestamp() (int64, error) {
+	return a.i64(JPEG_GPS_TIMESTAMP)
+}
+
+// JpegOrientation JPEG_ORIENTATION (int32)
+func (a Accessor) JpegOrientation() (int32, error) {
+	return a.i32(JPEG_ORIENTATION)
+}
+
+// JpegQuality JPEG_QUALITY (byte)
+func (a Accessor) JpegQuality() (uint8, error) {
+	return a.u8(JPEG_QUALITY)
+}
+
+// JpegThumbnailQuality JPEG_THUMBNAIL_QUALITY (byte)
+func (a Accessor) JpegThumbnailQuality() (uint8, error) {
+	return a.u8(JPEG_THUMBNAIL_QUALITY)
+}
+
+// JpegThumbnailSize JPEG_THUMBNAIL_SIZE (int32[2])
+func (a Accessor) JpegThumbnailSize() ([]int32, error) {
+	return a.i32s(JPEG_THUMBNAIL_SIZE)
+}
+
+// JpegAvailableThumbnailSizes JPEG_AVAILABLE_THUMBNAIL_SIZES (int32[2*n])
+func (a Accessor) JpegAvailableThumbnailSizes() ([]int32, error) {
+	return a.i32s(JPEG_AVAILABLE_THUMBNAIL_SIZES)
+}
+
+// LensAperture LENS_APERTURE (float)
+func (a Accessor) LensAperture() (float32, error) {
+	return a.f32(LENS_APERTURE)
+}
+
+// LensFilterDensity LENS_FILTER_DENSITY (float)
+func (a Accessor) LensFilterDensity() (float32, error) {
+	return a.f32(LENS_FILTER_DENSITY)
+}
+
+// LensFocalLength LENS_FOCAL_LENGTH (float)
+func (a Accessor) LensFocalLength() (float32, error) {
+	return a.f32(LENS_FOCAL_LENGTH)
+}
+
+// LensFocusDistance LENS_FOCUS_DISTANCE (float)
+func (a Accessor) LensFocusDistance() (float32, error) {
+	return a.f32(LENS_FOCUS_DISTANCE)
+}
+
+// LensOpticalStabilizationMode LENS_OPTICAL_STABILIZATION_MODE (byte)
+func (a Accessor) LensOpticalStabilizationMode() (MetadataLens_optical_stabilization_mode, error) {
+	v, err := a.u8(LENS_OPTICAL_STABILIZATION_MODE)
+	return MetadataLens_optical_stabilization_mode(v), err
+}
+
+// LensFacing LENS_FACING (byte)
+func (a Accessor) LensFacing() (MetadataLens_facing, error) {
+	v, err := a.u8(LENS_FACING)
+	return MetadataLens_facing(v), err
+}
+
+// LensPoseRotation LENS_POSE_ROTATION (float[4])
+func (a Accessor) LensPoseRotation() ([]float32, error) {
+	return a.f32s(LENS_POSE_ROTATION)
+}
+
+// LensPoseTranslation LENS_POSE_TRANSLATION (float[3])
+func (a Accessor) LensPoseTranslation() ([]float32, error) {
+	return a.f32s(LENS_POSE_TRANSLATION)
+}
+
+// LensFocusRange LENS_FOCUS_RANGE (float[2])
+func (a Accessor) LensFocusRange() ([]float32, error) {
+	return a.f32s(LENS_FOCUS_RANGE)
+}
+
+// LensState LENS_STATE (byte)
+func (a Accessor) LensState() (MetadataLens_state, error) {
+	v, err := a.u8(LENS_STATE)
+	return MetadataLens_state(v), err
+}
+
+// LensIntrinsicCalibration LENS_INTRINSIC_CALIBRATION (float[5])
+func (a Accessor) LensIntrinsicCalibration() ([]float32, error) {
+	return a.f32s(LENS_INTRINSIC_CALIBRATION)
+}
+
+// LensRadialDistortion LENS_RADIAL_DISTORTION (float[6])
+func (a Accessor) LensRadialDistortion() ([]float32, error) {
+	return a.f32s(LENS_RADIAL_DISTORTION)
+}
+
+// LensInfoAvailableApertures LENS_INFO_AVAILABLE_APERTURES (float[n])
+func (a Accessor) LensInfoAvailableApertures() ([]float32, error) {
+	return a.f32s(LENS_INFO_AVAILABLE_APERTURES)
+}
+
+// LensInfoAvailableFilterDensities LENS_INFO_AVAILABLE_FILTER_DENSITIES (float[n])
+func (a Accessor) LensInfoAvailableFilterDensities() ([]float32, error) {
+	return a.f32s(LENS_INFO_AVAILABLE_FILTER_DENSITIES)
+}
+
+// LensInfoAvailableFocalLengths LENS_INFO_AVAILABLE_FOCAL_LENGTHS (float[n])
+func (a Accessor) LensInfoAvailableFocalLengths() ([]float32, error) {
+	return a.f32s(LENS_INFO_AVAILABLE_FOCAL_LENGTHS)
+}
+
+// LensInfoAvailableOpticalStabilization LENS_INFO_AVAILABLE_OPTICAL_STABILIZATION (byte[n])
+func (a Accessor) LensInfoAvailableOpticalStabilization() ([]uint8, error) {
+	return a.u8s(LENS_INFO_AVAILABLE_OPTICAL_STABILIZATION)
+}
+
+// LensInfoHyperfocalDistance LENS_INFO_HYPERFOCAL_DISTANCE (float)
+func (a Accessor) LensInfoHyperfocalDistance() (float32, error) {
+	return a.f32(LENS_INFO_HYPERFOCAL_DISTANCE)
+}
+
+// LensInfoMinimumFocusDistance LENS_INFO_MINIMUM_FOCUS_DISTANCE (float)
+func (a Accessor) LensInfoMinimumFocusDistance() (float32, error) {
+	return a.f32(LENS_INFO_MINIMUM_FOCUS_DISTANCE)
+}
+
+// LensInfoShadingMapSize LENS_INFO_SHADING_MAP_SIZE (int32[2])
+func (a Accessor) LensInfoShadingMapSize() ([]int32, error) {
+	return a.i32s(LENS_INFO_SHADING_MAP_SIZE)
+}
+
+// LensInfoFocusDistanceCalibration LENS_INFO_FOCUS_DISTANCE_CALIBRATION (byte)
+func (a Accessor) LensInfoFocusDistanceCalibration() (MetadataLens_info_focus_distance_calibration, error) {
+	v, err := a.u8(LENS_INFO_FOCUS_DISTANCE_CALIBRATION)
+	return MetadataLens_info_focus_distance_calibration(v), err
+}
+
+// NoiseReductionMode NOISE_REDUCTION_MODE (byte)
+func (a Accessor) NoiseReductionMode() (MetadataNoise_reduction_mode, error) {
+	v, err := a.u8(NOISE_REDUCTION_MODE)
+	return MetadataNoise_reduction_mode(v), err
+}
+
+// NoiseReductionAvailableNoiseReductionModes NOISE_REDUCTION_AVAILABLE_NOISE_REDUCTION_MODES (byte[n])
+func (a Accessor) NoiseReductionAvailableNoiseReductionModes() ([]uint8, error) {
+	return a.u8s(NOISE_REDUCTION_AVAILABLE_NOISE_REDUCTION_MODES)
+}
+
+// RequestMaxNumOutputStreams REQUEST_MAX_NUM_OUTPUT_STREAMS (int32[3])
+func (a Accessor) RequestMaxNumOutputStreams() ([]int32, error) {
+	return a.i32s(REQUEST_MAX_NUM_OUTPUT_STREAMS)
+}
+
+// RequestPipelineDepth REQUEST_PIPELINE_DEPTH (byte)
+func (a Accessor) RequestPipelineDepth() (uint8, error) {
+	return a.u8(REQUEST_PIPELINE_DEPTH)
+}
+
+// RequestPipelineMaxDepth REQUEST_PIPELINE_MAX_DEPTH (byte)
+func (a Accessor) RequestPipelineMaxDepth() (uint8, error) {
+	return a.u8(REQUEST_PIPELINE_MAX_DEPTH)
+}
+
+// RequestPartialResultCount REQUEST_PARTIAL_RESULT_COUNT (int32)
+func (a Accessor) RequestPartialResultCount() (int32, error) {
+	return a.i32(REQUEST_PARTIAL_RESULT_COUNT)
+}
+
+// RequestAvailableCapabilities REQUEST_AVAILABLE_CAPABILITIES (byte[n])
+func (a Accessor) RequestAvailableCapabilities() ([]MetadataRequest_available_capabilities, error) {
+	vs, err := a.u8s(REQUEST_AVAILABLE_CAPABILITIES)
+	if err != nil {
+		return nil, err
+	}
+	es := make([]MetadataRequest_available_capabilities, len(vs))
+	for i, v := range vs {
+		es[i] = MetadataRequest_available_capabilities(v)
+	}
+	return es, nil
+}
+
+// RequestAvailableRequestKeys REQUEST_AVAILABLE_REQUEST_KEYS (int32[n])
+func (a Accessor) RequestAvailableRequestKeys() ([]int32, error) {
+	return a.i32s(REQUEST_AVAILABLE_REQUEST_KEYS)
+}
+
+// RequestAvailableResultKeys REQUEST_AVAILABLE_RESULT_KEYS (int32[n])
+func (a Accessor) RequestAvailableResultKeys() ([]int32, error) {
+	return a.i32s(REQUEST_AVAILABLE_RESULT_KEYS)
+}
+
+// RequestAvailableCharacteristicsKeys REQUEST_AVAILABLE_CHARACTERISTICS_KEYS (int32[n])
+func (a Accessor) RequestAvailableCharacteristicsKeys() ([]int32, error) {
+	return a.i32s(REQUEST_AVAILABLE_CHARACTERISTICS_KEYS)
+}
+
+// ScalerCropRegion SCALER_CROP_REGION (int32[4])
+func (a Accessor) ScalerCropRegion() ([]int32, error) {
+	return a.i32s(SCALER_CROP_REGION)
+}
+
+// ScalerAvailableMaxDigitalZoom SCALER_AVAILABLE_MAX_DIGITAL_ZOOM (float)
+func (a Accessor) ScalerAvailableMaxDigitalZoom() (float32, error) {
+	return a.f32(SCALER_AVAILABLE_MAX_DIGITAL_ZOOM)
+}
+
+// ScalerAvailableStreamConfigurations SCALER_AVAILABLE_STREAM_CONFIGURATIONS (int32[n*4])
+func (a Accessor) ScalerAvailableStreamConfigurations() ([]int32, error) {
+	return a.i32s(SCALER_AVAILABLE_STREAM_CONFIGURATIONS)
+}
+
+// ScalerAvailableMinFrameDurations SCALER_AVAILABLE_MIN_FRAME_DURATIONS (int64[4*n])
+func (a Accessor) ScalerAvailableMinFrameDurations() ([]int64, error) {
+	return a.i64s(SCALER_AVAILABLE_MIN_FRAME_DURATIONS)
+}
+
+// ScalerAvailableStallDurations SCALER_AVAILABLE_STALL_DURATIONS (int64[4*n])
+func (a Accessor) ScalerAvailableStallDurations() ([]int64, error) {
+	return a.i64s(SCALER_AVAILABLE_STALL_DURATIONS)
+}
+
+// ScalerCroppingType SCALER_CROPPING_TYPE (byte)
+func (a Accessor) ScalerCroppingType() (MetadataScaler_cropping_type, error) {
+	v, err := a.u8(SCALER_CROPPING_TYPE)
+	return MetadataScaler_cropping_type(v), err
+}
+
+// SensorExposureTime SENSOR_EXPOSURE_TIME (int64)
+func (a Accessor) SensorExposureTime() (int64, error) {
+	return a.i64(SENSOR_EXPOSURE_TIME)
+}
+
+// SensorFrameDuration SENSOR_FRAME_DURATION (int64)
+func (a Accessor) SensorFrameDuration() (int64, error) {
+	return a.i64(SENSOR_FRAME_DURATION)
+}
+
+// SensorSensitivity SENSOR_SENSITIVITY (int32)
+func (a Accessor) SensorSensitivity() (int32, error) {
+	return a.i32(SENSOR_SENSITIVITY)
+}
+
+// SensorReferenceIlluminant1 SENSOR_REFERENCE_ILLUMINANT1 (byte)
+func (a Accessor) SensorReferenceIlluminant1() (MetadataSensor_reference_illuminant1, error) {
+	v, err := a.u8(SENSOR_REFERENCE_ILLUMINANT1)
+	return MetadataSensor_reference_illuminant1(v), err
+}
+
+// SensorReferenceIlluminant2 SENSOR_REFERENCE_ILLUMINANT2 (byte)
+func (a Accessor) SensorReferenceIlluminant2() (uint8, error) {
+	return a.u8(SENSOR_REFERENCE_ILLUMINANT2)
+}
+
+// SensorCalibrationTransform1 SENSOR_CALIBRATION_TRANSFORM1 (rational[3*3])
+func (a Accessor) SensorCalibrationTransform1() ([]Rational, error) {
+	return a.rationals(SENSOR_CALIBRATION_TRANSFORM1)
+}
+
+// SensorCalibrationTransform2 SENSOR_CALIBRATION_TRANSFORM2 (rational[3*3])
+func (a Accessor) SensorCalibrationTransform2() ([]Rational, error) {
+	return a.rationals(SENSOR_CALIBRATION_TRANSFORM2)
+}
+
+// SensorColorTransform1 SENSOR_COLOR_TRANSFORM1 (rational[3*3])
+func (a Accessor) SensorColorTransform1() ([]Rational, error) {
+	return a.rationals(SENSOR_COLOR_TRANSFORM1)
+}
+
+// SensorColorTransform2 SENSOR_COLOR_TRANSFORM2 (rational[3*3])
+func (a Accessor) SensorColorTransform2() ([]Rational, error) {
+	return a.rationals(SENSOR_COLOR_TRANSFORM2)
+}
+
+// SensorForwardMatrix1 SENSOR_FORWARD_MATRIX1 (rational[3*3])
+func (a Accessor) SensorForwardMatrix1() ([]Rational, error) {
+	return a.rationals(SENSOR_FORWARD_MATRIX1)
+}
+
+// SensorForwardMatrix2 SENSOR_FORWARD_MATRIX2 (rational[3*3])
+func (a Accessor) SensorForwardMatrix2() ([]Rational, error) {
+	return a.rationals(SENSOR_FORWARD_MATRIX2)
+}
+
+// SensorBlackLevelPattern SENSOR_BLACK_LEVEL_PATTERN (int32[4])
+func (a Accessor) SensorBlackLevelPattern() ([]int32, error) {
+	return a.i32s(SENSOR_BLACK_LEVEL_PATTERN)
+}
+
+// SensorMaxAnalogSensitivity SENSOR_MAX_ANALOG_SENSITIVITY (int32)
+func (a Accessor) SensorMaxAnalogSensitivity() (int32, error) {
+	return a.i32(SENSOR_MAX_ANALOG_SENSITIVITY)
+}
+
+// SensorOrientation SENSOR_ORIENTATION (int32)
+func (a Accessor) SensorOrientation() (int32, error) {
+	return a.i32(SENSOR_ORIENTATION)
+}
+
+// SensorTimestamp SENSOR_TIMESTAMP (int64)
+func (a Accessor) SensorTimestamp() (int64, error) {
+	return a.i64(SENSOR_TIMESTAMP)
+}
+
+// SensorNeutralColorPoint SENSOR_NEUTRAL_COLOR_POINT (rational[3])
+func (a Accessor) SensorNeutralColorPoint() ([]Rational, error) {
+	return a.rationals(SENSOR_NEUTRAL_COLOR_POINT)
+}
+
+// SensorNoiseProfile SENSOR_NOISE_PROFILE (double[2*CFA Channels])
+func (a Accessor) SensorNoiseProfile() ([]float64, error) {
+	return a.f64s(SENSOR_NOISE_PROFILE)
+}
+
+// SensorGreenSplit SENSOR_GREEN_SPLIT (float)
+func (a Accessor) SensorGreenSplit() (float32, error) {
+	return a.f32(SENSOR_GREEN_SPLIT)
+}
+
+// SensorTestPatternData SENSOR_TEST_PATTERN_DATA (int32[4])
+func (a Accessor) SensorTestPatternData() ([]int32, error) {
+	return a.i32s(SENSOR_TEST_PATTERN_DATA)
+}
+
+// SensorTestPatternMode SENSOR_TEST_PATTERN_MODE (int32)
+func (a Accessor) SensorTestPatternMode() (MetadataSensor_test_pattern_mode, error) {
+	v, err := a.i32(SENSOR_TEST_PATTERN_MODE)
+	return MetadataSensor_test_pattern_mode(v), err
+}
+
+// SensorAvailableTestPatternModes SENSOR_AVAILABLE_TEST_PATTERN_MODES (int32[n])
+func (a Accessor) SensorAvailableTestPatternModes() ([]int32, error) {
+	return a.i32s(SENSOR_AVAILABLE_TEST_PATTERN_MODES)
+}
+
+// SensorRollingShutterSkew SENSOR_ROLLING_SHUTTER_SKEW (int64)
+func (a Accessor) SensorRollingShutterSkew() (int64, error) {
+	return a.i64(SENSOR_ROLLING_SHUTTER_SKEW)
+}
+
+// SensorOpticalBlackRegions SENSOR_OPTICAL_BLACK_REGIONS (int32[4*num_regions])
+func (a Accessor) SensorOpticalBlackRegions() ([]int32, error) {
+	return a.i32s(SENSOR_OPTICAL_BLACK_REGIONS)
+}
+
+// SensorDynamicBlackLevel SENSOR_DYNAMIC_BLACK_LEVEL (float[4])
+func (a Accessor) SensorDynamicBlackLevel() ([]float32, error) {
+	return a.f32s(SENSOR_DYNAMIC_BLACK_LEVEL)
+}
+
+// SensorDynamicWhiteLevel SENSOR_DYNAMIC_WHITE_LEVEL (int32)
+func (a Accessor) SensorDynamicWhiteLevel() (int32, error) {
+	return a.i32(SENSOR_DYNAMIC_WHITE_LEVEL)
+}
+
+// SensorInfoActiveArraySize SENSOR_INFO_ACTIVE_ARRAY_SIZE (int32[4])
+func (a Accessor) SensorInfoActiveArraySize() ([]int32, error) {
+	return a.i32s(SENSOR_INFO_ACTIVE_ARRAY_SIZE)
+}
+
+// SensorInfoSensitivityRange SENSOR_INFO_SENSITIVITY_RANGE (int32[2])
+func (a Accessor) SensorInfoSensitivityRange() ([]int32, error) {
+	return a.i32s(SENSOR_INFO_SENSITIVITY_RANGE)
+}
+
+// SensorInfoColorFilterArrangement SENSOR_INFO_COLOR_FILTER_ARRANGEMENT (byte)
+func (a Accessor) SensorInfoColorFilterArrangement() (MetadataSensor_info_color_filter_arrangement, error) {
+	v, err := a.u8(SENSOR_INFO_COLOR_FILTER_ARRANGEMENT)
+	return MetadataSensor_info_color_filter_arrangement(v), err
+}
+
+// SensorInfoExposureTimeRange SENSOR_INFO_EXPOSURE_TIME_RANGE (int64[2])
+func (a Accessor) SensorInfoExposureTimeRange() ([]int64, error) {
+	return a.i64s(SENSOR_INFO_EXPOSURE_TIME_RANGE)
+}
+
+// SensorInfoMaxFrameDuration SENSOR_INFO_MAX_FRAME_DURATION (int64)
+func (a Accessor) SensorInfoMaxFrameDuration() (int64, error) {
+	return a.i64(SENSOR_INFO_MAX_FRAME_DURATION)
+}
+
+// SensorInfoPhysicalSize SENSOR_INFO_PHYSICAL_SIZE (float[2])
+func (a Accessor) SensorInfoPhysicalSize() ([]float32, error) {
+	return a.f32s(SENSOR_INFO_PHYSICAL_SIZE)
+}
+
+// SensorInfoPixelArraySize SENSOR_INFO_PIXEL_ARRAY_SIZE (int32[2])
+func (a Accessor) SensorInfoPixelArraySize() ([]int32, error) {
+	return a.i32s(SENSOR_INFO_PIXEL_ARRAY_SIZE)
+}
+
+// SensorInfoWhiteLevel SENSOR_INFO_WHITE_LEVEL (int32)
+func (a Accessor) SensorInfoWhiteLevel() (int32, error) {
+	return a.i32(SENSOR_INFO_WHITE_LEVEL)
+}
+
+// SensorInfoTimestampSource SENSOR_INFO_TIMESTAMP_SOURCE (byte)
+func (a Accessor) SensorInfoTimestampSource() (MetadataSensor_info_timestamp_source, error) {
+	v, err := a.u8(SENSOR_INFO_TIMESTAMP_SOURCE)
+	return MetadataSensor_info_timestamp_source(v), err
+}
+
+// SensorInfoLensShadingApplied SENSOR_INFO_LENS_SHADING_APPLIED (byte)
+func (a Accessor) SensorInfoLensShadingApplied() (MetadataSensor_info_lens_shading_applied, error) {
+	v, err := a.u8(SENSOR_INFO_LENS_SHADING_APPLIED)
+	return MetadataSensor_info_lens_shading_applied(v), err
+}
+
+// SensorInfoPreCorrectionActiveArraySize SENSOR_INFO_PRE_CORRECTION_ACTIVE_ARRAY_SIZE (int32[4])
+func (a Accessor) SensorInfoPreCorrectionActiveArraySize() ([]int32, error) {
+	return a.i32s(SENSOR_INFO_PRE_CORRECTION_ACTIVE_ARRAY_SIZE)
+}
+
+// ShadingMode SHADING_MODE (byte)
+func (a Accessor) ShadingMode() (MetadataShading_mode, error) {
+	v, err := a.u8(SHADING_MODE)
+	return MetadataShading_mode(v), err
+}
+
+// ShadingAvailableModes SHADING_AVAILABLE_MODES (byte[n])
+func (a Accessor) ShadingAvailableModes() ([]uint8, error) {
+	return a.u8s(SHADING_AVAILABLE_MODES)
+}
+
+// StatisticsFaceDetectMode STATISTICS_FACE_DETECT_MODE (byte)
+func (a Accessor) StatisticsFaceDetectMode() (MetadataStatistics_face_detect_mode, error) {
+	v, err := a.u8(STATISTICS_FACE_DETECT_MODE)
+	return MetadataStatistics_face_detect_mode(v), err
+}
+
+// StatisticsHotPixelMapMode STATISTICS_HOT_PIXEL_MAP_MODE (byte)
+func (a Accessor) StatisticsHotPixelMapMode() (MetadataStatistics_hot_pixel_map_mode, error) {
+	v, err := a.u8(STATISTICS_HOT_PIXEL_MAP_MODE)
+	return MetadataStatistics_hot_pixel_map_mode(v), err
+}
+
+// StatisticsFaceIds STATISTICS_FACE_IDS (int32[n])
+func (a Accessor) StatisticsFaceIds() ([]int32, error) {
+	return a.i32s(STATISTICS_FACE_IDS)
+}
+
+// StatisticsFaceLandmarks STATISTICS_FACE_LANDMARKS (int32[n*6])
+func (a Accessor) StatisticsFaceLandmarks() ([]int32, error) {
+	return a.i32s(STATISTICS_FACE_LANDMARKS)
+}
+
+// StatisticsFaceRectangles STATISTICS_FACE_RECTANGLES (int32[n*4])
+func (a Accessor) StatisticsFaceRectangles() ([]int32, error) {
+	return a.i32s(STATISTICS_FACE_RECTANGLES)
+}
+
+// StatisticsFaceScores STATISTICS_FACE_SCORES (byte[n])
+func (a Accessor) StatisticsFaceScores() ([]uint8, error) {
+	return a.u8s(STATISTICS_FACE_SCORES)
+}
+
+// StatisticsLensShadingMap STATISTICS_LENS_SHADING_MAP (float[4*n*m])
+func (a Accessor) StatisticsLensShadingMap() ([]float32, error) {
+	return a.f32s(STATISTICS_LENS_SHADING_MAP)
+}
+
+// StatisticsSceneFlicker STATISTICS_SCENE_FLICKER (byte)
+func (a Accessor) StatisticsSceneFlicker() (MetadataStatistics_scene_flicker, error) {
+	v, err := a.u8(STATISTICS_SCENE_FLICKER)
+	return MetadataStatistics_scene_flicker(v), err
+}
+
+// StatisticsHotPixelMap STATISTICS_HOT_PIXEL_MAP (int32[2*n])
+func (a Accessor) StatisticsHotPixelMap() ([]int32, error) {
+	return a.i32s(STATISTICS_HOT_PIXEL_MAP)
+}
+
+// StatisticsLensShadingMapMode STATISTICS_LENS_SHADING_MAP_MODE (byte)
+func (a Accessor) StatisticsLensShadingMapMode() (MetadataStatistics_lens_shading_map_mode, error) {
+	v, err := a.u8(STATISTICS_LENS_SHADING_MAP_MODE)
+	return MetadataStatistics_lens_shading_map_mode(v), err
+}
+
+// StatisticsInfoAvailableFaceDetectModes STATISTICS_INFO_AVAILABLE_FACE_DETECT_MODES (byte[n])
+func (a Accessor) StatisticsInfoAvailableFaceDetectModes() ([]uint8, error) {
+	return a.u8s(STATISTICS_INFO_AVAILABLE_FACE_DETECT_MODES)
+}
+
+// StatisticsInfoMaxFaceCount STATISTICS_INFO_MAX_FACE_COUNT (int32)
+func (a Accessor) StatisticsInfoMaxFaceCount() (int32, error) {
+	return a.i32(STATISTICS_INFO_MAX_FACE_COUNT)
+}
+
+// StatisticsInfoAvailableHotPixelMapModes STATISTICS_INFO_AVAILABLE_HOT_PIXEL_MAP_MODES (byte[n])
+func (a Accessor) StatisticsInfoAvailableHotPixelMapModes() ([]uint8, error) {
+	return a.u8s(STATISTICS_INFO_AVAILABLE_HOT_PIXEL_MAP_MODES)
+}
+
+// StatisticsInfoAvailableLensShadingMapModes STATISTICS_INFO_AVAILABLE_LENS_SHADING_MAP_MODES (byte[n])
+func (a Accessor) StatisticsInfoAvailableLensShadingMapModes() ([]uint8, error) {
+	return a.u8s(STATISTICS_INFO_AVAILABLE_LENS_SHADING_MAP_MODES)
+}
+
+// TonemapCurveBlue TONEMAP_CURVE_BLUE (float[n*2])
+func (a Accessor) TonemapCurveBlue() ([]float32, error) {
+	return a.f32s(TONEMAP_CURVE_BLUE)
+}
+
+// TonemapCurveGreen TONEMAP_CURVE_GREEN (float[n*2])
+func (a Accessor) TonemapCurveGreen() ([]float32, error) {
+	return a.f32s(TONEMAP_CURVE_GREEN)
+}
+
+// TonemapCurveRed TONEMAP_CURVE_RED (float[n*2])
+func (a Accessor) TonemapCurveRed() ([]float32, error) {
+	return a.f32s(TONEMAP_CURVE_RED)
+}
+
+// TonemapMode TONEMAP_MODE (byte)
+func (a Accessor) TonemapMode() (MetadataTonemap_mode, error) {
+	v, err := a.u8(TONEMAP_MODE)
+	return MetadataTonemap_mode(v), err
+}
+
+// TonemapMaxCurvePoints TONEMAP_MAX_CURVE_POINTS (int32)
+func (a Accessor) TonemapMaxCurvePoints() (int32, error) {
+	return a.i32(TONEMAP_MAX_CURVE_POINTS)
+}
+
+// TonemapAvailableToneMapModes TONEMAP_AVAILABLE_TONE_MAP_MODES (byte[n])
+func (a Accessor) TonemapAvailableToneMapModes() ([]uint8, error) {
+	return a.u8s(TONEMAP_AVAILABLE_TONE_MAP_MODES)
+}
+
+// TonemapGamma TONEMAP_GAMMA (float)
+func (a Accessor) TonemapGamma() (float32, error) {
+	return a.f32(TONEMAP_GAMMA)
+}
+
+// TonemapPresetCurve TONEMAP_PRESET_CURVE (byte)
+func (a Accessor) TonemapPresetCurve() (MetadataTonemap_preset_curve, error) {
+	v, err := a.u8(TONEMAP_PRESET_CURVE)
+	return MetadataTonemap_preset_curve(v), err
+}
+
+// InfoSupportedHardwareLevel INFO_SUPPORTED_HARDWARE_LEVEL (byte)
+func (a Accessor) InfoSupportedHardwareLevel() (MetadataInfo_supported_hardware_level, error) {
+	v, err := a.u8(INFO_SUPPORTED_HARDWARE_LEVEL)
+	return MetadataInfo_supported_hardware_level(v), err
+}
+
+// BlackLevelLock BLACK_LEVEL_LOCK (byte)
+func (a Accessor) BlackLevelLock() (MetadataBlack_level_lock, error) {
+	v, err := a.u8(BLACK_LEVEL_LOCK)
+	return MetadataBlack_level_lock(v), err
+}
+
+// SyncFrameNumber SYNC_FRAME_NUMBER (int64)
+func (a Accessor) SyncFrameNumber() (MetadataSync_frame_number, error) {
+	v, err := a.i64(SYNC_FRAME_NUMBER)
+	return MetadataSync_frame_number(v), err
+}
+
+// SyncMaxLatency SYNC_MAX_LATENCY (int32)
+func (a Accessor) SyncMaxLatency() (MetadataSync_max_latency, error) {
+	v, err := a.i32(SYNC_MAX_LATENCY)
+	return MetadataSync_max_latency(v), err
+}
+
+// DepthAvailableDepthStreamConfigurations DEPTH_AVAILABLE_DEPTH_STREAM_CONFIGURATIONS (int32[n*4])
+func (a Accessor) DepthAvailableDepthStreamConfigurations() ([]int32, error) {
+	return a.i32s(DEPTH_AVAILABLE_DEPTH_STREAM_CONFIGURATIONS)
+}
+
+// DepthAvailableDepthMinFrameDurations DEPTH_AVAILABLE_DEPTH_MIN_FRAME_DURATIONS (int64[4*n])
+func (a Accessor) DepthAvailableDepthMinFrameDurations() ([]int64, error) {
+	return a.i64s(DEPTH_AVAILABLE_DEPTH_MIN_FRAME_DURATIONS)
+}
+
+// DepthAvailableDepthStallDurations DEPTH_AVAILABLE_DEPTH_STALL_DURATIONS (int64[4*n])
+func (a Accessor) DepthAvailableDepthStallDurations() ([]int64, error) {
+	return a.i64s(DEPTH_AVAILABLE_DEPTH_STALL_DURATIONS)
+}
+
+// DepthDepthIsExclusive DEPTH_DEPTH_IS_EXCLUSIVE (byte)
+func (a Accessor) DepthDepthIsExclusive() (MetadataDepth_depth_is_exclusive, error) {
+	v, err := a.u8(DEPTH_DEPTH_IS_EXCLUSIVE)
+	return MetadataDepth_depth_is_exclusive(v), err
+}
+
+func (e MetadataColor_correction_mode) String() string {
+	switch e {
+	case COLOR_CORRECTION_MODE_TRANSFORM_MATRIX:
+		return "COLOR_CORRECTION_MODE_TRANSFORM_MATRIX"
+	case COLOR_CORRECTION_MODE_FAST:
+		return "COLOR_CORRECTION_MODE_FAST"
+	case COLOR_CORRECTION_MODE_HIGH_QUALITY:
+		return "COLOR_CORRECTION_MODE_HIGH_QUALITY"
+	default:
+		return fmt.Sprintf("UNKNOW_COLOR_CORRECTION_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataColor_correction_aberration_mode) String() string {
+	switch e {
+	case COLOR_CORRECTION_ABERRATION_MODE_OFF:
+		return "COLOR_CORRECTION_ABERRATION_MODE_OFF"
+	case COLOR_CORRECTION_ABERRATION_MODE_FAST:
+		return "COLOR_CORRECTION_ABERRATION_MODE_FAST"
+	case COLOR_CORRECTION_ABERRATION_MODE_HIGH_QUALITY:
+		return "COLOR_CORRECTION_ABERRATION_MODE_HIGH_QUALITY"
+	default:
+		return fmt.Sprintf("UNKNOW_COLOR_CORRECTION_ABERRATION_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_ae_antibanding_mode) String() string {
+	switch e {
+	case CONTROL_AE_ANTIBANDING_MODE_OFF:
+		return "CONTROL_AE_ANTIBANDING_MODE_OFF"
+	case CONTROL_AE_ANTIBANDING_MODE_50HZ:
+		return "CONTROL_AE_ANTIBANDING_MODE_50HZ"
+	case CONTROL_AE_ANTIBANDING_MODE_60HZ:
+		return "CONTROL_AE_ANTIBANDING_MODE_60HZ"
+	case CONTROL_AE_ANTIBANDING_MODE_AUTO:
+		return "CONTROL_AE_ANTIBANDING_MODE_AUTO"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AE_ANTIBANDING_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_ae_lock) String() string {
+	switch e {
+	case CONTROL_AE_LOCK_OFF:
+		return "CONTROL_AE_LOCK_OFF"
+	case CONTROL_AE_LOCK_ON:
+		return "CONTROL_AE_LOCK_ON"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AE_LOCK_%d", int(e))
+	}
+}
+
+func (e MetadataEnumAcameraControlAeMode) String() string {
+	switch e {
+	case CONTROL_AE_MODE_OFF:
+		return "CONTROL_AE_MODE_OFF"
+	case CONTROL_AE_MODE_ON:
+		return "CONTROL_AE_MODE_ON"
+	case CONTROL_AE_MODE_ON_AUTO_FLASH:
+		return "CONTROL_AE_MODE_ON_AUTO_FLASH"
+	case CONTROL_AE_MODE_ON_ALWAYS_FLASH:
+		return "CONTROL_AE_MODE_ON_ALWAYS_FLASH"
+	case CONTROL_AE_MODE_ON_AUTO_FLASH_REDEYE:
+		return "CONTROL_AE_MODE_ON_AUTO_FLASH_REDEYE"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AE_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_ae_precapture_trigger) String() string {
+	switch e {
+	case CONTROL_AE_PRECAPTURE_TRIGGER_IDLE:
+		return "CONTROL_AE_PRECAPTURE_TRIGGER_IDLE"
+	case CONTROL_AE_PRECAPTURE_TRIGGER_START:
+		return "CONTROL_AE_PRECAPTURE_TRIGGER_START"
+	case CONTROL_AE_PRECAPTURE_TRIGGER_CANCEL:
+		return "CONTROL_AE_PRECAPTURE_TRIGGER_CANCEL"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AE_PRECAPTURE_TRIGGER_%d", int(e))
+	}
+}
+
+func (e MetadataControl_af_mode) String() string {
+	switch e {
+	case CONTROL_AF_MODE_OFF:
+		return "CONTROL_AF_MODE_OFF"
+	case CONTROL_AF_MODE_AUTO:
+		return "CONTROL_AF_MODE_AUTO"
+	case CONTROL_AF_MODE_MACRO:
+		return "CONTROL_AF_MODE_MACRO"
+	case CONTROL_AF_MODE_CONTINUOUS_VIDEO:
+		return "CONTROL_AF_MODE_CONTINUOUS_VIDEO"
+	case CONTROL_AF_MODE_CONTINUOUS_PICTURE:
+		return "CONTROL_AF_MODE_CONTINUOUS_PICTURE"
+	case CONTROL_AF_MODE_EDOF:
+		return "CONTROL_AF_MODE_EDOF"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AF_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataEnumAcameraControlAfTrigger) String() string {
+	switch e {
+	case CONTROL_AF_TRIGGER_IDLE:
+		return "CONTROL_AF_TRIGGER_IDLE"
+	case CONTROL_AF_TRIGGER_START:
+		return "CONTROL_AF_TRIGGER_START"
+	case CONTROL_AF_TRIGGER_CANCEL:
+		return "CONTROL_AF_TRIGGER_CANCEL"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AF_TRIGGER_%d", int(e))
+	}
+}
+
+func (e MetadataControl_awb_lock) String() string {
+	switch e {
+	case CONTROL_AWB_LOCK_OFF:
+		return "CONTROL_AWB_LOCK_OFF"
+	case CONTROL_AWB_LOCK_ON:
+		return "CONTROL_AWB_LOCK_ON"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AWB_LOCK_%d", int(e))
+	}
+}
+
+func (e MetadataControl_awb_mode) String() string {
+	switch e {
+	case CONTROL_AWB_MODE_OFF:
+		return "CONTROL_AWB_MODE_OFF"
+	case CONTROL_AWB_MODE_AUTO:
+		return "CONTROL_AWB_MODE_AUTO"
+	case CONTROL_AWB_MODE_INCANDESCENT:
+		return "CONTROL_AWB_MODE_INCANDESCENT"
+	case CONTROL_AWB_MODE_FLUORESCENT:
+		return "CONTROL_AWB_MODE_FLUORESCENT"
+	case CONTROL_AWB_MODE_WARM_FLUORESCENT:
+		return "CONTROL_AWB_MODE_WARM_FLUORESCENT"
+	case CONTROL_AWB_MODE_DAYLIGHT:
+		return "CONTROL_AWB_MODE_DAYLIGHT"
+	case CONTROL_AWB_MODE_CLOUDY_DAYLIGHT:
+		return "CONTROL_AWB_MODE_CLOUDY_DAYLIGHT"
+	case CONTROL_AWB_MODE_TWILIGHT:
+		return "CONTROL_AWB_MODE_TWILIGHT"
+	case CONTROL_AWB_MODE_SHADE:
+		return "CONTROL_AWB_MODE_SHADE"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AWB_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_capture_intent) String() string {
+	switch e {
+	case CONTROL_CAPTURE_INTENT_CUSTOM:
+		return "CONTROL_CAPTURE_INTENT_CUSTOM"
+	case CONTROL_CAPTURE_INTENT_PREVIEW:
+		return "CONTROL_CAPTURE_INTENT_PREVIEW"
+	case CONTROL_CAPTURE_INTENT_STILL_CAPTURE:
+		return "CONTROL_CAPTURE_INTENT_STILL_CAPTURE"
+	case CONTROL_CAPTURE_INTENT_VIDEO_RECORD:
+		return "CONTROL_CAPTURE_INTENT_VIDEO_RECORD"
+	case CONTROL_CAPTURE_INTENT_VIDEO_SNAPSHOT:
+		return "CONTROL_CAPTURE_INTENT_VIDEO_SNAPSHOT"
+	case CONTROL_CAPTURE_INTENT_ZERO_SHUTTER_LAG:
+		return "CONTROL_CAPTURE_INTENT_ZERO_SHUTTER_LAG"
+	case CONTROL_CAPTURE_INTENT_MANUAL:
+		return "CONTROL_CAPTURE_INTENT_MANUAL"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_CAPTURE_INTENT_%d", int(e))
+	}
+}
+
+func (e MetadataControl_effect_mode) String() string {
+	switch e {
+	case CONTROL_EFFECT_MODE_OFF:
+		return "CONTROL_EFFECT_MODE_OFF"
+	case CONTROL_EFFECT_MODE_MONO:
+		return "CONTROL_EFFECT_MODE_MONO"
+	case CONTROL_EFFECT_MODE_NEGATIVE:
+		return "CONTROL_EFFECT_MODE_NEGATIVE"
+	case CONTROL_EFFECT_MODE_SOLARIZE:
+		return "CONTROL_EFFECT_MODE_SOLARIZE"
+	case CONTROL_EFFECT_MODE_SEPIA:
+		return "CONTROL_EFFECT_MODE_SEPIA"
+	case CONTROL_EFFECT_MODE_POSTERIZE:
+		return "CONTROL_EFFECT_MODE_POSTERIZE"
+	case CONTROL_EFFECT_MODE_WHITEBOARD:
+		return "CONTROL_EFFECT_MODE_WHITEBOARD"
+	case CONTROL_EFFECT_MODE_BLACKBOARD:
+		return "CONTROL_EFFECT_MODE_BLACKBOARD"
+	case CONTROL_EFFECT_MODE_AQUA:
+		return "CONTROL_EFFECT_MODE_AQUA"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_EFFECT_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_mode) String() string {
+	switch e {
+	case CONTROL_MODE_OFF:
+		return "CONTROL_MODE_OFF"
+	case CONTROL_MODE_AUTO:
+		return "CONTROL_MODE_AUTO"
+	case CONTROL_MODE_USE_SCENE_MODE:
+		return "CONTROL_MODE_USE_SCENE_MODE"
+	case CONTROL_MODE_OFF_KEEP_STATE:
+		return "CONTROL_MODE_OFF_KEEP_STATE"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_scene_mode) String() string {
+	switch e {
+	case CONTROL_SCENE_MODE_DISABLED:
+		return "CONTROL_SCENE_MODE_DISABLED"
+	case CONTROL_SCENE_MODE_FACE_PRIORITY:
+		return "CONTROL_SCENE_MODE_FACE_PRIORITY"
+	case CONTROL_SCENE_MODE_ACTION:
+		return "CONTROL_SCENE_MODE_ACTION"
+	case CONTROL_SCENE_MODE_PORTRAIT:
+		return "CONTROL_SCENE_MODE_PORTRAIT"
+	case CONTROL_SCENE_MODE_LANDSCAPE:
+		return "CONTROL_SCENE_MODE_LANDSCAPE"
+	case CONTROL_SCENE_MODE_NIGHT:
+		return "CONTROL_SCENE_MODE_NIGHT"
+	case CONTROL_SCENE_MODE_NIGHT_PORTRAIT:
+		return "CONTROL_SCENE_MODE_NIGHT_PORTRAIT"
+	case CONTROL_SCENE_MODE_THEATRE:
+		return "CONTROL_SCENE_MODE_THEATRE"
+	case CONTROL_SCENE_MODE_BEACH:
+		return "CONTROL_SCENE_MODE_BEACH"
+	case CONTROL_SCENE_MODE_SNOW:
+		return "CONTROL_SCENE_MODE_SNOW"
+	case CONTROL_SCENE_MODE_SUNSET:
+		return "CONTROL_SCENE_MODE_SUNSET"
+	case CONTROL_SCENE_MODE_STEADYPHOTO:
+		return "CONTROL_SCENE_MODE_STEADYPHOTO"
+	case CONTROL_SCENE_MODE_FIREWORKS:
+		return "CONTROL_SCENE_MODE_FIREWORKS"
+	case CONTROL_SCENE_MODE_SPORTS:
+		return "CONTROL_SCENE_MODE_SPORTS"
+	case CONTROL_SCENE_MODE_PARTY:
+		return "CONTROL_SCENE_MODE_PARTY"
+	case CONTROL_SCENE_MODE_CANDLELIGHT:
+		return "CONTROL_SCENE_MODE_CANDLELIGHT"
+	case CONTROL_SCENE_MODE_BARCODE:
+		return "CONTROL_SCENE_MODE_BARCODE"
+	case CONTROL_SCENE_MODE_HDR:
+		return "CONTROL_SCENE_MODE_HDR"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_SCENE_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_video_stabilization_mode) String() string {
+	switch e {
+	case CONTROL_VIDEO_STABILIZATION_MODE_OFF:
+		return "CONTROL_VIDEO_STABILIZATION_MODE_OFF"
+	case CONTROL_VIDEO_STABILIZATION_MODE_ON:
+		return "CONTROL_VIDEO_STABILIZATION_MODE_ON"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_VIDEO_STABILIZATION_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_ae_state) String() string {
+	switch e {
+	case CONTROL_AE_STATE_INACTIVE:
+		return "CONTROL_AE_STATE_INACTIVE"
+	case CONTROL_AE_STATE_SEARCHING:
+		return "CONTROL_AE_STATE_SEARCHING"
+	case CONTROL_AE_STATE_CONVERGED:
+		return "CONTROL_AE_STATE_CONVERGED"
+	case CONTROL_AE_STATE_LOCKED:
+		return "CONTROL_AE_STATE_LOCKED"
+	case CONTROL_AE_STATE_FLASH_REQUIRED:
+		return "CONTROL_AE_STATE_FLASH_REQUIRED"
+	case CONTROL_AE_STATE_PRECAPTURE:
+		return "CONTROL_AE_STATE_PRECAPTURE"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AE_STATE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_af_state) String() string {
+	switch e {
+	case CONTROL_AF_STATE_INACTIVE:
+		return "CONTROL_AF_STATE_INACTIVE"
+	case CONTROL_AF_STATE_PASSIVE_SCAN:
+		return "CONTROL_AF_STATE_PASSIVE_SCAN"
+	case CONTROL_AF_STATE_PASSIVE_FOCUSED:
+		return "CONTROL_AF_STATE_PASSIVE_FOCUSED"
+	case CONTROL_AF_STATE_ACTIVE_SCAN:
+		return "CONTROL_AF_STATE_ACTIVE_SCAN"
+	case CONTROL_AF_STATE_FOCUSED_LOCKED:
+		return "CONTROL_AF_STATE_FOCUSED_LOCKED"
+	case CONTROL_AF_STATE_NOT_FOCUSED_LOCKED:
+		return "CONTROL_AF_STATE_NOT_FOCUSED_LOCKED"
+	case CONTROL_AF_STATE_PASSIVE_UNFOCUSED:
+		return "CONTROL_AF_STATE_PASSIVE_UNFOCUSED"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AF_STATE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_awb_state) String() string {
+	switch e {
+	case CONTROL_AWB_STATE_INACTIVE:
+		return "CONTROL_AWB_STATE_INACTIVE"
+	case CONTROL_AWB_STATE_SEARCHING:
+		return "CONTROL_AWB_STATE_SEARCHING"
+	case CONTROL_AWB_STATE_CONVERGED:
+		return "CONTROL_AWB_STATE_CONVERGED"
+	case CONTROL_AWB_STATE_LOCKED:
+		return "CONTROL_AWB_STATE_LOCKED"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AWB_STATE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_ae_lock_available) String() string {
+	switch e {
+	case CONTROL_AE_LOCK_AVAILABLE_FALSE:
+		return "CONTROL_AE_LOCK_AVAILABLE_FALSE"
+	case CONTROL_AE_LOCK_AVAILABLE_TRUE:
+		return "CONTROL_AE_LOCK_AVAILABLE_TRUE"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AE_LOCK_AVAILABLE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_awb_lock_available) String() string {
+	switch e {
+	case CONTROL_AWB_LOCK_AVAILABLE_FALSE:
+		return "CONTROL_AWB_LOCK_AVAILABLE_FALSE"
+	case CONTROL_AWB_LOCK_AVAILABLE_TRUE:
+		return "CONTROL_AWB_LOCK_AVAILABLE_TRUE"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_AWB_LOCK_AVAILABLE_%d", int(e))
+	}
+}
+
+func (e MetadataControl_enable_zsl) String() string {
+	switch e {
+	case CONTROL_ENABLE_ZSL_FALSE:
+		return "CONTROL_ENABLE_ZSL_FALSE"
+	case CONTROL_ENABLE_ZSL_TRUE:
+		return "CONTROL_ENABLE_ZSL_TRUE"
+	default:
+		return fmt.Sprintf("UNKNOW_CONTROL_ENABLE_ZSL_%d", int(e))
+	}
+}
+
+func (e MetadataEdge_mode) String() string {
+	switch e {
+	case EDGE_MODE_OFF:
+		return "EDGE_MODE_OFF"
+	case EDGE_MODE_FAST:
+		return "EDGE_MODE_FAST"
+	case EDGE_MODE_HIGH_QUALITY:
+		return "EDGE_MODE_HIGH_QUALITY"
+	case EDGE_MODE_ZERO_SHUTTER_LAG:
+		return "EDGE_MODE_ZERO_SHUTTER_LAG"
+	default:
+		return fmt.Sprintf("UNKNOW_EDGE_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataFlash_mode) String() string {
+	switch e {
+	case FLASH_MODE_OFF:
+		return "FLASH_MODE_OFF"
+	case FLASH_MODE_SINGLE:
+		return "FLASH_MODE_SINGLE"
+	case FLASH_MODE_TORCH:
+		return "FLASH_MODE_TORCH"
+	default:
+		return fmt.Sprintf("UNKNOW_FLASH_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataFlash_state) String() string {
+	switch e {
+	case FLASH_STATE_UNAVAILABLE:
+		return "FLASH_STATE_UNAVAILABLE"
+	case FLASH_STATE_CHARGING:
+		return "FLASH_STATE_CHARGING"
+	case FLASH_STATE_READY:
+		return "FLASH_STATE_READY"
+	case FLASH_STATE_FIRED:
+		return "FLASH_STATE_FIRED"
+	case FLASH_STATE_PARTIAL:
+		return "FLASH_STATE_PARTIAL"
+	default:
+		return fmt.Sprintf("UNKNOW_FLASH_STATE_%d", int(e))
+	}
+}
+
+func (e MetadataFlash_info_available) String() string {
+	switch e {
+	case FLASH_INFO_AVAILABLE_FALSE:
+		return "FLASH_INFO_AVAILABLE_FALSE"
+	case FLASH_INFO_AVAILABLE_TRUE:
+		return "FLASH_INFO_AVAILABLE_TRUE"
+	default:
+		return fmt.Sprintf("UNKNOW_FLASH_INFO_AVAILABLE_%d", int(e))
+	}
+}
+
+func (e MetadataHot_pixel_mode) String() string {
+	switch e {
+	case HOT_PIXEL_MODE_OFF:
+		return "HOT_PIXEL_MODE_OFF"
+	case HOT_PIXEL_MODE_FAST:
+		return "HOT_PIXEL_MODE_FAST"
+	case HOT_PIXEL_MODE_HIGH_QUALITY:
+		return "HOT_PIXEL_MODE_HIGH_QUALITY"
+	default:
+		return fmt.Sprintf("UNKNOW_HOT_PIXEL_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataLens_optical_stabilization_mode) String() string {
+	switch e {
+	case LENS_OPTICAL_STABILIZATION_MODE_OFF:
+		return "LENS_OPTICAL_STABILIZATION_MODE_OFF"
+	case LENS_OPTICAL_STABILIZATION_MODE_ON:
+		return "LENS_OPTICAL_STABILIZATION_MODE_ON"
+	default:
+		return fmt.Sprintf("UNKNOW_LENS_OPTICAL_STABILIZATION_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataLens_facing) String() string {
+	switch e {
+	case LENS_FACING_FRONT:
+		return "LENS_FACING_FRONT"
+	case LENS_FACING_BACK:
+		return "LENS_FACING_BACK"
+	case LENS_FACING_EXTERNAL:
+		return "LENS_FACING_EXTERNAL"
+	default:
+		return fmt.Sprintf("UNKNOW_LENS_FACING_%d", int(e))
+	}
+}
+
+func (e MetadataLens_state) String() string {
+	switch e {
+	case LENS_STATE_STATIONARY:
+		return "LENS_STATE_STATIONARY"
+	case LENS_STATE_MOVING:
+		return "LENS_STATE_MOVING"
+	default:
+		return fmt.Sprintf("UNKNOW_LENS_STATE_%d", int(e))
+	}
+}
+
+func (e MetadataLens_info_focus_distance_calibration) String() string {
+	switch e {
+	case LENS_INFO_FOCUS_DISTANCE_CALIBRATION_UNCALIBRATED:
+		return "LENS_INFO_FOCUS_DISTANCE_CALIBRATION_UNCALIBRATED"
+	case LENS_INFO_FOCUS_DISTANCE_CALIBRATION_APPROXIMATE:
+		return "LENS_INFO_FOCUS_DISTANCE_CALIBRATION_APPROXIMATE"
+	case LENS_INFO_FOCUS_DISTANCE_CALIBRATION_CALIBRATED:
+		return "LENS_INFO_FOCUS_DISTANCE_CALIBRATION_CALIBRATED"
+	default:
+		return fmt.Sprintf("UNKNOW_LENS_INFO_FOCUS_DISTANCE_CALIBRATION_%d", int(e))
+	}
+}
+
+func (e MetadataNoise_reduction_mode) String() string {
+	switch e {
+	case NOISE_REDUCTION_MODE_OFF:
+		return "NOISE_REDUCTION_MODE_OFF"
+	case NOISE_REDUCTION_MODE_FAST:
+		return "NOISE_REDUCTION_MODE_FAST"
+	case NOISE_REDUCTION_MODE_HIGH_QUALITY:
+		return "NOISE_REDUCTION_MODE_HIGH_QUALITY"
+	case NOISE_REDUCTION_MODE_MINIMAL:
+		return "NOISE_REDUCTION_MODE_MINIMAL"
+	case NOISE_REDUCTION_MODE_ZERO_SHUTTER_LAG:
+		return "NOISE_REDUCTION_MODE_ZERO_SHUTTER_LAG"
+	default:
+		return fmt.Sprintf("UNKNOW_NOISE_REDUCTION_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataRequest_available_capabilities) String() string {
+	switch e {
+	case REQUEST_AVAILABLE_CAPABILITIES_BACKWARD_COMPATIBLE:
+		return "REQUEST_AVAILABLE_CAPABILITIES_BACKWARD_COMPATIBLE"
+	case REQUEST_AVAILABLE_CAPABILITIES_MANUAL_SENSOR:
+		return "REQUEST_AVAILABLE_CAPABILITIES_MANUAL_SENSOR"
+	case REQUEST_AVAILABLE_CAPABILITIES_MANUAL_POST_PROCESSING:
+		return "REQUEST_AVAILABLE_CAPABILITIES_MANUAL_POST_PROCESSING"
+	case REQUEST_AVAILABLE_CAPABILITIES_RAW:
+		return "REQUEST_AVAILABLE_CAPABILITIES_RAW"
+	case REQUEST_AVAILABLE_CAPABILITIES_READ_SENSOR_SETTINGS:
+		return "REQUEST_AVAILABLE_CAPABILITIES_READ_SENSOR_SETTINGS"
+	case REQUEST_AVAILABLE_CAPABILITIES_BURST_CAPTURE:
+		return "REQUEST_AVAILABLE_CAPABILITIES_BURST_CAPTURE"
+	case REQUEST_AVAILABLE_CAPABILITIES_DEPTH_OUTPUT:
+		return "REQUEST_AVAILABLE_CAPABILITIES_DEPTH_OUTPUT"
+	default:
+		return fmt.Sprintf("UNKNOW_REQUEST_AVAILABLE_CAPABILITIES_%d", int(e))
+	}
+}
+
+func (e MetadataScaler_available_stream_configurations) String() string {
+	switch e {
+	case SCALER_AVAILABLE_STREAM_CONFIGURATIONS_OUTPUT:
+		return "SCALER_AVAILABLE_STREAM_CONFIGURATIONS_OUTPUT"
+	case SCALER_AVAILABLE_STREAM_CONFIGURATIONS_INPUT:
+		return "SCALER_AVAILABLE_STREAM_CONFIGURATIONS_INPUT"
+	default:
+		return fmt.Sprintf("UNKNOW_SCALER_AVAILABLE_STREAM_CONFIGURATIONS_%d", int(e))
+	}
+}
+
+func (e MetadataScaler_cropping_type) String() string {
+	switch e {
+	case SCALER_CROPPING_TYPE_CENTER_ONLY:
+		return "SCALER_CROPPING_TYPE_CENTER_ONLY"
+	case SCALER_CROPPING_TYPE_FREEFORM:
+		return "SCALER_CROPPING_TYPE_FREEFORM"
+	default:
+		return fmt.Sprintf("UNKNOW_SCALER_CROPPING_TYPE_%d", int(e))
+	}
+}
+
+func (e MetadataSensor_reference_illuminant1) String() string {
+	switch e {
+	case SENSOR_REFERENCE_ILLUMINANT1_DAYLIGHT:
+		return "SENSOR_REFERENCE_ILLUMINANT1_DAYLIGHT"
+	case SENSOR_REFERENCE_ILLUMINANT1_FLUORESCENT:
+		return "SENSOR_REFERENCE_ILLUMINANT1_FLUORESCENT"
+	case SENSOR_REFERENCE_ILLUMINANT1_TUNGSTEN:
+		return "SENSOR_REFERENCE_ILLUMINANT1_TUNGSTEN"
+	case SENSOR_REFERENCE_ILLUMINANT1_FLASH:
+		return "SENSOR_REFERENCE_ILLUMINANT1_FLASH"
+	case SENSOR_REFERENCE_ILLUMINANT1_FINE_WEATHER:
+		return "SENSOR_REFERENCE_ILLUMINANT1_FINE_WEATHER"
+	case SENSOR_REFERENCE_ILLUMINANT1_CLOUDY_WEATHER:
+		return "SENSOR_REFERENCE_ILLUMINANT1_CLOUDY_WEATHER"
+	case SENSOR_REFERENCE_ILLUMINANT1_SHADE:
+		return "SENSOR_REFERENCE_ILLUMINANT1_SHADE"
+	case SENSOR_REFERENCE_ILLUMINANT1_DAYLIGHT_FLUORESCENT:
+		return "SENSOR_REFERENCE_ILLUMINANT1_DAYLIGHT_FLUORESCENT"
+	case SENSOR_REFERENCE_ILLUMINANT1_DAY_WHITE_FLUORESCENT:
+		return "SENSOR_REFERENCE_ILLUMINANT1_DAY_WHITE_FLUORESCENT"
+	case SENSOR_REFERENCE_ILLUMINANT1_COOL_WHITE_FLUORESCENT:
+		return "SENSOR_REFERENCE_ILLUMINANT1_COOL_WHITE_FLUORESCENT"
+	case SENSOR_REFERENCE_ILLUMINANT1_WHITE_FLUORESCENT:
+		return "SENSOR_REFERENCE_ILLUMINANT1_WHITE_FLUORESCENT"
+	case SENSOR_REFERENCE_ILLUMINANT1_STANDARD_A:
+		return "SENSOR_REFERENCE_ILLUMINANT1_STANDARD_A"
+	case SENSOR_REFERENCE_ILLUMINANT1_STANDARD_B:
+		return "SENSOR_REFERENCE_ILLUMINANT1_STANDARD_B"
+	case SENSOR_REFERENCE_ILLUMINANT1_STANDARD_C:
+		return "SENSOR_REFERENCE_ILLUMINANT1_STANDARD_C"
+	case SENSOR_REFERENCE_ILLUMINANT1_D55:
+		return "SENSOR_REFERENCE_ILLUMINANT1_D55"
+	case SENSOR_REFERENCE_ILLUMINANT1_D65:
+		return "SENSOR_REFERENCE_ILLUMINANT1_D65"
+	case SENSOR_REFERENCE_ILLUMINANT1_D75:
+		return "SENSOR_REFERENCE_ILLUMINANT1_D75"
+	case SENSOR_REFERENCE_ILLUMINANT1_D50:
+		return "SENSOR_REFERENCE_ILLUMINANT1_D50"
+	case SENSOR_REFERENCE_ILLUMINANT1_ISO_STUDIO_TUNGSTEN:
+		return "SENSOR_REFERENCE_ILLUMINANT1_ISO_STUDIO_TUNGSTEN"
+	default:
+		return fmt.Sprintf("UNKNOW_SENSOR_REFERENCE_ILLUMINANT1_%d", int(e))
+	}
+}
+
+func (e MetadataSensor_test_pattern_mode) String() string {
+	switch e {
+	case SENSOR_TEST_PATTERN_MODE_OFF:
+		return "SENSOR_TEST_PATTERN_MODE_OFF"
+	case SENSOR_TEST_PATTERN_MODE_SOLID_COLOR:
+		return "SENSOR_TEST_PATTERN_MODE_SOLID_COLOR"
+	case SENSOR_TEST_PATTERN_MODE_COLOR_BARS:
+		return "SENSOR_TEST_PATTERN_MODE_COLOR_BARS"
+	case SENSOR_TEST_PATTERN_MODE_COLOR_BARS_FADE_TO_GRAY:
+		return "SENSOR_TEST_PATTERN_MODE_COLOR_BARS_FADE_TO_GRAY"
+	case SENSOR_TEST_PATTERN_MODE_PN9:
+		return "SENSOR_TEST_PATTERN_MODE_PN9"
+	case SENSOR_TEST_PATTERN_MODE_CUSTOM1:
+		return "SENSOR_TEST_PATTERN_MODE_CUSTOM1"
+	default:
+		return fmt.Sprintf("UNKNOW_SENSOR_TEST_PATTERN_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataSensor_info_color_filter_arrangement) String() string {
+	switch e {
+	case SENSOR_INFO_COLOR_FILTER_ARRANGEMENT_RGGB:
+		return "SENSOR_INFO_COLOR_FILTER_ARRANGEMENT_RGGB"
+	case SENSOR_INFO_COLOR_FILTER_ARRANGEMENT_GRBG:
+		return "SENSOR_INFO_COLOR_FILTER_ARRANGEMENT_GRBG"
+	case SENSOR_INFO_COLOR_FILTER_ARRANGEMENT_GBRG:
+		return "SENSOR_INFO_COLOR_FILTER_ARRANGEMENT_GBRG"
+	case SENSOR_INFO_COLOR_FILTER_ARRANGEMENT_BGGR:
+		return "SENSOR_INFO_COLOR_FILTER_ARRANGEMENT_BGGR"
+	case SENSOR_INFO_COLOR_FILTER_ARRANGEMENT_RGB:
+		return "SENSOR_INFO_COLOR_FILTER_ARRANGEMENT_RGB"
+	default:
+		return fmt.Sprintf("UNKNOW_SENSOR_INFO_COLOR_FILTER_ARRANGEMENT_%d", int(e))
+	}
+}
+
+func (e MetadataSensor_info_timestamp_source) String() string {
+	switch e {
+	case SENSOR_INFO_TIMESTAMP_SOURCE_UNKNOWN:
+		return "SENSOR_INFO_TIMESTAMP_SOURCE_UNKNOWN"
+	case SENSOR_INFO_TIMESTAMP_SOURCE_REALTIME:
+		return "SENSOR_INFO_TIMESTAMP_SOURCE_REALTIME"
+	default:
+		return fmt.Sprintf("UNKNOW_SENSOR_INFO_TIMESTAMP_SOURCE_%d", int(e))
+	}
+}
+
+func (e MetadataSensor_info_lens_shading_applied) String() string {
+	switch e {
+	case SENSOR_INFO_LENS_SHADING_APPLIED_FALSE:
+		return "SENSOR_INFO_LENS_SHADING_APPLIED_FALSE"
+	case SENSOR_INFO_LENS_SHADING_APPLIED_TRUE:
+		return "SENSOR_INFO_LENS_SHADING_APPLIED_TRUE"
+	default:
+		return fmt.Sprintf("UNKNOW_SENSOR_INFO_LENS_SHADING_APPLIED_%d", int(e))
+	}
+}
+
+func (e MetadataShading_mode) String() string {
+	switch e {
+	case SHADING_MODE_OFF:
+		return "SHADING_MODE_OFF"
+	case SHADING_MODE_FAST:
+		return "SHADING_MODE_FAST"
+	case SHADING_MODE_HIGH_QUALITY:
+		return "SHADING_MODE_HIGH_QUALITY"
+	default:
+		return fmt.Sprintf("UNKNOW_SHADING_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataStatistics_face_detect_mode) String() string {
+	switch e {
+	case STATISTICS_FACE_DETECT_MODE_OFF:
+		return "STATISTICS_FACE_DETECT_MODE_OFF"
+	case STATISTICS_FACE_DETECT_MODE_SIMPLE:
+		return "STATISTICS_FACE_DETECT_MODE_SIMPLE"
+	case STATISTICS_FACE_DETECT_MODE_FULL:
+		return "STATISTICS_FACE_DETECT_MODE_FULL"
+	default:
+		return fmt.Sprintf("UNKNOW_STATISTICS_FACE_DETECT_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataStatistics_hot_pixel_map_mode) String() string {
+	switch e {
+	case STATISTICS_HOT_PIXEL_MAP_MODE_OFF:
+		return "STATISTICS_HOT_PIXEL_MAP_MODE_OFF"
+	case STATISTICS_HOT_PIXEL_MAP_MODE_ON:
+		return "STATISTICS_HOT_PIXEL_MAP_MODE_ON"
+	default:
+		return fmt.Sprintf("UNKNOW_STATISTICS_HOT_PIXEL_MAP_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataStatistics_scene_flicker) String() string {
+	switch e {
+	case STATISTICS_SCENE_FLICKER_NONE:
+		return "STATISTICS_SCENE_FLICKER_NONE"
+	case STATISTICS_SCENE_FLICKER_50HZ:
+		return "STATISTICS_SCENE_FLICKER_50HZ"
+	case STATISTICS_SCENE_FLICKER_60HZ:
+		return "STATISTICS_SCENE_FLICKER_60HZ"
+	default:
+		return fmt.Sprintf("UNKNOW_STATISTICS_SCENE_FLICKER_%d", int(e))
+	}
+}
+
+func (e MetadataStatistics_lens_shading_map_mode) String() string {
+	switch e {
+	case STATISTICS_LENS_SHADING_MAP_MODE_OFF:
+		return "STATISTICS_LENS_SHADING_MAP_MODE_OFF"
+	case STATISTICS_LENS_SHADING_MAP_MODE_ON:
+		return "STATISTICS_LENS_SHADING_MAP_MODE_ON"
+	default:
+		return fmt.Sprintf("UNKNOW_STATISTICS_LENS_SHADING_MAP_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataTonemap_mode) String() string {
+	switch e {
+	case TONEMAP_MODE_CONTRAST_CURVE:
+		return "TONEMAP_MODE_CONTRAST_CURVE"
+	case TONEMAP_MODE_FAST:
+		return "TONEMAP_MODE_FAST"
+	case TONEMAP_MODE_HIGH_QUALITY:
+		return "TONEMAP_MODE_HIGH_QUALITY"
+	case TONEMAP_MODE_GAMMA_VALUE:
+		return "TONEMAP_MODE_GAMMA_VALUE"
+	case TONEMAP_MODE_PRESET_CURVE:
+		return "TONEMAP_MODE_PRESET_CURVE"
+	default:
+		return fmt.Sprintf("UNKNOW_TONEMAP_MODE_%d", int(e))
+	}
+}
+
+func (e MetadataTonemap_preset_curve) String() string {
+	switch e {
+	case TONEMAP_PRESET_CURVE_SRGB:
+		return "TONEMAP_PRESET_CURVE_SRGB"
+	case TONEMAP_PRESET_CURVE_REC709:
+		return "TONEMAP_PRESET_CURVE_REC709"
+	default:
+		return fmt.Sprintf("UNKNOW_TONEMAP_PRESET_CURVE_%d", int(e))
+	}
+}
+
+func (e MetadataInfo_supported_hardware_level) String() string {
+	switch e {
+	case INFO_SUPPORTED_HARDWARE_LEVEL_LIMITED:
+		return "INFO_SUPPORTED_HARDWARE_LEVEL_LIMITED"
+	case INFO_SUPPORTED_HARDWARE_LEVEL_FULL:
+		return "INFO_SUPPORTED_HARDWARE_LEVEL_FULL"
+	case INFO_SUPPORTED_HARDWARE_LEVEL_LEGACY:
+		return "INFO_SUPPORTED_HARDWARE_LEVEL_LEGACY"
+	case INFO_SUPPORTED_HARDWARE_LEVEL_3:
+		return "INFO_SUPPORTED_HARDWARE_LEVEL_3"
+	default:
+		return fmt.Sprintf("UNKNOW_INFO_SUPPORTED_HARDWARE_LEVEL_%d", int(e))
+	}
+}
+
+func (e MetadataBlack_level_lock) String() string {
+	switch e {
+	case BLACK_LEVEL_LOCK_OFF:
+		return "BLACK_LEVEL_LOCK_OFF"
+	case BLACK_LEVEL_LOCK_ON:
+		return "BLACK_LEVEL_LOCK_ON"
+	default:
+		return fmt.Sprintf("UNKNOW_BLACK_LEVEL_LOCK_%d", int(e))
+	}
+}
+
+func (e MetadataSync_frame_number) String() string {
+	switch e {
+	case SYNC_FRAME_NUMBER_CONVERGING:
+		return "SYNC_FRAME_NUMBER_CONVERGING"
+	case SYNC_FRAME_NUMBER_UNKNOWN:
+		return "SYNC_FRAME_NUMBER_UNKNOWN"
+	default:
+		return fmt.Sprintf("UNKNOW_SYNC_FRAME_NUMBER_%d", int(e))
+	}
+}
+
+func (e MetadataSync_max_latency) String() string {
+	switch e {
+	case SYNC_MAX_LATENCY_PER_FRAME_CONTROL:
+		return "SYNC_MAX_LATENCY_PER_FRAME_CONTROL"
+	case SYNC_MAX_LATENCY_UNKNOWN:
+		return "SYNC_MAX_LATENCY_UNKNOWN"
+	default:
+		return fmt.Sprintf("UNKNOW_SYNC_MAX_LATENCY_%d", int(e))
+	}
+}
+
+func (e MetadataDepth_available_depth_stream_configurations) String() string {
+	switch e {
+	case DEPTH_AVAILABLE_DEPTH_STREAM_CONFIGURATIONS_OUTPUT:
+		return "DEPTH_AVAILABLE_DEPTH_STREAM_CONFIGURATIONS_OUTPUT"
+	case DEPTH_AVAILABLE_DEPTH_STREAM_CONFIGURATIONS_INPUT:
+		return "DEPTH_AVAILABLE_DEPTH_STREAM_CONFIGURATIONS_INPUT"
+	default:
+		return fmt.Sprintf("UNKNOW_DEPTH_AVAILABLE_DEPTH_STREAM_CONFIGURATIONS_%d", int(e))
+	}
+}
+
+func (e MetadataDepth_depth_is_exclusive) String() string {
+	switch e {
+	case DEPTH_DEPTH_IS_EXCLUSIVE_FALSE:
+		return "DEPTH_DEPTH_IS_EXCLUSIVE_FALSE"
+	case DEPTH_DEPTH_IS_EXCLUSIVE_TRUE:
+		return "DEPTH_DEPTH_IS_EXCLUSIVE_TRUE"
+	default:
+		return fmt.Sprintf("UNKNOW_DEPTH_DEPTH_IS_EXCLUSIVE_%d", int(e))
+	}
+}
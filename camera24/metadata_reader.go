@@ -0,0 +1,267 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+//go:generate go run gen_accessors.go
+
+import (
+	"fmt"
+)
+
+// Rational 与 MetadataRational 相同，但不依赖 C 内存
+type Rational struct {
+	Numerator, Denominator int32
+}
+
+func (r Rational) Float64() float64 {
+	if r.Denominator == 0 {
+		return 0
+	}
+	return float64(r.Numerator) / float64(r.Denominator)
+}
+
+func (r Rational) String() string {
+	return fmt.Sprintf("%d/%d", r.Numerator, r.Denominator)
+}
+
+func (r *MetadataRational) Rational() Rational {
+	return Rational{int32(r.cptr().numerator), int32(r.cptr().denominator)}
+}
+
+// MetadataValue 一个 entry 的值。
+// Data 为 []uint8, []int32, []float32, []int64, []float64 或 []Rational 之一，
+// 数据已复制到 Go 内存中，在 Metadata.Free 之后仍可使用。
+type MetadataValue struct {
+	Tag  MetadataTag
+	Type Type
+	Data interface{}
+}
+
+func (v *MetadataValue) Count() int {
+	switch ds := v.Data.(type) {
+	case []uint8:
+		return len(ds)
+	case []int32:
+		return len(ds)
+	case []float32:
+		return len(ds)
+	case []int64:
+		return len(ds)
+	case []float64:
+		return len(ds)
+	case []Rational:
+		return len(ds)
+	}
+	return 0
+}
+
+func (v *MetadataValue) clone() *MetadataValue {
+	c := *v
+	switch ds := v.Data.(type) {
+	case []uint8:
+		c.Data = append([]uint8{}, ds...)
+	case []int32:
+		c.Data = append([]int32{}, ds...)
+	case []float32:
+		c.Data = append([]float32{}, ds...)
+	case []int64:
+		c.Data = append([]int64{}, ds...)
+	case []float64:
+		c.Data = append([]float64{}, ds...)
+	case []Rational:
+		c.Data = append([]Rational{}, ds...)
+	}
+	return &c
+}
+
+// Value 复制 entry 中的数据
+func (entry *MetadataConstEntry) Value() *MetadataValue {
+	v := &MetadataValue{Tag: entry.Tag(), Type: entry.Type()}
+	switch ds := entry.Data().(type) {
+	case []uint8:
+		v.Data = append([]uint8{}, ds...)
+	case []int32:
+		v.Data = append([]int32{}, ds...)
+	case []float32:
+		v.Data = append([]float32{}, ds...)
+	case []int64:
+		v.Data = append([]int64{}, ds...)
+	case []float64:
+		v.Data = append([]float64{}, ds...)
+	case []MetadataRational:
+		rs := make([]Rational, len(ds))
+		for i := range ds {
+			rs[i] = ds[i].Rational()
+		}
+		v.Data = rs
+	}
+	return v
+}
+
+// MetadataReader 可读取 metadata 的对象，*Metadata 实现了该接口
+type MetadataReader interface {
+	Tags() ([]MetadataTag, error)
+	ReadEntry(tag MetadataTag) (*MetadataValue, error)
+}
+
+// Tags 所有 tag 的副本，在 metadata 释放后仍然有效
+func (metadata *Metadata) Tags() ([]MetadataTag, error) {
+	tags, err := metadata.GetAllTags()
+	if err != nil {
+		return nil, err
+	}
+	return append([]MetadataTag{}, tags...), nil
+}
+
+// ReadEntry 读取 tag 对应的值
+func (metadata *Metadata) ReadEntry(tag MetadataTag) (*MetadataValue, error) {
+	entry, err := metadata.GetConstEntry(tag)
+	if err != nil {
+		return nil, &MetadataError{tag, err}
+	}
+	return entry.Value(), nil
+}
+
+// MetadataError 读取 tag 失败时返回
+type MetadataError struct {
+	Tag MetadataTag
+	Err error
+}
+
+func (e *MetadataError) Error() string {
+	return e.Tag.String() + ": " + e.Err.Error()
+}
+
+func (e *MetadataError) Unwrap() error {
+	return e.Err
+}
+
+// IsNotFound 判断 err 是否因 tag 不存在
+func IsNotFound(err error) bool {
+	if e, ok := err.(*MetadataError); ok {
+		err = e.Err
+	}
+	return err == STATUS_ERROR_METADATA_NOT_FOUND
+}
+
+// Accessor 按 tag 提供类型化的读取方法，
+// 方法由 gen_accessors.go 根据 NdkCameraMetadataTags.go 生成
+type Accessor struct {
+	MetadataReader
+}
+
+func (metadata *Metadata) Accessor() Accessor {
+	return Accessor{metadata}
+}
+
+func (a Accessor) read(tag MetadataTag, t Type) (*MetadataValue, error) {
+	v, err := a.ReadEntry(tag)
+	if err != nil {
+		return nil, err
+	}
+	if v.Type != t {
+		return nil, &MetadataError{tag, fmt.Errorf("type is %v, want %v", v.Type, t)}
+	}
+	if v.Count() == 0 {
+		return nil, &MetadataError{tag, STATUS_ERROR_METADATA_NOT_FOUND}
+	}
+	return v, nil
+}
+
+func (a Accessor) u8s(tag MetadataTag) ([]uint8, error) {
+	v, err := a.read(tag, TYPE_BYTE)
+	if err != nil {
+		return nil, err
+	}
+	return v.Data.([]uint8), nil
+}
+
+func (a Accessor) i32s(tag MetadataTag) ([]int32, error) {
+	v, err := a.read(tag, TYPE_INT32)
+	if err != nil {
+		return nil, err
+	}
+	return v.Data.([]int32), nil
+}
+
+func (a Accessor) f32s(tag MetadataTag) ([]float32, error) {
+	v, err := a.read(tag, TYPE_FLOAT)
+	if err != nil {
+		return nil, err
+	}
+	return v.Data.([]float32), nil
+}
+
+func (a Accessor) i64s(tag MetadataTag) ([]int64, error) {
+	v, err := a.read(tag, TYPE_INT64)
+	if err != nil {
+		return nil, err
+	}
+	return v.Data.([]int64), nil
+}
+
+func (a Accessor) f64s(tag MetadataTag) ([]float64, error) {
+	v, err := a.read(tag, TYPE_DOUBLE)
+	if err != nil {
+		return nil, err
+	}
+	return v.Data.([]float64), nil
+}
+
+func (a Accessor) rationals(tag MetadataTag) ([]Rational, error) {
+	v, err := a.read(tag, TYPE_RATIONAL)
+	if err != nil {
+		return nil, err
+	}
+	return v.Data.([]Rational), nil
+}
+
+func (a Accessor) u8(tag MetadataTag) (uint8, error) {
+	vs, err := a.u8s(tag)
+	if err != nil {
+		return 0, err
+	}
+	return vs[0], nil
+}
+
+func (a Accessor) i32(tag MetadataTag) (int32, error) {
+	vs, err := a.i32s(tag)
+	if err != nil {
+		return 0, err
+	}
+	return vs[0], nil
+}
+
+func (a Accessor) f32(tag MetadataTag) (float32, error) {
+	vs, err := a.f32s(tag)
+	if err != nil {
+		return 0, err
+	}
+	return vs[0], nil
+}
+
+func (a Accessor) i64(tag MetadataTag) (int64, error) {
+	vs, err := a.i64s(tag)
+	if err != nil {
+		return 0, err
+	}
+	return vs[0], nil
+}
+
+func (a Accessor) f64(tag MetadataTag) (float64, error) {
+	vs, err := a.f64s(tag)
+	if err != nil {
+		return 0, err
+	}
+	return vs[0], nil
+}
+
+func (a Accessor) rational(tag MetadataTag) (Rational, error) {
+	vs, err := a.rationals(tag)
+	if err != nil {
+		return Rational{}, err
+	}
+	return vs[0], nil
+}
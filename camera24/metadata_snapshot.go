@@ -0,0 +1,224 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MetadataSnapshot 纯 Go 保存的 metadata，可序列化为 JSON，
+// 也可从 JSON 载入，用于离线分析或在没有设备时测试选择摄像头的逻辑。
+type MetadataSnapshot struct {
+	values map[MetadataTag]*MetadataValue
+}
+
+func NewMetadataSnapshot() *MetadataSnapshot {
+	return &MetadataSnapshot{values: map[MetadataTag]*MetadataValue{}}
+}
+
+// Snapshot 复制 r 中所有的 tag
+func Snapshot(r MetadataReader) (*MetadataSnapshot, error) {
+	tags, err := r.Tags()
+	if err != nil {
+		return nil, err
+	}
+	s := NewMetadataSnapshot()
+	for _, tag := range tags {
+		v, err := r.ReadEntry(tag)
+		if err != nil {
+			if IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		s.Set(v)
+	}
+	return s, nil
+}
+
+// Snapshot 复制所有 tag，得到的 MetadataSnapshot 在 Free 之后仍可使用
+func (metadata *Metadata) Snapshot() (*MetadataSnapshot, error) {
+	return Snapshot(metadata)
+}
+
+func (metadata *Metadata) MarshalJSON() ([]byte, error) {
+	s, err := metadata.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(s)
+}
+
+// Set 添加或替换一个 entry，保存的是 v 的副本
+func (s *MetadataSnapshot) Set(v *MetadataValue) {
+	s.values[v.Tag] = v.clone()
+}
+
+func (s *MetadataSnapshot) Delete(tag MetadataTag) {
+	delete(s.values, tag)
+}
+
+func (s *MetadataSnapshot) Tags() ([]MetadataTag, error) {
+	tags := make([]MetadataTag, 0, len(s.values))
+	for tag := range s.values {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+	return tags, nil
+}
+
+// ReadEntry 返回副本，修改 Data 不影响 snapshot
+func (s *MetadataSnapshot) ReadEntry(tag MetadataTag) (*MetadataValue, error) {
+	if v, ok := s.values[tag]; ok {
+		return v.clone(), nil
+	}
+	return nil, &MetadataError{tag, STATUS_ERROR_METADATA_NOT_FOUND}
+}
+
+func (s *MetadataSnapshot) Accessor() Accessor {
+	return Accessor{s}
+}
+
+// JSON 格式:
+//
+//	{"entries": [{"tag": 524293, "name": "CAMERA_LENS_FACING", "type": "BYTE", "data": [1]}, ...]}
+//
+// RATIONAL 的 data 为 [[numerator, denominator], ...]。
+// 载入时优先使用 tag，tag 为 0 时按 name 查找。
+type jsonMetadata struct {
+	Entries []jsonEntry `json:"entries"`
+}
+
+type jsonEntry struct {
+	Tag  MetadataTag     `json:"tag"`
+	Name string          `json:"name,omitempty"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+func (s *MetadataSnapshot) MarshalJSON() ([]byte, error) {
+	tags, _ := s.Tags()
+	jm := jsonMetadata{Entries: make([]jsonEntry, 0, len(tags))}
+	for _, tag := range tags {
+		v := s.values[tag]
+		var data interface{} = v.Data
+		switch ds := v.Data.(type) {
+		case []uint8:
+			// 避免 []byte 被编码为 base64
+			is := make([]int, len(ds))
+			for i, d := range ds {
+				is[i] = int(d)
+			}
+			data = is
+		case []Rational:
+			rs := make([][2]int32, len(ds))
+			for i, d := range ds {
+				rs[i] = [2]int32{d.Numerator, d.Denominator}
+			}
+			data = rs
+		}
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return nil, &MetadataError{tag, err}
+		}
+		jm.Entries = append(jm.Entries, jsonEntry{tag, tag.String(), v.Type.String(), raw})
+	}
+	return json.Marshal(jm)
+}
+
+func (s *MetadataSnapshot) UnmarshalJSON(b []byte) error {
+	var jm jsonMetadata
+	if err := json.Unmarshal(b, &jm); err != nil {
+		return err
+	}
+	values := map[MetadataTag]*MetadataValue{}
+	for _, e := range jm.Entries {
+		tag := e.Tag
+		if tag == 0 {
+			var ok bool
+			if tag, ok = metadataTagByName(e.Name); !ok {
+				return fmt.Errorf("camera: unknown metadata tag %q", e.Name)
+			}
+		}
+		v, err := decodeJSONValue(tag, e.Type, e.Data)
+		if err != nil {
+			return &MetadataError{tag, err}
+		}
+		values[tag] = v
+	}
+	s.values = values
+	return nil
+}
+
+func decodeJSONValue(tag MetadataTag, typ string, raw json.RawMessage) (*MetadataValue, error) {
+	v := &MetadataValue{Tag: tag}
+	var err error
+	switch typ {
+	case TYPE_BYTE.String():
+		var is []uint8
+		var ds []int
+		if err = json.Unmarshal(raw, &ds); err == nil {
+			is = make([]uint8, len(ds))
+			for i, d := range ds {
+				if d < 0 || d > 0xff {
+					return nil, fmt.Errorf("byte value %d out of range", d)
+				}
+				is[i] = uint8(d)
+			}
+		}
+		v.Type, v.Data = TYPE_BYTE, is
+	case TYPE_INT32.String():
+		var ds []int32
+		err = json.Unmarshal(raw, &ds)
+		v.Type, v.Data = TYPE_INT32, ds
+	case TYPE_FLOAT.String():
+		var ds []float32
+		err = json.Unmarshal(raw, &ds)
+		v.Type, v.Data = TYPE_FLOAT, ds
+	case TYPE_INT64.String():
+		var ds []int64
+		err = json.Unmarshal(raw, &ds)
+		v.Type, v.Data = TYPE_INT64, ds
+	case TYPE_DOUBLE.String():
+		var ds []float64
+		err = json.Unmarshal(raw, &ds)
+		v.Type, v.Data = TYPE_DOUBLE, ds
+	case TYPE_RATIONAL.String():
+		var ds [][2]int32
+		var rs []Rational
+		if err = json.Unmarshal(raw, &ds); err == nil {
+			rs = make([]Rational, len(ds))
+			for i, d := range ds {
+				rs[i] = Rational{d[0], d[1]}
+			}
+		}
+		v.Type, v.Data = TYPE_RATIONAL, rs
+	default:
+		return nil, fmt.Errorf("unknown type %q", typ)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+var (
+	metadataTagNames     map[string]MetadataTag
+	metadataTagNamesOnce sync.Once
+)
+
+func metadataTagByName(name string) (MetadataTag, bool) {
+	metadataTagNamesOnce.Do(func() {
+		metadataTagNames = make(map[string]MetadataTag, len(metadataTags))
+		for _, tag := range metadataTags {
+			metadataTagNames[tag.String()] = tag
+		}
+	})
+	tag, ok := metadataTagNames[name]
+	return tag, ok
+}
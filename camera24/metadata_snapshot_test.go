@@ -0,0 +1,153 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testValues 每种类型一个 entry
+var testValues = []*MetadataValue{
+	{LENS_FACING, TYPE_BYTE, []uint8{1}},
+	{SENSOR_ORIENTATION, TYPE_INT32, []int32{270}},
+	{LENS_INFO_AVAILABLE_FOCAL_LENGTHS, TYPE_FLOAT, []float32{4.25, 6.5}},
+	{SENSOR_INFO_EXPOSURE_TIME_RANGE, TYPE_INT64, []int64{13611, 683709000}},
+	{JPEG_GPS_COORDINATES, TYPE_DOUBLE, []float64{39.9042, 116.4074, 44.5}},
+	{CONTROL_AE_COMPENSATION_STEP, TYPE_RATIONAL, []Rational{{1, 6}}},
+}
+
+func testSnapshot() *MetadataSnapshot {
+	s := NewMetadataSnapshot()
+	for _, v := range testValues {
+		s.Set(v)
+	}
+	return s
+}
+
+func TestMetadataSnapshotJSON(t *testing.T) {
+	b, err := json.Marshal(testSnapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 检查格式：tag、name、type，RATIONAL 为 [numerator, denominator]
+	var jm struct {
+		Entries []struct {
+			Tag  MetadataTag
+			Name string
+			Type string
+			Data json.RawMessage
+		}
+	}
+	if err := json.Unmarshal(b, &jm); err != nil {
+		t.Fatal(err)
+	}
+	if len(jm.Entries) != len(testValues) {
+		t.Fatalf("%d entries, want %d", len(jm.Entries), len(testValues))
+	}
+	for i, e := range jm.Entries {
+		if i > 0 && e.Tag <= jm.Entries[i-1].Tag {
+			t.Errorf("entries not sorted by tag: %v after %v", e.Tag, jm.Entries[i-1].Tag)
+		}
+		if e.Name != e.Tag.String() {
+			t.Errorf("tag %d: name %q, want %q", e.Tag, e.Name, e.Tag.String())
+		}
+		switch e.Tag {
+		case LENS_FACING:
+			if e.Type != "BYTE" || string(e.Data) != "[1]" {
+				t.Errorf("LENS_FACING = %s %s, want BYTE [1]", e.Type, e.Data)
+			}
+		case CONTROL_AE_COMPENSATION_STEP:
+			if e.Type != "RATIONAL" || string(e.Data) != "[[1,6]]" {
+				t.Errorf("CONTROL_AE_COMPENSATION_STEP = %s %s, want RATIONAL [[1,6]]", e.Type, e.Data)
+			}
+		}
+	}
+
+	s := NewMetadataSnapshot()
+	if err := json.Unmarshal(b, s); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range testValues {
+		got, err := s.ReadEntry(want.Tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v = %+v, want %+v", want.Tag, got, want)
+		}
+	}
+	again, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(b) {
+		t.Errorf("round trip changed JSON:\n%s\n%s", b, again)
+	}
+
+	a := s.Accessor()
+	if step, err := a.ControlAeCompensationStep(); err != nil || step != (Rational{1, 6}) {
+		t.Errorf("ControlAeCompensationStep = %v, %v", step, err)
+	}
+	if o, err := a.SensorOrientation(); err != nil || o != 270 {
+		t.Errorf("SensorOrientation = %v, %v", o, err)
+	}
+}
+
+func TestMetadataSnapshotJSONByName(t *testing.T) {
+	s := NewMetadataSnapshot()
+	err := json.Unmarshal([]byte(`{"entries": [{"name": "CAMERA_SENSOR_ORIENTATION", "type": "INT32", "data": [90]}]}`), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o, err := s.Accessor().SensorOrientation(); err != nil || o != 90 {
+		t.Errorf("SensorOrientation = %v, %v", o, err)
+	}
+
+	for _, bad := range []string{
+		`{"entries": [{"name": "NO_SUCH_TAG", "type": "INT32", "data": [90]}]}`,
+		`{"entries": [{"name": "CAMERA_LENS_FACING", "type": "BYTE", "data": [256]}]}`,
+		`{"entries": [{"name": "CAMERA_LENS_FACING", "type": "STRING", "data": [1]}]}`,
+		`{"entries": [{"name": "CAMERA_LENS_FACING", "type": "RATIONAL", "data": [1]}]}`,
+	} {
+		if err := json.Unmarshal([]byte(bad), s); err == nil {
+			t.Errorf("%s: no error", bad)
+		}
+	}
+	// 失败时保留原来的内容
+	if tags, _ := s.Tags(); len(tags) != 1 {
+		t.Errorf("%d tags after failed Unmarshal, want 1", len(tags))
+	}
+}
+
+func TestMetadataSnapshotCopies(t *testing.T) {
+	v := &MetadataValue{SENSOR_ORIENTATION, TYPE_INT32, []int32{90}}
+	s := NewMetadataSnapshot()
+	s.Set(v)
+	v.Data.([]int32)[0] = 180
+
+	got, err := s.ReadEntry(SENSOR_ORIENTATION)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o := got.Data.([]int32)[0]; o != 90 {
+		t.Fatalf("Set kept the caller's slice: %d", o)
+	}
+	got.Data.([]int32)[0] = 0
+	if o, _ := s.Accessor().SensorOrientation(); o != 90 {
+		t.Errorf("ReadEntry returned the snapshot's slice: %d", o)
+	}
+
+	if _, err := s.ReadEntry(LENS_FACING); !IsNotFound(err) {
+		t.Errorf("missing tag: %v, want not found", err)
+	}
+	s.Delete(SENSOR_ORIENTATION)
+	if _, err := s.ReadEntry(SENSOR_ORIENTATION); !IsNotFound(err) || !strings.Contains(err.Error(), "SENSOR_ORIENTATION") {
+		t.Errorf("deleted tag: %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"fmt"
+	"image"
+)
+
+// MeteringRectangle 测光、对焦或白平衡区域，坐标基于 ActiveArraySize。
+// Weight 取值 [0, 1000]，为 0 时该区域被忽略。
+type MeteringRectangle struct {
+	Rect   image.Rectangle
+	Weight int
+}
+
+// RequestBuilder 以纯 Go 的方式组装 CaptureRequest 的参数，
+// 可以在没有设备时构造和检查，最后用 Apply 或 Build 写入 CaptureRequest。
+//
+// 设置方法可链式调用，出现的第一个错误由 Err 返回，之后的设置被忽略:
+//
+//	b := NewRequestBuilder(chars).SetAfMode(AF_MODE_CONTINUOUS_PICTURE).SetJpegOrientation(90)
+//	if err := b.Err(); err != nil { ... }
+type RequestBuilder struct {
+	settings *MetadataSnapshot
+	chars    Accessor
+	err      error
+}
+
+// NewRequestBuilder chars 为摄像头的 characteristics，用于校验参数；
+// 为 nil 时只做与设备无关的检查。
+func NewRequestBuilder(chars MetadataReader) *RequestBuilder {
+	return &RequestBuilder{settings: NewMetadataSnapshot(), chars: Accessor{chars}}
+}
+
+// NewRequestBuilderFrom 以 src 中已有的参数(如模板生成的 CaptureRequest)为初值
+func NewRequestBuilderFrom(src MetadataReader, chars MetadataReader) (*RequestBuilder, error) {
+	s, err := Snapshot(src)
+	if err != nil {
+		return nil, err
+	}
+	return &RequestBuilder{settings: s, chars: Accessor{chars}}, nil
+}
+
+func (b *RequestBuilder) Err() error {
+	return b.err
+}
+
+// Settings 已设置的参数
+func (b *RequestBuilder) Settings() *MetadataSnapshot {
+	return b.settings
+}
+
+// Accessor 按类型读取已设置的参数
+func (b *RequestBuilder) Accessor() Accessor {
+	return b.settings.Accessor()
+}
+
+// Clone 复制所有参数，两者之后的修改互不影响
+func (b *RequestBuilder) Clone() *RequestBuilder {
+	s := NewMetadataSnapshot()
+	for tag, v := range b.settings.values {
+		s.values[tag] = v.clone()
+	}
+	return &RequestBuilder{settings: s, chars: b.chars, err: b.err}
+}
+
+// Set 直接设置一个 entry，不做校验
+func (b *RequestBuilder) Set(v *MetadataValue) *RequestBuilder {
+	if b.err == nil {
+		b.settings.Set(v.clone())
+	}
+	return b
+}
+
+// Apply 将参数写入 request
+func (b *RequestBuilder) Apply(request *CaptureRequest) error {
+	if b.err != nil {
+		return b.err
+	}
+	tags, _ := b.settings.Tags()
+	for _, tag := range tags {
+		if err := request.SetEntry(b.settings.values[tag]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Build 用 template 创建 CaptureRequest 并写入参数。
+// 输出目标不属于参数，需另外调用 AddTarget。
+func (b *RequestBuilder) Build(device *Device, template DeviceRequestTemplate) (*CaptureRequest, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	request, err := device.CreateCaptureRequest(template)
+	if err != nil {
+		return nil, err
+	}
+	if err = b.Apply(request); err != nil {
+		request.Free()
+		return nil, err
+	}
+	return request, nil
+}
+
+func (b *RequestBuilder) SetAfMode(mode AfMode) *RequestBuilder {
+	if b.check() {
+		if modes, err := b.chars.AvailableAfModes(); err == nil && !contains(len(modes), func(i int) bool { return modes[i] == mode }) {
+			return b.fail(CONTROL_AF_MODE, fmt.Errorf("%v not supported", mode))
+		}
+	}
+	return b.setU8(CONTROL_AF_MODE, uint8(mode))
+}
+
+func (b *RequestBuilder) SetAeMode(mode AeMode) *RequestBuilder {
+	if b.check() {
+		if modes, err := b.chars.AvailableAeModes(); err == nil && !contains(len(modes), func(i int) bool { return modes[i] == mode }) {
+			return b.fail(CONTROL_AE_MODE, fmt.Errorf("%v not supported", mode))
+		}
+	}
+	return b.setU8(CONTROL_AE_MODE, uint8(mode))
+}
+
+func (b *RequestBuilder) SetAwbMode(mode AwbMode) *RequestBuilder {
+	if b.check() {
+		if modes, err := b.chars.AvailableAwbModes(); err == nil && !contains(len(modes), func(i int) bool { return modes[i] == mode }) {
+			return b.fail(CONTROL_AWB_MODE, fmt.Errorf("%v not supported", mode))
+		}
+	}
+	return b.setU8(CONTROL_AWB_MODE, uint8(mode))
+}
+
+func (b *RequestBuilder) SetAfTrigger(trigger AfTrigger) *RequestBuilder {
+	return b.setU8(CONTROL_AF_TRIGGER, uint8(trigger))
+}
+
+func (b *RequestBuilder) SetAePrecaptureTrigger(trigger AePrecaptureTrigger) *RequestBuilder {
+	return b.setU8(CONTROL_AE_PRECAPTURE_TRIGGER, uint8(trigger))
+}
+
+func (b *RequestBuilder) SetControlMode(mode ControlMode) *RequestBuilder {
+	return b.setU8(CONTROL_MODE, uint8(mode))
+}
+
+func (b *RequestBuilder) SetCaptureIntent(intent CaptureIntent) *RequestBuilder {
+	return b.setU8(CONTROL_CAPTURE_INTENT, uint8(intent))
+}
+
+// SetFlashMode 没有闪光灯的设备只能设置 FLASH_MODE_OFF
+func (b *RequestBuilder) SetFlashMode(mode FlashMode) *RequestBuilder {
+	if b.check() && mode != FLASH_MODE_OFF {
+		if avail, err := b.chars.FlashInfoAvailable(); err == nil && avail != FLASH_INFO_AVAILABLE_TRUE {
+			return b.fail(FLASH_MODE, fmt.Errorf("%v: no flash unit", mode))
+		}
+	}
+	return b.setU8(FLASH_MODE, uint8(mode))
+}
+
+func (b *RequestBuilder) SetAeRegions(regions []MeteringRectangle) *RequestBuilder {
+	return b.setRegions(CONTROL_AE_REGIONS, 0, regions)
+}
+
+func (b *RequestBuilder) SetAwbRegions(regions []MeteringRectangle) *RequestBuilder {
+	return b.setRegions(CONTROL_AWB_REGIONS, 1, regions)
+}
+
+func (b *RequestBuilder) SetAfRegions(regions []MeteringRectangle) *RequestBuilder {
+	return b.setRegions(CONTROL_AF_REGIONS, 2, regions)
+}
+
+// SetZoomCropRegion 设置 SCALER_CROP_REGION，坐标基于 ActiveArraySize
+func (b *RequestBuilder) SetZoomCropRegion(rect image.Rectangle) *RequestBuilder {
+	if rect.Empty() {
+		return b.fail(SCALER_CROP_REGION, fmt.Errorf("empty region %v", rect))
+	}
+	if b.check() {
+		if active, err := b.chars.ActiveArraySize(); err == nil {
+			if !rect.In(active.Sub(active.Min)) {
+				return b.fail(SCALER_CROP_REGION, fmt.Errorf("%v out of active array %v", rect, active))
+			}
+			if max, err := b.chars.MaxDigitalZoom(); err == nil && float64(active.Dx())/float64(rect.Dx()) > max+1e-6 {
+				return b.fail(SCALER_CROP_REGION, fmt.Errorf("%v exceeds max digital zoom %v", rect, max))
+			}
+		}
+	}
+	return b.setI32(SCALER_CROP_REGION, int32(rect.Min.X), int32(rect.Min.Y), int32(rect.Dx()), int32(rect.Dy()))
+}
+
+// SetZoom 以画面中心为基准的数码变焦，需要 characteristics
+func (b *RequestBuilder) SetZoom(zoom float64) *RequestBuilder {
+	if b.err != nil {
+		return b
+	}
+	if b.chars.MetadataReader == nil {
+		return b.fail(SCALER_CROP_REGION, fmt.Errorf("zoom needs characteristics"))
+	}
+	if zoom < 1 {
+		return b.fail(SCALER_CROP_REGION, fmt.Errorf("zoom %v less than 1", zoom))
+	}
+	active, err := b.chars.ActiveArraySize()
+	if err != nil {
+		return b.fail(SCALER_CROP_REGION, err)
+	}
+	w, h := int(float64(active.Dx())/zoom), int(float64(active.Dy())/zoom)
+	x, y := (active.Dx()-w)/2, (active.Dy()-h)/2
+	return b.SetZoomCropRegion(image.Rect(x, y, x+w, y+h))
+}
+
+// SetJpegOrientation 顺时针旋转角度，必须是 90 的倍数
+func (b *RequestBuilder) SetJpegOrientation(degrees int) *RequestBuilder {
+	if degrees%90 != 0 {
+		return b.fail(JPEG_ORIENTATION, fmt.Errorf("orientation %d not a multiple of 90", degrees))
+	}
+	return b.setI32(JPEG_ORIENTATION, int32((degrees%360+360)%360))
+}
+
+// SetJpegQuality 取值 [1, 100]
+func (b *RequestBuilder) SetJpegQuality(quality int) *RequestBuilder {
+	if quality < 1 || quality > 100 {
+		return b.fail(JPEG_QUALITY, fmt.Errorf("quality %d out of [1, 100]", quality))
+	}
+	return b.setU8(JPEG_QUALITY, uint8(quality))
+}
+
+func (b *RequestBuilder) SetFpsRange(r FpsRange) *RequestBuilder {
+	if r.Min <= 0 || r.Min > r.Max {
+		return b.fail(CONTROL_AE_TARGET_FPS_RANGE, fmt.Errorf("invalid fps range %v", r))
+	}
+	if b.check() {
+		if rs, err := b.chars.FpsRanges(); err == nil && !contains(len(rs), func(i int) bool { return rs[i] == r }) {
+			return b.fail(CONTROL_AE_TARGET_FPS_RANGE, fmt.Errorf("fps range %v not supported", r))
+		}
+	}
+	return b.setI32(CONTROL_AE_TARGET_FPS_RANGE, int32(r.Min), int32(r.Max))
+}
+
+// SetExposureCompensation 单位为 CONTROL_AE_COMPENSATION_STEP
+func (b *RequestBuilder) SetExposureCompensation(steps int) *RequestBuilder {
+	if b.check() {
+		if min, max, err := b.chars.AeCompensationRange(); err == nil && (steps < min || steps > max) {
+			return b.fail(CONTROL_AE_EXPOSURE_COMPENSATION, fmt.Errorf("%d out of [%d, %d]", steps, min, max))
+		}
+	}
+	return b.setI32(CONTROL_AE_EXPOSURE_COMPENSATION, int32(steps))
+}
+
+// index 为 CONTROL_MAX_REGIONS 中的位置: ae, awb, af
+func (b *RequestBuilder) setRegions(tag MetadataTag, index int, regions []MeteringRectangle) *RequestBuilder {
+	if len(regions) == 0 {
+		return b.fail(tag, fmt.Errorf("no region"))
+	}
+	for _, r := range regions {
+		if r.Rect.Empty() || r.Weight < 0 || r.Weight > 1000 {
+			return b.fail(tag, fmt.Errorf("invalid region %v", r))
+		}
+	}
+	if b.check() {
+		if ae, awb, af, err := b.chars.MaxRegions(); err == nil {
+			if max := [3]int{ae, awb, af}[index]; len(regions) > max {
+				return b.fail(tag, fmt.Errorf("%d regions, max %d", len(regions), max))
+			}
+		}
+		if active, err := b.chars.ActiveArraySize(); err == nil {
+			for _, r := range regions {
+				if !r.Rect.In(active.Sub(active.Min)) {
+					return b.fail(tag, fmt.Errorf("%v out of active array %v", r.Rect, active))
+				}
+			}
+		}
+	}
+	ds := make([]int32, 0, len(regions)*5)
+	for _, r := range regions {
+		ds = append(ds, int32(r.Rect.Min.X), int32(r.Rect.Min.Y), int32(r.Rect.Max.X), int32(r.Rect.Max.Y), int32(r.Weight))
+	}
+	return b.setI32(tag, ds...)
+}
+
+// check 返回是否需要根据 characteristics 校验
+func (b *RequestBuilder) check() bool {
+	return b.err == nil && b.chars.MetadataReader != nil
+}
+
+func (b *RequestBuilder) fail(tag MetadataTag, err error) *RequestBuilder {
+	if b.err == nil {
+		b.err = &MetadataError{tag, err}
+	}
+	return b
+}
+
+func (b *RequestBuilder) setU8(tag MetadataTag, ds ...uint8) *RequestBuilder {
+	if b.err == nil {
+		b.settings.Set(&MetadataValue{tag, TYPE_BYTE, ds})
+	}
+	return b
+}
+
+func (b *RequestBuilder) setI32(tag MetadataTag, ds ...int32) *RequestBuilder {
+	if b.err == nil {
+		b.settings.Set(&MetadataValue{tag, TYPE_INT32, ds})
+	}
+	return b
+}
+
+func contains(n int, eq func(i int) bool) bool {
+	for i := 0; i < n; i++ {
+		if eq(i) {
+			return true
+		}
+	}
+	return false
+}
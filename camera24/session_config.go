@@ -0,0 +1,305 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	app "github.com/gooid/gooid/internal/ndk"
+	media "github.com/gooid/gooid/media24"
+)
+
+// OutputKind 输出的用途
+type OutputKind int
+
+const (
+	OUTPUT_DISPLAY OutputKind = iota // 显示用的 Window
+	OUTPUT_READER                    // ImageReader，如 YUV 分析、JPEG 拍照
+	OUTPUT_ENCODER                   // 编码器的输入 surface
+)
+
+func (k OutputKind) String() string {
+	switch k {
+	case OUTPUT_DISPLAY:
+		return "DISPLAY"
+	case OUTPUT_READER:
+		return "READER"
+	case OUTPUT_ENCODER:
+		return "ENCODER"
+	}
+	return fmt.Sprintf("UNKNOW_OUTPUT_KIND%d", int(k))
+}
+
+// OutputConfig 一个命名的输出
+type OutputConfig struct {
+	Name   string
+	Kind   OutputKind
+	Window *app.Window
+	Reader *media.ImageReader // Kind 为 OUTPUT_READER 时使用，Window 取自 Reader
+	// Enabled 未指定输出时，请求是否包含该输出
+	Enabled bool
+}
+
+// DisplayOutput 显示窗口，默认启用
+func DisplayOutput(name string, w *app.Window) OutputConfig {
+	return OutputConfig{Name: name, Kind: OUTPUT_DISPLAY, Window: w, Enabled: true}
+}
+
+// ReaderOutput ImageReader，默认不启用
+func ReaderOutput(name string, reader *media.ImageReader) OutputConfig {
+	return OutputConfig{Name: name, Kind: OUTPUT_READER, Reader: reader}
+}
+
+// EncoderOutput 编码器的输入 surface，默认不启用
+func EncoderOutput(name string, w *app.Window) OutputConfig {
+	return OutputConfig{Name: name, Kind: OUTPUT_ENCODER, Window: w}
+}
+
+// SessionConfig 声明 session 的所有输出
+type SessionConfig struct {
+	Outputs []OutputConfig
+}
+
+// Output 已配置的输出，其 OutputTarget 由 Session 管理
+type Output struct {
+	Name   string
+	Kind   OutputKind
+	Reader *media.ImageReader
+
+	window  *app.Window
+	target  *OutputTarget
+	enabled bool
+}
+
+func (o *Output) Window() *app.Window {
+	return o.window
+}
+
+func (o *Output) Target() *OutputTarget {
+	return o.target
+}
+
+// Session 按 SessionConfig 创建的 session，
+// 请求可以指定输出的名字，未指定时使用所有已启用的输出。
+//
+// 相机重新连接后 session 会被 Camera 重建，在 Camera.OnReconnected 中调用 Resume 恢复重复请求。
+type Session struct {
+	camera  *Camera
+	outputs []*Output
+
+	mu          sync.Mutex
+	repeating   *RequestBuilder
+	repeatingCb interface{}
+	template    DeviceRequestTemplate
+	names       []string
+}
+
+// Configure 按 cfg 创建 session 并等待就绪，替换已有的 session
+func (c *Camera) Configure(ctx context.Context, cfg SessionConfig) (*Session, error) {
+	s := &Session{camera: c}
+	windows := make([]*app.Window, 0, len(cfg.Outputs))
+	for _, oc := range cfg.Outputs {
+		w, err := oc.window()
+		if err == nil && s.Output(oc.Name) != nil {
+			err = fmt.Errorf("camera: duplicate output %q", oc.Name)
+		}
+		var target *OutputTarget
+		if err == nil {
+			target, err = CameraOutputTargetCreate(w)
+		}
+		if err != nil {
+			s.free()
+			return nil, err
+		}
+		s.outputs = append(s.outputs, &Output{
+			Name:    oc.Name,
+			Kind:    oc.Kind,
+			Reader:  oc.Reader,
+			window:  w,
+			target:  target,
+			enabled: oc.Enabled,
+		})
+		windows = append(windows, w)
+	}
+	if err := c.CreateSession(ctx, windows...); err != nil {
+		s.free()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (oc *OutputConfig) window() (*app.Window, error) {
+	if oc.Kind == OUTPUT_READER {
+		if oc.Reader == nil {
+			return nil, fmt.Errorf("camera: output %q has no reader", oc.Name)
+		}
+		return oc.Reader.GetWindow()
+	}
+	if oc.Window == nil {
+		return nil, fmt.Errorf("camera: output %q has no window", oc.Name)
+	}
+	return oc.Window, nil
+}
+
+func (s *Session) Camera() *Camera {
+	return s.camera
+}
+
+// Output 按名字查找，不存在时返回 nil
+func (s *Session) Output(name string) *Output {
+	for _, o := range s.outputs {
+		if o.Name == name {
+			return o
+		}
+	}
+	return nil
+}
+
+func (s *Session) Outputs() []*Output {
+	return append([]*Output{}, s.outputs...)
+}
+
+// SetEnabled 改变未指定输出时请求是否包含 name，
+// 已提交的重复请求不受影响，需要再次 SetRepeating 或 Resume
+func (s *Session) SetEnabled(name string, enabled bool) error {
+	o := s.Output(name)
+	if o == nil {
+		return fmt.Errorf("camera: unknown output %q", name)
+	}
+	s.mu.Lock()
+	o.enabled = enabled
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Session) Enabled(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o := s.Output(name)
+	return o != nil && o.enabled
+}
+
+// Targets names 对应的输出目标，names 为空时返回所有已启用的
+func (s *Session) Targets(names ...string) ([]*OutputTarget, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var targets []*OutputTarget
+	if len(names) == 0 {
+		for _, o := range s.outputs {
+			if o.enabled {
+				targets = append(targets, o.target)
+			}
+		}
+	}
+	for _, name := range names {
+		o := s.Output(name)
+		if o == nil {
+			return nil, fmt.Errorf("camera: unknown output %q", name)
+		}
+		targets = append(targets, o.target)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("camera: request has no output")
+	}
+	return targets, nil
+}
+
+// NewRequest 以 b 的参数创建请求并加入 names 对应的输出，用完后需 Free
+func (s *Session) NewRequest(b *RequestBuilder, template DeviceRequestTemplate, names ...string) (*CaptureRequest, error) {
+	targets, err := s.Targets(names...)
+	if err != nil {
+		return nil, err
+	}
+	device := s.camera.Device()
+	if device == nil {
+		return nil, STATUS_ERROR_CAMERA_DISCONNECTED
+	}
+	request, err := b.Build(device, template)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range targets {
+		if err = request.AddTarget(t); err != nil {
+			request.Free()
+			return nil, err
+		}
+	}
+	return request, nil
+}
+
+// SetRepeating 提交重复请求，返回 sequenceId，cb 同 SetRepeatingRequestWithCallbacks
+func (s *Session) SetRepeating(cb interface{}, b *RequestBuilder, template DeviceRequestTemplate, names ...string) (int, error) {
+	id, err := s.submit(cb, b, template, names, true)
+	if err == nil {
+		s.mu.Lock()
+		s.repeating, s.repeatingCb = b.Clone(), cb
+		s.template, s.names = template, append([]string{}, names...)
+		s.mu.Unlock()
+	}
+	return id, err
+}
+
+// Capture 提交一次请求，返回 sequenceId
+func (s *Session) Capture(cb interface{}, b *RequestBuilder, template DeviceRequestTemplate, names ...string) (int, error) {
+	return s.submit(cb, b, template, names, false)
+}
+
+// StopRepeating 停止重复请求，Resume 不再恢复它
+func (s *Session) StopRepeating() error {
+	s.mu.Lock()
+	s.repeating, s.repeatingCb = nil, nil
+	s.mu.Unlock()
+	session := s.camera.Session()
+	if session == nil {
+		return STATUS_ERROR_SESSION_CLOSED
+	}
+	return session.StopRepeating()
+}
+
+// Resume 以最近一次 SetRepeating 的参数和 cb 重新提交重复请求，
+// 输出按当前的启用状态选择
+func (s *Session) Resume() error {
+	s.mu.Lock()
+	b, cb, template, names := s.repeating, s.repeatingCb, s.template, s.names
+	s.mu.Unlock()
+	if b == nil {
+		return nil
+	}
+	_, err := s.submit(cb, b, template, names, true)
+	return err
+}
+
+func (s *Session) submit(cb interface{}, b *RequestBuilder, template DeviceRequestTemplate, names []string, repeat bool) (int, error) {
+	session := s.camera.Session()
+	if session == nil {
+		return 0, STATUS_ERROR_SESSION_CLOSED
+	}
+	request, err := s.NewRequest(b, template, names...)
+	if err != nil {
+		return 0, err
+	}
+	defer request.Free()
+	if repeat {
+		return session.SetRepeatingRequestWithCallbacks(cb, []*CaptureRequest{request})
+	}
+	return session.Capture(cb, []*CaptureRequest{request})
+}
+
+// Close 关闭 session 并释放输出目标，相机保持打开
+func (s *Session) Close() {
+	s.camera.CloseSession()
+	s.free()
+}
+
+func (s *Session) free() {
+	for _, o := range s.outputs {
+		if o.target != nil {
+			o.target.Free()
+			o.target = nil
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package camera
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	media "github.com/gooid/gooid/media24"
+)
+
+// PhotoFlash 拍照时的闪光灯设置，对应不同的 AE 模式
+type PhotoFlash int
+
+const (
+	PHOTO_FLASH_OFF PhotoFlash = iota
+	PHOTO_FLASH_AUTO
+	PHOTO_FLASH_ON
+	PHOTO_FLASH_TORCH
+)
+
+// PhotoOptions 拍照参数
+type PhotoOptions struct {
+	Flash PhotoFlash
+	// Orientation JPEG_ORIENTATION，见 Accessor.JpegOrientationFor
+	Orientation int
+	// Quality JPEG 质量 [1, 100]，为 0 时使用设备默认值
+	Quality int
+}
+
+// Photo 拍摄得到的照片
+type Photo struct {
+	JPEG        []byte
+	Orientation int
+	Timestamp   time.Duration
+}
+
+// ErrPhotoTimeout 在限定时间内没有得到照片
+var ErrPhotoTimeout = errors.New("camera: still capture timeout")
+
+// StillCapture 拍照流程:
+// 对焦(AF_TRIGGER_START)并等待锁定，测光(AE_PRECAPTURE_TRIGGER_START)并等待收敛，
+// 通过 FORMAT_JPEG 的 ImageReader 拍照，最后取消对焦锁定并恢复预览。
+//
+// session 创建时必须已包含预览窗口和 reader 的窗口。
+// reader 的 ImageListener 由 StillCapture 接管。
+type StillCapture struct {
+	session        *CaptureSession
+	device         *Device
+	chars          Accessor
+	preview        *RequestBuilder
+	previewTargets []*OutputTarget
+	reader         *media.ImageReader
+	jpegTarget     *OutputTarget
+	images         chan struct{}
+
+	// Timeout 每个等待步骤的最长时间
+	Timeout time.Duration
+}
+
+// NewStillCapture preview 为预览使用的参数，previewTargets 为预览的输出目标，
+// 拍照前后预览以这些参数重复请求。
+func NewStillCapture(session *CaptureSession, device *Device, chars MetadataReader,
+	preview *RequestBuilder, previewTargets []*OutputTarget, reader *media.ImageReader) (*StillCapture, error) {
+	window, err := reader.GetWindow()
+	if err != nil {
+		return nil, err
+	}
+	target, err := CameraOutputTargetCreate(window)
+	if err != nil {
+		return nil, err
+	}
+	c := &StillCapture{
+		session:        session,
+		device:         device,
+		chars:          Accessor{chars},
+		preview:        preview,
+		previewTargets: previewTargets,
+		reader:         reader,
+		jpegTarget:     target,
+		images:         make(chan struct{}, 1),
+		Timeout:        3 * time.Second,
+	}
+	err = reader.SetImageListener(func(*media.ImageReader) {
+		select {
+		case c.images <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		target.Free()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close 释放 JPEG 输出目标，session、reader 由调用者释放
+func (c *StillCapture) Close() {
+	if c.jpegTarget != nil {
+		c.jpegTarget.Free()
+		c.jpegTarget = nil
+	}
+}
+
+// Take 拍一张照片，结束后恢复预览
+func (c *StillCapture) Take(ctx context.Context, opts PhotoOptions) (*Photo, error) {
+	base := c.preview.Clone()
+	c.setFlash(base, opts.Flash)
+	if err := base.Err(); err != nil {
+		return nil, err
+	}
+
+	// 带回调的预览，用于观察 AF/AE 状态
+	stream := NewResultStream(4)
+	repeat, err := c.build(base, TEMPLATE_PREVIEW, c.previewTargets)
+	if err != nil {
+		return nil, err
+	}
+	defer repeat.Free()
+	if _, err = c.session.SetRepeatingRequestWithCallbacks(stream, []*CaptureRequest{repeat}); err != nil {
+		return nil, err
+	}
+	defer c.restorePreview(base)
+
+	if c.hasAf() {
+		err = c.trigger(base.Clone().SetAfTrigger(AF_TRIGGER_START))
+		if err == nil {
+			_, err = c.wait(ctx, stream, (*CaptureResult).AfLocked)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ae, _ := base.Accessor().ControlAeMode(); ae != AE_MODE_OFF {
+		err = c.trigger(base.Clone().SetAePrecaptureTrigger(AE_PRECAPTURE_TRIGGER_START))
+		if err != nil {
+			return nil, err
+		}
+		// 先等待进入 PRECAPTURE，部分设备会跳过该状态，所以超时不算错误
+		short, cancel := context.WithTimeout(ctx, c.Timeout/4)
+		_, err = stream.WaitFor(short, func(r *CaptureResult) bool {
+			return r.AeState == CONTROL_AE_STATE_PRECAPTURE || r.AeState == CONTROL_AE_STATE_FLASH_REQUIRED
+		})
+		cancel()
+		if err == ErrSequenceEnded {
+			return nil, err
+		}
+		if _, err = c.wait(ctx, stream, func(r *CaptureResult) bool {
+			return r.AeState != CONTROL_AE_STATE_PRECAPTURE && r.AeConverged()
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.capture(ctx, base, opts)
+}
+
+func (c *StillCapture) capture(ctx context.Context, base *RequestBuilder, opts PhotoOptions) (*Photo, error) {
+	// 丢弃之前残留的图像
+	for {
+		img, err := c.reader.AcquireLatestImage()
+		if err != nil || img == nil {
+			break
+		}
+		img.Delete()
+	}
+	select {
+	case <-c.images:
+	default:
+	}
+
+	still := base.Clone().SetCaptureIntent(CONTROL_CAPTURE_INTENT_STILL_CAPTURE).SetJpegOrientation(opts.Orientation)
+	if opts.Quality > 0 {
+		still.SetJpegQuality(opts.Quality)
+	}
+	request, err := c.build(still, TEMPLATE_STILL_CAPTURE, []*OutputTarget{c.jpegTarget})
+	if err != nil {
+		return nil, err
+	}
+	defer request.Free()
+	if _, err = c.session.Capture(nil, []*CaptureRequest{request}); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(c.Timeout)
+	defer timer.Stop()
+	select {
+	case <-c.images:
+	case <-timer.C:
+		return nil, ErrPhotoTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	img, err := c.reader.AcquireNextImage()
+	if err != nil {
+		return nil, err
+	}
+	defer img.Delete()
+	data, err := img.GetPlaneData(0)
+	if err != nil {
+		return nil, err
+	}
+	ts, _ := img.GetTimestamp()
+	return &Photo{
+		JPEG:        append([]byte{}, data...),
+		Orientation: (opts.Orientation%360 + 360) % 360,
+		Timestamp:   ts,
+	}, nil
+}
+
+func (c *StillCapture) setFlash(b *RequestBuilder, flash PhotoFlash) {
+	switch flash {
+	case PHOTO_FLASH_AUTO:
+		b.SetAeMode(AE_MODE_ON_AUTO_FLASH).SetFlashMode(FLASH_MODE_OFF)
+	case PHOTO_FLASH_ON:
+		b.SetAeMode(AE_MODE_ON_ALWAYS_FLASH).SetFlashMode(FLASH_MODE_OFF)
+	case PHOTO_FLASH_TORCH:
+		b.SetAeMode(AE_MODE_ON).SetFlashMode(FLASH_MODE_TORCH)
+	default:
+		b.SetAeMode(AE_MODE_ON).SetFlashMode(FLASH_MODE_OFF)
+	}
+}
+
+// hasAf 设备支持对焦且预览没有关闭对焦
+func (c *StillCapture) hasAf() bool {
+	if d, err := c.chars.LensInfoMinimumFocusDistance(); err != nil || d == 0 {
+		return false
+	}
+	mode, err := c.preview.Accessor().ControlAfMode()
+	return err != nil || (mode != AF_MODE_OFF && mode != AF_MODE_EDOF)
+}
+
+func (c *StillCapture) build(b *RequestBuilder, template DeviceRequestTemplate, targets []*OutputTarget) (*CaptureRequest, error) {
+	request, err := b.Build(c.device, template)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range targets {
+		if err = request.AddTarget(t); err != nil {
+			request.Free()
+			return nil, err
+		}
+	}
+	return request, nil
+}
+
+// trigger 发出一次性的触发请求
+func (c *StillCapture) trigger(b *RequestBuilder) error {
+	request, err := c.build(b, TEMPLATE_PREVIEW, c.previewTargets)
+	if err != nil {
+		return err
+	}
+	defer request.Free()
+	_, err = c.session.Capture(nil, []*CaptureRequest{request})
+	return err
+}
+
+func (c *StillCapture) wait(ctx context.Context, stream *ResultStream, cond func(*CaptureResult) bool) (*CaptureResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	r, err := stream.WaitFor(ctx, func(r *CaptureResult) bool { return !r.Failed() && cond(r) })
+	if err == context.DeadlineExceeded {
+		err = ErrPhotoTimeout
+	}
+	return r, err
+}
+
+// restorePreview 取消对焦锁定，恢复不带回调的预览
+func (c *StillCapture) restorePreview(base *RequestBuilder) {
+	if c.hasAf() {
+		c.trigger(base.Clone().SetAfTrigger(AF_TRIGGER_CANCEL))
+	}
+	if request, err := c.build(c.preview, TEMPLATE_PREVIEW, c.previewTargets); err == nil {
+		c.session.SetRepeatingRequest([]*CaptureRequest{request})
+		request.Free()
+	}
+}
@@ -115,12 +115,13 @@ func (mgr *CameraManager) GetSensorOrientation(id string) (int, int) {
 	util.Assert(err)
 	defer metadata.Free()
 
-	lens, err := metadata.GetConstEntry(camera.LENS_FACING)
+	chars := metadata.Accessor()
+	lens, err := chars.LensFacing()
 	util.Assert(err)
-	orientation, err := metadata.GetConstEntry(camera.SENSOR_ORIENTATION)
+	orientation, err := chars.SensorOrientation()
 	util.Assert(err)
 
-	return int(lens.Data().([]byte)[0]), int(orientation.Data().([]int32)[0])
+	return int(lens), int(orientation)
 }
 
 func (mgr *CameraManager) GetSupportPixels(id string) [][2]int {
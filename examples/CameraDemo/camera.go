@@ -26,7 +26,7 @@ type cameraUI struct {
 
 	previewIndex int
 	irender      render.Render
-	imgFormat    string
+	imgFormat    camera.PixelFormat
 }
 
 type cameraObj struct {
@@ -127,15 +127,14 @@ func (cam *cameraObj) setPreviewSize(i int) {
 }
 
 func (cam *cameraUI) Init(nativeObj camera.Camera) {
-	str := nativeObj.SupportedPreviewSizes()
-	for _, s := range strings.Split(str, ",") {
-		w, h := 0, 0
-		fmt.Sscanf(string(s), "%dx%d", &w, &h)
-		cam.previewSizes = append(cam.previewSizes, [2]int{w, h})
+	names := []string{}
+	for _, p := range nativeObj.PreviewSizes() {
+		cam.previewSizes = append(cam.previewSizes, [2]int{p.X, p.Y})
+		names = append(names, fmt.Sprint(p.X, "x", p.Y))
 	}
 
-	cam.comboText = strings.Replace(str, ",", "\x00", -1) + "\x00"
-	cam.imgFormat = nativeObj.PreviewFormat()
+	cam.comboText = strings.Join(names, "\x00") + "\x00"
+	cam.imgFormat = nativeObj.PixelFormat()
 }
 
 func (cam *cameraUI) ResetProperty() {
@@ -150,7 +149,7 @@ func (cam *cameraUI) ResetRender() {
 	}
 
 	switch cam.imgFormat {
-	case "yuv420sp":
+	case camera.PIXEL_FORMAT_NV21:
 		cam.irender = &render.YuvRender{}
 	default:
 		log.Println("not support format:", cam.imgFormat)
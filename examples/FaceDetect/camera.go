@@ -28,7 +28,7 @@ type cameraUI struct {
 	previewIndex int
 	rotation     int
 	irender      render.Render
-	imgFormat    string
+	imgFormat    camera.PixelFormat
 }
 
 type cameraObj struct {
@@ -171,16 +171,15 @@ func (cam *cameraObj) setPreviewSize(i int) {
 }
 
 func (cam *cameraUI) Init(nativeObj camera.Camera) {
-	str := nativeObj.SupportedPreviewSizes()
-	for _, s := range strings.Split(str, ",") {
-		w, h := 0, 0
-		fmt.Sscanf(string(s), "%dx%d", &w, &h)
-		cam.previewSizes = append(cam.previewSizes, [2]int{w, h})
+	names := []string{}
+	for _, p := range nativeObj.PreviewSizes() {
+		cam.previewSizes = append(cam.previewSizes, [2]int{p.X, p.Y})
+		names = append(names, fmt.Sprint(p.X, "x", p.Y))
 	}
 
 	cam.rotation = render.ROTATION90
-	cam.comboText = strings.Replace(str, ",", "\x00", -1) + "\x00"
-	cam.imgFormat = nativeObj.PreviewFormat()
+	cam.comboText = strings.Join(names, "\x00") + "\x00"
+	cam.imgFormat = nativeObj.PixelFormat()
 }
 
 func (cam *cameraUI) ResetProperty() {
@@ -195,7 +194,7 @@ func (cam *cameraUI) ResetRender() {
 	}
 
 	switch cam.imgFormat {
-	case "yuv420sp":
+	case camera.PIXEL_FORMAT_NV21:
 		cam.irender = &render.YuvRender{}
 	default:
 		log.Println("not support format:", cam.imgFormat)
@@ -29,6 +29,11 @@ func (a *Activity) JActivity() uintptr {
 	return uintptr(a.cptr().clazz)
 }
 
+// JavaVM 进程的 JavaVM*，用于在其它线程中调用 JNI
+func (a *Activity) JavaVM() uintptr {
+	return uintptr(unsafe.Pointer(a.cptr().vm))
+}
+
 func (a *Activity) InternalDataPath() string {
 	return C.GoString(a.cptr().internalDataPath)
 }
@@ -65,6 +65,10 @@ static const char* _GetLocalClassName(JNIEnv *env, jobject jobj) {
 	return callGetStringMethod(env, jobj, "getLocalClassName");
 }
 
+static const char* _GetPackageCodePath(JNIEnv *env, jobject jobj) {
+	return callGetStringMethod(env, jobj, "getPackageCodePath");
+}
+
 static void* _GetMainPC() { return dlsym(RTLD_DEFAULT, "main.main"); }
 
 static void* _SetActivityCallbacks(ANativeActivity* activity) {
@@ -104,6 +108,13 @@ var appContext struct {
 	funcChan  chan func()
 	actMaps   map[string]*Activity
 	actMainCB func(*Context)
+	codePath  string
+}
+
+// PackageCodePath 应用 APK 的路径 (Context.getPackageCodePath)，
+// 在第一个 Activity 创建之前为空
+func PackageCodePath() string {
+	return appContext.codePath
 }
 
 // SetMainCB
@@ -192,6 +203,11 @@ func createActivity(act *Activity, savedState unsafe.Pointer, savedStateSize C.s
 	lname := C.GoString(C._GetLocalClassName(act.env, act.clazz))
 	pname := C.GoString(C._GetPackageName(act.env, act.clazz))
 	info("ANativeActivity_onCreate:", pname+"/"+lname)
+	if appContext.codePath == "" {
+		if p := C._GetPackageCodePath(act.env, act.clazz); p != nil {
+			appContext.codePath = C.GoString(p)
+		}
+	}
 
 	callMain()
 
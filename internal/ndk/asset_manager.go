@@ -13,6 +13,13 @@ static off64_t AAsset_getLength64(AAsset* asset) {
 static off64_t AAsset_getRemainingLength64(AAsset* asset) {
 	return AAsset_getRemainingLength(asset);
 }
+static int AAsset_openFileDescriptor64(AAsset* asset, off64_t* outStart, off64_t* outLength) {
+	off_t start, length;
+	int fd = AAsset_openFileDescriptor(asset, &start, &length);
+	*outStart = start;
+	*outLength = length;
+	return fd;
+}
 #endif
 */
 import "C"
@@ -221,7 +228,14 @@ func (asset *Asset) GetRemainingLength() int64 {
  * compressed).
  */
 //int AAsset_openFileDescriptor64(AAsset* asset, off64_t* outStart, off64_t* outLength);
-//func (asset *Asset) OpenFileDescriptor() int {}
+func (asset *Asset) FileDescriptor() (fd int, start, length int64, err error) {
+	var cstart, clength C.off64_t
+	ret := int(C.AAsset_openFileDescriptor64(asset.cptr(), &cstart, &clength))
+	if ret < 0 {
+		return -1, 0, 0, fmt.Errorf("ASSET: No file descriptor, asset is compressed.")
+	}
+	return ret, int64(cstart), int64(clength), nil
+}
 
 /**
  * Returns whether this asset's internal buffer is allocated in ordinary RAM (i.e. not
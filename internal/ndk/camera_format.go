@@ -0,0 +1,150 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strings"
+)
+
+// CameraPixelFormat 图像格式，取值与 android.graphics.ImageFormat 一致，
+// 因此也可直接用于 camera2 (AIMAGE_FORMAT_*) 的格式。
+type CameraPixelFormat int
+
+const (
+	CAMERA_PIXEL_FORMAT_UNKNOWN     CameraPixelFormat = 0
+	CAMERA_PIXEL_FORMAT_RGB_565     CameraPixelFormat = 0x04
+	CAMERA_PIXEL_FORMAT_NV16        CameraPixelFormat = 0x10 // yuv422sp
+	CAMERA_PIXEL_FORMAT_NV21        CameraPixelFormat = 0x11 // yuv420sp
+	CAMERA_PIXEL_FORMAT_YUY2        CameraPixelFormat = 0x14 // yuv422i-yuyv
+	CAMERA_PIXEL_FORMAT_RAW_SENSOR  CameraPixelFormat = 0x20
+	CAMERA_PIXEL_FORMAT_PRIVATE     CameraPixelFormat = 0x22
+	CAMERA_PIXEL_FORMAT_YUV_420_888 CameraPixelFormat = 0x23
+	CAMERA_PIXEL_FORMAT_RAW_PRIVATE CameraPixelFormat = 0x24
+	CAMERA_PIXEL_FORMAT_RAW10       CameraPixelFormat = 0x25
+	CAMERA_PIXEL_FORMAT_RAW12       CameraPixelFormat = 0x26
+	CAMERA_PIXEL_FORMAT_JPEG        CameraPixelFormat = 0x100
+	CAMERA_PIXEL_FORMAT_YV12        CameraPixelFormat = 0x32315659 // yuv420p
+)
+
+var cameraPixelFormatNames = map[CameraPixelFormat]string{
+	CAMERA_PIXEL_FORMAT_RGB_565:     "rgb565",
+	CAMERA_PIXEL_FORMAT_NV16:        "yuv422sp",
+	CAMERA_PIXEL_FORMAT_NV21:        "yuv420sp",
+	CAMERA_PIXEL_FORMAT_YUY2:        "yuv422i-yuyv",
+	CAMERA_PIXEL_FORMAT_RAW_SENSOR:  "raw-sensor",
+	CAMERA_PIXEL_FORMAT_PRIVATE:     "private",
+	CAMERA_PIXEL_FORMAT_YUV_420_888: "yuv420-888",
+	CAMERA_PIXEL_FORMAT_RAW_PRIVATE: "raw-private",
+	CAMERA_PIXEL_FORMAT_RAW10:       "raw10",
+	CAMERA_PIXEL_FORMAT_RAW12:       "raw12",
+	CAMERA_PIXEL_FORMAT_JPEG:        "jpeg",
+	CAMERA_PIXEL_FORMAT_YV12:        "yuv420p",
+}
+
+// ParseCameraPixelFormat 解析 Camera.Parameters 中的格式字符串，如 "yuv420sp"
+func ParseCameraPixelFormat(s string) CameraPixelFormat {
+	s = strings.TrimSpace(s)
+	for f, n := range cameraPixelFormatNames {
+		if n == s {
+			return f
+		}
+	}
+	return CAMERA_PIXEL_FORMAT_UNKNOWN
+}
+
+func (f CameraPixelFormat) String() string {
+	if n, ok := cameraPixelFormatNames[f]; ok {
+		return n
+	}
+	return fmt.Sprintf("UNKNOW_FORMAT_0x%x", int(f))
+}
+
+// CameraFpsRange 帧率范围（帧/秒）
+type CameraFpsRange struct {
+	Min, Max int
+}
+
+func (r CameraFpsRange) String() string {
+	return fmt.Sprintf("[%d, %d]", r.Min, r.Max)
+}
+
+// ParseCameraSizes 解析 "640x480,320x240" 形式的尺寸列表
+func ParseCameraSizes(s string) []image.Point {
+	var ps []image.Point
+	for _, item := range strings.Split(s, ",") {
+		var w, h int
+		if n, _ := fmt.Sscanf(strings.TrimSpace(item), "%dx%d", &w, &h); n == 2 && w > 0 && h > 0 {
+			ps = append(ps, image.Pt(w, h))
+		}
+	}
+	return ps
+}
+
+// BestCameraSize 在 sizes 中选择最接近 target 的尺寸。
+//
+// 宽高比与 target 的差异不超过 aspectTolerance 的尺寸优先，其中面积最接近的胜出；
+// 若没有满足宽高比的尺寸，则忽略宽高比仅按面积选择。
+// 比较宽高比时不区分横竖，因此 target 可以直接使用竖屏窗口的尺寸。
+// sizes 为空时返回 false。
+func BestCameraSize(sizes []image.Point, target image.Point, aspectTolerance float64) (image.Point, bool) {
+	if len(sizes) == 0 {
+		return image.Point{}, false
+	}
+
+	aspect := func(p image.Point) float64 {
+		w, h := p.X, p.Y
+		if w < h {
+			w, h = h, w
+		}
+		if h == 0 {
+			return 0
+		}
+		return float64(w) / float64(h)
+	}
+	area := func(p image.Point) float64 { return float64(p.X) * float64(p.Y) }
+
+	ta, tarea := aspect(target), area(target)
+	cs := make([]image.Point, len(sizes))
+	copy(cs, sizes)
+	sort.SliceStable(cs, func(i, j int) bool {
+		return math.Abs(area(cs[i])-tarea) < math.Abs(area(cs[j])-tarea)
+	})
+
+	for _, p := range cs {
+		if math.Abs(aspect(p)-ta) <= aspectTolerance {
+			return p, true
+		}
+	}
+	return cs[0], true
+}
+
+// PreviewSizes 支持的预览尺寸
+func (c Camera) PreviewSizes() []image.Point {
+	return ParseCameraSizes(c.SupportedPreviewSizes())
+}
+
+// PixelFormat 当前预览格式
+func (c Camera) PixelFormat() CameraPixelFormat {
+	return ParseCameraPixelFormat(c.PreviewFormat())
+}
+
+// CurrentFpsRange 当前帧率，Min == Max。
+// native camera 库不提供支持的帧率范围，需要范围时使用 camera24 的 FpsRanges
+func (c Camera) CurrentFpsRange() (CameraFpsRange, bool) {
+	fps := c.Fps()
+	if fps <= 0 {
+		return CameraFpsRange{}, false
+	}
+	return CameraFpsRange{fps, fps}, true
+}
+
+// BestPreviewSize 见 BestCameraSize
+func (c Camera) BestPreviewSize(target image.Point, aspectTolerance float64) (image.Point, bool) {
+	return BestCameraSize(c.PreviewSizes(), target, aspectTolerance)
+}
@@ -35,10 +35,56 @@ type Callbacks struct {
 
 	// Touch is called by the app when a touch event occurs.
 	Event func(*Activity, *InputEvent)
+	// KeyFilter 在 Event 之前处理按键，返回 true 表示已处理，
+	// 事件不再传给 Event 和系统 (如音量键不再调整系统音量)
+	KeyFilter func(*Activity, *KeyEvent) bool
 	// Sensor
 	Sensor func(*Activity, []SensorEvent)
 }
 
+// LifecycleListener 包级的生命周期通知，供 audio 等需要跟随 Activity 暂停的包使用，
+// 不需要应用把 Callbacks 传入。Pause 在 Callbacks.Pause 之前调用，Resume 在 Callbacks.Resume 之后调用
+type LifecycleListener struct {
+	Pause  func(*Activity)
+	Resume func(*Activity)
+}
+
+var (
+	lifecycleLock      sync.Mutex
+	lifecycleListeners []*LifecycleListener
+)
+
+// AddLifecycleListener 注册 l，之后的 onPause/onResume 会通知 l
+func AddLifecycleListener(l *LifecycleListener) {
+	lifecycleLock.Lock()
+	lifecycleListeners = append(lifecycleListeners, l)
+	lifecycleLock.Unlock()
+}
+
+// RemoveLifecycleListener 注销 l，可以在通知中调用
+func RemoveLifecycleListener(l *LifecycleListener) {
+	lifecycleLock.Lock()
+	defer lifecycleLock.Unlock()
+	for i, o := range lifecycleListeners {
+		if o == l {
+			lifecycleListeners = append(lifecycleListeners[:i:i], lifecycleListeners[i+1:]...)
+			return
+		}
+	}
+}
+
+// notifyLifecycle 对所有 listener 调用 pick 返回的函数
+func notifyLifecycle(act *Activity, pick func(*LifecycleListener) func(*Activity)) {
+	lifecycleLock.Lock()
+	listeners := lifecycleListeners
+	lifecycleLock.Unlock()
+	for _, l := range listeners {
+		if fn := pick(l); fn != nil {
+			fn(act)
+		}
+	}
+}
+
 type Context struct {
 	Callbacks
 
@@ -232,10 +278,11 @@ func (ctx *Context) loopEvents(timeoutMillis int) bool {
 					break
 				}
 				if !ctx.input.PreDispatchEvent(event) {
-					if !ctx.willDestory {
-						ctx.processEvent(event)
+					handled := 0
+					if !ctx.willDestory && ctx.processEvent(event) {
+						handled = 1
 					}
-					ctx.input.FinishEvent(event, 0)
+					ctx.input.FinishEvent(event, handled)
 				}
 			}
 			if ctx.willDestory {
@@ -282,11 +329,17 @@ func (ctx *Context) loopEvents(timeoutMillis int) bool {
 	}
 }
 
-func (ctx *Context) processEvent(e *InputEvent) {
+// processEvent 返回事件是否已被 KeyFilter 处理
+func (ctx *Context) processEvent(e *InputEvent) bool {
 	//Info("processEvent:", e)
+	if ctx.KeyFilter != nil && e.GetType() == INPUT_EVENT_TYPE_KEY &&
+		ctx.KeyFilter(ctx.act, (*KeyEvent)(e)) {
+		return true
+	}
 	if ctx.Event != nil {
 		ctx.Event(ctx.act, e)
 	}
+	return false
 }
 
 func (ctx *Context) pollEvent(timeoutMillis int) bool {
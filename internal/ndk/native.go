@@ -59,11 +59,12 @@ func onStart(act *Activity) {
 func onResume(act *Activity) {
 	ctx := act.Context()
 	info("onResume:", act)
-	if ctx.Resume != nil {
-		ctx.runFunc(func() {
+	ctx.runFunc(func() {
+		if ctx.Resume != nil {
 			ctx.Resume(ctx.act)
-		}, true)
-	}
+		}
+		notifyLifecycle(ctx.act, func(l *LifecycleListener) func(*Activity) { return l.Resume })
+	}, true)
 	ctx.isResume = true
 }
 
@@ -73,6 +74,7 @@ func onPause(act *Activity) {
 	info("onPause:", act)
 	ctx.isResume = false
 	ctx.runFunc(func() {
+		notifyLifecycle(ctx.act, func(l *LifecycleListener) func(*Activity) { return l.Pause })
 		if ctx.Pause != nil {
 			ctx.Pause(ctx.act)
 		}
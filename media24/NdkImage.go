@@ -54,6 +54,7 @@ import "C"
 
 import (
 	"fmt"
+	"image"
 	"time"
 	"unsafe"
 )
@@ -536,6 +537,11 @@ const (
 */
 type ImageCropRect C.AImageCropRect
 
+// Rectangle 转为 image.Rectangle
+func (r ImageCropRect) Rectangle() image.Rectangle {
+	return image.Rect(int(r.left), int(r.top), int(r.right), int(r.bottom))
+}
+
 /**
  * Return the image back the the system and delete the AImage object from memory.
  *
@@ -47,6 +47,7 @@ extern void cgoImageListenerCallback(void*, AImageReader*);
 import "C"
 
 import (
+	"sync"
 	"unsafe"
 
 	app "github.com/gooid/gooid/internal/ndk"
@@ -121,7 +122,9 @@ func NewImageReader(width, height int, format Formats, maxImages int) (*ImageRea
  */
 //void AImageReader_delete(AImageReader* reader) __INTRODUCED_IN(24);
 func (reader *ImageReader) Delete() {
+	listenerLock.Lock()
 	delete(listenerCallbackMap, unsafe.Pointer(reader.cptr()))
+	listenerLock.Unlock()
 	C.AImageReader_delete(reader.cptr())
 }
 
@@ -361,16 +364,25 @@ func (reader *ImageReader) SetImageListener(onImageAvailable func(*ImageReader))
 	var listener C.AImageReader_ImageListener
 	listener.context = unsafe.Pointer(reader.cptr())
 	listener.onImageAvailable = (C.AImageReader_ImageCallback)(C.cgoImageListenerCallback)
+	listenerLock.Lock()
 	listenerCallbackMap[listener.context] = onImageAvailable
+	listenerLock.Unlock()
 	return Status(C.AImageReader_setImageListener(reader.cptr(), &listener))
 }
 
-var listenerCallbackMap = map[unsafe.Pointer]func(*ImageReader){}
+// listenerCallbackMap 在 NDK 的线程中读取，Go 中修改
+var (
+	listenerLock        sync.RWMutex
+	listenerCallbackMap = map[unsafe.Pointer]func(*ImageReader){}
+)
 
 //export cgoImageListenerCallback
 func cgoImageListenerCallback(context unsafe.Pointer, reader *C.AImageReader) {
 	creader := (*C.AImageReader)(context)
-	if fn, ok := listenerCallbackMap[context]; ok {
+	listenerLock.RLock()
+	fn, ok := listenerCallbackMap[context]
+	listenerLock.RUnlock()
+	if ok && fn != nil {
 		fn((*ImageReader)(creader))
 	}
 }
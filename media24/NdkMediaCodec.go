@@ -0,0 +1,619 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+/*
+ * Copyright (C) 2014 The Android Open Source Project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/**
+ * @addtogroup Media
+ * @{
+ */
+
+/**
+ * @file NdkMediaCodec.h
+ */
+
+/*
+ * This file defines an NDK API.
+ * Do not remove methods.
+ * Do not change method signatures.
+ * Do not change the value of constants.
+ * Do not change the size of any of the classes defined in here.
+ * Do not reference types that are not part of the NDK.
+ * Do not #include files that aren't part of the NDK.
+ */
+
+package media
+
+/*
+#include <stdlib.h>
+#include <dlfcn.h>
+#include <media/NdkMediaCodec.h>
+
+extern void cgoCodecOnInputAvailable(AMediaCodec* codec, void* userdata, int32_t index);
+extern void cgoCodecOnOutputAvailable(AMediaCodec* codec, void* userdata, int32_t index,
+        AMediaCodecBufferInfo* bufferInfo);
+extern void cgoCodecOnFormatChanged(AMediaCodec* codec, void* userdata, AMediaFormat* format);
+extern void cgoCodecOnError(AMediaCodec* codec, void* userdata, media_status_t error,
+        int32_t actionCode, char* detail);
+
+// API 26、28 新增的函数在运行时从 libmediandk.so 取得，minSdk 较低时也能编译，
+// 设备不支持时函数指针为 NULL。回调结构体同 AMediaCodecOnAsyncNotifyCallback
+typedef struct {
+	void (*onAsyncInputAvailable)(AMediaCodec* codec, void* userdata, int32_t index);
+	void (*onAsyncOutputAvailable)(AMediaCodec* codec, void* userdata, int32_t index,
+		AMediaCodecBufferInfo* bufferInfo);
+	void (*onAsyncFormatChanged)(AMediaCodec* codec, void* userdata, AMediaFormat* format);
+	void (*onAsyncError)(AMediaCodec* codec, void* userdata, media_status_t error,
+		int32_t actionCode, const char* detail);
+} codecAsyncNotifyCallback;
+
+static media_status_t (*createInputSurfaceC)(AMediaCodec* codec, ANativeWindow** surface);
+static media_status_t (*setInputSurfaceC)(AMediaCodec* codec, ANativeWindow* surface);
+static media_status_t (*signalEndOfInputStreamC)(AMediaCodec* codec);
+static media_status_t (*setParametersC)(AMediaCodec* codec, const AMediaFormat* params);
+static media_status_t (*setAsyncNotifyCallbackC)(AMediaCodec* codec,
+	codecAsyncNotifyCallback callback, void* userdata);
+static AMediaFormat* (*getInputFormatC)(AMediaCodec* codec);
+static AMediaFormat* (*getBufferFormatC)(AMediaCodec* codec, size_t index);
+static media_status_t (*getNameC)(AMediaCodec* codec, char** name);
+static void (*releaseNameC)(AMediaCodec* codec, char* name);
+
+static void initCodec() {
+	void *handler = dlopen("libmediandk.so", RTLD_NOW);
+	if (handler == NULL) return;
+#define LOAD(p, name) *(void**)&p = dlsym(handler, name)
+	// API 26
+	LOAD(createInputSurfaceC, "AMediaCodec_createInputSurface");
+	LOAD(setInputSurfaceC, "AMediaCodec_setInputSurface");
+	LOAD(signalEndOfInputStreamC, "AMediaCodec_signalEndOfInputStream");
+	LOAD(setParametersC, "AMediaCodec_setParameters");
+	// API 28
+	LOAD(setAsyncNotifyCallbackC, "AMediaCodec_setAsyncNotifyCallback");
+	LOAD(getInputFormatC, "AMediaCodec_getInputFormat");
+	LOAD(getBufferFormatC, "AMediaCodec_getBufferFormat");
+	LOAD(getNameC, "AMediaCodec_getName");
+	LOAD(releaseNameC, "AMediaCodec_releaseName");
+#undef LOAD
+}
+
+static media_status_t codecCreateInputSurface(AMediaCodec* codec, ANativeWindow** surface) {
+	if (createInputSurfaceC == NULL) return AMEDIA_ERROR_UNSUPPORTED;
+	return createInputSurfaceC(codec, surface);
+}
+static media_status_t codecSetInputSurface(AMediaCodec* codec, ANativeWindow* surface) {
+	if (setInputSurfaceC == NULL) return AMEDIA_ERROR_UNSUPPORTED;
+	return setInputSurfaceC(codec, surface);
+}
+static media_status_t codecSignalEndOfInputStream(AMediaCodec* codec) {
+	if (signalEndOfInputStreamC == NULL) return AMEDIA_ERROR_UNSUPPORTED;
+	return signalEndOfInputStreamC(codec);
+}
+static media_status_t codecSetParameters(AMediaCodec* codec, const AMediaFormat* params) {
+	if (setParametersC == NULL) return AMEDIA_ERROR_UNSUPPORTED;
+	return setParametersC(codec, params);
+}
+
+static void codecOnError(AMediaCodec* codec, void* userdata, media_status_t error,
+        int32_t actionCode, const char* detail) {
+	cgoCodecOnError(codec, userdata, error, actionCode, (char*)detail);
+}
+static media_status_t codecSetAsyncNotifyCallback(AMediaCodec* codec, void* userdata) {
+	if (setAsyncNotifyCallbackC == NULL) return AMEDIA_ERROR_UNSUPPORTED;
+	codecAsyncNotifyCallback callback = {
+		cgoCodecOnInputAvailable,
+		cgoCodecOnOutputAvailable,
+		cgoCodecOnFormatChanged,
+		codecOnError,
+	};
+	return setAsyncNotifyCallbackC(codec, callback, userdata);
+}
+static AMediaFormat* codecGetInputFormat(AMediaCodec* codec) {
+	if (getInputFormatC == NULL) return NULL;
+	return getInputFormatC(codec);
+}
+static AMediaFormat* codecGetBufferFormat(AMediaCodec* codec, size_t index) {
+	if (getBufferFormatC == NULL) return NULL;
+	return getBufferFormatC(codec, index);
+}
+static media_status_t codecGetName(AMediaCodec* codec, char** name) {
+	if (getNameC == NULL || releaseNameC == NULL) return AMEDIA_ERROR_UNSUPPORTED;
+	return getNameC(codec, name);
+}
+static void codecReleaseName(AMediaCodec* codec, char* name) {
+	releaseNameC(codec, name);
+}
+*/
+import "C"
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+
+	app "github.com/gooid/gooid/internal/ndk"
+)
+
+/**
+ * AMediaCodec is an opaque type for a hardware or software codec.
+ */
+//typedef struct AMediaCodec AMediaCodec;
+type Codec C.AMediaCodec
+
+func (codec *Codec) cptr() *C.AMediaCodec {
+	return (*C.AMediaCodec)(codec)
+}
+
+var codecOnce sync.Once
+
+// codecInit 取得 API 26、28 新增的函数，不支持的函数返回 ERROR_UNSUPPORTED
+func codecInit() {
+	codecOnce.Do(func() {
+		C.initCodec()
+	})
+}
+
+/**
+ * Describes the data of an output buffer returned by dequeueOutputBuffer.
+ */
+/*struct AMediaCodecBufferInfo {
+    int32_t offset;
+    int32_t size;
+    int64_t presentationTimeUs;
+    uint32_t flags;
+};
+*/
+type CodecBufferInfo struct {
+	Offset           int
+	Size             int
+	PresentationTime time.Duration
+	Flags            uint32
+}
+
+func (info *CodecBufferInfo) fromC(c *C.AMediaCodecBufferInfo) {
+	info.Offset = int(c.offset)
+	info.Size = int(c.size)
+	info.PresentationTime = time.Duration(c.presentationTimeUs) * time.Microsecond
+	info.Flags = uint32(c.flags)
+}
+
+const (
+	BUFFER_FLAG_CODEC_CONFIG  = C.AMEDIACODEC_BUFFER_FLAG_CODEC_CONFIG
+	BUFFER_FLAG_END_OF_STREAM = C.AMEDIACODEC_BUFFER_FLAG_END_OF_STREAM
+	BUFFER_FLAG_PARTIAL_FRAME = 8
+	// BUFFER_FLAG_KEY_FRAME 输出中的关键帧，头文件在 API 34 才定义
+	BUFFER_FLAG_KEY_FRAME = 1
+
+	CONFIGURE_FLAG_ENCODE = C.AMEDIACODEC_CONFIGURE_FLAG_ENCODE
+
+	// Dequeue*Buffer 返回的负值
+	INFO_OUTPUT_BUFFERS_CHANGED = C.AMEDIACODEC_INFO_OUTPUT_BUFFERS_CHANGED
+	INFO_OUTPUT_FORMAT_CHANGED  = C.AMEDIACODEC_INFO_OUTPUT_FORMAT_CHANGED
+	INFO_TRY_AGAIN_LATER        = C.AMEDIACODEC_INFO_TRY_AGAIN_LATER
+)
+
+/**
+ * Create codec by name. Use this if you know the exact codec you want to use.
+ * When configuring, you will need to specify whether to use the codec as an
+ * encoder or decoder.
+ */
+//AMediaCodec* AMediaCodec_createCodecByName(const char *name);
+func CreateCodecByName(name string) (*Codec, error) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	return newCodec(C.AMediaCodec_createCodecByName(cname))
+}
+
+/**
+ * Create codec by mime type. Most applications will use this, specifying a
+ * mime type obtained from media extractor.
+ */
+//AMediaCodec* AMediaCodec_createDecoderByType(const char *mime_type);
+func CreateDecoderByType(mime string) (*Codec, error) {
+	cmime := C.CString(mime)
+	defer C.free(unsafe.Pointer(cmime))
+	return newCodec(C.AMediaCodec_createDecoderByType(cmime))
+}
+
+/**
+ * Create encoder by name.
+ */
+//AMediaCodec* AMediaCodec_createEncoderByType(const char *mime_type);
+func CreateEncoderByType(mime string) (*Codec, error) {
+	cmime := C.CString(mime)
+	defer C.free(unsafe.Pointer(cmime))
+	return newCodec(C.AMediaCodec_createEncoderByType(cmime))
+}
+
+func newCodec(codec *C.AMediaCodec) (*Codec, error) {
+	if codec == nil {
+		return nil, iStatus(ERROR_UNSUPPORTED)
+	}
+	return (*Codec)(codec), nil
+}
+
+/**
+ * delete the codec and free its resources
+ */
+//media_status_t AMediaCodec_delete(AMediaCodec*);
+func (codec *Codec) Delete() error {
+	codecCallbackLock.Lock()
+	delete(codecCallbackMap, unsafe.Pointer(codec.cptr()))
+	codecCallbackLock.Unlock()
+	return Status(C.AMediaCodec_delete(codec.cptr()))
+}
+
+/**
+ * Configure the codec. For decoding you would typically get the format from an extractor.
+ */
+//media_status_t AMediaCodec_configure(
+//        AMediaCodec*,
+//        const AMediaFormat* format,
+//        ANativeWindow* surface,
+//        AMediaCrypto *crypto,
+//        uint32_t flags);
+func (codec *Codec) Configure(format *Format, surface *app.Window, flags uint32) error {
+	return Status(C.AMediaCodec_configure(codec.cptr(), format.cptr(),
+		(*C.ANativeWindow)(unsafe.Pointer(surface)), nil, C.uint32_t(flags)))
+}
+
+/**
+ * Start the codec. A codec must be configured before it can be started, and must be started
+ * before buffers can be sent to it.
+ */
+//media_status_t AMediaCodec_start(AMediaCodec*);
+func (codec *Codec) Start() error {
+	return Status(C.AMediaCodec_start(codec.cptr()))
+}
+
+/**
+ * Stop the codec.
+ */
+//media_status_t AMediaCodec_stop(AMediaCodec*);
+func (codec *Codec) Stop() error {
+	return Status(C.AMediaCodec_stop(codec.cptr()))
+}
+
+/*
+ * Flush the codec's input and output. All indices previously returned from calls to
+ * AMediaCodec_dequeueInputBuffer and AMediaCodec_dequeueOutputBuffer become invalid.
+ */
+//media_status_t AMediaCodec_flush(AMediaCodec*);
+func (codec *Codec) Flush() error {
+	return Status(C.AMediaCodec_flush(codec.cptr()))
+}
+
+/**
+ * Get an input buffer. The specified buffer index must have been previously obtained from
+ * dequeueInputBuffer, and not yet queued.
+ */
+//uint8_t* AMediaCodec_getInputBuffer(AMediaCodec*, size_t idx, size_t *out_size);
+func (codec *Codec) GetInputBuffer(idx int) []byte {
+	var size C.size_t
+	data := C.AMediaCodec_getInputBuffer(codec.cptr(), C.size_t(idx), &size)
+	if data == nil {
+		return nil
+	}
+	return ((*[1 << 30]byte)(unsafe.Pointer(data)))[:size:size]
+}
+
+/**
+ * Get an output buffer. The specified buffer index must have been previously obtained from
+ * dequeueOutputBuffer, and not yet queued.
+ */
+//uint8_t* AMediaCodec_getOutputBuffer(AMediaCodec*, size_t idx, size_t *out_size);
+func (codec *Codec) GetOutputBuffer(idx int) []byte {
+	var size C.size_t
+	data := C.AMediaCodec_getOutputBuffer(codec.cptr(), C.size_t(idx), &size)
+	if data == nil {
+		return nil
+	}
+	return ((*[1 << 30]byte)(unsafe.Pointer(data)))[:size:size]
+}
+
+/**
+ * Get the index of the next available input buffer. An app will typically use this with
+ * getInputBuffer() to get a pointer to the buffer, then copy the data to be encoded or decoded
+ * into the buffer before passing it to the codec.
+ */
+//ssize_t AMediaCodec_dequeueInputBuffer(AMediaCodec*, int64_t timeoutUs);
+// DequeueInputBuffer 返回 buffer 的序号，没有可用的 buffer 时返回 INFO_TRY_AGAIN_LATER。
+// timeout 为负时一直等待
+func (codec *Codec) DequeueInputBuffer(timeout time.Duration) int {
+	return int(C.AMediaCodec_dequeueInputBuffer(codec.cptr(), timeoutUs(timeout)))
+}
+
+/**
+ * Send the specified buffer to the codec for processing.
+ */
+//media_status_t AMediaCodec_queueInputBuffer(AMediaCodec*,
+//        size_t idx, off_t offset, size_t size, uint64_t time, uint32_t flags);
+func (codec *Codec) QueueInputBuffer(idx, offset, size int, pts time.Duration, flags uint32) error {
+	return Status(C.AMediaCodec_queueInputBuffer(codec.cptr(), C.size_t(idx), C.off_t(offset),
+		C.size_t(size), C.uint64_t(pts/time.Microsecond), C.uint32_t(flags)))
+}
+
+/**
+ * Get the index of the next available buffer of processed data.
+ */
+//ssize_t AMediaCodec_dequeueOutputBuffer(AMediaCodec*, AMediaCodecBufferInfo *info,
+//        int64_t timeoutUs);
+// DequeueOutputBuffer 返回 buffer 的序号，或者
+// INFO_TRY_AGAIN_LATER、INFO_OUTPUT_FORMAT_CHANGED、INFO_OUTPUT_BUFFERS_CHANGED
+func (codec *Codec) DequeueOutputBuffer(info *CodecBufferInfo, timeout time.Duration) int {
+	var cinfo C.AMediaCodecBufferInfo
+	idx := int(C.AMediaCodec_dequeueOutputBuffer(codec.cptr(), &cinfo, timeoutUs(timeout)))
+	if idx >= 0 && info != nil {
+		info.fromC(&cinfo)
+	}
+	return idx
+}
+
+func timeoutUs(timeout time.Duration) C.int64_t {
+	if timeout < 0 {
+		return -1
+	}
+	return C.int64_t(timeout / time.Microsecond)
+}
+
+/**
+ * Returns the format of the codec's output. The caller must free the returned format.
+ */
+//AMediaFormat* AMediaCodec_getOutputFormat(AMediaCodec*);
+func (codec *Codec) GetOutputFormat() *Format {
+	return (*Format)(C.AMediaCodec_getOutputFormat(codec.cptr()))
+}
+
+/**
+ * Get format of the buffer. The specified buffer index must have been previously obtained from
+ * dequeueOutputBuffer. The caller must free the returned format.
+ *
+ * Available since API level 28.
+ */
+//AMediaFormat* AMediaCodec_getBufferFormat(AMediaCodec*, size_t index) __INTRODUCED_IN(28);
+func (codec *Codec) GetBufferFormat(idx int) *Format {
+	codecInit()
+	return (*Format)(C.codecGetBufferFormat(codec.cptr(), C.size_t(idx)))
+}
+
+/**
+ * Get the component name. If the codec was created by createDecoderByType
+ * or createEncoderByType, what component is chosen is not known beforehand.
+ *
+ * Available since API level 28.
+ */
+//media_status_t AMediaCodec_getName(AMediaCodec*, char** out_name) __INTRODUCED_IN(28);
+func (codec *Codec) GetName() (string, error) {
+	codecInit()
+	var name *C.char
+	ret := Status(C.codecGetName(codec.cptr(), &name))
+	if ret != nil {
+		return "", ret
+	}
+	defer C.codecReleaseName(codec.cptr(), name)
+	return C.GoString(name), nil
+}
+
+/**
+ * Returns the format of the codec's input. The caller must free the returned format.
+ *
+ * Available since API level 28.
+ */
+//AMediaFormat* AMediaCodec_getInputFormat(AMediaCodec*) __INTRODUCED_IN(28);
+func (codec *Codec) GetInputFormat() *Format {
+	codecInit()
+	return (*Format)(C.codecGetInputFormat(codec.cptr()))
+}
+
+/**
+ * If you are done with a buffer, use this call to return the buffer to
+ * the codec. If you previously specified a surface when configuring this
+ * video decoder you can optionally render the buffer.
+ */
+//media_status_t AMediaCodec_releaseOutputBuffer(AMediaCodec*, size_t idx, bool render);
+func (codec *Codec) ReleaseOutputBuffer(idx int, render bool) error {
+	return Status(C.AMediaCodec_releaseOutputBuffer(codec.cptr(), C.size_t(idx), C.bool(render)))
+}
+
+/**
+ * Dynamically sets the output surface of a codec.
+ *
+ *  This can only be used if the codec was configured with an output surface.  The
+ *  new output surface should have a compatible usage type to the original output surface.
+ *  E.g. codecs may not support switching from a SurfaceTexture (GPU readable) output
+ *  to ImageReader (software readable) output.
+ */
+//media_status_t AMediaCodec_setOutputSurface(AMediaCodec*, ANativeWindow* surface);
+func (codec *Codec) SetOutputSurface(surface *app.Window) error {
+	return Status(C.AMediaCodec_setOutputSurface(codec.cptr(),
+		(*C.ANativeWindow)(unsafe.Pointer(surface))))
+}
+
+/**
+ * If you are done with a buffer, use this call to update its surface timestamp
+ * and return it to the codec to render it on the output surface. If you
+ * have not specified an output surface when configuring this video codec,
+ * this call will simply return the buffer to the codec.
+ */
+//media_status_t AMediaCodec_releaseOutputBufferAtTime(
+//        AMediaCodec *mData, size_t idx, int64_t timestampNs);
+func (codec *Codec) ReleaseOutputBufferAtTime(idx int, timestamp time.Duration) error {
+	return Status(C.AMediaCodec_releaseOutputBufferAtTime(codec.cptr(), C.size_t(idx),
+		C.int64_t(timestamp)))
+}
+
+/**
+ * Creates a Surface that can be used as the input to encoder, in place of input buffers
+ *
+ * This can only be called after the codec has been configured via
+ * AMediaCodec_configure(..); and before AMediaCodec_start() has been called.
+ *
+ * The application is responsible for releasing the surface by calling
+ * ANativeWindow_release() when done.
+ *
+ * Available since API level 26.
+ */
+//media_status_t AMediaCodec_createInputSurface(
+//        AMediaCodec *mData, ANativeWindow **surface) __INTRODUCED_IN(26);
+func (codec *Codec) CreateInputSurface() (*app.Window, error) {
+	codecInit()
+	var surface *C.ANativeWindow
+	ret := Status(C.codecCreateInputSurface(codec.cptr(), &surface))
+	if ret != nil {
+		return nil, ret
+	}
+	return (*app.Window)(unsafe.Pointer(surface)), nil
+}
+
+/**
+ * Set a persistent-surface that can be used as the input to encoder, in place of input buffers
+ *
+ * Available since API level 26.
+ */
+//media_status_t AMediaCodec_setInputSurface(
+//        AMediaCodec *mData, ANativeWindow *surface) __INTRODUCED_IN(26);
+func (codec *Codec) SetInputSurface(surface *app.Window) error {
+	codecInit()
+	return Status(C.codecSetInputSurface(codec.cptr(), (*C.ANativeWindow)(unsafe.Pointer(surface))))
+}
+
+/**
+ * Signal additional parameters to the codec instance.
+ *
+ * Parameters can be communicated only when the codec is running, i.e
+ * after AMediaCodec_start() has been called.
+ *
+ * NOTE: Some of these parameter changes may silently fail to apply.
+ *
+ * Available since API level 26.
+ */
+//media_status_t AMediaCodec_setParameters(
+//        AMediaCodec *mData, const AMediaFormat* params) __INTRODUCED_IN(26);
+func (codec *Codec) SetParameters(params *Format) error {
+	codecInit()
+	return Status(C.codecSetParameters(codec.cptr(), params.cptr()))
+}
+
+/**
+ * Signals end-of-stream on input. Equivalent to submitting an empty buffer with
+ * AMEDIACODEC_BUFFER_FLAG_END_OF_STREAM set.
+ *
+ * Returns AMEDIA_ERROR_INVALID_OPERATION when used with an encoder not in executing state
+ * or not receiving input from a Surface created by AMediaCodec_createInputSurface.
+ *
+ * Available since API level 26.
+ */
+//media_status_t AMediaCodec_signalEndOfInputStream(AMediaCodec *mData) __INTRODUCED_IN(26);
+func (codec *Codec) SignalEndOfInputStream() error {
+	codecInit()
+	return Status(C.codecSignalEndOfInputStream(codec.cptr()))
+}
+
+// CodecCallbacks 异步模式的回调，在编解码器的线程中调用
+type CodecCallbacks interface {
+	// OnInputAvailable 用 GetInputBuffer(index) 填充后 QueueInputBuffer
+	OnInputAvailable(codec *Codec, index int)
+	// OnOutputAvailable 处理 GetOutputBuffer(index) 后 ReleaseOutputBuffer
+	OnOutputAvailable(codec *Codec, index int, info *CodecBufferInfo)
+	// OnFormatChanged format 在回调返回后被释放
+	OnFormatChanged(codec *Codec, format *Format)
+	OnError(codec *Codec, err error, actionCode int, detail string)
+}
+
+/**
+ * Set an asynchronous callback for actionable AMediaCodec events.
+ * When asynchronous callback is enabled, the client should not call
+ * AMediaCodec_getInputBuffers(), AMediaCodec_getOutputBuffers(),
+ * AMediaCodec_dequeueInputBuffer() or AMediaCodec_dequeueOutputBuffer().
+ *
+ * Also, AMediaCodec_flush() behaves differently in asynchronous mode.
+ * After calling AMediaCodec_flush(), you must call AMediaCodec_start() to
+ * "resume" receiving input buffers, even if an input surface was created.
+ *
+ * Refer to the definition of AMediaCodecOnAsyncNotifyCallback on how each
+ * callback function is called and what are specified.
+ * The specified userdata is the pointer used when those callback functions are
+ * called.
+ *
+ * All callbacks are fired on one NDK internal thread.
+ * AMediaCodec_setAsyncNotifyCallback should not be called on the callback thread.
+ * No heavy duty task should be performed on callback thread.
+ *
+ * Available since API level 28.
+ */
+//media_status_t AMediaCodec_setAsyncNotifyCallback(
+//        AMediaCodec*,
+//        AMediaCodecOnAsyncNotifyCallback callback,
+//        void *userdata) __INTRODUCED_IN(28);
+// SetAsyncNotifyCallback 必须在 Configure 之前调用，设备低于 API 28 时返回 ERROR_UNSUPPORTED
+func (codec *Codec) SetAsyncNotifyCallback(cbs CodecCallbacks) error {
+	codecInit()
+	context := unsafe.Pointer(codec.cptr())
+	codecCallbackLock.Lock()
+	codecCallbackMap[context] = cbs
+	codecCallbackLock.Unlock()
+	ret := Status(C.codecSetAsyncNotifyCallback(codec.cptr(), context))
+	if ret != nil {
+		codecCallbackLock.Lock()
+		delete(codecCallbackMap, context)
+		codecCallbackLock.Unlock()
+	}
+	return ret
+}
+
+var (
+	codecCallbackLock sync.Mutex
+	codecCallbackMap  = map[unsafe.Pointer]CodecCallbacks{}
+)
+
+func codecCallbacksOf(context unsafe.Pointer) CodecCallbacks {
+	codecCallbackLock.Lock()
+	defer codecCallbackLock.Unlock()
+	return codecCallbackMap[context]
+}
+
+//export cgoCodecOnInputAvailable
+func cgoCodecOnInputAvailable(codec *C.AMediaCodec, userdata unsafe.Pointer, index C.int32_t) {
+	if cbs := codecCallbacksOf(userdata); cbs != nil {
+		cbs.OnInputAvailable((*Codec)(codec), int(index))
+	}
+}
+
+//export cgoCodecOnOutputAvailable
+func cgoCodecOnOutputAvailable(codec *C.AMediaCodec, userdata unsafe.Pointer, index C.int32_t,
+	bufferInfo *C.AMediaCodecBufferInfo) {
+	if cbs := codecCallbacksOf(userdata); cbs != nil {
+		var info CodecBufferInfo
+		info.fromC(bufferInfo)
+		cbs.OnOutputAvailable((*Codec)(codec), int(index), &info)
+	}
+}
+
+//export cgoCodecOnFormatChanged
+func cgoCodecOnFormatChanged(codec *C.AMediaCodec, userdata unsafe.Pointer, format *C.AMediaFormat) {
+	if cbs := codecCallbacksOf(userdata); cbs != nil {
+		cbs.OnFormatChanged((*Codec)(codec), (*Format)(format))
+	}
+}
+
+//export cgoCodecOnError
+func cgoCodecOnError(codec *C.AMediaCodec, userdata unsafe.Pointer, status C.media_status_t,
+	actionCode C.int32_t, detail *C.char) {
+	if cbs := codecCallbacksOf(userdata); cbs != nil {
+		cbs.OnError((*Codec)(codec), Status(status), int(actionCode), C.GoString(detail))
+	}
+}
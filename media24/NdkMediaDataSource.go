@@ -0,0 +1,266 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+/*
+ * Copyright (C) 2017 The Android Open Source Project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/**
+ * @addtogroup Media
+ * @{
+ */
+
+/**
+ * @file NdkMediaDataSource.h
+ */
+
+/*
+ * This file defines an NDK API.
+ * Do not remove methods.
+ * Do not change method signatures.
+ * Do not change the value of constants.
+ * Do not change the size of any of the classes defined in here.
+ * Do not reference types that are not part of the NDK.
+ * Do not #include files that aren't part of the NDK.
+ */
+
+package media
+
+/*
+#include <sys/types.h>
+#include <dlfcn.h>
+
+extern ssize_t cgoDataSourceReadAt(void* userdata, off64_t offset, void* buffer, size_t size);
+extern ssize_t cgoDataSourceGetSize(void* userdata);
+extern void cgoDataSourceClose(void* userdata);
+
+// 不包含 <media/NdkMediaDataSource.h>：minSdk 低于 28 时头文件中没有这些函数，
+// 所以在运行时从 libmediandk.so 取得
+typedef struct AMediaDataSource AMediaDataSource;
+typedef ssize_t (*dataSourceReadAt)(void* userdata, off64_t offset, void* buffer, size_t size);
+typedef ssize_t (*dataSourceGetSize)(void* userdata);
+typedef void (*dataSourceClose)(void* userdata);
+
+static AMediaDataSource* (*dataSourceNewC)();
+static void (*dataSourceDeleteC)(AMediaDataSource* source);
+static void (*dataSourceSetUserdataC)(AMediaDataSource* source, void* userdata);
+static void (*dataSourceSetReadAtC)(AMediaDataSource* source, dataSourceReadAt readAt);
+static void (*dataSourceSetGetSizeC)(AMediaDataSource* source, dataSourceGetSize getSize);
+static void (*dataSourceSetCloseC)(AMediaDataSource* source, dataSourceClose close);
+
+static int initDataSource() {
+	void *handler = dlopen("libmediandk.so", RTLD_NOW);
+	if (handler == NULL) return 0;
+#define LOAD(p, name) if ((*(void**)&p = dlsym(handler, name)) == NULL) return 0
+	LOAD(dataSourceNewC, "AMediaDataSource_new");
+	LOAD(dataSourceDeleteC, "AMediaDataSource_delete");
+	LOAD(dataSourceSetUserdataC, "AMediaDataSource_setUserdata");
+	LOAD(dataSourceSetReadAtC, "AMediaDataSource_setReadAt");
+	LOAD(dataSourceSetGetSizeC, "AMediaDataSource_setGetSize");
+	LOAD(dataSourceSetCloseC, "AMediaDataSource_setClose");
+#undef LOAD
+	return 1;
+}
+
+static AMediaDataSource* dataSourceNew() {
+	AMediaDataSource* source = dataSourceNewC();
+	if (source != NULL) {
+		dataSourceSetUserdataC(source, source);
+		dataSourceSetReadAtC(source, cgoDataSourceReadAt);
+		dataSourceSetGetSizeC(source, cgoDataSourceGetSize);
+		dataSourceSetCloseC(source, cgoDataSourceClose);
+	}
+	return source;
+}
+static void dataSourceDelete(AMediaDataSource* source) {
+	dataSourceDeleteC(source);
+}
+*/
+import "C"
+
+import (
+	"io"
+	"sync"
+	"unsafe"
+)
+
+/**
+ * AMediaDataSource is an opaque type that supplies media data to AMediaExtractor
+ * through application provided callbacks.
+ */
+//typedef struct AMediaDataSource AMediaDataSource;
+type DataSource C.AMediaDataSource
+
+func (source *DataSource) cptr() *C.AMediaDataSource {
+	return (*C.AMediaDataSource)(source)
+}
+
+// dataSource 回调中使用的 reader
+type dataSource struct {
+	mu     sync.Mutex
+	r      io.ReadSeeker
+	size   int64
+	closed bool
+}
+
+var (
+	dataSourceLock sync.Mutex
+	dataSourceMap  = map[unsafe.Pointer]*dataSource{}
+)
+
+var (
+	dataSourceOnce sync.Once
+	dataSourceOK   bool
+)
+
+// dataSourceAvailable 设备是否支持 AMediaDataSource (API 28)
+func dataSourceAvailable() bool {
+	dataSourceOnce.Do(func() {
+		dataSourceOK = C.initDataSource() != 0
+	})
+	return dataSourceOK
+}
+
+func dataSourceOf(userdata unsafe.Pointer) *dataSource {
+	dataSourceLock.Lock()
+	defer dataSourceLock.Unlock()
+	return dataSourceMap[userdata]
+}
+
+/**
+ * Create new media data source. Returns NULL if memory allocation
+ * for the new data source object fails.
+ *
+ * Available since API level 28.
+ */
+//AMediaDataSource* AMediaDataSource_new() __INTRODUCED_IN(28);
+// NewDataSource 从 r 读取数据 (如 *app.Asset)，r 实现 io.ReaderAt 时直接使用 ReadAt。
+// 回调在 extractor 的线程中调用，r 不能同时在别处使用。
+// API 28 之前返回 ERROR_UNSUPPORTED
+func NewDataSource(r io.ReadSeeker) (*DataSource, error) {
+	if !dataSourceAvailable() {
+		return nil, iStatus(ERROR_UNSUPPORTED)
+	}
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		size = -1
+	}
+	source := C.dataSourceNew()
+	if source == nil {
+		return nil, iStatus(ERROR_UNSUPPORTED)
+	}
+	dataSourceLock.Lock()
+	dataSourceMap[unsafe.Pointer(source)] = &dataSource{r: r, size: size}
+	dataSourceLock.Unlock()
+	return (*DataSource)(source), nil
+}
+
+/**
+ * Delete a previously created media data source.
+ *
+ * Available since API level 28.
+ */
+//void AMediaDataSource_delete(AMediaDataSource*) __INTRODUCED_IN(28);
+// Delete 不关闭 r
+func (source *DataSource) Delete() {
+	dataSourceLock.Lock()
+	delete(dataSourceMap, unsafe.Pointer(source.cptr()))
+	dataSourceLock.Unlock()
+	C.dataSourceDelete(source.cptr())
+}
+
+func (s *dataSource) readAt(p []byte, offset int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if ra, ok := s.r.(io.ReaderAt); ok {
+		return ra.ReadAt(p, offset)
+	}
+	if _, err := s.r.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(s.r, p)
+}
+
+/**
+ * Called to request data from the given |offset|.
+ *
+ * Implementations should should write up to |size| bytes into
+ * |buffer|, and return the number of bytes written.
+ *
+ * Return 0 if size is zero (thus no bytes are read).
+ *
+ * Return -1 to indicate that end of stream is reached.
+ */
+//typedef ssize_t (*AMediaDataSourceReadAt)(
+//        void *userdata, off64_t offset, void * buffer, size_t size);
+//export cgoDataSourceReadAt
+func cgoDataSourceReadAt(userdata unsafe.Pointer, offset C.off64_t, buffer unsafe.Pointer, size C.size_t) C.ssize_t {
+	s := dataSourceOf(userdata)
+	if s == nil {
+		return -1
+	}
+	if size == 0 {
+		return 0
+	}
+	if s.size >= 0 && int64(offset) >= s.size {
+		return -1
+	}
+	p := ((*[1 << 30]byte)(buffer))[:size:size]
+	n, err := s.readAt(p, int64(offset))
+	if n > 0 {
+		return C.ssize_t(n)
+	}
+	if err != nil {
+		return -1
+	}
+	return 0
+}
+
+/**
+ * Called to get the size of the data source.
+ *
+ * Return the size of data source in bytes, or -1 if the size is unknown.
+ */
+//typedef ssize_t (*AMediaDataSourceGetSize)(void *userdata);
+//export cgoDataSourceGetSize
+func cgoDataSourceGetSize(userdata unsafe.Pointer) C.ssize_t {
+	if s := dataSourceOf(userdata); s != nil {
+		return C.ssize_t(s.size)
+	}
+	return -1
+}
+
+/**
+ * Called to close the data source and release associated resources.
+ * The NDK media framework guarantees that after |close| is called
+ * no future callbacks will be invoked on the data source except for
+ * |close| itself.
+ *
+ * Closing a data source allows readAt calls that were blocked waiting
+ * for I/O data to return promptly.
+ */
+//typedef void (*AMediaDataSourceClose)(void *userdata);
+//export cgoDataSourceClose
+func cgoDataSourceClose(userdata unsafe.Pointer) {
+	if s := dataSourceOf(userdata); s != nil {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,369 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+/*
+ * Copyright (C) 2014 The Android Open Source Project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/**
+ * @addtogroup Media
+ * @{
+ */
+
+/**
+ * @file NdkMediaExtractor.h
+ */
+
+/*
+ * This file defines an NDK API.
+ * Do not remove methods.
+ * Do not change method signatures.
+ * Do not change the value of constants.
+ * Do not change the size of any of the classes defined in here.
+ * Do not reference types that are not part of the NDK.
+ * Do not #include files that aren't part of the NDK.
+ */
+
+package media
+
+/*
+#include <stdlib.h>
+#include <dlfcn.h>
+#include <media/NdkMediaExtractor.h>
+
+// API 28 新增的函数在运行时从 libmediandk.so 取得，设备不支持时函数指针为 NULL
+static media_status_t (*setDataSourceCustomC)(AMediaExtractor* ex, void* src);
+static AMediaFormat* (*getFileFormatC)(AMediaExtractor* ex);
+static ssize_t (*getSampleSizeC)(AMediaExtractor* ex);
+static int64_t (*getCachedDurationC)(AMediaExtractor* ex);
+
+static void initExtractor() {
+	void *handler = dlopen("libmediandk.so", RTLD_NOW);
+	if (handler == NULL) return;
+#define LOAD(p, name) *(void**)&p = dlsym(handler, name)
+	LOAD(setDataSourceCustomC, "AMediaExtractor_setDataSourceCustom");
+	LOAD(getFileFormatC, "AMediaExtractor_getFileFormat");
+	LOAD(getSampleSizeC, "AMediaExtractor_getSampleSize");
+	LOAD(getCachedDurationC, "AMediaExtractor_getCachedDuration");
+#undef LOAD
+}
+
+static media_status_t extractorSetDataSourceCustom(AMediaExtractor* ex, void* src) {
+	if (setDataSourceCustomC == NULL) return AMEDIA_ERROR_UNSUPPORTED;
+	return setDataSourceCustomC(ex, src);
+}
+static AMediaFormat* extractorGetFileFormat(AMediaExtractor* ex) {
+	if (getFileFormatC == NULL) return NULL;
+	return getFileFormatC(ex);
+}
+static ssize_t extractorGetSampleSize(AMediaExtractor* ex) {
+	if (getSampleSizeC == NULL) return -1;
+	return getSampleSizeC(ex);
+}
+static int64_t extractorGetCachedDuration(AMediaExtractor* ex) {
+	if (getCachedDurationC == NULL) return -1;
+	return getCachedDurationC(ex);
+}
+*/
+import "C"
+
+import (
+	"io"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+/**
+ * AMediaExtractor is an opaque type that demuxes encoded samples from a container.
+ */
+//typedef struct AMediaExtractor AMediaExtractor;
+type Extractor C.AMediaExtractor
+
+func (ex *Extractor) cptr() *C.AMediaExtractor {
+	return (*C.AMediaExtractor)(ex)
+}
+
+var extractorOnce sync.Once
+
+// extractorInit 取得 API 28 新增的函数
+func extractorInit() {
+	extractorOnce.Do(func() {
+		C.initExtractor()
+	})
+}
+
+type SeekMode int
+
+const (
+	SEEK_PREVIOUS_SYNC SeekMode = C.AMEDIAEXTRACTOR_SEEK_PREVIOUS_SYNC
+	SEEK_NEXT_SYNC     SeekMode = C.AMEDIAEXTRACTOR_SEEK_NEXT_SYNC
+	SEEK_CLOSEST_SYNC  SeekMode = C.AMEDIAEXTRACTOR_SEEK_CLOSEST_SYNC
+)
+
+// GetSampleFlags 的返回值
+const (
+	SAMPLE_FLAG_SYNC      = C.AMEDIAEXTRACTOR_SAMPLE_FLAG_SYNC
+	SAMPLE_FLAG_ENCRYPTED = C.AMEDIAEXTRACTOR_SAMPLE_FLAG_ENCRYPTED
+)
+
+/**
+ * Create new media extractor
+ */
+//AMediaExtractor* AMediaExtractor_new();
+func NewExtractor() (*Extractor, error) {
+	ex := C.AMediaExtractor_new()
+	if ex == nil {
+		return nil, iStatus(ERROR_UNKNOWN)
+	}
+	return (*Extractor)(ex), nil
+}
+
+/**
+ * Delete a previously created media extractor
+ */
+//media_status_t AMediaExtractor_delete(AMediaExtractor*);
+// Delete 同时释放 SetDataSourceCustom 的 DataSource
+func (ex *Extractor) Delete() error {
+	ret := Status(C.AMediaExtractor_delete(ex.cptr()))
+	extractorSourceLock.Lock()
+	owned := extractorSources[ex]
+	delete(extractorSources, ex)
+	extractorSourceLock.Unlock()
+	if owned.source != nil {
+		owned.source.Delete()
+	}
+	if owned.closer != nil {
+		owned.closer.Close()
+	}
+	return ret
+}
+
+// extractorOwned 随 Extractor 释放的资源
+type extractorOwned struct {
+	source *DataSource
+	closer io.Closer
+}
+
+var (
+	extractorSourceLock sync.Mutex
+	extractorSources    = map[*Extractor]extractorOwned{}
+)
+
+/**
+ *  Set the file descriptor from which the extractor will read.
+ */
+//media_status_t AMediaExtractor_setDataSourceFd(AMediaExtractor*, int fd, off64_t offset,
+//        off64_t length);
+func (ex *Extractor) SetDataSourceFd(fd int, offset, length int64) error {
+	return Status(C.AMediaExtractor_setDataSourceFd(ex.cptr(), C.int(fd),
+		C.off64_t(offset), C.off64_t(length)))
+}
+
+/**
+ * Set the URI from which the extractor will read.
+ */
+//media_status_t AMediaExtractor_setDataSource(AMediaExtractor*, const char *location);
+func (ex *Extractor) SetDataSource(location string) error {
+	clocation := C.CString(location)
+	defer C.free(unsafe.Pointer(clocation))
+	return Status(C.AMediaExtractor_setDataSource(ex.cptr(), clocation))
+}
+
+/**
+ * Set the custom data source implementation from which the extractor will read.
+ *
+ * Available since API level 28.
+ */
+//media_status_t AMediaExtractor_setDataSourceCustom(AMediaExtractor*,
+//        AMediaDataSource *src) __INTRODUCED_IN(28);
+// SetDataSourceCustom source 由 Extractor 接管，在 Delete 时释放
+func (ex *Extractor) SetDataSourceCustom(source *DataSource) error {
+	extractorInit()
+	ret := Status(C.extractorSetDataSourceCustom(ex.cptr(), unsafe.Pointer(source.cptr())))
+	if ret == nil {
+		ex.own(extractorOwned{source: source})
+	}
+	return ret
+}
+
+func (ex *Extractor) own(owned extractorOwned) {
+	extractorSourceLock.Lock()
+	defer extractorSourceLock.Unlock()
+	old := extractorSources[ex]
+	if owned.source == nil {
+		owned.source = old.source
+	}
+	if owned.closer == nil {
+		owned.closer = old.closer
+	}
+	extractorSources[ex] = owned
+}
+
+/**
+ * Return the number of tracks in the previously specified media file
+ */
+//size_t AMediaExtractor_getTrackCount(AMediaExtractor*);
+func (ex *Extractor) GetTrackCount() int {
+	return int(C.AMediaExtractor_getTrackCount(ex.cptr()))
+}
+
+/**
+ * Return the format of the specified track. The caller must free the returned format
+ */
+//AMediaFormat* AMediaExtractor_getTrackFormat(AMediaExtractor*, size_t idx);
+func (ex *Extractor) GetTrackFormat(idx int) *Format {
+	return (*Format)(C.AMediaExtractor_getTrackFormat(ex.cptr(), C.size_t(idx)))
+}
+
+/**
+ * Select the specified track. Subsequent calls to readSampleData, getSampleTrackIndex and
+ * getSampleTime only retrieve information for the subset of tracks selected.
+ * Selecting the same track multiple times has no effect, the track is
+ * only selected once.
+ */
+//media_status_t AMediaExtractor_selectTrack(AMediaExtractor*, size_t idx);
+func (ex *Extractor) SelectTrack(idx int) error {
+	return Status(C.AMediaExtractor_selectTrack(ex.cptr(), C.size_t(idx)))
+}
+
+/**
+ * Unselect the specified track. Subsequent calls to readSampleData, getSampleTrackIndex and
+ * getSampleTime only retrieve information for the subset of tracks selected..
+ */
+//media_status_t AMediaExtractor_unselectTrack(AMediaExtractor*, size_t idx);
+func (ex *Extractor) UnselectTrack(idx int) error {
+	return Status(C.AMediaExtractor_unselectTrack(ex.cptr(), C.size_t(idx)))
+}
+
+/**
+ * Read the current sample.
+ */
+//ssize_t AMediaExtractor_readSampleData(AMediaExtractor*, uint8_t *buffer, size_t capacity);
+// ReadSampleData 没有更多样本时返回 io.EOF
+func (ex *Extractor) ReadSampleData(buf []byte) (int, error) {
+	if len(buf) == 0 {
+		return 0, iStatus(ERROR_INVALID_PARAMETER)
+	}
+	n := int(C.AMediaExtractor_readSampleData(ex.cptr(), (*C.uint8_t)(unsafe.Pointer(&buf[0])),
+		C.size_t(len(buf))))
+	if n < 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+/**
+ * Read the current sample's flags.
+ */
+//uint32_t AMediaExtractor_getSampleFlags(AMediaExtractor*); // see definitions below
+func (ex *Extractor) GetSampleFlags() uint32 {
+	return uint32(C.AMediaExtractor_getSampleFlags(ex.cptr()))
+}
+
+/**
+ * Returns the track index the current sample originates from (or -1
+ * if no more samples are available)
+ */
+//int AMediaExtractor_getSampleTrackIndex(AMediaExtractor*);
+func (ex *Extractor) GetSampleTrackIndex() int {
+	return int(C.AMediaExtractor_getSampleTrackIndex(ex.cptr()))
+}
+
+/**
+ * Returns the current sample's presentation time in microseconds.
+ * or -1 if no more samples are available.
+ */
+//int64_t AMediaExtractor_getSampleTime(AMediaExtractor*);
+// GetSampleTime 没有更多样本时返回负值
+func (ex *Extractor) GetSampleTime() time.Duration {
+	t := int64(C.AMediaExtractor_getSampleTime(ex.cptr()))
+	if t < 0 {
+		return -1
+	}
+	return time.Duration(t) * time.Microsecond
+}
+
+/**
+ * Advance to the next sample. Returns false if no more sample data
+ * is available (end of stream).
+ */
+//bool AMediaExtractor_advance(AMediaExtractor*);
+func (ex *Extractor) Advance() bool {
+	return bool(C.AMediaExtractor_advance(ex.cptr()))
+}
+
+/**
+ * Seek to the sync sample nearest to the given position, as chosen by mode.
+ */
+//media_status_t AMediaExtractor_seekTo(AMediaExtractor*, int64_t seekPosUs, SeekMode mode);
+func (ex *Extractor) SeekTo(pos time.Duration, mode SeekMode) error {
+	return Status(C.AMediaExtractor_seekTo(ex.cptr(), C.int64_t(pos/time.Microsecond),
+		C.SeekMode(mode)))
+}
+
+/**
+ * Returns the format of the extractor. The caller must free the returned format
+ * using AMediaFormat_delete(format).
+ *
+ * This function will always return a format; however, the format could be empty
+ * (no key-value pairs) if the media container does not provide format information.
+ *
+ * Available since API level 28.
+ */
+//AMediaFormat* AMediaExtractor_getFileFormat(AMediaExtractor*) __INTRODUCED_IN(28);
+// GetFileFormat API 28 之前返回 nil
+func (ex *Extractor) GetFileFormat() *Format {
+	extractorInit()
+	return (*Format)(C.extractorGetFileFormat(ex.cptr()))
+}
+
+/**
+ * Returns the size of the current sample in bytes, or -1 when no samples are
+ * available (end of stream). This API can be used in in conjunction with
+ * AMediaExtractor_readSampleData:
+ *
+ * Available since API level 28.
+ */
+//ssize_t AMediaExtractor_getSampleSize(AMediaExtractor*) __INTRODUCED_IN(28);
+// GetSampleSize API 28 之前返回 -1
+func (ex *Extractor) GetSampleSize() int {
+	extractorInit()
+	return int(C.extractorGetSampleSize(ex.cptr()))
+}
+
+/**
+ * Returns the duration of cached media samples downloaded from a network data source
+ * (AMediaExtractor_setDataSource with a "http(s)" URI) in microseconds.
+ *
+ * This information is calculated using total bitrate; if total bitrate is not in the
+ * media container it is calculated using total duration and file size.
+ *
+ * Returns -1 when the extractor is not reading from a network data source, or when the
+ * cached duration cannot be calculated (bitrate, duration, and file size information
+ * not available).
+ *
+ * Available since API level 28.
+ */
+//int64_t AMediaExtractor_getCachedDuration(AMediaExtractor *) __INTRODUCED_IN(28);
+func (ex *Extractor) GetCachedDuration() time.Duration {
+	extractorInit()
+	d := int64(C.extractorGetCachedDuration(ex.cptr()))
+	if d < 0 {
+		return -1
+	}
+	return time.Duration(d) * time.Microsecond
+}
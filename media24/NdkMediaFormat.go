@@ -0,0 +1,283 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+/*
+ * Copyright (C) 2014 The Android Open Source Project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/**
+ * @addtogroup Media
+ * @{
+ */
+
+/**
+ * @file NdkMediaFormat.h
+ */
+
+/*
+ * This file defines an NDK API.
+ * Do not remove methods.
+ * Do not change method signatures.
+ * Do not change the value of constants.
+ * Do not change the size of any of the classes defined in here.
+ * Do not reference types that are not part of the NDK.
+ * Do not #include files that aren't part of the NDK.
+ */
+
+package media
+
+/*
+#include <stdlib.h>
+#include <media/NdkMediaFormat.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+/**
+ * AMediaFormat is an opaque type holding key/value pairs that describe a media format.
+ */
+//typedef struct AMediaFormat AMediaFormat;
+type Format C.AMediaFormat
+
+func (format *Format) cptr() *C.AMediaFormat {
+	return (*C.AMediaFormat)(format)
+}
+
+// AMEDIAFORMAT_KEY_*，与头文件中的字符串相同。
+// 头文件中它们是变量，部分只在较高的 API 中存在，这里直接使用字符串
+const (
+	KEY_AAC_PROFILE                 = "aac-profile"
+	KEY_BIT_RATE                    = "bitrate"
+	KEY_BITRATE_MODE                = "bitrate-mode"
+	KEY_CHANNEL_COUNT               = "channel-count"
+	KEY_CHANNEL_MASK                = "channel-mask"
+	KEY_COLOR_FORMAT                = "color-format"
+	KEY_CSD_0                       = "csd-0"
+	KEY_CSD_1                       = "csd-1"
+	KEY_CSD_2                       = "csd-2"
+	KEY_DURATION                    = "durationUs"
+	KEY_FLAC_COMPRESSION_LEVEL      = "flac-compression-level"
+	KEY_FRAME_RATE                  = "frame-rate"
+	KEY_HEIGHT                      = "height"
+	KEY_IS_ADTS                     = "is-adts"
+	KEY_IS_AUTOSELECT               = "is-autoselect"
+	KEY_IS_DEFAULT                  = "is-default"
+	KEY_IS_FORCED_SUBTITLE          = "is-forced-subtitle"
+	KEY_I_FRAME_INTERVAL            = "i-frame-interval"
+	KEY_LANGUAGE                    = "language"
+	KEY_LEVEL                       = "level"
+	KEY_MAX_HEIGHT                  = "max-height"
+	KEY_MAX_INPUT_SIZE              = "max-input-size"
+	KEY_MAX_WIDTH                   = "max-width"
+	KEY_MIME                        = "mime"
+	KEY_PCM_ENCODING                = "pcm-encoding"
+	KEY_PROFILE                     = "profile"
+	KEY_PUSH_BLANK_BUFFERS_ON_STOP  = "push-blank-buffers-on-shutdown"
+	KEY_REPEAT_PREVIOUS_FRAME_AFTER = "repeat-previous-frame-after"
+	KEY_REQUEST_SYNC_FRAME          = "request-sync"
+	KEY_ROTATION                    = "rotation-degrees"
+	KEY_SAMPLE_RATE                 = "sample-rate"
+	KEY_STRIDE                      = "stride"
+	KEY_VIDEO_BITRATE               = "video-bitrate"
+	KEY_WIDTH                       = "width"
+)
+
+// 常用的 MIME 类型
+const (
+	MIMETYPE_VIDEO_AVC  = "video/avc"
+	MIMETYPE_VIDEO_HEVC = "video/hevc"
+	MIMETYPE_VIDEO_VP8  = "video/x-vnd.on2.vp8"
+	MIMETYPE_VIDEO_VP9  = "video/x-vnd.on2.vp9"
+	MIMETYPE_AUDIO_AAC  = "audio/mp4a-latm"
+	MIMETYPE_AUDIO_OPUS = "audio/opus"
+	MIMETYPE_AUDIO_RAW  = "audio/raw"
+)
+
+// KEY_COLOR_FORMAT 的常用值 (MediaCodecInfo.CodecCapabilities)
+const (
+	COLOR_FormatYUV420Planar     = 19
+	COLOR_FormatYUV420SemiPlanar = 21
+	COLOR_FormatYUV420Flexible   = 0x7F420888
+	COLOR_FormatSurface          = 0x7F000789
+)
+
+// KEY_BITRATE_MODE 的取值
+const (
+	BITRATE_MODE_CQ  = 0
+	BITRATE_MODE_VBR = 1
+	BITRATE_MODE_CBR = 2
+)
+
+/**
+ * Create a new, empty format. It must be released with AMediaFormat_delete.
+ */
+//AMediaFormat *AMediaFormat_new();
+func NewFormat() *Format {
+	return (*Format)(C.AMediaFormat_new())
+}
+
+/**
+ * Release the format and its resources.
+ */
+//media_status_t AMediaFormat_delete(AMediaFormat*);
+func (format *Format) Delete() error {
+	return Status(C.AMediaFormat_delete(format.cptr()))
+}
+
+/**
+ * Human readable representation of the format. The returned string is owned by the format,
+ * and remains valid until the next call to toString, or until the format is deleted.
+ */
+//const char* AMediaFormat_toString(AMediaFormat*);
+func (format *Format) String() string {
+	return C.GoString(C.AMediaFormat_toString(format.cptr()))
+}
+
+/**
+ * Get the named int32 value. Returns false if the entry is missing or of another type.
+ */
+//bool AMediaFormat_getInt32(AMediaFormat*, const char *name, int32_t *out);
+func (format *Format) GetInt32(name string) (int32, bool) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	var v C.int32_t
+	ok := C.AMediaFormat_getInt32(format.cptr(), cname, &v)
+	return int32(v), bool(ok)
+}
+
+/**
+ * Get the named int64 value. Returns false if the entry is missing or of another type.
+ */
+//bool AMediaFormat_getInt64(AMediaFormat*, const char *name, int64_t *out);
+func (format *Format) GetInt64(name string) (int64, bool) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	var v C.int64_t
+	ok := C.AMediaFormat_getInt64(format.cptr(), cname, &v)
+	return int64(v), bool(ok)
+}
+
+/**
+ * Get the named float value. Returns false if the entry is missing or of another type.
+ */
+//bool AMediaFormat_getFloat(AMediaFormat*, const char *name, float *out);
+func (format *Format) GetFloat(name string) (float32, bool) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	var v C.float
+	ok := C.AMediaFormat_getFloat(format.cptr(), cname, &v)
+	return float32(v), bool(ok)
+}
+
+/**
+ * Get the named size_t value. Returns false if the entry is missing or of another type.
+ */
+//bool AMediaFormat_getSize(AMediaFormat*, const char *name, size_t *out);
+func (format *Format) GetSize(name string) (int, bool) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	var v C.size_t
+	ok := C.AMediaFormat_getSize(format.cptr(), cname, &v)
+	return int(v), bool(ok)
+}
+
+/**
+ * The returned data is owned by the format and remains valid as long as the named entry
+ * is part of the format.
+ */
+//bool AMediaFormat_getBuffer(AMediaFormat*, const char *name, void** data, size_t *size);
+func (format *Format) GetBuffer(name string) ([]byte, bool) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	var data unsafe.Pointer
+	var size C.size_t
+	if !C.AMediaFormat_getBuffer(format.cptr(), cname, &data, &size) {
+		return nil, false
+	}
+	return C.GoBytes(data, C.int(size)), true
+}
+
+/**
+ * The returned string is owned by the format, and remains valid until the next call to getString,
+ * or until the format is deleted.
+ */
+//bool AMediaFormat_getString(AMediaFormat*, const char *name, const char **out);
+func (format *Format) GetString(name string) (string, bool) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	var v *C.char
+	if !C.AMediaFormat_getString(format.cptr(), cname, &v) {
+		return "", false
+	}
+	return C.GoString(v), true
+}
+
+/**
+ * Set the named int32 value, replacing any existing entry of the same name.
+ */
+//void AMediaFormat_setInt32(AMediaFormat*, const char* name, int32_t value);
+func (format *Format) SetInt32(name string, value int32) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	C.AMediaFormat_setInt32(format.cptr(), cname, C.int32_t(value))
+}
+
+/**
+ * Set the named int64 value, replacing any existing entry of the same name.
+ */
+//void AMediaFormat_setInt64(AMediaFormat*, const char* name, int64_t value);
+func (format *Format) SetInt64(name string, value int64) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	C.AMediaFormat_setInt64(format.cptr(), cname, C.int64_t(value))
+}
+
+/**
+ * Set the named float value, replacing any existing entry of the same name.
+ */
+//void AMediaFormat_setFloat(AMediaFormat*, const char* name, float value);
+func (format *Format) SetFloat(name string, value float32) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	C.AMediaFormat_setFloat(format.cptr(), cname, C.float(value))
+}
+
+/**
+ * The provided string is copied into the format.
+ */
+//void AMediaFormat_setString(AMediaFormat*, const char* name, const char* value);
+func (format *Format) SetString(name string, value string) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	cvalue := C.CString(value)
+	defer C.free(unsafe.Pointer(cvalue))
+	C.AMediaFormat_setString(format.cptr(), cname, cvalue)
+}
+
+/**
+ * The provided data is copied into the format.
+ */
+//void AMediaFormat_setBuffer(AMediaFormat*, const char* name, void* data, size_t size);
+func (format *Format) SetBuffer(name string, data []byte) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	cdata := C.CBytes(data)
+	defer C.free(cdata)
+	C.AMediaFormat_setBuffer(format.cptr(), cname, cdata, C.size_t(len(data)))
+}
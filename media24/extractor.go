@@ -0,0 +1,178 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package media
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	app "github.com/gooid/gooid/internal/ndk"
+	"github.com/gooid/gooid/media24/mp4"
+)
+
+// OpenExtractor 打开本地文件 (MP4/WebM/MP3 等)
+func OpenExtractor(path string) (*Extractor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// extractor 复制了 fd
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	ex, err := NewExtractor()
+	if err != nil {
+		return nil, err
+	}
+	if err = ex.SetDataSourceFd(int(f.Fd()), 0, fi.Size()); err != nil {
+		ex.Delete()
+		return nil, err
+	}
+	return ex, nil
+}
+
+// NewReaderExtractor 从 r 读取，r 在 Delete 之前须保持可用。API 28
+func NewReaderExtractor(r io.ReadSeeker) (*Extractor, error) {
+	source, err := NewDataSource(r)
+	if err != nil {
+		return nil, err
+	}
+	ex, err := NewExtractor()
+	if err != nil {
+		source.Delete()
+		return nil, err
+	}
+	if err = ex.SetDataSourceCustom(source); err != nil {
+		ex.Delete()
+		source.Delete()
+		return nil, err
+	}
+	return ex, nil
+}
+
+// OpenAssetExtractor 从 APK 的 assets 中读取。
+// 未压缩的 asset 直接使用文件描述符；压缩的通过 AMediaDataSource 读取，
+// 需要 API 28，asset 在 Delete 时关闭
+func OpenAssetExtractor(mgr *app.AssetManager, name string) (*Extractor, error) {
+	asset := mgr.Open(name, app.ASSET_MODE_RANDOM)
+	if asset == nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	if fd, start, length, err := asset.FileDescriptor(); err == nil {
+		asset.Close()
+		// extractor 复制了 fd
+		f := os.NewFile(uintptr(fd), name)
+		defer f.Close()
+		ex, err := NewExtractor()
+		if err != nil {
+			return nil, err
+		}
+		if err = ex.SetDataSourceFd(int(f.Fd()), start, length); err != nil {
+			ex.Delete()
+			return nil, err
+		}
+		return ex, nil
+	}
+	ex, err := NewReaderExtractor(asset)
+	if err != nil {
+		asset.Close()
+		return nil, err
+	}
+	ex.own(extractorOwned{closer: asset})
+	return ex, nil
+}
+
+// TrackInfo 轨道的格式，KEY_DURATION 和 KEY_LANGUAGE 之外的字段见 mp4.TrackFormat
+type TrackInfo struct {
+	mp4.TrackFormat
+	Index    int
+	Duration time.Duration
+	Language string
+}
+
+// Tracks 所有轨道的格式
+func (ex *Extractor) Tracks() []TrackInfo {
+	n := ex.GetTrackCount()
+	tracks := make([]TrackInfo, 0, n)
+	for i := 0; i < n; i++ {
+		format := ex.GetTrackFormat(i)
+		if format == nil {
+			continue
+		}
+		info := TrackInfo{TrackFormat: TrackFormatOf(format), Index: i}
+		if d, ok := format.GetInt64(KEY_DURATION); ok {
+			info.Duration = time.Duration(d) * time.Microsecond
+		}
+		info.Language, _ = format.GetString(KEY_LANGUAGE)
+		format.Delete()
+		tracks = append(tracks, info)
+	}
+	return tracks
+}
+
+// Sample ReadSample 读取的样本
+type Sample struct {
+	Track            int
+	Data             []byte
+	PresentationTime time.Duration
+	Flags            uint32 // SAMPLE_FLAG_*
+}
+
+// IsSync 是否为同步样本 (关键帧)
+func (s *Sample) IsSync() bool {
+	return s.Flags&SAMPLE_FLAG_SYNC != 0
+}
+
+const (
+	minSampleBuffer = 256 << 10
+	maxSampleBuffer = 64 << 20
+)
+
+// ReadSample 读取已选择轨道的当前样本并前进到下一个。
+// 样本存入 buf，容量不够时重新分配；没有更多样本时返回 io.EOF
+func (ex *Extractor) ReadSample(buf []byte) (Sample, error) {
+	track := ex.GetSampleTrackIndex()
+	if track < 0 {
+		return Sample{}, io.EOF
+	}
+	size := ex.GetSampleSize()
+	known := size >= 0 // API 28 之前不知道样本大小
+	if !known {
+		size = minSampleBuffer
+	}
+	if cap(buf) > size {
+		size = cap(buf)
+	}
+	for {
+		buf = buf[:cap(buf)]
+		if len(buf) < size {
+			buf = make([]byte, size)
+		}
+		n, err := ex.ReadSampleData(buf)
+		if err == nil {
+			s := Sample{
+				Track:            track,
+				Data:             buf[:n],
+				PresentationTime: ex.GetSampleTime(),
+				Flags:            ex.GetSampleFlags(),
+			}
+			ex.Advance()
+			return s, nil
+		}
+		// 知道样本大小时 buf 已足够，失败不是因为 buf 太小
+		if known {
+			return Sample{}, err
+		}
+		// 不知道样本大小时，失败说明 buf 太小
+		if size >= maxSampleBuffer {
+			return Sample{}, fmt.Errorf("media: sample of track %d larger than %d bytes", track, maxSampleBuffer)
+		}
+		size *= 2
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package media
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FramePolicy 消费者跟不上时的处理方式
+type FramePolicy int
+
+const (
+	FRAME_DROP_OLDEST FramePolicy = iota // 丢弃队列中最旧的帧，总是提供最新的画面
+	FRAME_DROP_NEWEST                    // 丢弃新到的帧
+	FRAME_BLOCK                          // 不丢帧，图像留在 ImageReader 的队列中等待消费者
+)
+
+// ErrStreamClosed FrameStream 已关闭
+var ErrStreamClosed = errors.New("media: frame stream closed")
+
+// Frame 从 ImageReader 取得的一帧，Image 在 Release 之后不可再用
+type Frame struct {
+	Image     *Image
+	Timestamp time.Duration
+	Seq       uint64 // 从 1 开始的序号，包括被丢弃的帧
+
+	stream   *FrameStream
+	released int32
+}
+
+// Release 把图像还给 ImageReader，可以多次调用
+func (f *Frame) Release() {
+	if atomic.CompareAndSwapInt32(&f.released, 0, 1) {
+		f.Image.Delete()
+		f.stream.released()
+	}
+}
+
+// YUV 不复制数据的视图，只能在 Release 之前使用
+func (f *Frame) YUV() (*YUVImage, error) {
+	return f.Image.YUV()
+}
+
+// NV21 复制为 NV21，数据在 Frame.Release 之后仍可使用
+func (f *Frame) NV21(t Transform) (*FrameBuffer, error) {
+	return f.copy(t, (*YUVImage).NV21)
+}
+
+// I420 复制为 I420，数据在 Frame.Release 之后仍可使用
+func (f *Frame) I420(t Transform) (*FrameBuffer, error) {
+	return f.copy(t, (*YUVImage).I420)
+}
+
+func (f *Frame) copy(t Transform, conv func(*YUVImage, []byte, Transform) []byte) (*FrameBuffer, error) {
+	m, err := f.YUV()
+	if err != nil {
+		return nil, err
+	}
+	b := f.stream.buffers.Get().(*FrameBuffer)
+	b.Data = conv(m, b.Data, t)
+	b.Width, b.Height = m.Size(t)
+	b.Timestamp = f.Timestamp
+	b.Seq = f.Seq
+	return b, nil
+}
+
+// FrameBuffer 复制出的帧数据，Release 后缓冲区被下一帧复用
+type FrameBuffer struct {
+	Data          []byte
+	Width, Height int
+	Timestamp     time.Duration
+	Seq           uint64
+
+	pool *sync.Pool
+}
+
+func (b *FrameBuffer) Release() {
+	if b.pool != nil {
+		b.pool.Put(b)
+	}
+}
+
+// FrameStream 把 ImageReader 的图像通过 channel 交给 Each 的回调。
+// 已取出但未 Release 的图像不超过 ImageReader 的 maxImages，
+// ImageListener 由 FrameStream 接管，相机线程不会被阻塞。
+type FrameStream struct {
+	reader    *ImageReader
+	policy    FramePolicy
+	maxImages int32
+
+	frames   chan *Frame
+	notify   chan struct{}
+	done     chan struct{}
+	exited   chan struct{}
+	closeMu  sync.Once
+	buffers  sync.Pool
+	pending  int32 // ImageReader 中等待取出的图像
+	acquired int32 // 已取出未 Release 的图像
+	seq      uint64
+	dropped  uint64
+}
+
+// NewFrameStream depth 为 channel 的长度，不超过 maxImages
+func NewFrameStream(reader *ImageReader, policy FramePolicy, depth int) (*FrameStream, error) {
+	maxImages, err := reader.GetMaxImages()
+	if err != nil {
+		return nil, err
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	if depth > maxImages {
+		depth = maxImages
+	}
+	s := &FrameStream{
+		reader:    reader,
+		policy:    policy,
+		maxImages: int32(maxImages),
+		frames:    make(chan *Frame, depth),
+		notify:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+		exited:    make(chan struct{}),
+	}
+	s.buffers.New = func() interface{} { return &FrameBuffer{pool: &s.buffers} }
+	if err = reader.SetImageListener(s.onImage); err != nil {
+		return nil, err
+	}
+	go s.pump()
+	return s, nil
+}
+
+// Dropped 被丢弃的帧数
+func (s *FrameStream) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// next 取下一帧，调用者负责 Release
+func (s *FrameStream) next(ctx context.Context) (*Frame, error) {
+	select {
+	case f, ok := <-s.frames:
+		if !ok {
+			return nil, ErrStreamClosed
+		}
+		return f, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Each 依次处理每一帧直到 fn 返回错误、ctx 结束或 stream 关闭，是取帧的唯一方式。
+// fn 返回或 panic 时帧都会被 Release，需要在 fn 之外使用的数据用 NV21、I420 复制
+func (s *FrameStream) Each(ctx context.Context, fn func(*Frame) error) error {
+	for {
+		f, err := s.next(ctx)
+		if err != nil {
+			return err
+		}
+		if err = handleFrame(f, fn); err != nil {
+			return err
+		}
+	}
+}
+
+func handleFrame(f *Frame, fn func(*Frame) error) error {
+	defer f.Release()
+	return fn(f)
+}
+
+// Close 停止接收图像，释放队列中的帧；消费者持有的帧仍需 Release
+func (s *FrameStream) Close() {
+	s.closeMu.Do(func() {
+		s.reader.SetImageListener(nil)
+		close(s.done)
+		<-s.exited
+		close(s.frames)
+		for f := range s.frames {
+			f.Release()
+		}
+	})
+}
+
+func (s *FrameStream) onImage(*ImageReader) {
+	atomic.AddInt32(&s.pending, 1)
+	s.wake()
+}
+
+func (s *FrameStream) released() {
+	atomic.AddInt32(&s.acquired, -1)
+	s.wake()
+}
+
+func (s *FrameStream) wake() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *FrameStream) pump() {
+	defer close(s.exited)
+	for {
+		select {
+		case <-s.notify:
+		case <-s.done:
+			return
+		}
+		for atomic.LoadInt32(&s.pending) > 0 {
+			if atomic.LoadInt32(&s.acquired) >= s.maxImages && !s.dropQueued() {
+				// 等待消费者 Release
+				break
+			}
+			f := s.acquire()
+			if f == nil {
+				break
+			}
+			if !s.deliver(f) {
+				return
+			}
+		}
+	}
+}
+
+// dropQueued FRAME_DROP_OLDEST 时丢弃 channel 中最旧的帧以腾出图像
+func (s *FrameStream) dropQueued() bool {
+	if s.policy != FRAME_DROP_OLDEST {
+		return false
+	}
+	select {
+	case f := <-s.frames:
+		atomic.AddUint64(&s.dropped, 1)
+		f.Release()
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *FrameStream) acquire() *Frame {
+	var img *Image
+	var err error
+	if s.policy == FRAME_DROP_OLDEST {
+		// 跳过队列中更旧的图像
+		n := atomic.SwapInt32(&s.pending, 0)
+		img, err = s.reader.AcquireLatestImage()
+		if err == nil && n > 1 {
+			atomic.AddUint64(&s.seq, uint64(n-1))
+			atomic.AddUint64(&s.dropped, uint64(n-1))
+		}
+	} else {
+		atomic.AddInt32(&s.pending, -1)
+		img, err = s.reader.AcquireNextImage()
+	}
+	if err != nil || img == nil {
+		switch err {
+		case iStatus(IMGREADER_NO_BUFFER_AVAILABLE):
+			atomic.StoreInt32(&s.pending, 0)
+		case iStatus(IMGREADER_MAX_IMAGES_ACQUIRED):
+			// 图像仍在队列中，等待 Release 后再取
+			atomic.AddInt32(&s.pending, 1)
+		}
+		return nil
+	}
+	atomic.AddInt32(&s.acquired, 1)
+	f := &Frame{Image: img, stream: s, Seq: atomic.AddUint64(&s.seq, 1)}
+	f.Timestamp, _ = img.GetTimestamp()
+	return f
+}
+
+// deliver 按策略把帧放入 channel，stream 关闭时返回 false
+func (s *FrameStream) deliver(f *Frame) bool {
+	for {
+		select {
+		case s.frames <- f:
+			return true
+		default:
+		}
+		switch s.policy {
+		case FRAME_DROP_NEWEST:
+			atomic.AddUint64(&s.dropped, 1)
+			f.Release()
+			return true
+		case FRAME_DROP_OLDEST:
+			s.dropQueued()
+		default:
+			select {
+			case s.frames <- f:
+				return true
+			case <-s.done:
+				f.Release()
+				return false
+			}
+		}
+	}
+}
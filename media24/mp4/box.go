@@ -0,0 +1,150 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// boxBuffer 用于构造 box，box/fullBox 在 body 写完后回填大小
+type boxBuffer struct {
+	bytes.Buffer
+}
+
+func (b *boxBuffer) u8(v uint8) {
+	b.WriteByte(v)
+}
+
+func (b *boxBuffer) u16(v uint16) {
+	b.Write([]byte{byte(v >> 8), byte(v)})
+}
+
+func (b *boxBuffer) u24(v uint32) {
+	b.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+}
+
+func (b *boxBuffer) u32(v uint32) {
+	var p [4]byte
+	binary.BigEndian.PutUint32(p[:], v)
+	b.Write(p[:])
+}
+
+func (b *boxBuffer) u64(v uint64) {
+	var p [8]byte
+	binary.BigEndian.PutUint64(p[:], v)
+	b.Write(p[:])
+}
+
+func (b *boxBuffer) zeros(n int) {
+	for ; n > 0; n-- {
+		b.WriteByte(0)
+	}
+}
+
+func (b *boxBuffer) box(typ string, body func()) {
+	start := b.Len()
+	b.u32(0)
+	b.WriteString(typ)
+	body()
+	binary.BigEndian.PutUint32(b.Bytes()[start:], uint32(b.Len()-start))
+}
+
+func (b *boxBuffer) fullBox(typ string, version uint8, flags uint32, body func()) {
+	b.box(typ, func() {
+		b.u8(version)
+		b.u24(flags)
+		body()
+	})
+}
+
+// Box 解析出的 box，Data 为去掉头部后的内容
+type Box struct {
+	Type     string
+	Offset   int64 // 在文件中的位置
+	Size     int64 // 包括头部
+	Data     []byte
+	Children []Box
+}
+
+// containers 内容为子 box 的类型
+var containers = map[string]bool{
+	"moov": true, "trak": true, "mdia": true, "minf": true, "stbl": true,
+	"dinf": true, "edts": true, "mvex": true, "moof": true, "traf": true,
+	"udta": true, "mfra": true,
+}
+
+// ParseBoxes 解析 data 中的 box，容器类的 box 递归解析
+func ParseBoxes(data []byte) ([]Box, error) {
+	return parseBoxes(data, 0)
+}
+
+func parseBoxes(data []byte, offset int64) ([]Box, error) {
+	var boxes []Box
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return boxes, fmt.Errorf("mp4: truncated box header at %d", offset)
+		}
+		size := int64(binary.BigEndian.Uint32(data))
+		typ := string(data[4:8])
+		header := int64(8)
+		switch size {
+		case 0:
+			size = int64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return boxes, fmt.Errorf("mp4: truncated box header at %d", offset)
+			}
+			size = int64(binary.BigEndian.Uint64(data[8:]))
+			header = 16
+		}
+		if size < header || size > int64(len(data)) {
+			return boxes, fmt.Errorf("mp4: invalid size %d of box %q at %d", size, typ, offset)
+		}
+		box := Box{Type: typ, Offset: offset, Size: size, Data: data[header:size]}
+		if containers[typ] {
+			children, err := parseBoxes(box.Data, offset+header)
+			if err != nil {
+				return boxes, err
+			}
+			box.Children = children
+		}
+		boxes = append(boxes, box)
+		data = data[size:]
+		offset += size
+	}
+	return boxes, nil
+}
+
+// Find 按路径查找第一个子 box，如 "mdia/minf/stbl"
+func (b *Box) Find(path string) *Box {
+	return find(b.Children, path)
+}
+
+// FindBox 在 boxes 中按路径查找第一个 box，如 "moov/trak/tkhd"
+func FindBox(boxes []Box, path string) *Box {
+	return find(boxes, path)
+}
+
+func find(boxes []Box, path string) *Box {
+	name, rest := path, ""
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		name, rest = path[:i], path[i+1:]
+	}
+	for i := range boxes {
+		if boxes[i].Type != name {
+			continue
+		}
+		if rest == "" {
+			return &boxes[i]
+		}
+		if found := find(boxes[i].Children, rest); found != nil {
+			return found
+		}
+	}
+	return nil
+}
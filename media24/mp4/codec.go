@@ -0,0 +1,202 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	nalSPS = 7
+	nalPPS = 8
+
+	// HEVC 的 NAL 类型
+	hevcVPS = 32
+	hevcSPS = 33
+	hevcPPS = 34
+)
+
+var startCode = []byte{0, 0, 1}
+
+// isAnnexB data 是否以起始码开头
+func isAnnexB(data []byte) bool {
+	return bytes.HasPrefix(data, startCode) || bytes.HasPrefix(data, []byte{0, 0, 0, 1})
+}
+
+// splitNALs 按起始码切分 NAL；不是 Annex-B 格式时整体作为一个 NAL
+func splitNALs(data []byte) [][]byte {
+	if !isAnnexB(data) {
+		if len(data) == 0 {
+			return nil
+		}
+		return [][]byte{data}
+	}
+	var nals [][]byte
+	for {
+		i := bytes.Index(data, startCode)
+		if i < 0 {
+			break
+		}
+		data = data[i+len(startCode):]
+		end := bytes.Index(data, startCode)
+		if end < 0 {
+			end = len(data)
+		}
+		// 4 字节起始码的第一个 0 属于下一个起始码
+		nal := bytes.TrimRight(data[:end], "\x00")
+		if len(nal) > 0 {
+			nals = append(nals, nal)
+		}
+		data = data[end:]
+	}
+	return nals
+}
+
+// toAVCC 把 Annex-B 格式的样本转为 4 字节长度前缀的格式，
+// 已经是长度前缀格式的样本原样返回
+func toAVCC(data []byte) []byte {
+	if !isAnnexB(data) {
+		return data
+	}
+	nals := splitNALs(data)
+	n := 0
+	for _, nal := range nals {
+		n += 4 + len(nal)
+	}
+	out := make([]byte, 0, n)
+	for _, nal := range nals {
+		var p [4]byte
+		binary.BigEndian.PutUint32(p[:], uint32(len(nal)))
+		out = append(append(out, p[:]...), nal...)
+	}
+	return out
+}
+
+// avcParameterSets 从 CSD 中取出 SPS 和 PPS
+func avcParameterSets(csd [][]byte) (sps, pps [][]byte) {
+	for _, b := range csd {
+		for _, nal := range splitNALs(b) {
+			switch nal[0] & 0x1f {
+			case nalSPS:
+				sps = append(sps, nal)
+			case nalPPS:
+				pps = append(pps, nal)
+			}
+		}
+	}
+	return
+}
+
+// SplitCSD 把编码器输出的一个 SAMPLE_CODEC_CONFIG 样本按 AMediaFormat 的约定分为 csd-0、csd-1 ...
+// AVC 分为 SPS (csd-0) 和 PPS (csd-1)，HEVC 的 VPS、SPS、PPS 都放在 csd-0，
+// 每个 NAL 带 4 字节起始码。其它格式或不是 Annex-B 格式时整体作为 csd-0。
+// 返回的数据不引用 config
+func SplitCSD(mime string, config []byte) [][]byte {
+	whole := [][]byte{append([]byte(nil), config...)}
+	if !isAnnexB(config) {
+		return whole
+	}
+	var sps, pps, params []byte
+	for _, nal := range splitNALs(config) {
+		switch mime {
+		case MIMETYPE_VIDEO_AVC:
+			switch nal[0] & 0x1f {
+			case nalSPS:
+				sps = appendNAL(sps, nal)
+			case nalPPS:
+				pps = appendNAL(pps, nal)
+			}
+		case MIMETYPE_VIDEO_HEVC:
+			switch nal[0] >> 1 & 0x3f {
+			case hevcVPS, hevcSPS, hevcPPS:
+				params = appendNAL(params, nal)
+			}
+		}
+	}
+	switch {
+	case sps != nil && pps != nil:
+		return [][]byte{sps, pps}
+	case params != nil:
+		return [][]byte{params}
+	}
+	return whole
+}
+
+func appendNAL(b, nal []byte) []byte {
+	return append(append(b, 0, 0, 0, 1), nal...)
+}
+
+// writeAVCC AVCDecoderConfigurationRecord
+func (b *boxBuffer) writeAVCC(sps, pps [][]byte) {
+	b.box("avcC", func() {
+		b.u8(1)
+		b.u8(sps[0][1]) // profile_idc
+		b.u8(sps[0][2]) // constraint flags
+		b.u8(sps[0][3]) // level_idc
+		b.u8(0xfc | 3)  // 4 字节长度前缀
+		b.u8(0xe0 | uint8(len(sps)))
+		for _, nal := range sps {
+			b.u16(uint16(len(nal)))
+			b.Write(nal)
+		}
+		b.u8(uint8(len(pps)))
+		for _, nal := range pps {
+			b.u16(uint16(len(nal)))
+			b.Write(nal)
+		}
+	})
+}
+
+var aacSampleRates = []int{
+	96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350,
+}
+
+// aacConfig AudioSpecificConfig，没有 CSD 时按 AAC-LC 生成
+func aacConfig(f *TrackFormat) []byte {
+	if len(f.CSD) > 0 && len(f.CSD[0]) >= 2 {
+		return f.CSD[0]
+	}
+	index := -1
+	for i, rate := range aacSampleRates {
+		if rate == f.SampleRate {
+			index = i
+			break
+		}
+	}
+	if index < 0 || f.ChannelCount < 1 || f.ChannelCount > 7 {
+		return nil
+	}
+	const objectType = 2 // AAC-LC
+	v := uint16(objectType)<<11 | uint16(index)<<7 | uint16(f.ChannelCount)<<3
+	return []byte{byte(v >> 8), byte(v)}
+}
+
+// writeESDS MPEG-4 elementary stream descriptor
+func (b *boxBuffer) writeESDS(track uint32, config []byte, bitrate int) {
+	descriptor := func(tag uint8, size int) {
+		b.u8(tag)
+		// 4 字节的长度编码
+		b.Write([]byte{0x80 | byte(size>>21), 0x80 | byte(size>>14), 0x80 | byte(size>>7), byte(size & 0x7f)})
+	}
+	decoderSpecific := 5 + len(config)
+	decoderConfig := 5 + 13 + decoderSpecific
+	es := 5 + 3 + decoderConfig + 5 + 1
+	b.fullBox("esds", 0, 0, func() {
+		descriptor(0x03, es-5) // ES_Descriptor
+		b.u16(uint16(track))
+		b.u8(0)
+		descriptor(0x04, decoderConfig-5) // DecoderConfigDescriptor
+		b.u8(0x40)                        // Audio ISO/IEC 14496-3
+		b.u8(0x15)                        // AudioStream
+		b.u24(0)
+		b.u32(uint32(bitrate))
+		b.u32(uint32(bitrate))
+		descriptor(0x05, len(config)) // DecoderSpecificInfo
+		b.Write(config)
+		descriptor(0x06, 1) // SLConfigDescriptor
+		b.u8(0x02)
+	})
+}
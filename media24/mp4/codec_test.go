@@ -0,0 +1,101 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mp4
+
+import (
+	"bytes"
+	"testing"
+)
+
+func annexB(nals ...[]byte) []byte {
+	var b []byte
+	for _, nal := range nals {
+		b = appendNAL(b, nal)
+	}
+	return b
+}
+
+func TestSplitCSD(t *testing.T) {
+	sei := []byte{0x06, 0x05, 0x01}
+	vps := []byte{0x40, 0x01, 0x0c}
+	hevcSPSNAL := []byte{0x42, 0x01, 0x01}
+	hevcPPSNAL := []byte{0x44, 0x01, 0xc1}
+	hevcSEI := []byte{0x4e, 0x01, 0x05}
+	asc := []byte{0x12, 0x10}
+
+	cases := []struct {
+		name   string
+		mime   string
+		config []byte
+		want   [][]byte
+	}{
+		{"avc", MIMETYPE_VIDEO_AVC, testCSD, [][]byte{annexB(testSPS), annexB(testPPS)}},
+		// 3 字节起始码、PPS 在前、夹有 SEI
+		{"avc short start codes", MIMETYPE_VIDEO_AVC,
+			append(append(append([]byte{0, 0, 1}, testPPS...), append([]byte{0, 0, 1}, sei...)...), append([]byte{0, 0, 1}, testSPS...)...),
+			[][]byte{annexB(testSPS), annexB(testPPS)}},
+		// 缺少 PPS 时不拆分
+		{"avc without pps", MIMETYPE_VIDEO_AVC, annexB(testSPS), [][]byte{annexB(testSPS)}},
+		{"hevc", MIMETYPE_VIDEO_HEVC, annexB(vps, hevcSPSNAL, hevcSEI, hevcPPSNAL),
+			[][]byte{annexB(vps, hevcSPSNAL, hevcPPSNAL)}},
+		{"aac", MIMETYPE_AUDIO_AAC, asc, [][]byte{asc}},
+		// 长度前缀格式原样保留
+		{"avcc", MIMETYPE_VIDEO_AVC, toAVCC(testCSD), [][]byte{toAVCC(testCSD)}},
+	}
+	for _, c := range cases {
+		config := append([]byte(nil), c.config...)
+		got := SplitCSD(c.mime, config)
+		if len(got) != len(c.want) {
+			t.Errorf("%s: %d buffers %x, want %x", c.name, len(got), got, c.want)
+			continue
+		}
+		for i := range got {
+			if !bytes.Equal(got[i], c.want[i]) {
+				t.Errorf("%s: csd-%d = %x, want %x", c.name, i, got[i], c.want[i])
+			}
+		}
+		// 返回的数据不引用 config
+		for i := range config {
+			config[i] = 0xff
+		}
+		for i := range got {
+			if !bytes.Equal(got[i], c.want[i]) {
+				t.Errorf("%s: csd-%d changed with config", c.name, i)
+			}
+		}
+	}
+}
+
+// TestWriterSplitCSD SplitCSD 的结果可以作为 Writer 的 CSD
+func TestWriterSplitCSD(t *testing.T) {
+	var out bytes.Buffer
+	m := NewWriter(&out)
+	if _, err := m.AddTrack(TrackFormat{MIME: MIMETYPE_VIDEO_AVC, Width: 64, Height: 48, CSD: SplitCSD(MIMETYPE_VIDEO_AVC, testCSD)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.WriteSample(0, videoFrame(0, true), 0, SAMPLE_KEY_FRAME); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	boxes, _ := walk(t, out.Bytes())
+	trak := FindBox(boxes, "moov/trak")
+	if trak == nil {
+		t.Fatal("missing trak")
+	}
+	avcC, err := ParseBoxes(sampleEntry(t, trak).Data[78:])
+	if err != nil || len(avcC) != 1 || avcC[0].Type != "avcC" {
+		t.Fatalf("avc1 children %v, %v", boxTypes(avcC), err)
+	}
+	// 一个 SPS、一个 PPS，不带起始码
+	want := append(append([]byte{0xe1, 0, byte(len(testSPS))}, testSPS...), append([]byte{1, 0, byte(len(testPPS))}, testPPS...)...)
+	if !bytes.HasSuffix(avcC[0].Data, want) {
+		t.Errorf("avcC % x, want suffix % x", avcC[0].Data, want)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package mp4 把 H.264/AAC 基本流写入 MP4。
+// 它不依赖 cgo，可以在桌面上运行和测试；
+// 设备上可以用 media.MP4Muxer (AMediaMuxer) 替换 Writer。
+package mp4
+
+import (
+	"errors"
+	"time"
+)
+
+// 常用的 MIME 类型，与 media.MIMETYPE_* 相同
+const (
+	MIMETYPE_VIDEO_AVC  = "video/avc"
+	MIMETYPE_VIDEO_HEVC = "video/hevc"
+	MIMETYPE_AUDIO_AAC  = "audio/mp4a-latm"
+)
+
+// WriteSample 的 flags，与 media.BUFFER_FLAG_* 的值相同，
+// 编码器输出的 flags 可以直接传入
+const (
+	SAMPLE_KEY_FRAME     = 1
+	SAMPLE_CODEC_CONFIG  = 2
+	SAMPLE_END_OF_STREAM = 4
+)
+
+var (
+	ErrStarted     = errors.New("mp4: muxer already started")
+	ErrNotStarted  = errors.New("mp4: muxer not started")
+	ErrStopped     = errors.New("mp4: muxer stopped")
+	ErrBadTrack    = errors.New("mp4: invalid track index")
+	ErrMissingCSD  = errors.New("mp4: missing codec specific data")
+	ErrUnsupported = errors.New("mp4: unsupported mime type")
+)
+
+// TrackFormat 轨道的格式，对应 AMediaFormat 中的同名字段
+type TrackFormat struct {
+	MIME string
+
+	// 视频
+	Width, Height int
+
+	// 音频
+	SampleRate   int
+	ChannelCount int
+
+	// CSD csd-0、csd-1 ...
+	// AVC 为 SPS 和 PPS (可以带起始码)，AAC 为 AudioSpecificConfig。
+	// 为空时使用 SAMPLE_CODEC_CONFIG 的样本
+	CSD [][]byte
+
+	Bitrate int
+}
+
+// IsVideo MIME 是否为 video/*
+func (f *TrackFormat) IsVideo() bool {
+	return len(f.MIME) > 6 && f.MIME[:6] == "video/"
+}
+
+// Muxer 把编码后的样本写入容器。
+// AddTrack 和 SetOrientationHint 须在 Start 之前调用
+type Muxer interface {
+	AddTrack(format TrackFormat) (int, error)
+	// SetOrientationHint 播放时顺时针旋转的角度，0/90/180/270
+	SetOrientationHint(degrees int) error
+	Start() error
+	// WriteSample 按解码顺序写入一个样本，flags 为 SAMPLE_* 的组合
+	WriteSample(track int, data []byte, pts time.Duration, flags uint32) error
+	Stop() error
+}
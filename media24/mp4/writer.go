@@ -0,0 +1,563 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mp4
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	writerNew = iota
+	writerStarted
+	writerStopped
+)
+
+// trun 中的 sample_flags
+const (
+	sampleFlagsSync    = 0x02000000 // sample_depends_on = 2
+	sampleFlagsNonSync = 0x01010000 // sample_depends_on = 1, sample_is_non_sync_sample
+)
+
+// Writer 以分片 MP4 (fMP4) 实现 Muxer。
+// 初始化段 (ftyp+moov) 在第一个媒体样本之前写入，之后每个片段为 moof+mdat。
+// 只需要 io.Writer，异常退出时已写入的片段仍可播放。
+//
+// 以第一个媒体样本的 pts 为起点，其它轨道中更早的样本被丢弃。
+// 每个轨道的 pts 须递增 (不支持 B 帧)
+type Writer struct {
+	// FragmentDuration 片段的最短时长，默认 1 秒。
+	// 有视频轨道时片段总是从视频的关键帧开始
+	FragmentDuration time.Duration
+
+	mu       sync.Mutex
+	w        io.Writer
+	state    int
+	err      error
+	tracks   []*track
+	rotation int
+	inited   bool
+	startPts time.Duration
+	seq      uint32
+	pending  []pendingSample // 等待 CSD 时缓存的样本
+}
+
+type pendingSample struct {
+	index int
+	data  []byte
+	pts   time.Duration
+	flags uint32
+}
+
+// maxPendingSamples 等待 CSD 时最多缓存的样本数
+const maxPendingSamples = 1024
+
+type track struct {
+	id        uint32
+	format    TrackFormat
+	timescale int64
+	samples   []sample // 尚未写入的样本
+	duration  int64    // 最后一个样本的时长，下一个样本到来之前用于估计
+}
+
+type sample struct {
+	data  []byte
+	ticks int64
+	flags uint32
+}
+
+// NewWriter 写入 w，Stop 时不关闭 w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, FragmentDuration: time.Second}
+}
+
+func (m *Writer) AddTrack(format TrackFormat) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state != writerNew {
+		return -1, ErrStarted
+	}
+	t := &track{id: uint32(len(m.tracks) + 1), format: format}
+	t.format.CSD = append([][]byte(nil), format.CSD...)
+	switch format.MIME {
+	case MIMETYPE_VIDEO_AVC:
+		t.timescale = 90000
+		t.duration = t.timescale / 30
+	case MIMETYPE_AUDIO_AAC:
+		if format.SampleRate <= 0 || format.ChannelCount <= 0 {
+			return -1, fmt.Errorf("mp4: invalid audio format %d Hz, %d channels", format.SampleRate, format.ChannelCount)
+		}
+		t.timescale = int64(format.SampleRate)
+		t.duration = 1024 // 一个 AAC 帧
+	default:
+		return -1, ErrUnsupported
+	}
+	m.tracks = append(m.tracks, t)
+	return len(m.tracks) - 1, nil
+}
+
+func (m *Writer) SetOrientationHint(degrees int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state != writerNew {
+		return ErrStarted
+	}
+	switch degrees {
+	case 0, 90, 180, 270:
+		m.rotation = degrees
+		return nil
+	}
+	return fmt.Errorf("mp4: invalid orientation %d", degrees)
+}
+
+func (m *Writer) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state != writerNew {
+		return ErrStarted
+	}
+	if len(m.tracks) == 0 {
+		return fmt.Errorf("mp4: no tracks")
+	}
+	m.state = writerStarted
+	return nil
+}
+
+// WriteSample data 被复制。
+// SAMPLE_CODEC_CONFIG 的样本在轨道没有 CSD 时作为 CSD，否则被忽略。
+// 初始化段在所有轨道都有 CSD 后写入，在此之前的样本被缓存
+func (m *Writer) WriteSample(index int, data []byte, pts time.Duration, flags uint32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case m.state == writerNew:
+		return ErrNotStarted
+	case m.state == writerStopped:
+		return ErrStopped
+	case m.err != nil:
+		return m.err
+	case index < 0 || index >= len(m.tracks):
+		return ErrBadTrack
+	}
+	t := m.tracks[index]
+	if flags&SAMPLE_CODEC_CONFIG != 0 {
+		if !m.inited && !t.hasCSD() {
+			t.format.CSD = [][]byte{append([]byte(nil), data...)}
+		}
+		return nil
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	if !m.inited {
+		if t := m.missingCSD(); t != nil {
+			if len(m.pending) >= maxPendingSamples {
+				return fmt.Errorf("%v: track %d (%s)", ErrMissingCSD, t.id, t.format.MIME)
+			}
+			m.pending = append(m.pending, pendingSample{index, append([]byte(nil), data...), pts, flags})
+			return nil
+		}
+		if err := m.init(pts); err != nil {
+			return err
+		}
+	}
+	return m.writeSample(t, data, pts, flags)
+}
+
+// init 写入初始化段和缓存的样本，pts 为没有缓存时的起点
+func (m *Writer) init(pts time.Duration) error {
+	if err := m.writeInit(); err != nil {
+		return err
+	}
+	pending := m.pending
+	m.pending = nil
+	m.startPts = pts
+	if len(pending) > 0 {
+		m.startPts = pending[0].pts
+	}
+	for _, s := range pending {
+		if err := m.writeSample(m.tracks[s.index], s.data, s.pts, s.flags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Writer) writeSample(t *track, data []byte, pts time.Duration, flags uint32) error {
+	if pts < m.startPts {
+		return nil
+	}
+	ticks := toTicks(pts-m.startPts, t.timescale)
+	if n := len(t.samples); n > 0 && ticks <= t.samples[n-1].ticks {
+		ticks = t.samples[n-1].ticks + 1
+	}
+
+	if t == m.lead() && (!t.format.IsVideo() || flags&SAMPLE_KEY_FRAME != 0) && len(t.samples) > 0 &&
+		ticks-t.samples[0].ticks >= toTicks(m.FragmentDuration, t.timescale) {
+		if err := m.flush(t, ticks); err != nil {
+			return err
+		}
+	}
+
+	if t.format.IsVideo() {
+		data = toAVCC(data)
+	}
+	t.samples = append(t.samples, sample{
+		data:  append([]byte(nil), data...),
+		ticks: ticks,
+		flags: flags,
+	})
+	return nil
+}
+
+// Stop 写入剩余的样本。没有写入任何样本时只写初始化段，
+// 有轨道始终没有 CSD 时返回 ErrMissingCSD
+func (m *Writer) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch m.state {
+	case writerNew:
+		return ErrNotStarted
+	case writerStopped:
+		return ErrStopped
+	}
+	m.state = writerStopped
+	if m.err != nil {
+		return m.err
+	}
+	if !m.inited {
+		if len(m.pending) == 0 || m.missingCSD() != nil {
+			return m.writeInit()
+		}
+		if err := m.init(0); err != nil {
+			return err
+		}
+	}
+	return m.flush(nil, 0)
+}
+
+// toTicks 以微秒计算，避免溢出
+func toTicks(d time.Duration, timescale int64) int64 {
+	return (int64(d/time.Microsecond)*timescale + 500000) / 1000000
+}
+
+// lead 决定分片位置的轨道，优先为视频轨道
+func (m *Writer) lead() *track {
+	for _, t := range m.tracks {
+		if t.format.IsVideo() {
+			return t
+		}
+	}
+	return m.tracks[0]
+}
+
+// missingCSD 第一个没有 CSD 的轨道
+func (m *Writer) missingCSD() *track {
+	for _, t := range m.tracks {
+		if !t.hasCSD() {
+			return t
+		}
+	}
+	return nil
+}
+
+func (t *track) hasCSD() bool {
+	switch t.format.MIME {
+	case MIMETYPE_VIDEO_AVC:
+		sps, pps := avcParameterSets(t.format.CSD)
+		return len(sps) > 0 && len(pps) > 0
+	case MIMETYPE_AUDIO_AAC:
+		return aacConfig(&t.format) != nil
+	}
+	return false
+}
+
+func (m *Writer) write(b []byte) error {
+	if _, err := m.w.Write(b); err != nil {
+		m.err = err
+		return err
+	}
+	return nil
+}
+
+func (m *Writer) writeInit() error {
+	if t := m.missingCSD(); t != nil {
+		return fmt.Errorf("%v: track %d (%s)", ErrMissingCSD, t.id, t.format.MIME)
+	}
+	var b boxBuffer
+	b.box("ftyp", func() {
+		b.WriteString("iso5")
+		b.u32(512)
+		b.WriteString("iso5iso6mp41")
+		if m.lead().format.IsVideo() {
+			b.WriteString("avc1")
+		}
+	})
+	b.box("moov", func() {
+		b.fullBox("mvhd", 0, 0, func() {
+			b.u32(0) // creation_time
+			b.u32(0) // modification_time
+			b.u32(1000)
+			b.u32(0) // duration
+			b.u32(0x00010000)
+			b.u16(0x0100)
+			b.zeros(10)
+			b.matrix(0)
+			b.zeros(24)
+			b.u32(uint32(len(m.tracks) + 1))
+		})
+		for _, t := range m.tracks {
+			m.writeTrak(&b, t)
+		}
+		b.box("mvex", func() {
+			for _, t := range m.tracks {
+				b.fullBox("trex", 0, 0, func() {
+					b.u32(t.id)
+					b.u32(1) // default_sample_description_index
+					b.u32(0)
+					b.u32(0)
+					b.u32(0)
+				})
+			}
+		})
+	})
+	if err := m.write(b.Bytes()); err != nil {
+		return err
+	}
+	m.inited = true
+	return nil
+}
+
+func (m *Writer) writeTrak(b *boxBuffer, t *track) {
+	video := t.format.IsVideo()
+	b.box("trak", func() {
+		b.fullBox("tkhd", 0, 3, func() {
+			b.u32(0)
+			b.u32(0)
+			b.u32(t.id)
+			b.u32(0)
+			b.u32(0) // duration
+			b.zeros(8)
+			b.u16(0) // layer
+			b.u16(0) // alternate_group
+			if video {
+				b.u16(0)
+				b.u16(0)
+				b.matrix(m.rotation)
+				b.u32(uint32(t.format.Width) << 16)
+				b.u32(uint32(t.format.Height) << 16)
+			} else {
+				b.u16(0x0100)
+				b.u16(0)
+				b.matrix(0)
+				b.u32(0)
+				b.u32(0)
+			}
+		})
+		b.box("mdia", func() {
+			b.fullBox("mdhd", 0, 0, func() {
+				b.u32(0)
+				b.u32(0)
+				b.u32(uint32(t.timescale))
+				b.u32(0)
+				b.u16(0x55c4) // "und"
+				b.u16(0)
+			})
+			b.fullBox("hdlr", 0, 0, func() {
+				b.u32(0)
+				if video {
+					b.WriteString("vide")
+				} else {
+					b.WriteString("soun")
+				}
+				b.zeros(12)
+				if video {
+					b.WriteString("VideoHandler\x00")
+				} else {
+					b.WriteString("SoundHandler\x00")
+				}
+			})
+			b.box("minf", func() {
+				if video {
+					b.fullBox("vmhd", 0, 1, func() { b.zeros(8) })
+				} else {
+					b.fullBox("smhd", 0, 0, func() { b.zeros(4) })
+				}
+				b.box("dinf", func() {
+					b.fullBox("dref", 0, 0, func() {
+						b.u32(1)
+						b.fullBox("url ", 0, 1, func() {})
+					})
+				})
+				b.box("stbl", func() {
+					b.fullBox("stsd", 0, 0, func() {
+						b.u32(1)
+						if video {
+							m.writeAVC1(b, t)
+						} else {
+							m.writeMP4A(b, t)
+						}
+					})
+					b.fullBox("stts", 0, 0, func() { b.u32(0) })
+					b.fullBox("stsc", 0, 0, func() { b.u32(0) })
+					b.fullBox("stsz", 0, 0, func() { b.u32(0); b.u32(0) })
+					b.fullBox("stco", 0, 0, func() { b.u32(0) })
+				})
+			})
+		})
+	})
+}
+
+func (m *Writer) writeAVC1(b *boxBuffer, t *track) {
+	sps, pps := avcParameterSets(t.format.CSD)
+	b.box("avc1", func() {
+		b.zeros(6)
+		b.u16(1) // data_reference_index
+		b.zeros(16)
+		b.u16(uint16(t.format.Width))
+		b.u16(uint16(t.format.Height))
+		b.u32(0x00480000) // 72 dpi
+		b.u32(0x00480000)
+		b.u32(0)
+		b.u16(1) // frame_count
+		b.zeros(32)
+		b.u16(0x0018)
+		b.u16(0xffff)
+		b.writeAVCC(sps, pps)
+	})
+}
+
+func (m *Writer) writeMP4A(b *boxBuffer, t *track) {
+	b.box("mp4a", func() {
+		b.zeros(6)
+		b.u16(1)
+		b.zeros(8)
+		b.u16(uint16(t.format.ChannelCount))
+		b.u16(16)
+		b.u32(0)
+		// samplerate 为 16.16 定点数，放不下时写 0，实际采样率在 mdhd 的 timescale 和 esds 中
+		if t.format.SampleRate <= 0xffff {
+			b.u32(uint32(t.format.SampleRate) << 16)
+		} else {
+			b.u32(0)
+		}
+		b.writeESDS(t.id, aacConfig(&t.format), t.format.Bitrate)
+	})
+}
+
+// matrix tkhd/mvhd 中的变换矩阵，顺时针旋转 degrees
+func (b *boxBuffer) matrix(degrees int) {
+	const one = 0x00010000
+	cos, sin := int32(1), int32(0)
+	switch degrees {
+	case 90:
+		cos, sin = 0, 1
+	case 180:
+		cos, sin = -1, 0
+	case 270:
+		cos, sin = 0, -1
+	}
+	for _, v := range []int32{cos * one, sin * one, 0, -sin * one, cos * one, 0, 0, 0, 0x40000000} {
+		b.u32(uint32(v))
+	}
+}
+
+// flush 写入一个片段。
+// lead 的样本全部写入，最后一个样本的时长由 next 决定；
+// 其它轨道的最后一个样本留到下一个片段，以便由后一个样本得到时长。
+// lead 为 nil 时写入所有样本
+func (m *Writer) flush(lead *track, next int64) error {
+	type run struct {
+		t         *track
+		samples   []sample
+		durations []int64
+	}
+	var runs []run
+	for _, t := range m.tracks {
+		n := len(t.samples)
+		if n == 0 {
+			continue
+		}
+		r := run{t: t}
+		for i := 0; i < n-1; i++ {
+			r.durations = append(r.durations, t.samples[i+1].ticks-t.samples[i].ticks)
+		}
+		switch {
+		case t == lead:
+			r.durations = append(r.durations, next-t.samples[n-1].ticks)
+		case lead == nil:
+			r.durations = append(r.durations, t.duration)
+		}
+		r.samples = t.samples[:len(r.durations)]
+		if len(r.samples) == 0 {
+			continue
+		}
+		t.duration = r.durations[len(r.durations)-1]
+		runs = append(runs, r)
+	}
+	if len(runs) == 0 {
+		return nil
+	}
+
+	m.seq++
+	var b boxBuffer
+	var offsets []int
+	b.box("moof", func() {
+		b.fullBox("mfhd", 0, 0, func() { b.u32(m.seq) })
+		for _, r := range runs {
+			b.box("traf", func() {
+				b.fullBox("tfhd", 0, 0x020000, func() { b.u32(r.t.id) }) // default-base-is-moof
+				b.fullBox("tfdt", 1, 0, func() { b.u64(uint64(r.samples[0].ticks)) })
+				// data-offset, sample-duration, sample-size, sample-flags
+				b.fullBox("trun", 0, 0x000701, func() {
+					b.u32(uint32(len(r.samples)))
+					offsets = append(offsets, b.Len())
+					b.u32(0)
+					for i, s := range r.samples {
+						b.u32(uint32(r.durations[i]))
+						b.u32(uint32(len(s.data)))
+						if !r.t.format.IsVideo() || s.flags&SAMPLE_KEY_FRAME != 0 {
+							b.u32(sampleFlagsSync)
+						} else {
+							b.u32(sampleFlagsNonSync)
+						}
+					}
+				})
+			})
+		}
+	})
+
+	// data_offset 相对于 moof 的开始
+	offset := b.Len() + 8
+	for i, r := range runs {
+		binary.BigEndian.PutUint32(b.Bytes()[offsets[i]:], uint32(offset))
+		for _, s := range r.samples {
+			offset += len(s.data)
+		}
+	}
+	b.u32(uint32(offset - b.Len()))
+	b.WriteString("mdat")
+	for _, r := range runs {
+		for _, s := range r.samples {
+			b.Write(s.data)
+		}
+	}
+	if err := m.write(b.Bytes()); err != nil {
+		return err
+	}
+
+	for _, r := range runs {
+		rest := copy(r.t.samples, r.t.samples[len(r.samples):])
+		for i := rest; i < len(r.t.samples); i++ {
+			r.t.samples[i] = sample{}
+		}
+		r.t.samples = r.t.samples[:rest]
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mp4
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testSPS = []byte{0x67, 0x42, 0xc0, 0x1e, 0xaa}
+	testPPS = []byte{0x68, 0xce, 0x38, 0x80}
+	// testCSD 编码器输出的 SAMPLE_CODEC_CONFIG，带起始码的 SPS 和 PPS
+	testCSD = append(append([]byte{0, 0, 0, 1}, testSPS...), append([]byte{0, 0, 0, 1}, testPPS...)...)
+	testASC = []byte{0x12, 0x10} // AAC-LC 44100 Hz 双声道
+)
+
+// videoFrame 带起始码的 IDR 或非 IDR 帧
+func videoFrame(i int, key bool) []byte {
+	nal := byte(0x41)
+	if key {
+		nal = 0x65
+	}
+	return []byte{0, 0, 0, 1, nal, byte(i), 0xab, 0xcd}
+}
+
+func audioFrame(i int) []byte {
+	return []byte{0x21, byte(i), 0x5a}
+}
+
+// parsedSample 从 moof/mdat 中取出的样本
+type parsedSample struct {
+	ticks int64
+	dur   uint32
+	data  []byte
+	sync  bool
+}
+
+// walk 检查 fMP4 的结构，按轨道 id 返回样本
+func walk(t *testing.T, data []byte) ([]Box, map[uint32][]parsedSample) {
+	t.Helper()
+	boxes, err := ParseBoxes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boxes) < 2 || boxes[0].Type != "ftyp" || boxes[1].Type != "moov" {
+		t.Fatalf("file starts with %v, want ftyp, moov", boxTypes(boxes))
+	}
+	samples := map[uint32][]parsedSample{}
+	seq := uint32(0)
+	for i := 2; i < len(boxes); i += 2 {
+		moof := &boxes[i]
+		if moof.Type != "moof" || i+1 >= len(boxes) || boxes[i+1].Type != "mdat" {
+			t.Fatalf("fragment %v, want moof, mdat", boxTypes(boxes[i:]))
+		}
+		mdat := &boxes[i+1]
+		mfhd := moof.Find("mfhd")
+		if mfhd == nil {
+			t.Fatal("missing moof/mfhd")
+		}
+		if n := binary.BigEndian.Uint32(mfhd.Data[4:]); n != seq+1 {
+			t.Errorf("sequence_number = %d, want %d", n, seq+1)
+		}
+		seq++
+		for _, traf := range moof.Children {
+			if traf.Type != "traf" {
+				continue
+			}
+			tfhd, tfdt, trun := traf.Find("tfhd"), traf.Find("tfdt"), traf.Find("trun")
+			if tfhd == nil || tfdt == nil || trun == nil {
+				t.Fatalf("traf has %v, want tfhd, tfdt, trun", boxTypes(traf.Children))
+			}
+			id := binary.BigEndian.Uint32(tfhd.Data[4:])
+			ticks := int64(binary.BigEndian.Uint64(tfdt.Data[4:]))
+			count := int(binary.BigEndian.Uint32(trun.Data[4:]))
+			pos := moof.Offset + int64(binary.BigEndian.Uint32(trun.Data[8:]))
+			mdatStart, mdatEnd := mdat.Offset+8, mdat.Offset+mdat.Size
+			entries := trun.Data[12:]
+			if len(entries) != 12*count {
+				t.Fatalf("trun has %d bytes of entries for %d samples", len(entries), count)
+			}
+			for j := 0; j < count; j++ {
+				e := entries[12*j:]
+				dur := binary.BigEndian.Uint32(e)
+				size := int64(binary.BigEndian.Uint32(e[4:]))
+				if pos < mdatStart || pos+size > mdatEnd {
+					t.Fatalf("track %d sample %d at %d+%d outside mdat [%d, %d)", id, j, pos, size, mdatStart, mdatEnd)
+				}
+				samples[id] = append(samples[id], parsedSample{
+					ticks: ticks,
+					dur:   dur,
+					data:  data[pos : pos+size],
+					sync:  binary.BigEndian.Uint32(e[8:]) == sampleFlagsSync,
+				})
+				pos += size
+				ticks += int64(dur)
+			}
+		}
+	}
+	return boxes, samples
+}
+
+func boxTypes(boxes []Box) []string {
+	var types []string
+	for _, b := range boxes {
+		types = append(types, b.Type)
+	}
+	return types
+}
+
+// sampleEntry stsd 中的第一个样本描述
+func sampleEntry(t *testing.T, trak *Box) *Box {
+	t.Helper()
+	stsd := trak.Find("mdia/minf/stbl/stsd")
+	if stsd == nil {
+		t.Fatal("missing stsd")
+	}
+	entries, err := ParseBoxes(stsd.Data[8:])
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("stsd entries %v, %v", boxTypes(entries), err)
+	}
+	return &entries[0]
+}
+
+func TestWriterAVCAndAAC(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out)
+	v, err := w.AddTrack(TrackFormat{MIME: MIMETYPE_VIDEO_AVC, Width: 640, Height: 480})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := w.AddTrack(TrackFormat{MIME: MIMETYPE_AUDIO_AAC, SampleRate: 44100, ChannelCount: 2,
+		CSD: [][]byte{testASC}, Bitrate: 128000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.SetOrientationHint(90); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.WriteSample(v, testCSD, 0, SAMPLE_CODEC_CONFIG); err != nil {
+		t.Fatal(err)
+	}
+	base := 5 * time.Second
+	const frames = 90
+	for i := 0; i < frames; i++ {
+		pts := base + time.Duration(i)*time.Second/30
+		flags := uint32(0)
+		if i%30 == 0 {
+			flags = SAMPLE_KEY_FRAME
+		}
+		if err = w.WriteSample(v, videoFrame(i, i%30 == 0), pts, flags); err != nil {
+			t.Fatal(err)
+		}
+		if i%2 == 0 {
+			if err = w.WriteSample(a, audioFrame(i), pts, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err = w.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Stop(); err != ErrStopped {
+		t.Errorf("second Stop = %v, want ErrStopped", err)
+	}
+
+	boxes, samples := walk(t, out.Bytes())
+	if ftyp := boxes[0].Data; !bytes.Contains(ftyp, []byte("avc1")) {
+		t.Errorf("ftyp %q has no avc1 brand", ftyp)
+	}
+	moov := &boxes[1]
+	for _, p := range []string{"mvhd", "trak", "mvex/trex"} {
+		if moov.Find(p) == nil {
+			t.Errorf("missing moov/%s", p)
+		}
+	}
+	var traks []*Box
+	for i := range moov.Children {
+		if moov.Children[i].Type == "trak" {
+			traks = append(traks, &moov.Children[i])
+		}
+	}
+	if len(traks) != 2 {
+		t.Fatalf("%d traks, want 2", len(traks))
+	}
+
+	// 视频: avc1/avcC 中为不带起始码的 SPS、PPS
+	avc1 := sampleEntry(t, traks[0])
+	if avc1.Type != "avc1" {
+		t.Fatalf("video sample entry %q, want avc1", avc1.Type)
+	}
+	if w, h := binary.BigEndian.Uint16(avc1.Data[24:]), binary.BigEndian.Uint16(avc1.Data[26:]); w != 640 || h != 480 {
+		t.Errorf("avc1 size %dx%d, want 640x480", w, h)
+	}
+	avcC, err := ParseBoxes(avc1.Data[78:])
+	if err != nil || len(avcC) != 1 || avcC[0].Type != "avcC" {
+		t.Fatalf("avc1 children %v, %v", boxTypes(avcC), err)
+	}
+	if !bytes.Contains(avcC[0].Data, testSPS) || !bytes.Contains(avcC[0].Data, testPPS) {
+		t.Errorf("avcC % x does not contain SPS and PPS", avcC[0].Data)
+	}
+	if mdhd := traks[0].Find("mdia/mdhd"); binary.BigEndian.Uint32(mdhd.Data[12:]) != 90000 {
+		t.Errorf("video timescale %d, want 90000", binary.BigEndian.Uint32(mdhd.Data[12:]))
+	}
+
+	// 音频: mp4a/esds 中为 AudioSpecificConfig
+	mp4a := sampleEntry(t, traks[1])
+	if mp4a.Type != "mp4a" {
+		t.Fatalf("audio sample entry %q, want mp4a", mp4a.Type)
+	}
+	if ch, rate := binary.BigEndian.Uint16(mp4a.Data[16:]), binary.BigEndian.Uint32(mp4a.Data[24:]); ch != 2 || rate != 44100<<16 {
+		t.Errorf("mp4a channels %d, samplerate %#x", ch, rate)
+	}
+	esds, err := ParseBoxes(mp4a.Data[28:])
+	if err != nil || len(esds) != 1 || esds[0].Type != "esds" {
+		t.Fatalf("mp4a children %v, %v", boxTypes(esds), err)
+	}
+	if !bytes.Contains(esds[0].Data, append([]byte{0x05, 0x80, 0x80, 0x80, 0x02}, testASC...)) {
+		t.Errorf("esds % x does not contain the AudioSpecificConfig", esds[0].Data)
+	}
+
+	// 样本: 视频转为 AVCC，时间从第一个样本开始，片段从关键帧开始
+	video, audio := samples[1], samples[2]
+	if len(video) != frames || len(audio) != frames/2 {
+		t.Fatalf("%d video and %d audio samples, want %d and %d", len(video), len(audio), frames, frames/2)
+	}
+	for i, s := range video {
+		want := append([]byte{0, 0, 0, 4}, videoFrame(i, i%30 == 0)[4:]...)
+		if !bytes.Equal(s.data, want) {
+			t.Fatalf("video sample %d = % x, want % x", i, s.data, want)
+		}
+		if wantTicks := int64(i) * 3000; s.ticks != wantTicks {
+			t.Errorf("video sample %d at %d ticks, want %d", i, s.ticks, wantTicks)
+		}
+		if s.sync != (i%30 == 0) {
+			t.Errorf("video sample %d sync = %v", i, s.sync)
+		}
+	}
+	for i, s := range audio {
+		if !bytes.Equal(s.data, audioFrame(2*i)) {
+			t.Fatalf("audio sample %d = % x", i, s.data)
+		}
+	}
+	for i := 2; i < len(boxes); i += 2 {
+		traf := boxes[i].Find("traf")
+		if id := binary.BigEndian.Uint32(traf.Find("tfhd").Data[4:]); id != 1 {
+			continue
+		}
+		trun := traf.Find("trun")
+		if flags := binary.BigEndian.Uint32(trun.Data[20:]); flags != sampleFlagsSync {
+			t.Errorf("fragment %d does not start with a key frame", i/2)
+		}
+	}
+	if fragments := (len(boxes) - 2) / 2; fragments != 3 {
+		t.Errorf("%d fragments, want 3", fragments)
+	}
+}
+
+func TestWriterWaitsForCSD(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out)
+	v, _ := w.AddTrack(TrackFormat{MIME: MIMETYPE_VIDEO_AVC, Width: 320, Height: 240})
+	a, _ := w.AddTrack(TrackFormat{MIME: MIMETYPE_AUDIO_AAC, SampleRate: 48000, ChannelCount: 1})
+	w.Start()
+
+	// 视频的 CSD 到达之前的音频样本被缓存，不写入任何数据
+	for i := 0; i < 3; i++ {
+		if err := w.WriteSample(a, audioFrame(i), time.Duration(i)*20*time.Millisecond, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out.Len() != 0 {
+		t.Fatalf("wrote %d bytes before all tracks have CSD", out.Len())
+	}
+	w.WriteSample(v, testCSD, 0, SAMPLE_CODEC_CONFIG)
+	if err := w.WriteSample(v, videoFrame(0, true), 60*time.Millisecond, SAMPLE_KEY_FRAME); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, samples := walk(t, out.Bytes())
+	if len(samples[2]) != 3 || len(samples[1]) != 1 {
+		t.Fatalf("%d audio and %d video samples, want 3 and 1", len(samples[2]), len(samples[1]))
+	}
+	if samples[2][0].ticks != 0 {
+		t.Errorf("first audio sample at %d, want 0", samples[2][0].ticks)
+	}
+	// 起点为第一个缓存的样本，视频在 60ms
+	if samples[1][0].ticks != 5400 {
+		t.Errorf("video sample at %d ticks, want 5400", samples[1][0].ticks)
+	}
+}
+
+func TestWriterMissingCSD(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out)
+	v, _ := w.AddTrack(TrackFormat{MIME: MIMETYPE_VIDEO_AVC, Width: 320, Height: 240})
+	w.Start()
+	for i := 0; i < maxPendingSamples; i++ {
+		if err := w.WriteSample(v, videoFrame(i, true), time.Duration(i)*time.Millisecond, SAMPLE_KEY_FRAME); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteSample(v, videoFrame(0, true), time.Second, SAMPLE_KEY_FRAME); err == nil || !strings.HasPrefix(err.Error(), ErrMissingCSD.Error()) {
+		t.Errorf("WriteSample after %d pending samples = %v, want ErrMissingCSD", maxPendingSamples, err)
+	}
+	if err := w.Stop(); err == nil {
+		t.Error("Stop without CSD succeeded")
+	}
+	if out.Len() != 0 {
+		t.Errorf("wrote %d bytes without CSD", out.Len())
+	}
+}
+
+func TestWriterHighSampleRate(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out)
+	w.AddTrack(TrackFormat{MIME: MIMETYPE_AUDIO_AAC, SampleRate: 96000, ChannelCount: 2})
+	w.Start()
+	if err := w.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	boxes, _ := walk(t, out.Bytes())
+	mp4a := sampleEntry(t, boxes[1].Find("trak"))
+	// 96000 放不下 16.16 的 samplerate
+	if rate := binary.BigEndian.Uint32(mp4a.Data[24:]); rate != 0 {
+		t.Errorf("mp4a samplerate %#x, want 0", rate)
+	}
+	if mdhd := boxes[1].Find("trak/mdia/mdhd"); binary.BigEndian.Uint32(mdhd.Data[12:]) != 96000 {
+		t.Errorf("audio timescale %d, want 96000", binary.BigEndian.Uint32(mdhd.Data[12:]))
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package media
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gooid/gooid/media24/mp4"
+)
+
+// MP4Muxer 以 AMediaMuxer 实现 mp4.Muxer，
+// 与 mp4.Writer 可以互换使用。
+// AMediaMuxer 的轨道在所有轨道都有 CSD 之后才加入并启动，
+// 在此之前的样本被缓存，SAMPLE_CODEC_CONFIG 的样本只用作 CSD，
+// 按 SplitCSD 分为 csd-0、csd-1
+type MP4Muxer struct {
+	mu      sync.Mutex
+	muxer   *Muxer
+	file    *os.File // 防止 file 被回收时关闭 fd
+	formats []mp4.TrackFormat
+	tracks  []int // AMediaMuxer 中的轨道序号
+	started bool  // 已调用 Start
+	pending []pendingSample
+}
+
+type pendingSample struct {
+	track int
+	data  []byte
+	pts   time.Duration
+	flags uint32
+}
+
+// maxPendingSamples 等待 CSD 时最多缓存的样本数
+const maxPendingSamples = 1024
+
+var _ mp4.Muxer = (*MP4Muxer)(nil)
+
+// NewMP4Muxer file 须以读写方式打开，Stop 之后由调用者关闭
+func NewMP4Muxer(file *os.File) (*MP4Muxer, error) {
+	muxer, err := NewMuxer(int(file.Fd()), OUTPUT_FORMAT_MPEG_4)
+	if err != nil {
+		return nil, err
+	}
+	return &MP4Muxer{muxer: muxer, file: file}, nil
+}
+
+func (m *MP4Muxer) AddTrack(f mp4.TrackFormat) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.muxer == nil {
+		return -1, mp4.ErrStopped
+	}
+	if m.started {
+		return -1, mp4.ErrStarted
+	}
+	f.CSD = append([][]byte(nil), f.CSD...)
+	m.formats = append(m.formats, f)
+	return len(m.formats) - 1, nil
+}
+
+func (m *MP4Muxer) SetOrientationHint(degrees int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.muxer == nil {
+		return mp4.ErrStopped
+	}
+	return m.muxer.SetOrientationHint(degrees)
+}
+
+// Start 所有轨道都有 CSD 时立即启动 AMediaMuxer
+func (m *MP4Muxer) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.muxer == nil {
+		return mp4.ErrStopped
+	}
+	if m.started {
+		return mp4.ErrStarted
+	}
+	if len(m.formats) == 0 {
+		return fmt.Errorf("mp4: no tracks")
+	}
+	m.started = true
+	if m.ready() {
+		return m.startMuxer()
+	}
+	return nil
+}
+
+// WriteSample 空的样本 (如只带 END_OF_STREAM 的 buffer) 被忽略
+func (m *MP4Muxer) WriteSample(track int, data []byte, pts time.Duration, flags uint32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case m.muxer == nil:
+		return mp4.ErrStopped
+	case !m.started:
+		return mp4.ErrNotStarted
+	case track < 0 || track >= len(m.formats):
+		return mp4.ErrBadTrack
+	}
+	if flags&mp4.SAMPLE_CODEC_CONFIG != 0 {
+		if m.tracks == nil && len(m.formats[track].CSD) == 0 {
+			m.formats[track].CSD = mp4.SplitCSD(m.formats[track].MIME, data)
+		}
+		return nil
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if m.tracks == nil {
+		if !m.ready() {
+			if len(m.pending) >= maxPendingSamples {
+				return m.missingCSD()
+			}
+			m.pending = append(m.pending, pendingSample{track, append([]byte(nil), data...), pts, flags})
+			return nil
+		}
+		if err := m.startMuxer(); err != nil {
+			return err
+		}
+	}
+	return m.writeSample(track, data, pts, flags)
+}
+
+func (m *MP4Muxer) writeSample(track int, data []byte, pts time.Duration, flags uint32) error {
+	info := CodecBufferInfo{Size: len(data), PresentationTime: pts, Flags: flags}
+	return m.muxer.WriteSampleData(m.tracks[track], data, &info)
+}
+
+// ready 所有轨道是否都有 CSD
+func (m *MP4Muxer) ready() bool {
+	for i := range m.formats {
+		if len(m.formats[i].CSD) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *MP4Muxer) missingCSD() error {
+	for i := range m.formats {
+		if len(m.formats[i].CSD) == 0 {
+			return fmt.Errorf("%v: track %d (%s)", mp4.ErrMissingCSD, i, m.formats[i].MIME)
+		}
+	}
+	return mp4.ErrMissingCSD
+}
+
+// startMuxer 加入轨道，启动 AMediaMuxer 并写入缓存的样本
+func (m *MP4Muxer) startMuxer() error {
+	tracks := make([]int, len(m.formats))
+	for i, f := range m.formats {
+		format := NewTrackFormat(f)
+		idx, err := m.muxer.AddTrack(format)
+		format.Delete()
+		if err != nil {
+			return err
+		}
+		tracks[i] = idx
+	}
+	if err := m.muxer.Start(); err != nil {
+		return err
+	}
+	m.tracks = tracks
+	pending := m.pending
+	m.pending = nil
+	for _, s := range pending {
+		if err := m.writeSample(s.track, s.data, s.pts, s.flags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop 完成文件并释放 AMediaMuxer。
+// 有轨道始终没有 CSD 时返回 ErrMissingCSD
+func (m *MP4Muxer) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.muxer == nil {
+		return mp4.ErrStopped
+	}
+	var err error
+	switch {
+	case !m.started:
+		err = mp4.ErrNotStarted
+	case m.tracks == nil && !m.ready():
+		err = m.missingCSD()
+	case m.tracks == nil:
+		err = m.startMuxer()
+	}
+	if m.tracks != nil {
+		if e := m.muxer.Stop(); err == nil {
+			err = e
+		}
+	}
+	m.muxer.Delete()
+	m.muxer = nil
+	m.pending = nil
+	return err
+}
+
+// NewTrackFormat 由 mp4.TrackFormat 构造 AMediaFormat，用完须 Delete
+func NewTrackFormat(f mp4.TrackFormat) *Format {
+	format := NewFormat()
+	format.SetString(KEY_MIME, f.MIME)
+	if f.Width > 0 && f.Height > 0 {
+		format.SetInt32(KEY_WIDTH, int32(f.Width))
+		format.SetInt32(KEY_HEIGHT, int32(f.Height))
+	}
+	if f.SampleRate > 0 {
+		format.SetInt32(KEY_SAMPLE_RATE, int32(f.SampleRate))
+		format.SetInt32(KEY_CHANNEL_COUNT, int32(f.ChannelCount))
+	}
+	if f.Bitrate > 0 {
+		format.SetInt32(KEY_BIT_RATE, int32(f.Bitrate))
+	}
+	for i, csd := range f.CSD {
+		if i < len(csdKeys) {
+			format.SetBuffer(csdKeys[i], csd)
+		}
+	}
+	return format
+}
+
+var csdKeys = []string{KEY_CSD_0, KEY_CSD_1, KEY_CSD_2}
+
+// TrackFormatOf 从 AMediaFormat (如编码器 INFO_OUTPUT_FORMAT_CHANGED 后的输出格式)
+// 取得 mp4.TrackFormat
+func TrackFormatOf(format *Format) mp4.TrackFormat {
+	var f mp4.TrackFormat
+	f.MIME, _ = format.GetString(KEY_MIME)
+	if v, ok := format.GetInt32(KEY_WIDTH); ok {
+		f.Width = int(v)
+	}
+	if v, ok := format.GetInt32(KEY_HEIGHT); ok {
+		f.Height = int(v)
+	}
+	if v, ok := format.GetInt32(KEY_SAMPLE_RATE); ok {
+		f.SampleRate = int(v)
+	}
+	if v, ok := format.GetInt32(KEY_CHANNEL_COUNT); ok {
+		f.ChannelCount = int(v)
+	}
+	if v, ok := format.GetInt32(KEY_BIT_RATE); ok {
+		f.Bitrate = int(v)
+	}
+	for _, key := range csdKeys {
+		csd, ok := format.GetBuffer(key)
+		if !ok {
+			break
+		}
+		f.CSD = append(f.CSD, csd)
+	}
+	return f
+}
@@ -0,0 +1,276 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package media
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// YUVPlane YUV_420_888 的一个平面，Data 直接引用图像的内存
+type YUVPlane struct {
+	Data        []byte
+	RowStride   int
+	PixelStride int
+}
+
+func (p *YUVPlane) at(x, y int) byte {
+	return p.Data[y*p.RowStride+x*p.PixelStride]
+}
+
+// Transform 转换时的旋转(顺时针，0/90/180/270)和旋转后的水平镜像
+type Transform struct {
+	Rotation int
+	Mirror   bool
+}
+
+func (t Transform) rotation() int {
+	return ((t.Rotation+45)/90*90%360 + 360) % 360
+}
+
+// YUVImage YUV_420_888 图像的视图，不复制数据，
+// 只能在对应的 Image.Delete 之前使用。
+// 它实现了 image.Image，Bounds 为裁剪区域。
+type YUVImage struct {
+	Y, U, V YUVPlane
+	Rect    image.Rectangle
+}
+
+// YUV 以 i 的平面和裁剪区域构造 YUVImage，i 的格式须为 FORMAT_YUV_420_888
+func (i *Image) YUV() (*YUVImage, error) {
+	format, err := i.GetFormat()
+	if err != nil {
+		return nil, err
+	}
+	if format != FORMAT_YUV_420_888 {
+		return nil, fmt.Errorf("media: image format is %v, want FORMAT_YUV_420_888", format)
+	}
+	crop, err := i.GetCropRect()
+	if err != nil {
+		return nil, err
+	}
+	var planes [3]YUVPlane
+	for n := range planes {
+		p := &planes[n]
+		if p.Data, err = i.GetPlaneData(n); err != nil {
+			return nil, err
+		}
+		if p.RowStride, err = i.GetPlaneRowStride(n); err != nil {
+			return nil, err
+		}
+		if p.PixelStride, err = i.GetPlanePixelStride(n); err != nil {
+			return nil, err
+		}
+	}
+	return NewYUVImage(crop.Rectangle(), planes[0], planes[1], planes[2])
+}
+
+// NewYUVImage rect 为 Y 平面中的有效区域，检查各平面的数据是否足够
+func NewYUVImage(rect image.Rectangle, y, u, v YUVPlane) (*YUVImage, error) {
+	if rect.Empty() || rect.Min.X < 0 || rect.Min.Y < 0 {
+		return nil, fmt.Errorf("media: invalid yuv rect %v", rect)
+	}
+	m := &YUVImage{Y: y, U: u, V: v, Rect: rect}
+	if err := checkPlane("Y", &m.Y, rect); err != nil {
+		return nil, err
+	}
+	c := m.chromaRect()
+	if err := checkPlane("U", &m.U, c); err != nil {
+		return nil, err
+	}
+	if err := checkPlane("V", &m.V, c); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// checkPlane 最后一行可以没有行尾的填充
+func checkPlane(name string, p *YUVPlane, r image.Rectangle) error {
+	if p.PixelStride < 1 || p.RowStride < (r.Max.X-1)*p.PixelStride+1 {
+		return fmt.Errorf("media: invalid %s plane stride %d/%d", name, p.RowStride, p.PixelStride)
+	}
+	if last := (r.Max.Y-1)*p.RowStride + (r.Max.X-1)*p.PixelStride; last >= len(p.Data) {
+		return fmt.Errorf("media: %s plane has %d bytes, need %d", name, len(p.Data), last+1)
+	}
+	return nil
+}
+
+// chromaRect U、V 平面中对应的区域，裁剪区域的起点为奇数时也包含首尾像素的色度
+func (m *YUVImage) chromaRect() image.Rectangle {
+	r := m.Rect
+	return image.Rect(r.Min.X/2, r.Min.Y/2, (r.Max.X+1)/2, (r.Max.Y+1)/2)
+}
+
+func (m *YUVImage) ColorModel() color.Model {
+	return color.YCbCrModel
+}
+
+func (m *YUVImage) Bounds() image.Rectangle {
+	return m.Rect
+}
+
+func (m *YUVImage) At(x, y int) color.Color {
+	if !image.Pt(x, y).In(m.Rect) {
+		return color.YCbCr{}
+	}
+	return color.YCbCr{Y: m.Y.at(x, y), Cb: m.U.at(x/2, y/2), Cr: m.V.at(x/2, y/2)}
+}
+
+// Size 经 t 变换后的宽高
+func (m *YUVImage) Size(t Transform) (w, h int) {
+	w, h = m.Rect.Dx(), m.Rect.Dy()
+	if r := t.rotation(); r == 90 || r == 270 {
+		w, h = h, w
+	}
+	return
+}
+
+// srcPoint 输出坐标 (x, y) 对应的源坐标 (相对于裁剪区域)，w、h 为源的宽高
+func (t Transform) srcPoint(x, y, w, h int) (int, int) {
+	r := t.rotation()
+	if t.Mirror {
+		if r == 90 || r == 270 {
+			x = h - 1 - x
+		} else {
+			x = w - 1 - x
+		}
+	}
+	switch r {
+	case 90:
+		return y, h - 1 - x
+	case 180:
+		return w - 1 - x, h - 1 - y
+	case 270:
+		return w - 1 - y, x
+	}
+	return x, y
+}
+
+// planeWalker 按输出坐标遍历平面: 偏移 = cols[x] + rows[y]
+type planeWalker struct {
+	data       []byte
+	cols, rows []int
+	w, h       int // 输出的宽高
+}
+
+// newPlaneWalker r 为 Y 平面中的区域，输出每隔 step 个像素取一个样本，
+// 样本在平面中的坐标为其 Y 平面绝对坐标除以 sub。
+// 色度按绝对坐标计算，裁剪区域的起点为奇数时也不会错位
+func newPlaneWalker(p *YUVPlane, r image.Rectangle, t Transform, step, sub int) planeWalker {
+	w, h := r.Dx(), r.Dy()
+	ow, oh := w, h
+	if rot := t.rotation(); rot == 90 || rot == 270 {
+		ow, oh = h, w
+	}
+	offset := func(x, y int) int {
+		sx, sy := t.srcPoint(x, y, w, h)
+		return (r.Min.Y+sy)/sub*p.RowStride + (r.Min.X+sx)/sub*p.PixelStride
+	}
+	// 源的 x、y 各只随输出的一个坐标变化，偏移可以按行列分开
+	pw := planeWalker{data: p.Data, w: (ow + step - 1) / step, h: (oh + step - 1) / step}
+	pw.cols = make([]int, pw.w)
+	pw.rows = make([]int, pw.h)
+	base := offset(0, 0)
+	for x := range pw.cols {
+		pw.cols[x] = offset(x*step, 0)
+	}
+	for y := range pw.rows {
+		pw.rows[y] = offset(0, y*step) - base
+	}
+	return pw
+}
+
+// copyTo 把平面复制到 dst，dst 每行间隔 dstStride，每个像素间隔 dstStep
+func (pw *planeWalker) copyTo(dst []byte, dstStride, dstStep int) {
+	// step 与 sub 相同时列偏移等距，首尾相差 w-1 即为连续
+	contiguous := dstStep == 1 && pw.w > 0 && pw.cols[pw.w-1]-pw.cols[0] == pw.w-1
+	for y, row := range pw.rows {
+		d := y * dstStride
+		if contiguous {
+			src := row + pw.cols[0]
+			copy(dst[d:d+pw.w], pw.data[src:src+pw.w])
+			continue
+		}
+		for _, c := range pw.cols {
+			dst[d] = pw.data[row+c]
+			d += dstStep
+		}
+	}
+}
+
+func (m *YUVImage) walkers(t Transform) (y, u, v planeWalker) {
+	return newPlaneWalker(&m.Y, m.Rect, t, 1, 1), newPlaneWalker(&m.U, m.Rect, t, 2, 2), newPlaneWalker(&m.V, m.Rect, t, 2, 2)
+}
+
+// ToYCbCr 转为 4:2:0 的 image.YCbCr，dst 大小合适时复用
+func (m *YUVImage) ToYCbCr(dst *image.YCbCr, t Transform) *image.YCbCr {
+	w, h := m.Size(t)
+	r := image.Rect(0, 0, w, h)
+	if dst == nil || dst.Rect != r || dst.SubsampleRatio != image.YCbCrSubsampleRatio420 {
+		dst = image.NewYCbCr(r, image.YCbCrSubsampleRatio420)
+	}
+	y, u, v := m.walkers(t)
+	y.copyTo(dst.Y, dst.YStride, 1)
+	u.copyTo(dst.Cb, dst.CStride, 1)
+	v.copyTo(dst.Cr, dst.CStride, 1)
+	return dst
+}
+
+// I420 Y 平面之后依次为 U、V 平面，dst 容量足够时复用
+func (m *YUVImage) I420(dst []byte, t Transform) []byte {
+	y, u, v := m.walkers(t)
+	ySize, cSize := y.w*y.h, u.w*u.h
+	dst = grow(dst, ySize+2*cSize)
+	y.copyTo(dst, y.w, 1)
+	u.copyTo(dst[ySize:], u.w, 1)
+	v.copyTo(dst[ySize+cSize:], u.w, 1)
+	return dst
+}
+
+// NV21 Y 平面之后为 VU 交错的平面，dst 容量足够时复用
+func (m *YUVImage) NV21(dst []byte, t Transform) []byte {
+	y, u, v := m.walkers(t)
+	ySize := y.w * y.h
+	dst = grow(dst, ySize+2*u.w*u.h)
+	y.copyTo(dst, y.w, 1)
+	v.copyTo(dst[ySize:], 2*u.w, 2)
+	u.copyTo(dst[ySize+1:], 2*u.w, 2)
+	return dst
+}
+
+// ToRGBA 转为 image.RGBA，dst 大小合适时复用。
+// 每个像素使用其源坐标对应的色度
+func (m *YUVImage) ToRGBA(dst *image.RGBA, t Transform) *image.RGBA {
+	y := newPlaneWalker(&m.Y, m.Rect, t, 1, 1)
+	u := newPlaneWalker(&m.U, m.Rect, t, 1, 2)
+	v := newPlaneWalker(&m.V, m.Rect, t, 1, 2)
+	r := image.Rect(0, 0, y.w, y.h)
+	if dst == nil || dst.Rect != r {
+		dst = image.NewRGBA(r)
+	}
+	for dy := 0; dy < y.h; dy++ {
+		yr, ur, vr := y.rows[dy], u.rows[dy], v.rows[dy]
+		d := dy * dst.Stride
+		for dx := 0; dx < y.w; dx++ {
+			cb := u.data[ur+u.cols[dx]]
+			cr := v.data[vr+v.cols[dx]]
+			R, G, B := color.YCbCrToRGB(y.data[yr+y.cols[dx]], cb, cr)
+			dst.Pix[d+0] = R
+			dst.Pix[d+1] = G
+			dst.Pix[d+2] = B
+			dst.Pix[d+3] = 0xff
+			d += 4
+		}
+	}
+	return dst
+}
+
+func grow(b []byte, n int) []byte {
+	if cap(b) < n {
+		return make([]byte, n)
+	}
+	return b[:n]
+}
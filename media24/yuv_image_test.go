@@ -0,0 +1,281 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package media
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// 测试图像的大小和各平面的值，值由绝对坐标决定
+const testW, testH = 10, 8
+
+func lumaAt(x, y int) byte           { return byte(16 + x + 20*y) }
+func cbAt(cx, cy int) byte           { return byte(60 + cx + 10*cy) }
+func crAt(cx, cy int) byte           { return byte(160 + 3*cx + 11*cy) }
+func chromaAt(x, y int) (byte, byte) { return cbAt(x/2, y/2), crAt(x/2, y/2) }
+
+// testLayout 生成 testW x testH 的 YUV_420_888 平面，行尾有填充
+type testLayout struct {
+	name   string
+	planes func() (y, u, v YUVPlane)
+}
+
+var testLayouts = []testLayout{
+	{"I420", func() (y, u, v YUVPlane) {
+		y = YUVPlane{Data: make([]byte, 16*testH), RowStride: 16, PixelStride: 1}
+		u = YUVPlane{Data: make([]byte, 8*testH/2), RowStride: 8, PixelStride: 1}
+		v = YUVPlane{Data: make([]byte, 8*testH/2), RowStride: 8, PixelStride: 1}
+		fill(&y, &u, &v)
+		return
+	}},
+	{"NV21", func() (y, u, v YUVPlane) {
+		y = YUVPlane{Data: make([]byte, 12*testH), RowStride: 12, PixelStride: 1}
+		vu := make([]byte, 16*testH/2)
+		v = YUVPlane{Data: vu, RowStride: 16, PixelStride: 2}
+		u = YUVPlane{Data: vu[1:], RowStride: 16, PixelStride: 2}
+		fill(&y, &u, &v)
+		return
+	}},
+}
+
+func fill(y, u, v *YUVPlane) {
+	for i := range y.Data {
+		y.Data[i] = 0xee // 填充区域，不应被读到
+	}
+	for j := 0; j < testH; j++ {
+		for i := 0; i < testW; i++ {
+			y.Data[j*y.RowStride+i*y.PixelStride] = lumaAt(i, j)
+		}
+	}
+	for j := 0; j < testH/2; j++ {
+		for i := 0; i < testW/2; i++ {
+			u.Data[j*u.RowStride+i*u.PixelStride] = cbAt(i, j)
+			v.Data[j*v.RowStride+i*v.PixelStride] = crAt(i, j)
+		}
+	}
+}
+
+var testRects = []image.Rectangle{
+	image.Rect(0, 0, testW, testH),
+	image.Rect(2, 2, 8, 6),
+	image.Rect(1, 1, 6, 4), // 奇数起点和奇数宽高
+	image.Rect(3, 1, 9, 7), // 奇数起点和偶数宽高
+	image.Rect(1, 0, 3, 1),
+}
+
+var testTransforms = []Transform{
+	{0, false}, {90, false}, {180, false}, {270, false},
+	{0, true}, {90, true}, {180, true}, {270, true},
+}
+
+// dstPoint 与 srcPoint 相反，裁剪区域中的 (x, y) 变换后的坐标
+func dstPoint(t Transform, x, y, w, h int) (int, int) {
+	ow := w
+	switch t.Rotation {
+	case 90:
+		x, y, ow = h-1-y, x, h
+	case 180:
+		x, y = w-1-x, h-1-y
+	case 270:
+		x, y, ow = y, w-1-x, h
+	}
+	if t.Mirror {
+		x = ow - 1 - x
+	}
+	return x, y
+}
+
+// expected 按变换后的坐标排列的亮度和色度
+type expected struct {
+	w, h   int
+	y      []byte
+	cb, cr []byte
+}
+
+func expect(r image.Rectangle, t Transform) *expected {
+	w, h := r.Dx(), r.Dy()
+	e := &expected{w: w, h: h}
+	if t.Rotation == 90 || t.Rotation == 270 {
+		e.w, e.h = h, w
+	}
+	e.y = make([]byte, w*h)
+	e.cb = make([]byte, w*h)
+	e.cr = make([]byte, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := dstPoint(t, x, y, w, h)
+			i := dy*e.w + dx
+			e.y[i] = lumaAt(r.Min.X+x, r.Min.Y+y)
+			e.cb[i], e.cr[i] = chromaAt(r.Min.X+x, r.Min.Y+y)
+		}
+	}
+	return e
+}
+
+// chroma 输出的 4:2:0 色度，取每 2x2 块左上像素的色度
+func (e *expected) chroma() (cw, ch int, cb, cr []byte) {
+	cw, ch = (e.w+1)/2, (e.h+1)/2
+	for y := 0; y < ch; y++ {
+		for x := 0; x < cw; x++ {
+			i := 2*y*e.w + 2*x
+			cb = append(cb, e.cb[i])
+			cr = append(cr, e.cr[i])
+		}
+	}
+	return
+}
+
+func forEachCase(t *testing.T, f func(t *testing.T, m *YUVImage, tr Transform, e *expected)) {
+	for _, l := range testLayouts {
+		for _, r := range testRects {
+			for _, tr := range testTransforms {
+				name := fmt.Sprintf("%s/%v/rot%d/mirror=%v", l.name, r, tr.Rotation, tr.Mirror)
+				t.Run(name, func(t *testing.T) {
+					y, u, v := l.planes()
+					m, err := NewYUVImage(r, y, u, v)
+					if err != nil {
+						t.Fatal(err)
+					}
+					f(t, m, tr, expect(r, tr))
+				})
+			}
+		}
+	}
+}
+
+func TestYUVImageAt(t *testing.T) {
+	for _, l := range testLayouts {
+		for _, r := range testRects {
+			y, u, v := l.planes()
+			m, err := NewYUVImage(r, y, u, v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for py := r.Min.Y; py < r.Max.Y; py++ {
+				for px := r.Min.X; px < r.Max.X; px++ {
+					cb, cr := chromaAt(px, py)
+					want := color.YCbCr{Y: lumaAt(px, py), Cb: cb, Cr: cr}
+					if got := m.At(px, py); got != want {
+						t.Errorf("%s %v: At(%d, %d) = %v, want %v", l.name, r, px, py, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestYUVImageSize(t *testing.T) {
+	forEachCase(t, func(t *testing.T, m *YUVImage, tr Transform, e *expected) {
+		if w, h := m.Size(tr); w != e.w || h != e.h {
+			t.Errorf("Size = %dx%d, want %dx%d", w, h, e.w, e.h)
+		}
+	})
+}
+
+func TestYUVImageToRGBA(t *testing.T) {
+	forEachCase(t, func(t *testing.T, m *YUVImage, tr Transform, e *expected) {
+		dst := m.ToRGBA(nil, tr)
+		if dst.Rect != image.Rect(0, 0, e.w, e.h) {
+			t.Fatalf("Rect = %v, want %dx%d", dst.Rect, e.w, e.h)
+		}
+		for y := 0; y < e.h; y++ {
+			for x := 0; x < e.w; x++ {
+				i := y*e.w + x
+				R, G, B := color.YCbCrToRGB(e.y[i], e.cb[i], e.cr[i])
+				want := color.RGBA{R, G, B, 0xff}
+				if got := dst.RGBAAt(x, y); got != want {
+					t.Fatalf("(%d, %d) = %v, want %v", x, y, got, want)
+				}
+			}
+		}
+		if again := m.ToRGBA(dst, tr); again != dst {
+			t.Error("ToRGBA did not reuse dst")
+		}
+	})
+}
+
+func TestYUVImageI420(t *testing.T) {
+	forEachCase(t, func(t *testing.T, m *YUVImage, tr Transform, e *expected) {
+		cw, ch, cb, cr := e.chroma()
+		got := m.I420(nil, tr)
+		want := append(append(append([]byte(nil), e.y...), cb...), cr...)
+		if len(got) != e.w*e.h+2*cw*ch {
+			t.Fatalf("len = %d, want %d", len(got), e.w*e.h+2*cw*ch)
+		}
+		if string(got) != string(want) {
+			t.Errorf("I420 = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestYUVImageNV21(t *testing.T) {
+	forEachCase(t, func(t *testing.T, m *YUVImage, tr Transform, e *expected) {
+		_, _, cb, cr := e.chroma()
+		want := append([]byte(nil), e.y...)
+		for i := range cb {
+			want = append(want, cr[i], cb[i])
+		}
+		buf := make([]byte, 0, 1024)
+		got := m.NV21(buf, tr)
+		if &got[0] != &buf[:1][0] {
+			t.Error("NV21 did not reuse dst")
+		}
+		if string(got) != string(want) {
+			t.Errorf("NV21 = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestYUVImageToYCbCr(t *testing.T) {
+	forEachCase(t, func(t *testing.T, m *YUVImage, tr Transform, e *expected) {
+		cw, ch, cb, cr := e.chroma()
+		dst := m.ToYCbCr(nil, tr)
+		for y := 0; y < e.h; y++ {
+			for x := 0; x < e.w; x++ {
+				if got, want := dst.Y[dst.YOffset(x, y)], e.y[y*e.w+x]; got != want {
+					t.Fatalf("Y(%d, %d) = %d, want %d", x, y, got, want)
+				}
+			}
+		}
+		for y := 0; y < ch; y++ {
+			for x := 0; x < cw; x++ {
+				i := y*dst.CStride + x
+				if dst.Cb[i] != cb[y*cw+x] || dst.Cr[i] != cr[y*cw+x] {
+					t.Fatalf("C(%d, %d) = %d/%d, want %d/%d", x, y, dst.Cb[i], dst.Cr[i], cb[y*cw+x], cr[y*cw+x])
+				}
+			}
+		}
+	})
+}
+
+func TestNewYUVImageShortPlane(t *testing.T) {
+	for _, l := range testLayouts {
+		y, u, v := l.planes()
+		r := image.Rect(0, 0, testW, testH)
+		if _, err := NewYUVImage(r, y, u, v); err != nil {
+			t.Fatalf("%s: %v", l.name, err)
+		}
+		// 最后一行不需要行尾的填充
+		y.Data = y.Data[:(testH-1)*y.RowStride+testW]
+		if _, err := NewYUVImage(r, y, u, v); err != nil {
+			t.Errorf("%s: without last row padding: %v", l.name, err)
+		}
+		y.Data = y.Data[:len(y.Data)-1]
+		if _, err := NewYUVImage(r, y, u, v); err == nil {
+			t.Errorf("%s: short Y plane accepted", l.name)
+		}
+		y, u, v = l.planes()
+		v.Data = v.Data[:(testH/2-1)*v.RowStride+(testW/2-1)*v.PixelStride]
+		if _, err := NewYUVImage(r, y, u, v); err == nil {
+			t.Errorf("%s: short V plane accepted", l.name)
+		}
+		if _, err := NewYUVImage(image.Rect(-1, 0, 2, 2), y, u, v); err == nil {
+			t.Errorf("%s: negative rect accepted", l.name)
+		}
+	}
+}
@@ -0,0 +1,543 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package recorder 把相机的画面编码为 H.264 并写入 MP4。
+//
+// 相机 (camera24) 的输出直接送到编码器的输入 surface，
+// 编码器的输出由 AMediaMuxer 或 mp4.Muxer 写入文件。
+// 设备需要 API 26 (AMediaCodec_createInputSurface)，更低的版本 Start 返回 ERROR_UNSUPPORTED。
+package recorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"sync"
+	"time"
+
+	camera "github.com/gooid/gooid/camera24"
+	app "github.com/gooid/gooid/internal/ndk"
+	media "github.com/gooid/gooid/media24"
+	"github.com/gooid/gooid/media24/mp4"
+)
+
+// State 录制状态
+type State int
+
+const (
+	RECORDING State = iota
+	PAUSED
+	STOPPED
+)
+
+func (s State) String() string {
+	switch s {
+	case RECORDING:
+		return "RECORDING"
+	case PAUSED:
+		return "PAUSED"
+	case STOPPED:
+		return "STOPPED"
+	}
+	return fmt.Sprintf("UNKNOW_RECORDER_STATE%d", int(s))
+}
+
+var (
+	ErrStopped = errors.New("recorder: stopped")
+	// ErrEmpty 停止时没有录到任何画面
+	ErrEmpty = errors.New("recorder: nothing recorded")
+)
+
+// Options 录制参数，零值使用默认值
+type Options struct {
+	// CameraId 为空时选择后置摄像头，Front 为 true 时选择前置摄像头
+	CameraId string
+	Front    bool
+
+	// Size 视频尺寸，按相机支持的尺寸调整，默认 1280x720
+	Size image.Point
+	// FrameRate 默认 30
+	FrameRate int
+	// Bitrate 默认按尺寸和帧率估计
+	Bitrate int
+	// KeyFrameInterval 关键帧间隔，单位为秒，默认 1
+	KeyFrameInterval int
+
+	// Rotation 设备当前的旋转角度 (顺时针，0/90/180/270)，
+	// 用于计算 orientation hint，使视频以设备当前方向正向播放
+	Rotation int
+
+	// Preview 不为 nil 时同时作为预览输出
+	Preview *app.Window
+
+	// Muxer 为 nil 时以 AMediaMuxer 写入文件
+	Muxer mp4.Muxer
+}
+
+const (
+	outputPreview = "preview"
+	outputEncoder = "encoder"
+
+	// drainTimeout Stop 后等待编码器输出 END_OF_STREAM 的最长时间
+	drainTimeout = 3 * time.Second
+)
+
+// Recorder 一次录制，Start 开始，Stop 结束
+type Recorder struct {
+	opts        Options
+	camera      *camera.Camera
+	session     *camera.Session
+	request     *camera.RequestBuilder
+	codec       *media.Codec
+	surface     *app.Window
+	muxer       mp4.Muxer
+	file        *os.File // 由 Recorder 创建时在 Stop 中关闭
+	orientation int
+	interval    time.Duration // 帧间隔
+
+	mu       sync.Mutex
+	state    State
+	err      error
+	started  bool // muxer 已 Start
+	track    int
+	written  bool
+	firstPts time.Duration
+	lastPts  time.Duration
+	offset   time.Duration // 暂停的总时长
+	resumed  bool
+
+	stopping  chan struct{}
+	done      chan struct{}
+	stopOnce  sync.Once
+	inputOnce sync.Once
+	stopErr   error
+}
+
+// Start 打开相机和编码器并开始录制到 path，opts.Muxer 不为 nil 时忽略 path
+func Start(ctx context.Context, path string, opts Options) (*Recorder, error) {
+	r := &Recorder{
+		opts:     opts,
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if err := r.start(ctx, path); err != nil {
+		close(r.done)
+		r.release()
+		return nil, err
+	}
+	go r.drain()
+	return r, nil
+}
+
+func (r *Recorder) start(ctx context.Context, path string) error {
+	opts := &r.opts
+	if opts.Size == (image.Point{}) {
+		opts.Size = image.Pt(1280, 720)
+	}
+	if opts.FrameRate <= 0 {
+		opts.FrameRate = 30
+	}
+	if opts.Bitrate <= 0 {
+		opts.Bitrate = opts.Size.X * opts.Size.Y * opts.FrameRate / 8
+	}
+	if opts.KeyFrameInterval <= 0 {
+		opts.KeyFrameInterval = 1
+	}
+	r.interval = time.Second / time.Duration(opts.FrameRate)
+
+	id := opts.CameraId
+	if id == "" {
+		var err error
+		if id, err = selectCamera(opts.Front); err != nil {
+			return err
+		}
+	}
+	c, err := camera.Open(ctx, id)
+	if err != nil {
+		return err
+	}
+	r.camera = c
+	chars := c.Characteristics()
+	if opts.Size, err = videoSize(chars, opts.Size); err != nil {
+		return err
+	}
+	if r.orientation, err = chars.JpegOrientationFor(opts.Rotation); err != nil {
+		return err
+	}
+
+	if err = r.startEncoder(); err != nil {
+		return err
+	}
+	if r.muxer = opts.Muxer; r.muxer == nil {
+		if r.file, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644); err != nil {
+			return err
+		}
+		if r.muxer, err = media.NewMP4Muxer(r.file); err != nil {
+			return err
+		}
+	}
+
+	outputs := []camera.OutputConfig{camera.EncoderOutput(outputEncoder, r.surface)}
+	outputs[0].Enabled = true
+	if opts.Preview != nil {
+		outputs = append(outputs, camera.DisplayOutput(outputPreview, opts.Preview))
+	}
+	if r.session, err = c.Configure(ctx, camera.SessionConfig{Outputs: outputs}); err != nil {
+		return err
+	}
+	r.request = recordRequest(chars, opts.FrameRate)
+	c.OnReconnected = func(*camera.Camera) {
+		r.session.Resume()
+	}
+	_, err = r.session.SetRepeating(nil, r.request, camera.TEMPLATE_RECORD)
+	return err
+}
+
+func selectCamera(front bool) (string, error) {
+	mgr := camera.ManagerCreate()
+	if mgr == nil {
+		return "", camera.STATUS_ERROR_CAMERA_SERVICE
+	}
+	defer mgr.Delete()
+	facing := camera.LensFacing(camera.LENS_FACING_BACK)
+	if front {
+		facing = camera.LENS_FACING_FRONT
+	}
+	info, err := mgr.SelectCamera(facing)
+	if err != nil {
+		return "", err
+	}
+	return info.Id, nil
+}
+
+// videoSize 编码器 surface 使用 PRIVATE 格式，设备没有列出时按 YUV_420_888 选择
+func videoSize(chars camera.Accessor, target image.Point) (image.Point, error) {
+	size, err := chars.BestPreviewSize(camera.PIXEL_FORMAT_PRIVATE, target, 0.05)
+	if err != nil {
+		size, err = chars.BestPreviewSize(camera.PIXEL_FORMAT_YUV_420_888, target, 0.05)
+	}
+	return size, err
+}
+
+// recordRequest 连续视频对焦，帧率固定为 fps (设备支持时)
+func recordRequest(chars camera.Accessor, fps int) *camera.RequestBuilder {
+	b := camera.NewRequestBuilder(chars).SetCaptureIntent(camera.CONTROL_CAPTURE_INTENT_VIDEO_RECORD)
+	if modes, err := chars.AvailableAfModes(); err == nil {
+		for _, m := range modes {
+			if m == camera.AF_MODE_CONTINUOUS_VIDEO {
+				b.SetAfMode(m)
+			}
+		}
+	}
+	if ranges, err := chars.FpsRanges(); err == nil {
+		best := -1
+		for i, fr := range ranges {
+			if fr.Max != fps {
+				continue
+			}
+			if best < 0 || fr.Min > ranges[best].Min {
+				best = i
+			}
+		}
+		if best >= 0 {
+			b.SetFpsRange(ranges[best])
+		}
+	}
+	return b
+}
+
+func (r *Recorder) startEncoder() error {
+	codec, err := media.CreateEncoderByType(media.MIMETYPE_VIDEO_AVC)
+	if err != nil {
+		return err
+	}
+	r.codec = codec
+
+	format := media.NewFormat()
+	defer format.Delete()
+	format.SetString(media.KEY_MIME, media.MIMETYPE_VIDEO_AVC)
+	format.SetInt32(media.KEY_WIDTH, int32(r.opts.Size.X))
+	format.SetInt32(media.KEY_HEIGHT, int32(r.opts.Size.Y))
+	format.SetInt32(media.KEY_COLOR_FORMAT, media.COLOR_FormatSurface)
+	format.SetInt32(media.KEY_BIT_RATE, int32(r.opts.Bitrate))
+	format.SetInt32(media.KEY_FRAME_RATE, int32(r.opts.FrameRate))
+	format.SetInt32(media.KEY_I_FRAME_INTERVAL, int32(r.opts.KeyFrameInterval))
+	if err = codec.Configure(format, nil, media.CONFIGURE_FLAG_ENCODE); err != nil {
+		return err
+	}
+	if r.surface, err = codec.CreateInputSurface(); err != nil {
+		return err
+	}
+	return codec.Start()
+}
+
+// release 释放已创建的资源，相机先于 surface 关闭
+func (r *Recorder) release() {
+	if r.session != nil {
+		r.session.Close()
+	}
+	if r.camera != nil {
+		r.camera.Close()
+	}
+	if r.codec != nil {
+		r.codec.Stop()
+		r.codec.Delete()
+	}
+	if r.surface != nil {
+		r.surface.Release()
+	}
+	if r.file != nil {
+		if _, ok := r.muxer.(*media.MP4Muxer); ok && !r.started {
+			// 释放未启动的 AMediaMuxer
+			r.muxer.Stop()
+		}
+		r.file.Close()
+	}
+}
+
+// Camera 录制使用的相机，可以用它调整变焦等参数，停止后为 nil
+func (r *Recorder) Camera() *camera.Camera {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.camera
+}
+
+// Orientation 写入文件的 orientation hint
+func (r *Recorder) Orientation() int {
+	return r.orientation
+}
+
+func (r *Recorder) State() State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+// Err 录制过程中的错误，出错后录制停止写入
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Duration 已录制的时长，不包括暂停的时间
+func (r *Recorder) Duration() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.written {
+		return 0
+	}
+	return r.lastPts - r.offset - r.firstPts
+}
+
+// Pause 暂停录制，预览继续
+func (r *Recorder) Pause() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch r.state {
+	case STOPPED:
+		return ErrStopped
+	case PAUSED:
+		return nil
+	}
+	if err := r.session.SetEnabled(outputEncoder, false); err != nil {
+		return err
+	}
+	var err error
+	if r.opts.Preview != nil {
+		_, err = r.session.SetRepeating(nil, r.request, camera.TEMPLATE_RECORD)
+	} else {
+		err = r.session.StopRepeating()
+	}
+	if err != nil {
+		r.session.SetEnabled(outputEncoder, true)
+		return err
+	}
+	r.state = PAUSED
+	return nil
+}
+
+// Resume 继续录制，时间戳与暂停前连续，并从关键帧开始
+func (r *Recorder) Resume() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch r.state {
+	case STOPPED:
+		return ErrStopped
+	case RECORDING:
+		return nil
+	}
+	if err := r.session.SetEnabled(outputEncoder, true); err != nil {
+		return err
+	}
+	if _, err := r.session.SetRepeating(nil, r.request, camera.TEMPLATE_RECORD); err != nil {
+		r.session.SetEnabled(outputEncoder, false)
+		return err
+	}
+	params := media.NewFormat()
+	params.SetInt32(media.KEY_REQUEST_SYNC_FRAME, 0)
+	r.codec.SetParameters(params)
+	params.Delete()
+	r.resumed = true
+	r.state = RECORDING
+	return nil
+}
+
+// Stop 停止录制：停止相机输出，等待编码器输出剩余的帧，完成文件并释放资源。
+// 可以多次调用，可能阻塞到 drainTimeout
+func (r *Recorder) Stop() error {
+	r.stopOnce.Do(func() {
+		r.stopInput()
+		<-r.done
+
+		r.mu.Lock()
+		err, started, written := r.err, r.started, r.written
+		r.mu.Unlock()
+		if started {
+			if e := r.muxer.Stop(); err == nil {
+				err = e
+			}
+		}
+		if err == nil && !written {
+			err = ErrEmpty
+		}
+		r.release()
+		r.stopErr = err
+	})
+	return r.stopErr
+}
+
+// stopInput 停止并关闭相机，通知编码器输入结束，不等待编码器
+func (r *Recorder) stopInput() {
+	r.inputOnce.Do(func() {
+		r.mu.Lock()
+		r.state = STOPPED
+		r.mu.Unlock()
+
+		r.session.StopRepeating()
+		r.codec.SignalEndOfInputStream()
+		close(r.stopping)
+		r.session.Close()
+		r.camera.Close()
+		r.mu.Lock()
+		r.session, r.camera = nil, nil
+		r.mu.Unlock()
+	})
+}
+
+// StopOnPause 在 cbs.Pause 中停止录制，之后调用原来的 Pause。
+// cbs 可以是传给 Context.Run 的 Callbacks，或运行中的 &ctx.Callbacks。
+// Pause 中只停止相机并通知编码器结束，不阻塞主线程；
+// 剩余的帧在后台写完，调用 Stop 可以等待完成并取得结果
+func (r *Recorder) StopOnPause(cbs *app.Callbacks) {
+	pause := cbs.Pause
+	cbs.Pause = func(act *app.Activity) {
+		r.stopInput()
+		go r.Stop()
+		if pause != nil {
+			pause(act)
+		}
+	}
+}
+
+func (r *Recorder) fail(err error) {
+	r.mu.Lock()
+	if r.err == nil {
+		r.err = err
+	}
+	r.mu.Unlock()
+}
+
+// drain 取出编码器的输出写入 muxer，直到 END_OF_STREAM
+func (r *Recorder) drain() {
+	defer close(r.done)
+	var info media.CodecBufferInfo
+	var deadline time.Time
+	for {
+		if deadline.IsZero() {
+			select {
+			case <-r.stopping:
+				deadline = time.Now().Add(drainTimeout)
+			default:
+			}
+		} else if time.Now().After(deadline) {
+			return
+		}
+
+		idx := r.codec.DequeueOutputBuffer(&info, 100*time.Millisecond)
+		switch {
+		case idx == media.INFO_OUTPUT_FORMAT_CHANGED:
+			if err := r.startMuxer(); err != nil {
+				r.fail(err)
+				return
+			}
+		case idx < 0:
+			// INFO_TRY_AGAIN_LATER、INFO_OUTPUT_BUFFERS_CHANGED
+		default:
+			err := r.writeSample(idx, &info)
+			r.codec.ReleaseOutputBuffer(idx, false)
+			if err != nil {
+				r.fail(err)
+				return
+			}
+			if info.Flags&media.BUFFER_FLAG_END_OF_STREAM != 0 {
+				return
+			}
+		}
+	}
+}
+
+func (r *Recorder) startMuxer() error {
+	format := r.codec.GetOutputFormat()
+	tf := media.TrackFormatOf(format)
+	format.Delete()
+	track, err := r.muxer.AddTrack(tf)
+	if err != nil {
+		return err
+	}
+	if err = r.muxer.SetOrientationHint(r.orientation); err != nil {
+		return err
+	}
+	if err = r.muxer.Start(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.track, r.started = track, true
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Recorder) writeSample(idx int, info *media.CodecBufferInfo) error {
+	// SPS/PPS 已在输出格式的 csd-0/csd-1 中
+	if info.Flags&media.BUFFER_FLAG_CODEC_CONFIG != 0 || info.Size == 0 {
+		return nil
+	}
+	data := r.codec.GetOutputBuffer(idx)
+	if info.Offset+info.Size > len(data) {
+		return fmt.Errorf("recorder: invalid output buffer %d+%d/%d", info.Offset, info.Size, len(data))
+	}
+
+	r.mu.Lock()
+	if !r.started {
+		r.mu.Unlock()
+		return fmt.Errorf("recorder: output before format")
+	}
+	pts := info.PresentationTime
+	switch {
+	case !r.written:
+		r.firstPts, r.written = pts, true
+	case r.resumed:
+		// 去掉暂停的时间，与暂停前的最后一帧间隔一帧
+		r.offset += pts - r.lastPts - r.interval
+	}
+	r.resumed = false
+	r.lastPts = pts
+	pts -= r.offset
+	track := r.track
+	r.mu.Unlock()
+
+	return r.muxer.WriteSample(track, data[info.Offset:info.Offset+info.Size], pts, info.Flags)
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stampSuffix 记录已解压内容摘要的文件的后缀
+const stampSuffix = ".stamp"
+
+// Extract 把 asset name 解压到 dir 下的同名路径 (如 act.InternalDataPath())，返回文件路径。
+//
+// 需要真实文件路径的库 (SQLite、OpenCV 等) 可以使用。
+// 旁边的 .stamp 文件记录内容摘要：目录树来自 APK 时为 zip 中的 CRC32 和长度，
+// 不读取内容就能判断；否则为 SHA-256。摘要不变时不再解压，
+// 所以每个 APK 版本只解压一次。未压缩的 asset 也可以用 OpenSection 直接读取
+func (fsys *AssetFS) Extract(name, dir string) (string, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return "", &fs.PathError{Op: "extract", Path: name, Err: fs.ErrInvalid}
+	}
+	e, err := fsys.stat(name)
+	if err != nil {
+		return "", &fs.PathError{Op: "extract", Path: name, Err: err}
+	}
+	if e.dir {
+		return "", &fs.PathError{Op: "extract", Path: name, Err: errIsDir}
+	}
+
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	sum := e.sum
+	if sum == "" {
+		if sum, err = fsys.hash(name); err != nil {
+			return "", &fs.PathError{Op: "extract", Path: name, Err: err}
+		}
+	}
+	if extracted(target, sum, e.size) {
+		return target, nil
+	}
+
+	if err := fsys.extract(name, target); err != nil {
+		return "", &fs.PathError{Op: "extract", Path: name, Err: err}
+	}
+	if err := ioutil.WriteFile(target+stampSuffix, []byte(sum), 0644); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// extracted target 是否已是摘要为 sum 的内容
+func extracted(target, sum string, size int64) bool {
+	st, err := os.Stat(target)
+	if err != nil || st.Size() != size {
+		return false
+	}
+	stamp, err := ioutil.ReadFile(target + stampSuffix)
+	return err == nil && strings.TrimSpace(string(stamp)) == sum
+}
+
+func (fsys *AssetFS) hash(name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%s:%d", hex.EncodeToString(h.Sum(nil)), n), nil
+}
+
+// extract 先写到临时文件再改名，中途失败不会留下不完整的文件
+func (fsys *AssetFS) extract(name, target string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	tmp := target + ".tmp"
+	w, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	if err == nil {
+		err = w.Sync()
+	}
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, target)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
@@ -0,0 +1,322 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sync"
+)
+
+var errIsDir = errors.New("is a directory")
+
+// maxBuffer Asset.GetBuffer 能返回的最大长度
+const maxBuffer = 1 << 26
+
+// AssetFS 以 io/fs 的方式访问 assets，实现 fs.FS、fs.ReadDirFS、fs.StatFS 和 fs.ReadFileFS，
+// 可以直接用于 template.ParseFS、http.FS 等。
+//
+// AssetManager.OpenDir 不能列出子目录，目录树从 APK 的 zip 中央目录重建；
+// 找不到 APK 时退化为用 OpenDir 列出每个目录中的文件。
+// 打开的文件同时实现 io.Seeker 和 io.ReaderAt
+type AssetFS struct {
+	mgr *AssetManager
+	apk string
+
+	once sync.Once
+	idx  *index
+	err  error
+}
+
+// NewFS 用 apk 中的目录树访问 mgr 中的 assets
+func NewFS(mgr *AssetManager, apk string) *AssetFS {
+	return &AssetFS{mgr: mgr, apk: apk}
+}
+
+// Err 读取 APK 目录的错误，为 nil 时目录树完整
+func (fsys *AssetFS) Err() error {
+	fsys.index()
+	return fsys.err
+}
+
+func (fsys *AssetFS) index() *index {
+	fsys.once.Do(func() {
+		if fsys.apk == "" {
+			fsys.err = errors.New("storage: unknown APK path")
+			return
+		}
+		fsys.idx, fsys.err = readIndex(fsys.apk, assetsPrefix)
+	})
+	return fsys.idx
+}
+
+// assetName fs 路径转换为 AssetManager 的路径
+func assetName(name string) string {
+	if name == "." {
+		return ""
+	}
+	return name
+}
+
+func (fsys *AssetFS) stat(name string) (*entry, error) {
+	if idx := fsys.index(); idx != nil {
+		if e, ok := idx.lookup(name); ok {
+			return e, nil
+		}
+		return nil, fs.ErrNotExist
+	}
+
+	if name == "." {
+		return &entry{name: ".", dir: true}, nil
+	}
+	if a := fsys.mgr.Open(name, ASSET_MODE_UNKNOWN); a != nil {
+		e := &entry{name: path.Base(name), size: a.Length()}
+		a.Close()
+		return e, nil
+	}
+	// 不存在的目录也能打开，只是没有文件
+	d := fsys.mgr.OpenDir(name)
+	if d == nil {
+		return nil, fs.ErrNotExist
+	}
+	defer d.Close()
+	if d.GetNextFileName() == "" {
+		return nil, fs.ErrNotExist
+	}
+	return &entry{name: path.Base(name), dir: true}, nil
+}
+
+func (fsys *AssetFS) readDir(name string) []fs.DirEntry {
+	if idx := fsys.index(); idx != nil {
+		return append([]fs.DirEntry(nil), idx.readDir(name)...)
+	}
+
+	d := fsys.mgr.OpenDir(assetName(name))
+	if d == nil {
+		return nil
+	}
+	defer d.Close()
+	idx := newIndex()
+	for {
+		fname := d.GetNextFileName()
+		if fname == "" {
+			break
+		}
+		e := &entry{}
+		if a := fsys.mgr.Open(path.Join(name, fname), ASSET_MODE_UNKNOWN); a != nil {
+			e.size = a.Length()
+			a.Close()
+		}
+		idx.add(fname, e)
+	}
+	idx.sort()
+	return idx.readDir(".")
+}
+
+// Open 实现 fs.FS
+func (fsys *AssetFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	e, err := fsys.stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if e.dir {
+		return &dirFile{e: e, entries: fsys.readDir(name), path: name}, nil
+	}
+	a := fsys.mgr.Open(name, ASSET_MODE_RANDOM)
+	if a == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &file{a: a, e: e, path: name}, nil
+}
+
+// Stat 实现 fs.StatFS
+func (fsys *AssetFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	e, err := fsys.stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return e, nil
+}
+
+// ReadDir 实现 fs.ReadDirFS，按文件名排序
+func (fsys *AssetFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	e, err := fsys.stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	if !e.dir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return fsys.readDir(name), nil
+}
+
+// ReadFile 实现 fs.ReadFileFS，尽量使用 Asset.GetBuffer 避免逐块读取
+func (fsys *AssetFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	a := fsys.mgr.Open(assetName(name), ASSET_MODE_BUFFER)
+	if a == nil {
+		if e, err := fsys.stat(name); err == nil && e.dir {
+			return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+		}
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	defer a.Close()
+
+	size := a.Length()
+	if size <= maxBuffer {
+		if buf := a.GetBuffer(); buf != nil {
+			return append([]byte(nil), buf...), nil
+		}
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(&file{a: a}, data); err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+// file 打开的 asset
+type file struct {
+	mu     sync.Mutex
+	a      *Asset
+	e      *entry
+	path   string
+	closed bool
+
+	// 未压缩的 asset 用文件描述符实现 ReadAt，不需要加锁和移动读位置
+	sec   *Section
+	noSec bool
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.e, nil
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: fs.ErrClosed}
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return f.a.Read(p)
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.path, Err: fs.ErrClosed}
+	}
+	return f.a.Seek(offset, whence)
+}
+
+// ReadAt 实现 io.ReaderAt，不改变 Read 的位置
+func (f *file) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: fs.ErrClosed}
+	}
+	if off < 0 {
+		f.mu.Unlock()
+		return 0, &fs.PathError{Op: "read", Path: f.path, Err: fs.ErrInvalid}
+	}
+	if f.sec == nil && !f.noSec {
+		if sec, err := OpenSection(f.a); err == nil {
+			f.sec = sec
+		} else {
+			f.noSec = true
+		}
+	}
+	if sec := f.sec; sec != nil {
+		f.mu.Unlock()
+		return sec.ReadAt(p, off)
+	}
+	defer f.mu.Unlock()
+
+	pos, err := f.a.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	defer f.a.Seek(pos, io.SeekStart)
+	if _, err := f.a.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n := 0
+	for n < len(p) {
+		m, err := f.a.Read(p[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (f *file) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.path, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	if f.sec != nil {
+		f.sec.Close()
+	}
+	return f.a.Close()
+}
+
+// dirFile 打开的目录
+type dirFile struct {
+	e       *entry
+	path    string
+	entries []fs.DirEntry
+	pos     int
+}
+
+func (d *dirFile) Stat() (fs.FileInfo, error) {
+	return d.e, nil
+}
+
+func (d *dirFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errIsDir}
+}
+
+func (d *dirFile) Close() error {
+	return nil
+}
+
+// ReadDir 实现 fs.ReadDirFile
+func (d *dirFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.pos:]
+	if n <= 0 {
+		d.pos = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.pos += n
+	return rest[:n], nil
+}
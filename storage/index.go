@@ -0,0 +1,125 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// assetsPrefix APK 中 assets 目录的前缀
+const assetsPrefix = "assets/"
+
+// entry 目录树中的一项
+type entry struct {
+	name    string // 不含路径
+	size    int64
+	modTime time.Time
+	dir     bool
+	sum     string // zip 中的 CRC32 和长度，内容变化时改变
+}
+
+func (e *entry) Name() string               { return e.name }
+func (e *entry) Size() int64                { return e.size }
+func (e *entry) ModTime() time.Time         { return e.modTime }
+func (e *entry) IsDir() bool                { return e.dir }
+func (e *entry) Sys() interface{}           { return nil }
+func (e *entry) Type() fs.FileMode          { return e.Mode().Type() }
+func (e *entry) Info() (fs.FileInfo, error) { return e, nil }
+
+func (e *entry) Mode() fs.FileMode {
+	if e.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (e *entry) String() string {
+	return fs.FormatFileInfo(e)
+}
+
+// index 从 zip 中央目录重建的 assets 目录树，路径为 fs.FS 格式 (根为 ".")
+type index struct {
+	entries map[string]*entry
+	dirs    map[string][]fs.DirEntry // 按名字排序
+}
+
+func newIndex() *index {
+	return &index{
+		entries: map[string]*entry{".": {name: ".", dir: true}},
+		dirs:    map[string][]fs.DirEntry{},
+	}
+}
+
+// readIndex 读取 APK (zip) 中 prefix 下的文件
+func readIndex(apk, prefix string) (*index, error) {
+	zr, err := zip.OpenReader(apk)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return indexZip(&zr.Reader, prefix), nil
+}
+
+func indexZip(zr *zip.Reader, prefix string) *index {
+	idx := newIndex()
+	for _, f := range zr.File {
+		if !strings.HasPrefix(f.Name, prefix) {
+			continue
+		}
+		name := strings.TrimSuffix(f.Name[len(prefix):], "/")
+		if name == "" || !fs.ValidPath(name) {
+			continue
+		}
+		if f.FileInfo().IsDir() {
+			idx.addDir(name)
+		} else {
+			idx.add(name, &entry{
+				size:    int64(f.UncompressedSize64),
+				modTime: f.Modified,
+				sum:     fmt.Sprintf("crc32:%08x:%d", f.CRC32, f.UncompressedSize64),
+			})
+		}
+	}
+	idx.sort()
+	return idx
+}
+
+// add 加入 name，并补齐上级目录
+func (idx *index) add(name string, e *entry) {
+	if _, ok := idx.entries[name]; ok {
+		return
+	}
+	e.name = path.Base(name)
+	idx.entries[name] = e
+	dir := path.Dir(name)
+	idx.addDir(dir)
+	idx.dirs[dir] = append(idx.dirs[dir], e)
+}
+
+func (idx *index) addDir(name string) {
+	// add 会忽略已有的项，同名的文件和目录只保留先出现的
+	idx.add(name, &entry{dir: true})
+}
+
+func (idx *index) sort() {
+	for _, list := range idx.dirs {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	}
+}
+
+func (idx *index) lookup(name string) (*entry, bool) {
+	e, ok := idx.entries[name]
+	return e, ok
+}
+
+func (idx *index) readDir(name string) []fs.DirEntry {
+	return idx.dirs[name]
+}
@@ -0,0 +1,199 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !android
+// +build !android
+
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testModTime = time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// testAssets testAPK 中 assets/ 下的文件
+var testAssets = map[string]string{
+	"index.html":          "<html></html>",
+	"fonts/a.ttf":         "font a",
+	"fonts/cjk/b.otf":     "font b, deeper",
+	"db/stored.bin":       "STOREDCONTENT0123456789",
+	"data/level1.json":    `{"level": 1}`,
+	"data/deep/x/y/z.txt": "zzz",
+}
+
+// testAPK 内存中的 APK：assets 之外还有其它文件，
+// 多数目录没有自己的项 (aapt 的打包方式)，只有 assets/empty/ 是显式的空目录。
+// db/ 下的文件不压缩，其它的压缩
+func testAPK(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name string, method uint16, data string) {
+		h := &zip.FileHeader{Name: name, Method: method}
+		h.Modified = testModTime
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("AndroidManifest.xml", zip.Deflate, "manifest")
+	add("classes.dex", zip.Deflate, "dex")
+	add("res/raw/assets.txt", zip.Deflate, "not an asset")
+	add("assets/empty/", zip.Store, "")
+	add("assets/../evil.txt", zip.Deflate, "invalid path")
+	for name, data := range testAssets {
+		method := uint16(zip.Deflate)
+		if filepath.Dir(name) == "db" {
+			method = zip.Store
+		}
+		add("assets/"+name, method, data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// writeAPK 把 testAPK 写到临时目录，返回路径和删除它的函数
+func writeAPK(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	apk := filepath.Join(dir, "base.apk")
+	if err := ioutil.WriteFile(apk, testAPK(t), 0644); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return apk, func() { os.RemoveAll(dir) }
+}
+
+func dirNames(list []fs.DirEntry) []string {
+	var names []string
+	for _, e := range list {
+		n := e.Name()
+		if e.IsDir() {
+			n += "/"
+		}
+		names = append(names, n)
+	}
+	return names
+}
+
+func TestIndexZip(t *testing.T) {
+	data := testAPK(t)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := indexZip(zr, assetsPrefix)
+
+	dirs := map[string][]string{
+		".":           {"data/", "db/", "empty/", "fonts/", "index.html"},
+		"data":        {"deep/", "level1.json"},
+		"data/deep":   {"x/"},
+		"data/deep/x": {"y/"},
+		"fonts":       {"a.ttf", "cjk/"},
+		"fonts/cjk":   {"b.otf"},
+		"empty":       nil,
+	}
+	for name, want := range dirs {
+		e, ok := idx.lookup(name)
+		if !ok || !e.IsDir() {
+			t.Errorf("%s: missing directory", name)
+			continue
+		}
+		if got := dirNames(idx.readDir(name)); !reflect.DeepEqual(got, want) {
+			t.Errorf("readDir(%s) = %v, want %v", name, got, want)
+		}
+	}
+	for name, data := range testAssets {
+		e, ok := idx.lookup(name)
+		if !ok || e.IsDir() || e.Size() != int64(len(data)) || !e.ModTime().Equal(testModTime) || e.sum == "" {
+			t.Errorf("%s: %v, %v", name, e, ok)
+		}
+	}
+	for _, name := range []string{"AndroidManifest.xml", "res", "res/raw/assets.txt", "../evil.txt", "evil.txt", "assets"} {
+		if _, ok := idx.lookup(name); ok {
+			t.Errorf("%s: outside assets/ but indexed", name)
+		}
+	}
+}
+
+// TestFSIndex 用 APK 的目录树访问 assets，子目录可以列出和遍历
+func TestFSIndex(t *testing.T) {
+	apk, cleanup := writeAPK(t)
+	defer cleanup()
+	mgr, err := OpenAssetManager(apk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+	fsys := NewFS(mgr, apk)
+	if err := fsys.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := fs.ReadDir(fsys, "fonts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dirNames(list), []string{"a.ttf", "cjk/"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadDir(fonts) = %v, want %v", got, want)
+	}
+	if list, err := fs.ReadDir(fsys, "empty"); err != nil || len(list) != 0 {
+		t.Errorf("ReadDir(empty) = %v, %v", dirNames(list), err)
+	}
+	if _, err := fs.ReadDir(fsys, "index.html"); err == nil {
+		t.Error("ReadDir of a file: no error")
+	}
+
+	if fi, err := fs.Stat(fsys, "data/deep/x"); err != nil || !fi.IsDir() || fi.Name() != "x" {
+		t.Errorf("Stat(data/deep/x) = %v, %v", fi, err)
+	}
+	if fi, err := fs.Stat(fsys, "fonts/cjk/b.otf"); err != nil || fi.IsDir() || fi.Size() != int64(len(testAssets["fonts/cjk/b.otf"])) {
+		t.Errorf("Stat(fonts/cjk/b.otf) = %v, %v", fi, err)
+	}
+	for _, name := range []string{"nope", "fonts/nope", "AndroidManifest.xml"} {
+		if _, err := fs.Stat(fsys, name); !os.IsNotExist(err) {
+			t.Errorf("Stat(%s) = %v, want not exist", name, err)
+		}
+	}
+
+	for name, want := range testAssets {
+		if got, err := fs.ReadFile(fsys, name); err != nil || string(got) != want {
+			t.Errorf("ReadFile(%s) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := fs.ReadFile(fsys, "fonts"); err == nil {
+		t.Error("ReadFile of a directory: no error")
+	}
+
+	var files []string
+	for name := range testAssets {
+		files = append(files, name)
+	}
+	if err := fstest.TestFS(fsys, files...); err != nil {
+		t.Fatal(err)
+	}
+
+	// 找不到 APK 时 Err 报告错误
+	if err := NewFS(mgr, apk+".missing").Err(); err == nil {
+		t.Error("missing APK: no error")
+	}
+}
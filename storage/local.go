@@ -0,0 +1,341 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !android
+// +build !android
+
+package storage
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 非 Android 平台上用本地目录或 APK (zip) 模拟 AssetManager，
+// 行为与 NDK 的一致：OpenDir 只列出文件，Open 目录返回 nil，
+// Seek 超出范围时出错，读到结尾返回 io.EOF。
+// 测试和 glfw 等桌面版本可以共用读取 assets 的代码。
+
+/* Available modes for opening assets */
+const (
+	ASSET_MODE_UNKNOWN   = 0
+	ASSET_MODE_RANDOM    = 1
+	ASSET_MODE_STREAMING = 2
+	ASSET_MODE_BUFFER    = 3
+)
+
+// AssetManager 本地目录或 zip 中的 assets
+type AssetManager struct {
+	dir string // 本地目录
+
+	file *os.File // zip
+	name string
+	idx  *index
+	zips map[string]*zip.File
+}
+
+// NewAssetManager 以本地目录 dir (如 "basic/assets") 为 assets
+func NewAssetManager(dir string) *AssetManager {
+	return &AssetManager{dir: dir}
+}
+
+// OpenAssetManager 打开 APK 或 zip 文件。
+// 有 assets/ 目录时使用其中的文件 (APK)，否则使用整个 zip
+func OpenAssetManager(name string) (*AssetManager, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	zr, err := zip.NewReader(f, st.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	prefix := ""
+	for _, zf := range zr.File {
+		if strings.HasPrefix(zf.Name, assetsPrefix) {
+			prefix = assetsPrefix
+			break
+		}
+	}
+	mgr := &AssetManager{file: f, name: name, idx: indexZip(zr, prefix), zips: map[string]*zip.File{}}
+	for _, zf := range zr.File {
+		if strings.HasPrefix(zf.Name, prefix) && !zf.FileInfo().IsDir() {
+			mgr.zips[zf.Name[len(prefix):]] = zf
+		}
+	}
+	return mgr, nil
+}
+
+// Close 关闭 zip 文件，本地目录无需关闭
+func (mgr *AssetManager) Close() error {
+	if mgr.file != nil {
+		return mgr.file.Close()
+	}
+	return nil
+}
+
+// cleanName 检查并规范 Asset 路径，根目录为 "."
+func cleanName(name string) (string, bool) {
+	name = strings.TrimSuffix(name, "/")
+	if name == "" {
+		return ".", true
+	}
+	return name, fs.ValidPath(name)
+}
+
+// OpenDir 打开目录，只能列出其中的文件，不存在的目录没有文件。
+// 根目录为 ""
+func (mgr *AssetManager) OpenDir(dirName string) *AssetDir {
+	d := &AssetDir{}
+	name, ok := cleanName(dirName)
+	if !ok {
+		return d
+	}
+	if mgr.idx != nil {
+		for _, e := range mgr.idx.readDir(name) {
+			if !e.IsDir() {
+				d.names = append(d.names, e.Name())
+			}
+		}
+		return d
+	}
+	infos, err := ioutil.ReadDir(filepath.Join(mgr.dir, filepath.FromSlash(name)))
+	if err != nil {
+		return d
+	}
+	for _, fi := range infos {
+		if fi.Mode().IsRegular() {
+			d.names = append(d.names, fi.Name())
+		}
+	}
+	sort.Strings(d.names)
+	return d
+}
+
+// Open 打开文件，不存在或是目录时返回 nil
+func (mgr *AssetManager) Open(filename string, mode int) *Asset {
+	name, ok := cleanName(filename)
+	if !ok || name == "." || strings.HasSuffix(filename, "/") {
+		return nil
+	}
+	if mgr.zips != nil {
+		return mgr.openZip(name)
+	}
+
+	f, err := os.Open(filepath.Join(mgr.dir, filepath.FromSlash(name)))
+	if err != nil {
+		return nil
+	}
+	st, err := f.Stat()
+	if err != nil || !st.Mode().IsRegular() {
+		f.Close()
+		return nil
+	}
+	return &Asset{r: io.NewSectionReader(f, 0, st.Size()), closer: f, path: f.Name()}
+}
+
+func (mgr *AssetManager) openZip(name string) *Asset {
+	zf, ok := mgr.zips[name]
+	if !ok {
+		return nil
+	}
+	size := int64(zf.UncompressedSize64)
+	if zf.Method == zip.Store {
+		// 未压缩的直接读 zip 文件，同 NDK 的 mmap
+		off, err := zf.DataOffset()
+		if err != nil {
+			return nil
+		}
+		return &Asset{r: io.NewSectionReader(mgr.file, off, size), path: mgr.name, off: off}
+	}
+	rc, err := zf.Open()
+	if err != nil {
+		return nil
+	}
+	defer rc.Close()
+	buf, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil
+	}
+	return &Asset{r: io.NewSectionReader(bytes.NewReader(buf), 0, size), buf: buf, allocated: true}
+}
+
+// AssetDir 打开的目录
+type AssetDir struct {
+	names []string
+	pos   int
+}
+
+// GetNextFileName 下一个文件名，没有时返回 ""
+func (assetDir *AssetDir) GetNextFileName() string {
+	if assetDir.pos >= len(assetDir.names) {
+		return ""
+	}
+	assetDir.pos++
+	return assetDir.names[assetDir.pos-1]
+}
+
+// Rewind 回到第一个文件
+func (assetDir *AssetDir) Rewind() {
+	assetDir.pos = 0
+}
+
+// Close 关闭目录
+func (assetDir *AssetDir) Close() {
+}
+
+// Asset 打开的文件
+type Asset struct {
+	r         *io.SectionReader
+	closer    io.Closer
+	buf       []byte
+	allocated bool
+
+	path string // 未压缩时所在的文件
+	off  int64
+}
+
+// Read 读到结尾时返回 0, io.EOF
+func (asset *Asset) Read(buf []byte) (int, error) {
+	if len(buf) == 0 {
+		return 0, nil
+	}
+	n, err := asset.r.Read(buf)
+	if n > 0 {
+		return n, nil
+	}
+	if err == io.EOF {
+		return 0, io.EOF
+	}
+	return 0, fmt.Errorf("ASSET: Error code (%v)", err)
+}
+
+// Seek 位置不能小于 0 或超过文件长度
+func (asset *Asset) Seek(offset int64, whence int) (int64, error) {
+	pos, _ := asset.r.Seek(0, io.SeekCurrent)
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += pos
+	case io.SeekEnd:
+		offset += asset.r.Size()
+	default:
+		return 0, fmt.Errorf("ASSET: Seek fail.")
+	}
+	if offset < 0 || offset > asset.r.Size() {
+		return 0, fmt.Errorf("ASSET: Seek fail.")
+	}
+	return asset.r.Seek(offset, io.SeekStart)
+}
+
+// Close 关闭文件
+func (asset *Asset) Close() error {
+	if asset.closer != nil {
+		asset.closer.Close()
+		asset.closer = nil
+	}
+	return nil
+}
+
+// GetBuffer 整个文件的内容，失败时返回 nil
+func (asset *Asset) GetBuffer() []byte {
+	if asset.buf == nil {
+		buf := make([]byte, asset.r.Size())
+		if _, err := asset.r.ReadAt(buf, 0); err != nil && err != io.EOF {
+			return nil
+		}
+		asset.buf = buf
+		asset.allocated = true
+	}
+	return asset.buf
+}
+
+// Length 文件长度
+func (asset *Asset) Length() int64 {
+	return asset.r.Size()
+}
+
+// GetRemainingLength 从当前位置到结尾的长度
+func (asset *Asset) GetRemainingLength() int64 {
+	pos, _ := asset.r.Seek(0, io.SeekCurrent)
+	return asset.r.Size() - pos
+}
+
+// FileDescriptor 新打开的文件描述符和 asset 在其中的区段，压缩的 asset 不能使用。
+// fd 由调用者关闭
+func (asset *Asset) FileDescriptor() (fd int, start, length int64, err error) {
+	if asset.path == "" {
+		return -1, 0, 0, fmt.Errorf("ASSET: No file descriptor, asset is compressed.")
+	}
+	f, err := os.Open(asset.path)
+	if err != nil {
+		return -1, 0, 0, err
+	}
+	defer f.Close()
+	if fd, err = dupFd(f); err != nil {
+		return -1, 0, 0, err
+	}
+	return fd, asset.off, asset.r.Size(), nil
+}
+
+// IsAllocated 内容是否已读入内存
+func (asset *Asset) IsAllocated() bool {
+	return asset.allocated
+}
+
+// FS assets 的文件系统。本地目录的目录树只在这里读取一次，
+// 之后新增或删除的文件不会反映到 ReadDir 和 Stat
+func FS(mgr *AssetManager) *AssetFS {
+	fsys := &AssetFS{mgr: mgr}
+	fsys.once.Do(func() {
+		if mgr.idx != nil {
+			fsys.idx = mgr.idx
+			return
+		}
+		fsys.idx, fsys.err = indexDir(mgr.dir)
+	})
+	return fsys
+}
+
+// indexDir 本地目录的目录树
+func indexDir(dir string) (*index, error) {
+	idx := newIndex()
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		switch {
+		case fi.IsDir():
+			idx.addDir(name)
+		case fi.Mode().IsRegular():
+			idx.add(name, &entry{size: fi.Size(), modTime: fi.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	idx.sort()
+	return idx, nil
+}
@@ -0,0 +1,18 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !android && !windows
+// +build !android,!windows
+
+package storage
+
+import (
+	"os"
+	"syscall"
+)
+
+// dupFd 复制 f 的文件描述符，关闭 f 后仍然有效
+func dupFd(f *os.File) (int, error) {
+	return syscall.Dup(int(f.Fd()))
+}
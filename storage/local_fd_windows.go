@@ -0,0 +1,14 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"errors"
+	"os"
+)
+
+func dupFd(f *os.File) (int, error) {
+	return -1, errors.New("storage: file descriptors are not supported on windows")
+}
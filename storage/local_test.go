@@ -0,0 +1,323 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !android
+// +build !android
+
+package storage
+
+import (
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"testing/fstest"
+)
+
+// testDir 本地的 assets 目录，返回路径和删除它的函数
+func testDir(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "storage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{
+		"a.txt":            "hello, assets",
+		"z.bin":            "0123456789",
+		"sub/b.txt":        "b",
+		"sub/deeper/c.txt": "c",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// testManagers 本地目录和 testAPK 两种 AssetManager
+func testManagers(t *testing.T) (dir, apk *AssetManager, cleanup func()) {
+	t.Helper()
+	d, rmDir := testDir(t)
+	path, rmAPK := writeAPK(t)
+	apk, err := OpenAssetManager(path)
+	if err != nil {
+		rmDir()
+		rmAPK()
+		t.Fatal(err)
+	}
+	return NewAssetManager(d), apk, func() {
+		apk.Close()
+		rmDir()
+		rmAPK()
+	}
+}
+
+func listDir(mgr *AssetManager, name string) []string {
+	d := mgr.OpenDir(name)
+	defer d.Close()
+	var names []string
+	for n := d.GetNextFileName(); n != ""; n = d.GetNextFileName() {
+		names = append(names, n)
+	}
+	return names
+}
+
+// TestOpenDir 同 NDK，只列出文件，不列出子目录
+func TestOpenDir(t *testing.T) {
+	local, apk, cleanup := testManagers(t)
+	defer cleanup()
+
+	for _, c := range []struct {
+		mgr  *AssetManager
+		dir  string
+		want []string
+	}{
+		{local, "", []string{"a.txt", "z.bin"}},
+		{local, "sub", []string{"b.txt"}},
+		{local, "sub/", []string{"b.txt"}},
+		{local, "sub/deeper", []string{"c.txt"}},
+		{local, "missing", nil},
+		{local, "../", nil},
+		{apk, "", []string{"index.html"}},
+		{apk, "fonts", []string{"a.ttf"}},
+		{apk, "data/deep", nil},
+		{apk, "empty", nil},
+		{apk, "missing", nil},
+	} {
+		if got := listDir(c.mgr, c.dir); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: OpenDir(%q) = %v, want %v", c.mgr.name, c.dir, got, c.want)
+		}
+	}
+
+	d := local.OpenDir("")
+	d.GetNextFileName()
+	d.GetNextFileName()
+	if n := d.GetNextFileName(); n != "" {
+		t.Errorf("past the end: %q", n)
+	}
+	d.Rewind()
+	if n := d.GetNextFileName(); n != "a.txt" {
+		t.Errorf("after Rewind: %q, want a.txt", n)
+	}
+}
+
+// TestOpenNotFile 目录、不存在的文件和非法路径返回 nil
+func TestOpenNotFile(t *testing.T) {
+	local, apk, cleanup := testManagers(t)
+	defer cleanup()
+
+	for _, c := range []struct {
+		mgr  *AssetManager
+		name string
+	}{
+		{local, ""},
+		{local, "sub"},
+		{local, "sub/"},
+		{local, "a.txt/"},
+		{local, "missing.txt"},
+		{local, "../a.txt"},
+		{local, "/a.txt"},
+		{apk, ""},
+		{apk, "fonts"},
+		{apk, "fonts/cjk"},
+		{apk, "empty"},
+		{apk, "index.html/"},
+		{apk, "missing.txt"},
+		{apk, "../evil.txt"},
+	} {
+		if a := c.mgr.Open(c.name, ASSET_MODE_UNKNOWN); a != nil {
+			a.Close()
+			t.Errorf("%s: Open(%q) is not nil", c.mgr.name, c.name)
+		}
+	}
+}
+
+// TestAssetSeek 同 NDK：位置不能小于 0 或超过长度，读到结尾返回 io.EOF
+func TestAssetSeek(t *testing.T) {
+	local, apk, cleanup := testManagers(t)
+	defer cleanup()
+
+	for _, c := range []struct {
+		mgr  *AssetManager
+		name string
+		want string
+	}{
+		{local, "z.bin", "0123456789"},
+		{apk, "db/stored.bin", testAssets["db/stored.bin"]},
+		{apk, "data/level1.json", testAssets["data/level1.json"]},
+	} {
+		a := c.mgr.Open(c.name, ASSET_MODE_RANDOM)
+		if a == nil {
+			t.Fatalf("Open(%s) = nil", c.name)
+		}
+		size := int64(len(c.want))
+		if a.Length() != size || a.GetRemainingLength() != size {
+			t.Errorf("%s: Length %d, remaining %d, want %d", c.name, a.Length(), a.GetRemainingLength(), size)
+		}
+		seek := func(offset int64, whence int, want int64) {
+			t.Helper()
+			pos, err := a.Seek(offset, whence)
+			if want < 0 {
+				if err == nil {
+					t.Errorf("%s: Seek(%d, %d) = %d, want error", c.name, offset, whence, pos)
+				}
+				return
+			}
+			if err != nil || pos != want {
+				t.Errorf("%s: Seek(%d, %d) = %d, %v, want %d", c.name, offset, whence, pos, err, want)
+			}
+		}
+		seek(3, io.SeekStart, 3)
+		seek(2, io.SeekCurrent, 5)
+		seek(-1, io.SeekEnd, size-1)
+		seek(-1, io.SeekStart, -1)
+		seek(size+1, io.SeekStart, -1)
+		seek(1, io.SeekEnd, -1)
+		seek(-size-1, io.SeekEnd, -1)
+		seek(0, 7, -1)
+		// 失败的 Seek 不改变位置
+		if rem := a.GetRemainingLength(); rem != 1 {
+			t.Errorf("%s: remaining %d after failed seeks, want 1", c.name, rem)
+		}
+
+		seek(-4, io.SeekEnd, size-4)
+		buf := make([]byte, 3)
+		if n, err := a.Read(buf); n != 3 || err != nil || string(buf) != c.want[size-4:size-1] {
+			t.Errorf("%s: Read = %d %q, %v", c.name, n, buf[:n], err)
+		}
+		if n, err := a.Read(buf); n != 1 || err != nil || buf[0] != c.want[size-1] {
+			t.Errorf("%s: short Read = %d %q, %v", c.name, n, buf[:n], err)
+		}
+		if n, err := a.Read(buf); n != 0 || err != io.EOF {
+			t.Errorf("%s: Read at end = %d, %v, want io.EOF", c.name, n, err)
+		}
+		if n, err := a.Read(nil); n != 0 || err != nil {
+			t.Errorf("%s: empty Read = %d, %v", c.name, n, err)
+		}
+		seek(0, io.SeekStart, 0)
+		if b, err := ioutil.ReadAll(a); err != nil || string(b) != c.want {
+			t.Errorf("%s: ReadAll = %q, %v", c.name, b, err)
+		}
+		if string(a.GetBuffer()) != c.want {
+			t.Errorf("%s: GetBuffer = %q", c.name, a.GetBuffer())
+		}
+		a.Close()
+	}
+}
+
+// TestAssetZip 未压缩的项直接读 zip 文件，可以取得文件描述符；压缩的项解压到内存
+func TestAssetZip(t *testing.T) {
+	_, apk, cleanup := testManagers(t)
+	defer cleanup()
+
+	stored := apk.Open("db/stored.bin", ASSET_MODE_STREAMING)
+	defer stored.Close()
+	if stored.IsAllocated() {
+		t.Error("stored entry read into memory")
+	}
+	want := testAssets["db/stored.bin"]
+	if runtime.GOOS != "windows" {
+		fd, start, length, err := stored.FileDescriptor()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := os.NewFile(uintptr(fd), "fd")
+		b := make([]byte, length)
+		_, err = f.ReadAt(b, start)
+		f.Close()
+		if err != nil || string(b) != want {
+			t.Errorf("FileDescriptor region %d+%d = %q, %v, want %q", start, length, b, err, want)
+		}
+		if start <= 0 {
+			t.Errorf("FileDescriptor start %d, want the offset in the zip", start)
+		}
+	}
+	if string(stored.GetBuffer()) != want || !stored.IsAllocated() {
+		t.Errorf("GetBuffer = %q, allocated %v", stored.GetBuffer(), stored.IsAllocated())
+	}
+
+	deflated := apk.Open("fonts/cjk/b.otf", ASSET_MODE_STREAMING)
+	defer deflated.Close()
+	if !deflated.IsAllocated() {
+		t.Error("deflated entry not in memory")
+	}
+	if _, _, _, err := deflated.FileDescriptor(); err == nil {
+		t.Error("FileDescriptor of a deflated entry: no error")
+	}
+	if b, err := ioutil.ReadAll(deflated); err != nil || string(b) != testAssets["fonts/cjk/b.otf"] {
+		t.Errorf("deflated ReadAll = %q, %v", b, err)
+	}
+}
+
+func TestAssetFileDescriptorLocal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no file descriptors on windows")
+	}
+	local, _, cleanup := testManagers(t)
+	defer cleanup()
+
+	a := local.Open("sub/b.txt", ASSET_MODE_UNKNOWN)
+	defer a.Close()
+	fd, start, length, err := a.FileDescriptor()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := os.NewFile(uintptr(fd), "fd")
+	defer f.Close()
+	b := make([]byte, length)
+	if _, err := f.ReadAt(b, start); err != nil || start != 0 || string(b) != "b" {
+		t.Errorf("region %d+%d = %q, %v", start, length, b, err)
+	}
+}
+
+func TestLocalFS(t *testing.T) {
+	local, apk, cleanup := testManagers(t)
+	defer cleanup()
+
+	dirFS := FS(local)
+	if err := dirFS.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(dirFS, "a.txt", "z.bin", "sub/b.txt", "sub/deeper/c.txt"); err != nil {
+		t.Error(err)
+	}
+	if list, err := fs.ReadDir(dirFS, "sub"); err != nil || !reflect.DeepEqual(dirNames(list), []string{"b.txt", "deeper/"}) {
+		t.Errorf("ReadDir(sub) = %v, %v", dirNames(list), err)
+	}
+	// 目录树在 FS 中读取，之后新增的文件不可见
+	if err := ioutil.WriteFile(filepath.Join(local.dir, "late.txt"), []byte("late"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat(dirFS, "late.txt"); !os.IsNotExist(err) {
+		t.Errorf("Stat(late.txt) = %v, want not exist", err)
+	}
+	if _, err := fs.Stat(FS(local), "late.txt"); err != nil {
+		t.Errorf("new FS: Stat(late.txt) = %v", err)
+	}
+
+	zipFS := FS(apk)
+	var files []string
+	for name := range testAssets {
+		files = append(files, name)
+	}
+	if err := fstest.TestFS(zipFS, files...); err != nil {
+		t.Error(err)
+	}
+	if b, err := fs.ReadFile(zipFS, "data/deep/x/y/z.txt"); err != nil || string(b) != "zzz" {
+		t.Errorf("ReadFile = %q, %v", b, err)
+	}
+
+	// 不存在的本地目录
+	if err := FS(NewAssetManager(filepath.Join(local.dir, "missing"))).Err(); err == nil {
+		t.Error("missing directory: no error")
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2018 The gooid Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"io"
+	"os"
+)
+
+// Section 未压缩 (stored) 的 asset 在 APK 中的区段。
+// 直接读 APK 文件，不复制到内存，ReadAt 可以在多个 goroutine 中同时调用。
+// Fd 和 Offset 可以交给 AMediaExtractor、SQLite 等需要文件描述符的接口
+type Section struct {
+	*io.SectionReader
+	f     *os.File
+	start int64
+}
+
+// OpenSection 打开 asset 的文件描述符，压缩的 asset 返回错误。
+// 不再使用时调用 Close，asset 可以先关闭
+func OpenSection(asset *Asset) (*Section, error) {
+	fd, start, length, err := asset.FileDescriptor()
+	if err != nil {
+		return nil, err
+	}
+	f := os.NewFile(uintptr(fd), "asset")
+	return &Section{SectionReader: io.NewSectionReader(f, start, length), f: f, start: start}, nil
+}
+
+// Fd 文件描述符
+func (s *Section) Fd() uintptr {
+	return s.f.Fd()
+}
+
+// Offset asset 在文件中的起始位置
+func (s *Section) Offset() int64 {
+	return s.start
+}
+
+// Close 关闭文件描述符
+func (s *Section) Close() error {
+	return s.f.Close()
+}
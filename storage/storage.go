@@ -2,6 +2,8 @@
 // Use of this source code is governed by a MIT-style
 // license that can be found in the LICENSE file.
 
+//go:build android
+// +build android
 
 package storage
 
@@ -20,3 +22,8 @@ const (
 	ASSET_MODE_STREAMING = app.ASSET_MODE_STREAMING
 	ASSET_MODE_BUFFER    = app.ASSET_MODE_BUFFER
 )
+
+// FS 当前应用 assets 的文件系统，目录树从 APK 读取
+func FS(mgr *AssetManager) *AssetFS {
+	return NewFS(mgr, app.PackageCodePath())
+}